@@ -0,0 +1,80 @@
+// Package adapter makes the log package satisfy the logger interfaces of third-party libraries,
+// so that their messages go to the same loggers as the program's own.
+package adapter
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mailgun/log"
+)
+
+// GRPCLogger satisfies grpclog.LoggerV2 by logging through the log package, so it can be
+// installed with grpclog.SetLoggerV2(adapter.NewGRPCLogger(0)). gRPC's INFO, WARNING, ERROR
+// and FATAL levels are logged at the severities of the same name.
+type GRPCLogger struct {
+	verbosity int
+}
+
+// NewGRPCLogger makes a gRPC logger enabling verbose messages up to the provided verbosity level.
+func NewGRPCLogger(verbosity int) *GRPCLogger {
+	return &GRPCLogger{verbosity: verbosity}
+}
+
+func (l *GRPCLogger) Info(args ...interface{}) {
+	log.Output(2, log.SeverityInfo, "%s", fmt.Sprint(args...))
+}
+
+func (l *GRPCLogger) Infoln(args ...interface{}) {
+	log.Output(2, log.SeverityInfo, "%s", sprintln(args...))
+}
+
+func (l *GRPCLogger) Infof(format string, args ...interface{}) {
+	log.Output(2, log.SeverityInfo, format, args...)
+}
+
+func (l *GRPCLogger) Warning(args ...interface{}) {
+	log.Output(2, log.SeverityWarning, "%s", fmt.Sprint(args...))
+}
+
+func (l *GRPCLogger) Warningln(args ...interface{}) {
+	log.Output(2, log.SeverityWarning, "%s", sprintln(args...))
+}
+
+func (l *GRPCLogger) Warningf(format string, args ...interface{}) {
+	log.Output(2, log.SeverityWarning, format, args...)
+}
+
+func (l *GRPCLogger) Error(args ...interface{}) {
+	log.Output(2, log.SeverityError, "%s", fmt.Sprint(args...))
+}
+
+func (l *GRPCLogger) Errorln(args ...interface{}) {
+	log.Output(2, log.SeverityError, "%s", sprintln(args...))
+}
+
+func (l *GRPCLogger) Errorf(format string, args ...interface{}) {
+	log.Output(2, log.SeverityError, format, args...)
+}
+
+func (l *GRPCLogger) Fatal(args ...interface{}) {
+	log.Output(2, log.SeverityFatal, "%s", fmt.Sprint(args...))
+}
+
+func (l *GRPCLogger) Fatalln(args ...interface{}) {
+	log.Output(2, log.SeverityFatal, "%s", sprintln(args...))
+}
+
+func (l *GRPCLogger) Fatalf(format string, args ...interface{}) {
+	log.Output(2, log.SeverityFatal, format, args...)
+}
+
+// V reports whether verbose messages at the provided level are enabled.
+func (l *GRPCLogger) V(level int) bool {
+	return level <= l.verbosity
+}
+
+// sprintln formats the arguments in the manner of fmt.Sprintln without the trailing newline.
+func sprintln(args ...interface{}) string {
+	return strings.TrimSuffix(fmt.Sprintln(args...), "\n")
+}
@@ -0,0 +1,89 @@
+package adapter
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+// grpcLoggerV2 mirrors grpclog.LoggerV2 so that the adapter is checked against it without
+// depending on gRPC.
+type grpcLoggerV2 interface {
+	Info(args ...interface{})
+	Infoln(args ...interface{})
+	Infof(format string, args ...interface{})
+	Warning(args ...interface{})
+	Warningln(args ...interface{})
+	Warningf(format string, args ...interface{})
+	Error(args ...interface{})
+	Errorln(args ...interface{})
+	Errorf(format string, args ...interface{})
+	Fatal(args ...interface{})
+	Fatalln(args ...interface{})
+	Fatalf(format string, args ...interface{})
+	V(l int) bool
+}
+
+var _ grpcLoggerV2 = &GRPCLogger{}
+
+func TestGRPCLoggerSeverities(t *testing.T) {
+	defer log.CaptureOutput(ioutil.Discard)()
+	memory, err := log.NewMemoryLogger(log.Config{Severity: "debug"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Init(memory)
+
+	var l grpcLoggerV2 = NewGRPCLogger(0)
+	l.Info("info", 1)
+	l.Infoln("info", 2)
+	l.Infof("info %d", 3)
+	l.Warning("warning", 1)
+	l.Warningln("warning", 2)
+	l.Warningf("warning %d", 3)
+	l.Error("error", 1)
+	l.Errorln("error", 2)
+	l.Errorf("error %d", 3)
+
+	expected := []log.MemoryMessage{
+		{Severity: log.SeverityInfo, Message: "info1"},
+		{Severity: log.SeverityInfo, Message: "info 2"},
+		{Severity: log.SeverityInfo, Message: "info 3"},
+		{Severity: log.SeverityWarning, Message: "warning1"},
+		{Severity: log.SeverityWarning, Message: "warning 2"},
+		{Severity: log.SeverityWarning, Message: "warning 3"},
+		{Severity: log.SeverityError, Message: "error1"},
+		{Severity: log.SeverityError, Message: "error 2"},
+		{Severity: log.SeverityError, Message: "error 3"},
+	}
+	messages := memory.Messages()
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %v", len(expected), messages)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], messages[i])
+		}
+	}
+}
+
+func TestGRPCLoggerCaller(t *testing.T) {
+	var b bytes.Buffer
+	defer log.CaptureOutput(&b)()
+
+	NewGRPCLogger(0).Infof("hello")
+
+	if !strings.Contains(b.String(), "[grpc_test.go:") {
+		t.Errorf("expected the caller of the adapter, got %q", b.String())
+	}
+}
+
+func TestGRPCLoggerVerbosity(t *testing.T) {
+	l := NewGRPCLogger(2)
+	if !l.V(0) || !l.V(2) || l.V(3) {
+		t.Errorf("unexpected verbosity checks for level 2")
+	}
+}
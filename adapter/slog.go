@@ -0,0 +1,109 @@
+//go:build go1.21
+
+package adapter
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/mailgun/log"
+)
+
+// SlogHandler satisfies slog.Handler by logging through the log package, so that libraries
+// logging with log/slog end up in the same loggers, formatted the same way, as the program's
+// own messages. It is installed with slog.SetDefault(slog.New(adapter.NewSlogHandler())).
+//
+// Levels below slog.LevelDebug are logged at TRACE, then DEBUG, INFO, WARN and ERROR for
+// levels from slog.LevelDebug, slog.LevelInfo, slog.LevelWarn and slog.LevelError up: slog
+// messages never exit the program nor panic. Attributes become fields, the names of the
+// attributes in groups being prefixed with the group names, e.g. "request.method". Fields of
+// the entry stored in the context passed to slog, see log.NewContext, are attached as well.
+type SlogHandler struct {
+	fields log.Fields
+	prefix string // prefix of the names of the fields, ending with a dot unless empty
+}
+
+// NewSlogHandler makes a slog handler logging through the log package.
+func NewSlogHandler() *SlogHandler {
+	return &SlogHandler{}
+}
+
+// slogCallDepth is the number of stack frames between SlogHandler.Handle and the caller of the
+// slog.Logger method logging the record.
+const slogCallDepth = 4
+
+// Enabled reports whether a message at the level could be written, see log.Entry.Enabled,
+// through the entry stored in the context.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return log.FromContext(ctx).Enabled(severityOf(level))
+}
+
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := make(log.Fields, len(h.fields)+r.NumAttrs())
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addAttr(fields, h.prefix, a)
+		return true
+	})
+
+	e := log.FromContext(ctx).WithFields(fields)
+	if !r.Time.IsZero() {
+		e = e.At(r.Time)
+	}
+	e.Output(slogCallDepth, severityOf(r.Level), "%s", r.Message)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	fields := make(log.Fields, len(h.fields)+len(attrs))
+	for k, v := range h.fields {
+		fields[k] = v
+	}
+	for _, a := range attrs {
+		addAttr(fields, h.prefix, a)
+	}
+	return &SlogHandler{fields: fields, prefix: h.prefix}
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	return &SlogHandler{fields: h.fields, prefix: h.prefix + name + "."}
+}
+
+// addAttr adds the attribute to the fields under its name prefixed with the prefix, flattening
+// groups and skipping empty attributes as slog handlers should.
+func addAttr(fields log.Fields, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+	if a.Value.Kind() != slog.KindGroup {
+		fields[prefix+a.Key] = a.Value.Any()
+		return
+	}
+	if a.Key != "" {
+		prefix += a.Key + "."
+	}
+	for _, member := range a.Value.Group() {
+		addAttr(fields, prefix, member)
+	}
+}
+
+// severityOf returns the severity slog messages at the level are logged at.
+func severityOf(level slog.Level) log.Severity {
+	switch {
+	case level < slog.LevelDebug:
+		return log.SeverityTrace
+	case level < slog.LevelInfo:
+		return log.SeverityDebug
+	case level < slog.LevelWarn:
+		return log.SeverityInfo
+	case level < slog.LevelError:
+		return log.SeverityWarning
+	}
+	return log.SeverityError
+}
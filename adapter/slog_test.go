@@ -0,0 +1,90 @@
+//go:build go1.21
+
+package adapter
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+var _ slog.Handler = &SlogHandler{}
+
+func TestSlogHandlerSeverities(t *testing.T) {
+	defer log.CaptureOutput(ioutil.Discard)()
+	memory, err := log.NewMemoryLogger(log.Config{Severity: "trace"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	log.Init(memory)
+
+	l := slog.New(NewSlogHandler())
+	ctx := context.Background()
+	l.Log(ctx, slog.LevelDebug-1, "trace")
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	l.Log(ctx, slog.LevelError+4, "above error")
+
+	expected := []log.MemoryMessage{
+		{Severity: log.SeverityTrace, Message: "trace"},
+		{Severity: log.SeverityDebug, Message: "debug"},
+		{Severity: log.SeverityInfo, Message: "info"},
+		{Severity: log.SeverityWarning, Message: "warn"},
+		{Severity: log.SeverityError, Message: "error"},
+		{Severity: log.SeverityError, Message: "above error"},
+	}
+	messages := memory.Messages()
+	if len(messages) != len(expected) {
+		t.Fatalf("expected %d messages, got %v", len(expected), messages)
+	}
+	for i := range expected {
+		if messages[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected[i], messages[i])
+		}
+	}
+}
+
+func TestSlogHandlerEnabled(t *testing.T) {
+	defer log.CaptureOutput(ioutil.Discard)()
+	memory, err := log.NewMemoryLogger(log.Config{Severity: "warn"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.ReplaceLoggers(log.ReplaceLoggers(memory)...)
+
+	h := NewSlogHandler()
+	ctx := context.Background()
+	if h.Enabled(ctx, slog.LevelInfo) || !h.Enabled(ctx, slog.LevelWarn) {
+		t.Error("enabled levels do not follow the loggers")
+	}
+	ctx = log.NewContext(ctx, log.WithFields(nil).WithSeverityOverride(log.SeverityDebug))
+	if !h.Enabled(ctx, slog.LevelDebug) {
+		t.Error("severity override of the context entry ignored")
+	}
+}
+
+func TestSlogHandlerFields(t *testing.T) {
+	var b bytes.Buffer
+	defer log.CaptureOutput(&b)()
+
+	ctx := log.ContextWithFields(context.Background(), log.Fields{"trace_id": "t1"})
+	l := slog.New(NewSlogHandler()).With("service", "api").WithGroup("request")
+	l.InfoContext(ctx, "served", "method", "GET", slog.Group("user", "id", 7), slog.Attr{})
+
+	out := b.String()
+	for _, field := range []string{"request.method=GET", "request.user.id=7", "service=api", "trace_id=t1"} {
+		if !strings.Contains(out, field) {
+			t.Errorf("expected %s in %q", field, out)
+		}
+	}
+	if !strings.Contains(out, "[slog_test.go:") {
+		t.Errorf("expected the caller of slog, got %q", out)
+	}
+}
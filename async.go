@@ -0,0 +1,228 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Overflow policies of asynchronous loggers, see Config.Overflow.
+const (
+	OverflowBlock      = "block"
+	OverflowDropOldest = "drop-oldest"
+	OverflowDropNewest = "drop-newest"
+)
+
+func validateOverflow(policy string) error {
+	switch policy {
+	case "", OverflowBlock, OverflowDropOldest, OverflowDropNewest:
+		return nil
+	}
+	return fmt.Errorf("unsupported overflow policy: %s", policy)
+}
+
+// AsyncLogger is a logger queuing up to a fixed number of messages and writing them to the
+// logger it wraps in the background, so that logging does not wait for slow outputs such as
+// syslog or udplog. When the queue is full, logging waits for room in it or drops a message,
+// depending on the overflow policy: OverflowBlock (default), OverflowDropOldest or
+// OverflowDropNewest. Dropped messages are counted, see Dropped.
+//
+// Messages are formatted by the wrapped logger when they are logged. Flush waits for the
+// queued messages to be written, Close writes them and stops the logger. See SpillLogger for
+// a logger queuing messages on disk rather than dropping them.
+type AsyncLogger struct {
+	logger   Logger
+	size     int
+	overflow string
+
+	mu      sync.Mutex
+	changed *sync.Cond // signaled whenever the queue changes or the logger is closed
+
+	queue   []queuedMessage // oldest first
+	writing bool            // set while a message is being written to the wrapped logger
+	closed  bool
+	done    chan struct{}
+
+	dropped uint64
+}
+
+// NewAsyncLogger makes a logger queuing up to size messages for l, handling overflows
+// according to the policy.
+func NewAsyncLogger(l Logger, size int, overflow string) (*AsyncLogger, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("queue size must be positive: %d", size)
+	}
+	if err := validateOverflow(overflow); err != nil {
+		return nil, err
+	}
+	if overflow == "" {
+		overflow = OverflowBlock
+	}
+
+	a := &AsyncLogger{logger: l, size: size, overflow: overflow, done: make(chan struct{})}
+	a.changed = sync.NewCond(&a.mu)
+	go a.run()
+	return a, nil
+}
+
+func (l *AsyncLogger) Writer(sev Severity) io.Writer {
+	if l.logger.Writer(sev) == nil {
+		return nil
+	}
+	return &asyncWriter{l, sev}
+}
+
+func (l *AsyncLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.logger.FormatMessage(sev, caller, format, args...)
+}
+
+func (l *AsyncLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return formatMessage(l.logger, sev, caller, now(), fields, format, args...)
+}
+
+func (l *AsyncLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	return formatLazyMessage(l.logger, sev, caller, t, fields, m)
+}
+
+// Severity returns the severity of the wrapped logger if it implements LeveledLogger, or
+// SeverityTrace otherwise.
+func (l *AsyncLogger) Severity() Severity {
+	if leveled, ok := l.logger.(LeveledLogger); ok {
+		return leveled.Severity()
+	}
+	return SeverityTrace
+}
+
+// SetSeverity changes the severity of the wrapped logger if it implements LeveledLogger.
+func (l *AsyncLogger) SetSeverity(sev Severity) {
+	if leveled, ok := l.logger.(LeveledLogger); ok {
+		leveled.SetSeverity(sev)
+	}
+}
+
+// Dropped returns the number of messages dropped on overflows or after the logger was closed.
+func (l *AsyncLogger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Flush waits for the queued messages to be written to the wrapped logger, then flushes it
+// if it implements Flusher.
+func (l *AsyncLogger) Flush() error {
+	l.mu.Lock()
+	for len(l.queue) > 0 || l.writing {
+		l.changed.Wait()
+	}
+	l.mu.Unlock()
+
+	if f, ok := l.logger.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Sync waits for the queued messages to be written to the wrapped logger, then syncs it if
+// it implements Syncer.
+func (l *AsyncLogger) Sync() error {
+	if err := l.Flush(); err != nil {
+		return err
+	}
+	if s, ok := l.logger.(Syncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// Reopen reopens the wrapped logger if it implements Reopener.
+func (l *AsyncLogger) Reopen() error {
+	if r, ok := l.logger.(Reopener); ok {
+		return r.Reopen()
+	}
+	return nil
+}
+
+// Close writes the queued messages to the wrapped logger, then closes it if it implements
+// io.Closer. Messages logged afterwards are dropped. It is safe to call it more than once.
+func (l *AsyncLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.changed.Broadcast()
+	l.mu.Unlock()
+
+	<-l.done
+	if c, ok := l.logger.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// add queues the message, handling a full queue according to the overflow policy.
+func (l *AsyncLogger) add(sev Severity, p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for l.overflow == OverflowBlock && len(l.queue) == l.size && !l.closed {
+		l.changed.Wait()
+	}
+	if l.closed {
+		l.dropped++
+		return
+	}
+	if len(l.queue) == l.size {
+		l.dropped++
+		if l.overflow == OverflowDropNewest {
+			return
+		}
+		l.queue[0] = queuedMessage{}
+		l.queue = l.queue[1:]
+	}
+	l.queue = append(l.queue, queuedMessage{sev, append([]byte(nil), p...)})
+	l.changed.Broadcast()
+}
+
+// run writes the queued messages to the wrapped logger until the logger is closed and the
+// queue has been drained.
+func (l *AsyncLogger) run() {
+	defer close(l.done)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for {
+		for len(l.queue) == 0 && !l.closed {
+			l.changed.Wait()
+		}
+		if len(l.queue) == 0 {
+			return
+		}
+		m := l.queue[0]
+		l.queue[0] = queuedMessage{}
+		l.queue = l.queue[1:]
+
+		l.writing = true
+		l.changed.Broadcast()
+		l.mu.Unlock()
+		if w := l.logger.Writer(m.sev); w != nil {
+			w.Write(m.message)
+		}
+		l.mu.Lock()
+		l.writing = false
+		l.changed.Broadcast()
+	}
+}
+
+// asyncWriter queues the messages written to it at the severity.
+type asyncWriter struct {
+	l   *AsyncLogger
+	sev Severity
+}
+
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.l.add(w.sev, p)
+	return len(p), nil
+}
@@ -0,0 +1,129 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AsyncLoggerSuite struct {
+}
+
+var _ = Suite(&AsyncLoggerSuite{})
+
+func (s *AsyncLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+// stuck logs a message and waits for the logger to be stuck writing it.
+func stuck(c *C, l *AsyncLogger) {
+	Infof("message 0")
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		l.mu.Lock()
+		writing := l.writing
+		l.mu.Unlock()
+		if writing {
+			return
+		}
+		c.Assert(time.Now().Before(deadline), Equals, true)
+	}
+}
+
+func (s *AsyncLoggerSuite) TestDropOldest(c *C) {
+	gated := newGatedLogger()
+	l, err := NewAsyncLogger(gated, 2, OverflowDropOldest)
+	c.Assert(err, IsNil)
+	Init(l)
+
+	stuck(c, l)
+	for i := 1; i <= 5; i++ {
+		Infof("message %d", i)
+	}
+	c.Assert(l.Dropped(), Equals, uint64(3))
+
+	close(gated.open)
+	c.Assert(l.Flush(), IsNil)
+	c.Assert(gated.String(), Equals, "INFO message 0\nINFO message 4\nINFO message 5\n")
+	c.Assert(l.Close(), IsNil)
+}
+
+func (s *AsyncLoggerSuite) TestDropNewest(c *C) {
+	gated := newGatedLogger()
+	l, err := NewAsyncLogger(gated, 2, OverflowDropNewest)
+	c.Assert(err, IsNil)
+	Init(l)
+
+	stuck(c, l)
+	for i := 1; i <= 5; i++ {
+		Infof("message %d", i)
+	}
+	c.Assert(l.Dropped(), Equals, uint64(3))
+
+	close(gated.open)
+	c.Assert(l.Flush(), IsNil)
+	c.Assert(gated.String(), Equals, "INFO message 0\nINFO message 1\nINFO message 2\n")
+	c.Assert(l.Close(), IsNil)
+}
+
+func (s *AsyncLoggerSuite) TestBlock(c *C) {
+	gated := newGatedLogger()
+	l, err := NewAsyncLogger(gated, 2, OverflowBlock)
+	c.Assert(err, IsNil)
+	Init(l)
+
+	stuck(c, l)
+	logged := make(chan struct{})
+	go func() {
+		for i := 1; i <= 5; i++ {
+			Infof("message %d", i)
+		}
+		close(logged)
+	}()
+
+	// logging waits for room in the queue
+	select {
+	case <-logged:
+		c.Fatal("logging did not block on a full queue")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(gated.open)
+	<-logged
+
+	// Close writes the queued messages, in order
+	c.Assert(l.Close(), IsNil)
+	var expected string
+	for i := 0; i <= 5; i++ {
+		expected += fmt.Sprintf("INFO message %d\n", i)
+	}
+	c.Assert(gated.String(), Equals, expected)
+	c.Assert(l.Dropped(), Equals, uint64(0))
+
+	// closed loggers drop messages
+	Infof("too late")
+	c.Assert(l.Dropped(), Equals, uint64(1))
+	c.Assert(l.Close(), IsNil)
+}
+
+func (s *AsyncLoggerSuite) TestConfig(c *C) {
+	l, err := NewLogger(Config{Name: Console, Severity: "info", QueueSize: 16, Overflow: OverflowDropNewest})
+	c.Assert(err, IsNil)
+	a, ok := l.(*AsyncLogger)
+	c.Assert(ok, Equals, true)
+	c.Assert(a.size, Equals, 16)
+	c.Assert(a.overflow, Equals, OverflowDropNewest)
+	c.Assert(describe(a).Loggers[0].Type, Equals, Console)
+	c.Assert(a.Close(), IsNil)
+
+	l, err = NewLogger(Config{Name: Console, Severity: "info"})
+	c.Assert(err, IsNil)
+	_, ok = l.(*AsyncLogger)
+	c.Assert(ok, Equals, false)
+
+	_, err = NewLogger(Config{Name: Console, Severity: "info", QueueSize: 16, Overflow: "sometimes"})
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.Error(), "sometimes"), Equals, true)
+	_, err = NewLogger(Config{Name: Console, Severity: "info", QueueSize: -1})
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,263 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// auditGenesis is the previous hash of the first record of an audit chain.
+var auditGenesis = strings.Repeat("0", sha256.Size*2)
+
+var (
+	// auditMu guards the audit sinks and the chain, so that records are numbered, chained and
+	// written in the same order.
+	auditMu    sync.Mutex
+	auditSinks []Logger
+	auditSeq   uint64
+	auditHash  = auditGenesis
+	auditKey   []byte
+)
+
+// InitAudit adds the loggers to the sinks of the audit channel, see Audit. They are kept apart
+// from the logger chain initialized by Init, so that no ordinary message reaches them and
+// changes to the severities of the chain leave them alone.
+func InitAudit(l ...Logger) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	auditSinks = append(auditSinks, l...)
+}
+
+// InitAuditWithConfig instantiates loggers based on the provided configs and adds them to the
+// sinks of the audit channel, e.g. a file logger with the path of the audit log. They log at
+// every severity whatever their configs say.
+func InitAuditWithConfig(configs ...Config) error {
+	var l []Logger
+	for i, config := range configs {
+		config.Severity = SeverityTrace.String()
+		logger, err := NewLogger(config)
+		if err != nil {
+			// keep the loggers that have been instantiated so far
+			InitAudit(l...)
+			return fmt.Errorf("audit sink %d (%s): %v", i, config.Name, err)
+		}
+		l = append(l, logger)
+	}
+	InitAudit(l...)
+	return nil
+}
+
+// SetAuditKey sets the secret key the records of the audit channel are hashed with, as
+// HMAC-SHA256, see Audit, which VerifyAuditWithKey checks them with. A nil key restores plain
+// SHA-256 hashes. It is meant to be called once, before the first record, keyed and unkeyed
+// records not making a chain that can be verified.
+func SetAuditKey(key []byte) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	auditKey = append([]byte(nil), key...)
+	if len(key) == 0 {
+		auditKey = nil
+	}
+}
+
+// ReplaceAuditLoggers replaces the sinks of the audit channel with the provided loggers at
+// once and returns the previous sinks, which are not closed. The chain of records goes on.
+func ReplaceAuditLoggers(l ...Logger) []Logger {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	previous := auditSinks
+	auditSinks = append([]Logger(nil), l...)
+	return previous
+}
+
+// Audit records a security event, such as a login or a permission change, with the fields
+// describing it, e.g.
+//
+//	log.Audit("role.granted", log.Fields{"user": "bob", "role": "admin", "by": "alice"})
+//
+// Records go to the sinks of the audit channel only, see InitAudit, however the logger chain
+// is configured: they are neither sampled nor rate-limited, nor filtered by severity, the
+// sinks being written to at SeverityInfo. Fields are redacted like those of messages, see
+// SetRedaction.
+//
+// Every record is a JSON object on its own line, for example:
+//
+//	{"seq":1,"time":"2016-01-02T15:04:05Z","event":"login","fields":{"user":"bob"},"prev":"00…","hash":"9f…"}
+//
+// where seq numbers the records of the process from 1, prev is the hash of the previous record
+// and hash is the hex SHA-256 of the line up to the comma before the "hash" key, which makes
+// records that are altered, removed or reordered stand out, see VerifyAudit. Anyone able to
+// edit the log can recompute such hashes, so the chain only detects accidental changes
+// unless the records are hashed with a secret key, as HMAC-SHA256, see SetAuditKey. Sinks
+// implementing Syncer commit every record to stable storage before Audit returns. Records
+// that a sink fails to write are reported as errors through the logger chain.
+func Audit(event string, fields Fields) {
+	// like messages, the fields of Loggable values are redacted too
+	fields = expandLoggables(fields)
+	if r := currentRedaction(); r != nil {
+		fields, _ = r.redactFields(fields)
+	}
+
+	auditMu.Lock()
+	if len(auditSinks) == 0 {
+		auditMu.Unlock()
+		return
+	}
+	auditSeq++
+	seq := auditSeq
+	line, hash := formatAudit(seq, now(), event, fields, auditHash, auditKey)
+	auditHash = hash
+
+	type failure struct {
+		l   Logger
+		err error
+	}
+	var failures []failure
+	for _, l := range auditSinks {
+		w := l.Writer(SeverityInfo)
+		if w == nil {
+			failures = append(failures, failure{l, fmt.Errorf("not logging at %s", SeverityInfo)})
+			continue
+		}
+		_, err := w.Write(line)
+		if s, ok := l.(Syncer); ok && err == nil {
+			err = s.Sync()
+		}
+		if err != nil {
+			failures = append(failures, failure{l, err})
+		}
+	}
+	auditMu.Unlock()
+
+	for _, f := range failures {
+		writeMessage(1, SeverityError, nil, "audit record %d not written to %T: %v", seq, f.l, f.err)
+	}
+}
+
+// formatAudit returns the line of the audit record chained to the record of the previous hash,
+// and the hash of the record, keyed by the key unless it is nil.
+func formatAudit(seq uint64, t time.Time, event string, fields Fields, prev string, key []byte) ([]byte, string) {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	writeJSONPair(&b, "seq", seq)
+	writeJSONPair(&b, "time", t.UTC().Format(time.RFC3339Nano))
+	writeJSONPair(&b, "event", event)
+
+	names := make([]string, 0, len(fields))
+	for k := range fields {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var f bytes.Buffer
+	f.WriteByte('{')
+	for _, k := range names {
+		if !writeJSONPair(&f, k, jsonValue(fields[k])) {
+			writeJSONPair(&f, k, fmt.Sprint(fields[k]))
+		}
+	}
+	f.WriteByte('}')
+	b.WriteString(`,"fields":`)
+	b.Write(f.Bytes())
+
+	writeJSONPair(&b, "prev", prev)
+	hash := auditSum(key, b.Bytes())
+	writeJSONPair(&b, "hash", hash)
+	b.WriteByte('}')
+	// records are one per line whatever the line ending of messages, see VerifyAudit
+	b.WriteByte('\n')
+	return b.Bytes(), hash
+}
+
+// auditSum returns the hex hash of the audit record: its HMAC-SHA256 with the key, or its
+// SHA-256 if the key is nil.
+func auditSum(key, record []byte) string {
+	if key == nil {
+		sum := sha256.Sum256(record)
+		return hex.EncodeToString(sum[:])
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(record)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// auditRecord holds the parts of an audit record checked by VerifyAudit.
+type auditRecord struct {
+	Seq  uint64 `json:"seq"`
+	Prev string `json:"prev"`
+	Hash string `json:"hash"`
+}
+
+// VerifyAudit reads the records written by Audit, e.g. from the file of an audit log, and
+// makes sure none of them has been altered, removed or reordered, returning an error locating
+// the first one that has. A record numbered 1 chained to no previous record starts the chain
+// of another run of the program. Records removed from the end of the log cannot be detected.
+//
+// Without a key, records can be forged by rehashing them, even a whole chain spliced in from
+// a record numbered 1, see VerifyAuditWithKey.
+func VerifyAudit(r io.Reader) error {
+	return VerifyAuditWithKey(r, nil)
+}
+
+// VerifyAuditWithKey is like VerifyAudit for records hashed with the key, see SetAuditKey,
+// which cannot be forged without it.
+func VerifyAuditWithKey(r io.Reader, key []byte) error {
+	if len(key) == 0 {
+		key = nil
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	var seq uint64
+	prev := auditGenesis
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Bytes()
+		var record auditRecord
+		if err := json.Unmarshal(text, &record); err != nil {
+			return fmt.Errorf("audit line %d: %v", line, err)
+		}
+		if record.Seq == 1 && record.Prev == auditGenesis {
+			seq, prev = 0, auditGenesis
+		}
+		if record.Seq != seq+1 {
+			return fmt.Errorf("audit line %d: record %d follows record %d", line, record.Seq, seq)
+		}
+		if record.Prev != prev {
+			return fmt.Errorf("audit line %d: record %d is not chained to the previous record", line, record.Seq)
+		}
+		i := bytes.LastIndex(text, []byte(`,"hash":`))
+		if i < 0 {
+			return fmt.Errorf("audit line %d: record %d has no hash", line, record.Seq)
+		}
+		if !hmac.Equal([]byte(auditSum(key, text[:i])), []byte(record.Hash)) {
+			return fmt.Errorf("audit line %d: record %d does not match its hash", line, record.Seq)
+		}
+		seq, prev = record.Seq, record.Hash
+	}
+	return scanner.Err()
+}
+
+// currentAuditLoggers returns the sinks of the audit channel.
+func currentAuditLoggers() []Logger {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	return auditSinks
+}
+
+// allLoggers returns the loggers of the chain followed by the sinks of the audit channel.
+func allLoggers() []Logger {
+	chain, sinks := currentLoggers(), currentAuditLoggers()
+	all := make([]Logger, 0, len(chain)+len(sinks))
+	return append(append(all, chain...), sinks...)
+}
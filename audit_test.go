@@ -0,0 +1,170 @@
+package log
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type AuditSuite struct{}
+
+var _ = Suite(&AuditSuite{})
+
+func (s *AuditSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	auditSinks, auditSeq, auditHash, auditKey = nil, 0, auditGenesis, nil
+	SetClock(ClockFunc(func() time.Time { return time.Date(2016, 1, 2, 15, 4, 5, 0, time.UTC) }))
+}
+
+func (s *AuditSuite) TearDownTest(c *C) {
+	auditSinks, auditSeq, auditHash, auditKey = nil, 0, auditGenesis, nil
+	SetClock(nil)
+	SetRedaction(nil)
+	SetSampling(0, 0, 0)
+	SetGlobalRateLimit(0)
+}
+
+func (s *AuditSuite) TestAudit(c *C) {
+	sink, app := newTestLogger("audit"), newThresholdLogger("app", SeverityError)
+	Init(app)
+	InitAudit(sink)
+
+	Audit("login", Fields{"user": "bob", "ok": true})
+	Audit("role.granted", Fields{"user": "bob", "role": "admin"})
+
+	lines := strings.SplitAfter(sink.b.String(), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[0], Equals, `{"seq":1,"time":"2016-01-02T15:04:05Z","event":"login","fields":{"ok":true,"user":"bob"},"prev":"`+auditGenesis+`","hash":"`+auditHashOf(lines[0])+`"}`+"\n")
+	c.Assert(lines[1], Matches, `\{"seq":2,.*"event":"role\.granted","fields":\{"role":"admin","user":"bob"\},"prev":"`+auditHashOf(lines[0])+`","hash":"[0-9a-f]{64}"\}`+"\n")
+	c.Assert(VerifyAudit(strings.NewReader(sink.b.String())), IsNil)
+
+	// the logger chain gets none of the records
+	c.Assert(app.b.String(), Equals, "")
+}
+
+// auditHashOf returns the hash the audit record on the line should carry.
+func auditHashOf(line string) string {
+	line, _, _ = strings.Cut(line, `,"hash":`)
+	sum := sha256.Sum256([]byte(line))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *AuditSuite) TestLineEnding(c *C) {
+	defer SetLineEnding(LineEndingLF)
+	c.Assert(SetLineEnding(LineEndingNone), IsNil)
+	sink := newTestLogger("audit")
+	InitAudit(sink)
+
+	Audit("login", Fields{"user": "bob"})
+	Audit("logout", Fields{"user": "bob"})
+	c.Assert(strings.Count(sink.b.String(), "\n"), Equals, 2)
+	c.Assert(VerifyAudit(strings.NewReader(sink.b.String())), IsNil)
+}
+
+func (s *AuditSuite) TestImmuneToFiltering(c *C) {
+	sink := newTestLogger("audit")
+	InitAudit(sink)
+	SetSampling(time.Hour, 1, 0)
+	SetGlobalRateLimit(1)
+
+	for i := 0; i < 3; i++ {
+		Audit("login", Fields{"user": "bob"})
+	}
+	c.Assert(strings.Count(sink.b.String(), "\n"), Equals, 3)
+}
+
+func (s *AuditSuite) TestRedaction(c *C) {
+	sink := newTestLogger("audit")
+	InitAudit(sink)
+	c.Assert(SetRedaction([]string{"password"}), IsNil)
+
+	Audit("password.changed", Fields{"user": "bob", "password": "hunter2"})
+	c.Assert(sink.b.String(), Matches, `.*"fields":\{"password":"\[REDACTED\]","user":"bob"\}.*`+"\n")
+
+	// the fields of Loggable values are expanded before they are redacted
+	sink.b.Reset()
+	Audit("login", Fields{"user": account{"bob", "hunter2"}})
+	c.Assert(sink.b.String(), Matches, `.*"fields":\{"user\.name":"bob","user\.password":"\[REDACTED\]"\}.*`+"\n")
+}
+
+func (s *AuditSuite) TestFailedSink(c *C) {
+	var out bytes.Buffer
+	defer CaptureOutput(&out)()
+	InitAudit(newThresholdLogger("audit", SeverityError))
+
+	Audit("login", nil)
+	c.Assert(out.String(), Matches, ".* ERROR .* audit record 1 not written to \\*log.thresholdLogger: not logging at INFO\n")
+}
+
+func (s *AuditSuite) TestFileSink(c *C) {
+	path := filepath.Join(c.MkDir(), "audit.log")
+	c.Assert(InitAuditWithConfig(Config{Name: File, Severity: "error", Path: path}), IsNil)
+	defer currentAuditLoggers()[0].(*fileLogger).Close()
+	Infof("not audited")
+
+	Audit("login", Fields{"user": "bob"})
+	Audit("logout", Fields{"user": "bob"})
+	b, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Count(string(b), "\n"), Equals, 2)
+	c.Assert(VerifyAudit(bytes.NewReader(b)), IsNil)
+
+	c.Assert(InitAuditWithConfig(Config{Name: "nope"}), ErrorMatches, "audit sink 0 \\(nope\\): unknown logger: .*")
+}
+
+func (s *AuditSuite) TestVerifyAudit(c *C) {
+	sink := newTestLogger("audit")
+	InitAudit(sink)
+	for _, user := range []string{"alice", "bob", "carol"} {
+		Audit("login", Fields{"user": user})
+	}
+	lines := strings.SplitAfter(sink.b.String(), "\n")[:3]
+
+	// the records of another run of the program start another chain
+	auditSeq, auditHash = 0, auditGenesis
+	Audit("login", Fields{"user": "dave"})
+	c.Assert(VerifyAudit(strings.NewReader(sink.b.String())), IsNil)
+
+	for _, t := range []struct {
+		log, err string
+	}{
+		{lines[0] + strings.Replace(lines[1], "bob", "eve", 1) + lines[2], "audit line 2: record 2 does not match its hash"},
+		{lines[0] + lines[2], "audit line 2: record 3 follows record 1"},
+		{lines[1] + lines[2], "audit line 1: record 2 follows record 0"},
+		{lines[0] + lines[1] + "not json\n", "audit line 3: .*"},
+	} {
+		c.Assert(VerifyAudit(strings.NewReader(t.log)), ErrorMatches, t.err, Commentf("%s", t.log))
+	}
+
+	// rehashing an altered record breaks the chain to the next one
+	altered := strings.Replace(lines[1], "bob", "eve", 1)
+	altered = altered[:strings.LastIndex(altered, `"hash":`)] + `"hash":"` + auditHashOf(altered) + "\"}\n"
+	c.Assert(VerifyAudit(strings.NewReader(lines[0]+altered+lines[2])), ErrorMatches, "audit line 3: record 3 is not chained to the previous record")
+}
+
+func (s *AuditSuite) TestKey(c *C) {
+	sink := newTestLogger("audit")
+	InitAudit(sink)
+	key := []byte("secret")
+	SetAuditKey(key)
+	for _, user := range []string{"alice", "bob"} {
+		Audit("login", Fields{"user": user})
+	}
+	records := sink.b.String()
+	c.Assert(VerifyAuditWithKey(strings.NewReader(records), key), IsNil)
+	c.Assert(VerifyAuditWithKey(strings.NewReader(records), []byte("guess")), ErrorMatches, "audit line 1: record 1 does not match its hash")
+	c.Assert(VerifyAudit(strings.NewReader(records)), ErrorMatches, "audit line 1: record 1 does not match its hash")
+
+	// a chain spliced in from a forged record numbered 1 only stands out if records are keyed
+	forged, _ := formatAudit(1, now(), "login", Fields{"user": "eve"}, auditGenesis, nil)
+	unkeyed, _ := formatAudit(1, now(), "login", Fields{"user": "alice"}, auditGenesis, nil)
+	c.Assert(VerifyAudit(strings.NewReader(string(unkeyed)+string(forged))), IsNil)
+	first := records[:strings.Index(records, "\n")+1]
+	c.Assert(VerifyAuditWithKey(strings.NewReader(first+string(forged)), key), ErrorMatches, "audit line 2: record 1 does not match its hash")
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"io/ioutil"
+	"testing"
+)
+
+// benchmarkLoggers makes the loggers of the chain console loggers writing to nowhere at the
+// severity, in the text format, and returns a function restoring the chain.
+func benchmarkLoggers(b *testing.B, n int, sev Severity) func() {
+	var chain []Logger
+	for i := 0; i < n; i++ {
+		chain = append(chain, &consoleLogger{writerLogger: &writerLogger{sev, ioutil.Discard}, splitAt: SeverityOff})
+	}
+	previous := ReplaceLoggers(chain...)
+	b.ReportAllocs()
+	b.ResetTimer()
+	return func() {
+		b.StopTimer()
+		ReplaceLoggers(previous...)
+	}
+}
+
+func BenchmarkFiltered(b *testing.B) {
+	defer benchmarkLoggers(b, 1, SeverityError)()
+	for i := 0; i < b.N; i++ {
+		Debugf("hello %s %d", "world", i)
+	}
+}
+
+func BenchmarkFilteredWithFields(b *testing.B) {
+	e := WithFields(Fields{"user": "bob", "attempt": 2})
+	defer benchmarkLoggers(b, 1, SeverityError)()
+	for i := 0; i < b.N; i++ {
+		e.Debugf("hello %s %d", "world", i)
+	}
+}
+
+func BenchmarkInfof(b *testing.B) {
+	defer benchmarkLoggers(b, 1, SeverityInfo)()
+	for i := 0; i < b.N; i++ {
+		Infof("hello %s %d", "world", i)
+	}
+}
+
+func BenchmarkInfofWithFields(b *testing.B) {
+	e := WithFields(Fields{"user": "bob", "attempt": 2})
+	defer benchmarkLoggers(b, 1, SeverityInfo)()
+	for i := 0; i < b.N; i++ {
+		e.Infof("hello %s %d", "world", i)
+	}
+}
+
+func BenchmarkInfofThreeLoggers(b *testing.B) {
+	defer benchmarkLoggers(b, 3, SeverityInfo)()
+	for i := 0; i < b.N; i++ {
+		Infof("hello %s %d", "world", i)
+	}
+}
+
+func BenchmarkInfofParallel(b *testing.B) {
+	defer benchmarkLoggers(b, 1, SeverityInfo)()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			Infof("hello %s", "world")
+		}
+	})
+}
@@ -0,0 +1,350 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// maxBinaryRecord bounds the size of the records DecodeBinaryLog accepts, which guards
+// against allocating huge buffers for corrupted lengths.
+const maxBinaryRecord = 64 << 20
+
+// Tags of the values of binary records.
+const (
+	binaryNil byte = iota
+	binaryBool
+	binaryInt
+	binaryUint
+	binaryFloat32
+	binaryFloat64
+	binaryString
+	binaryBytes
+	binaryDuration
+	binaryTime
+)
+
+// BinaryLogger is a type of writerLogger writing messages in a compact binary format meant for
+// capturing high volumes of messages locally at little cost: the format string is written along
+// with the arguments instead of formatting them, see DecodeBinaryLog to read them back.
+//
+// Every record is prefixed with its length and carries the severity, the timestamp, the caller,
+// the format string, the arguments and the fields. Arguments of basic types such as numbers,
+// strings, durations and times are written as they are, the message is formatted up front if
+// any argument is of another type. Fields of other types are written as fmt renders them.
+type BinaryLogger struct {
+	*writerLogger // provides Writer() through embedding
+}
+
+// NewBinaryLogger makes a logger writing messages of any severity to w in the binary format.
+func NewBinaryLogger(w io.Writer) *BinaryLogger {
+	return &BinaryLogger{&writerLogger{SeverityTrace, w}}
+}
+
+func (l *BinaryLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.FormatMessageWithFields(sev, caller, nil, format, args...)
+}
+
+func (l *BinaryLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return l.formatTimed(sev, caller, now(), fields, &lazyMessage{format: format, args: args})
+}
+
+func (l *BinaryLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	format, args := m.format, m.args
+	var b bytes.Buffer
+	putVarint(&b, int64(sev))
+	putVarint(&b, t.UnixNano())
+	putString(&b, caller.FileName)
+	putString(&b, caller.FuncName)
+	putUvarint(&b, uint64(caller.LineNo))
+
+	mark := b.Len()
+	putString(&b, format)
+	putUvarint(&b, uint64(len(args)))
+	for _, arg := range args {
+		if !putValue(&b, arg) {
+			// format the message now rather than lose what the argument looks like
+			b.Truncate(mark)
+			putString(&b, "%s")
+			putUvarint(&b, 1)
+			putValue(&b, fmt.Sprintf(format, args...))
+			break
+		}
+	}
+
+	putUvarint(&b, uint64(len(fields)))
+	for k, v := range fields {
+		putString(&b, k)
+		if !putValue(&b, v) {
+			putValue(&b, fmt.Sprint(v))
+		}
+	}
+
+	var record bytes.Buffer
+	putUvarint(&record, uint64(b.Len()))
+	b.WriteTo(&record)
+	return record.String()
+}
+
+func putVarint(b *bytes.Buffer, v int64) {
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutVarint(buf[:], v)])
+}
+
+func putUvarint(b *bytes.Buffer, v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	b.Write(buf[:binary.PutUvarint(buf[:], v)])
+}
+
+func putString(b *bytes.Buffer, s string) {
+	putUvarint(b, uint64(len(s)))
+	b.WriteString(s)
+}
+
+// putValue writes the tagged value if it is of a type the binary format supports.
+func putValue(b *bytes.Buffer, v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		b.WriteByte(binaryNil)
+	case bool:
+		b.WriteByte(binaryBool)
+		if v {
+			b.WriteByte(1)
+		} else {
+			b.WriteByte(0)
+		}
+	case int:
+		b.WriteByte(binaryInt)
+		putVarint(b, int64(v))
+	case int8:
+		b.WriteByte(binaryInt)
+		putVarint(b, int64(v))
+	case int16:
+		b.WriteByte(binaryInt)
+		putVarint(b, int64(v))
+	case int32:
+		b.WriteByte(binaryInt)
+		putVarint(b, int64(v))
+	case int64:
+		b.WriteByte(binaryInt)
+		putVarint(b, v)
+	case uint:
+		b.WriteByte(binaryUint)
+		putUvarint(b, uint64(v))
+	case uint8:
+		b.WriteByte(binaryUint)
+		putUvarint(b, uint64(v))
+	case uint16:
+		b.WriteByte(binaryUint)
+		putUvarint(b, uint64(v))
+	case uint32:
+		b.WriteByte(binaryUint)
+		putUvarint(b, uint64(v))
+	case uint64:
+		b.WriteByte(binaryUint)
+		putUvarint(b, v)
+	case float32:
+		b.WriteByte(binaryFloat32)
+		binary.Write(b, binary.LittleEndian, math.Float32bits(v))
+	case float64:
+		b.WriteByte(binaryFloat64)
+		binary.Write(b, binary.LittleEndian, math.Float64bits(v))
+	case string:
+		b.WriteByte(binaryString)
+		putString(b, v)
+	case []byte:
+		b.WriteByte(binaryBytes)
+		putString(b, string(v))
+	case error:
+		b.WriteByte(binaryString)
+		putString(b, v.Error())
+	case time.Duration:
+		b.WriteByte(binaryDuration)
+		putVarint(b, int64(v))
+	case time.Time:
+		b.WriteByte(binaryTime)
+		putVarint(b, v.UnixNano())
+	default:
+		return false
+	}
+	return true
+}
+
+// DecodeBinaryLog returns a reader of the messages written by a BinaryLogger to r as lines of
+// text: the timestamp, the severity, the caller, the message and the fields. Reading fails with
+// io.ErrUnexpectedEOF if r ends in the middle of a record.
+func DecodeBinaryLog(r io.Reader) io.Reader {
+	return &binaryDecoder{r: bufio.NewReader(r)}
+}
+
+// binaryDecoder decodes binary records into lines of text.
+type binaryDecoder struct {
+	r    *bufio.Reader
+	line []byte // the part of the current line that has not been read yet
+	err  error
+}
+
+func (d *binaryDecoder) Read(p []byte) (int, error) {
+	for len(d.line) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		d.line, d.err = d.next()
+	}
+	n := copy(p, d.line)
+	d.line = d.line[n:]
+	return n, nil
+}
+
+// next decodes the next record.
+func (d *binaryDecoder) next() ([]byte, error) {
+	size, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return nil, err
+	}
+	if size > maxBinaryRecord {
+		return nil, fmt.Errorf("binary log record too large: %d bytes", size)
+	}
+	record := make([]byte, size)
+	if _, err := io.ReadFull(d.r, record); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+
+	line, err := decodeBinaryRecord(bytes.NewReader(record))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt binary log record: %v", err)
+	}
+	return line, nil
+}
+
+// errBinaryValue reports values of unknown types, which only corrupted records have.
+var errBinaryValue = errors.New("unknown value type")
+
+func decodeBinaryRecord(r *bytes.Reader) ([]byte, error) {
+	sev, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	file, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+	funcName, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+	lineNo, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	format, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+
+	args, err := readValues(r)
+	if err != nil {
+		return nil, err
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(Fields)
+	for i := uint64(0); i < count; i++ {
+		k, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if fields[k], err = readValue(r); err != nil {
+			return nil, err
+		}
+	}
+
+	message := fmt.Sprintf(format, args...)
+	if len(fields) > 0 {
+		message += " " + formatFields(fields)
+	}
+	return []byte(fmt.Sprintf("%s %s [%s:%d:%s] %s\n",
+		time.Unix(0, timestamp).UTC().Format(time.RFC3339Nano), Severity(sev), file, lineNo, funcName, message)), nil
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", err
+	}
+	if n > uint64(r.Len()) {
+		return "", io.ErrUnexpectedEOF
+	}
+	s := make([]byte, n)
+	_, err = io.ReadFull(r, s)
+	return string(s), err
+}
+
+func readValues(r *bytes.Reader) ([]interface{}, error) {
+	n, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(r.Len()) {
+		return nil, io.ErrUnexpectedEOF
+	}
+	values := make([]interface{}, n)
+	for i := range values {
+		if values[i], err = readValue(r); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+func readValue(r *bytes.Reader) (interface{}, error) {
+	tag, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch tag {
+	case binaryNil:
+		return nil, nil
+	case binaryBool:
+		b, err := r.ReadByte()
+		return b != 0, err
+	case binaryInt:
+		return binary.ReadVarint(r)
+	case binaryUint:
+		return binary.ReadUvarint(r)
+	case binaryFloat32:
+		var bits uint32
+		err := binary.Read(r, binary.LittleEndian, &bits)
+		return math.Float32frombits(bits), err
+	case binaryFloat64:
+		var bits uint64
+		err := binary.Read(r, binary.LittleEndian, &bits)
+		return math.Float64frombits(bits), err
+	case binaryString:
+		return readString(r)
+	case binaryBytes:
+		s, err := readString(r)
+		return []byte(s), err
+	case binaryDuration:
+		d, err := binary.ReadVarint(r)
+		return time.Duration(d), err
+	case binaryTime:
+		t, err := binary.ReadVarint(r)
+		return time.Unix(0, t).UTC(), err
+	}
+	return nil, errBinaryValue
+}
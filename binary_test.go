@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type BinaryLoggerSuite struct {
+}
+
+var _ = Suite(&BinaryLoggerSuite{})
+
+func (s *BinaryLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 500, time.UTC) }))
+}
+
+func (s *BinaryLoggerSuite) TearDownTest(c *C) {
+	SetClock(nil)
+}
+
+type binaryPoint struct {
+	X, Y int
+}
+
+func (s *BinaryLoggerSuite) TestRoundTrip(c *C) {
+	var buf bytes.Buffer
+	l := NewBinaryLogger(&buf)
+	l.SetSeverity(SeverityInfo)
+	Init(l)
+
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	Debugf("hello %s", "debug")
+	Infof("hello %s %d %d %t", "world", -42, uint8(7), true)
+	Warningf("%.2f %v %q %x %v", 1.5, float32(0.1), "quoted", []byte("hex"), nil)
+	WithFields(Fields{"user": "bob", "point": binaryPoint{1, 2}}).Errorf("took %v at %v: %v", 1500*time.Millisecond, at, errors.New("oops"))
+	Infof("point %+v", binaryPoint{1, 2})
+	Info("hello ", "world")
+
+	decoded, err := ioutil.ReadAll(DecodeBinaryLog(&buf))
+	c.Assert(err, IsNil)
+	c.Assert(string(decoded), Matches, ""+
+		`2014-03-05T07:09:11.0000005Z INFO \[binary_test.go:[0-9]+:.*TestRoundTrip\] hello world -42 7 true`+"\n"+
+		`2014-03-05T07:09:11.0000005Z WARN \[binary_test.go:[0-9]+:.*TestRoundTrip\] 1.50 0.1 "quoted" 686578 <nil>`+"\n"+
+		`2014-03-05T07:09:11.0000005Z ERROR \[binary_test.go:[0-9]+:.*TestRoundTrip\] took 1.5s at 2020-01-02 03:04:05 \+0000 UTC: oops point="{1 2}" user=bob`+"\n"+
+		`2014-03-05T07:09:11.0000005Z INFO \[binary_test.go:[0-9]+:.*TestRoundTrip\] point {X:1 Y:2}`+"\n"+
+		`2014-03-05T07:09:11.0000005Z INFO \[binary_test.go:[0-9]+:.*TestRoundTrip\] hello world`+"\n")
+}
+
+func (s *BinaryLoggerSuite) TestTruncated(c *C) {
+	var buf bytes.Buffer
+	Init(NewBinaryLogger(&buf))
+	Infof("hello %s", "world")
+	Infof("hello %s", "again")
+
+	record := buf.Bytes()[:buf.Len()-3]
+	decoder := DecodeBinaryLog(bytes.NewReader(record))
+	decoded, err := ioutil.ReadAll(decoder)
+	c.Assert(err, Equals, io.ErrUnexpectedEOF)
+	c.Assert(string(decoded), Matches, ".* INFO .* hello world\n")
+
+	_, err = ioutil.ReadAll(DecodeBinaryLog(bytes.NewReader([]byte{2, 0, 0})))
+	c.Assert(err, ErrorMatches, "corrupt binary log record: .*")
+}
@@ -0,0 +1,86 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// bootstrapBufferSize is the maximum number of messages kept until loggers are initialized.
+const bootstrapBufferSize = 1000
+
+// bootstrap buffers messages logged before the package is initialized, for example from
+// init functions of libraries, so that they are not lost.
+var bootstrap = &bootstrapBuffer{enabled: true}
+
+// bootstrapBuffer is a ring of messages logged before the first Init.
+type bootstrapBuffer struct {
+	mu sync.Mutex
+
+	enabled  bool
+	messages []bufferedMessage
+	next     int // index of the oldest message once the ring is full
+	dropped  int
+}
+
+// bufferedMessage is a message kept by the bootstrap buffer.
+type bufferedMessage struct {
+	sev     Severity
+	caller  *CallerInfo
+	t       time.Time
+	e       *Entry
+	fields  Fields
+	message string
+}
+
+// add keeps the message if buffering is enabled, dropping the oldest one if the buffer is full.
+func (b *bootstrapBuffer) add(m bufferedMessage) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.enabled {
+		return
+	}
+	if len(b.messages) < bootstrapBufferSize {
+		b.messages = append(b.messages, m)
+		return
+	}
+	b.messages[b.next] = m
+	b.next = (b.next + 1) % bootstrapBufferSize
+	b.dropped++
+}
+
+// flush sends the buffered messages to the loggers and disables buffering.
+func (b *bootstrapBuffer) flush(chain []Logger) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.enabled {
+		return
+	}
+	b.enabled = false
+
+	if b.dropped > 0 {
+		message := fmt.Sprintf("%d messages logged before initialization were dropped", b.dropped)
+		guardSending(func() {
+			sendMessage(chain, SeverityWarning, getCallerInfo(0), now(), nil, nil, formattedMessage(message))
+		})
+	}
+	for i := range b.messages {
+		// the messages keep the time they were logged at
+		m := b.messages[(b.next+i)%len(b.messages)]
+		guardSending(func() {
+			sendMessage(chain, m.sev, m.caller, m.t, m.e, m.fields, formattedMessage(m.message))
+		})
+	}
+	b.messages, b.next, b.dropped = nil, 0, 0
+}
+
+// stop disables buffering, discarding buffered messages.
+func (b *bootstrapBuffer) stop() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.enabled = false
+	b.messages, b.next, b.dropped = nil, 0, 0
+}
@@ -0,0 +1,97 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func init() {
+	// other tests log before initializing their loggers, do not flush their messages
+	// into loggers of unrelated tests
+	bootstrap.stop()
+}
+
+type BootstrapSuite struct {
+}
+
+var _ = Suite(&BootstrapSuite{})
+
+func (s *BootstrapSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	bootstrap = &bootstrapBuffer{enabled: true}
+}
+
+func (s *BootstrapSuite) TearDownTest(c *C) {
+	bootstrap.stop()
+}
+
+func (s *BootstrapSuite) TestFlushLogging(c *C) {
+	var fallback bytes.Buffer
+	stderr = &fallback
+	defer func() { stderr = os.Stderr }()
+	Infof("hello %s", "world")
+
+	// a logger logging while the buffered messages are flushed to it does not hang Init
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Init(&failingLogger{})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		c.Fatal("Init hangs")
+	}
+	c.Assert(fallback.String(), Equals, "ERROR failed to write 11 bytes error=\"connection refused\" (logged while logging)\n")
+}
+
+func (s *BootstrapSuite) TestFlush(c *C) {
+	Debugf("hello %s", "debug")
+	WithFields(Fields{"user": "bob"}).Warningf("hello %s", "warning")
+
+	info := newThresholdLogger("info", SeverityInfo)
+	debug := newThresholdLogger("debug", SeverityDebug)
+	Init(info, debug)
+
+	// buffered messages respect the loggers' severities
+	c.Assert(info.b.String(), Equals, "WARN hello warning user=bob\n")
+	c.Assert(debug.b.String(), Equals, "DEBUG hello debug\nWARN hello warning user=bob\n")
+	c.Assert(strings.HasSuffix(debug.caller.FuncName, "TestFlush"), Equals, true)
+
+	// buffering is disabled and nothing is flushed again
+	loggers = []Logger{}
+	Infof("hello %s", "lost")
+	later := newTestLogger("later")
+	Init(later)
+	c.Assert(later.b.Len(), Equals, 0)
+}
+
+func (s *BootstrapSuite) TestBounded(c *C) {
+	for i := 0; i < bootstrapBufferSize+10; i++ {
+		Infof("message %d", i)
+	}
+	c.Assert(bootstrap.messages, HasLen, bootstrapBufferSize)
+
+	logger := newTestLogger("log")
+	Init(logger)
+
+	// the oldest messages are dropped
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, bootstrapBufferSize+1)
+	c.Assert(lines[0], Equals, "WARN 10 messages logged before initialization were dropped")
+	c.Assert(lines[1], Equals, "INFO message 10")
+	c.Assert(lines[bootstrapBufferSize], Equals, fmt.Sprintf("INFO message %d", bootstrapBufferSize+9))
+}
+
+func (s *BootstrapSuite) TestInitWithConfig(c *C) {
+	Infof("hello %s", "world")
+
+	c.Assert(InitWithConfig(Config{Name: UDPLog, Severity: "info"}, Config{Name: UDPLog, Severity: "error"}), IsNil)
+	c.Assert(bootstrap.enabled, Equals, false)
+	c.Assert(bootstrap.messages, HasLen, 0)
+}
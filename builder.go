@@ -0,0 +1,139 @@
+package log
+
+import (
+	"fmt"
+	"io"
+)
+
+// Builder makes a logger from settings chained in code, as an alternative to filling a Config
+// and selecting the logger type by name, for example:
+//
+//	logger, err := log.NewConsole().WithSeverity(log.SeverityWarning).WithColor(true).Build()
+//
+// Settings that do not apply to the type of logger being built are ignored, as they are in a Config.
+type Builder struct {
+	conf Config
+	sev  Severity
+	w    io.Writer
+}
+
+// NewConsole starts building a console logger logging messages of any severity to the standard output.
+func NewConsole() *Builder {
+	return newBuilder(Console)
+}
+
+// NewSyslog starts building a syslog logger logging messages of any severity.
+func NewSyslog() *Builder {
+	return newBuilder(Syslog)
+}
+
+// NewUDP starts building a udplog logger logging messages of any severity.
+func NewUDP() *Builder {
+	return newBuilder(UDPLog)
+}
+
+// NewGELF starts building a gelf logger logging messages of any severity to a local Graylog input.
+func NewGELF() *Builder {
+	return newBuilder(GELF)
+}
+
+// NewJournald starts building a journald logger logging messages of any severity to the local journal.
+func NewJournald() *Builder {
+	return newBuilder(Journald)
+}
+
+// NewFile starts building a file logger appending messages of any severity to the file at path.
+func NewFile(path string) *Builder {
+	b := newBuilder(File)
+	b.conf.Path = path
+	return b
+}
+
+func newBuilder(name string) *Builder {
+	return &Builder{conf: Config{Name: name}, sev: SeverityDebug}
+}
+
+// WithSeverity sets the minimum severity the logger will be logging messages at.
+func (b *Builder) WithSeverity(sev Severity) *Builder {
+	b.sev = sev
+	return b
+}
+
+// WithLevelStyle sets the style of severity names, see Config.LevelStyle.
+func (b *Builder) WithLevelStyle(style string) *Builder {
+	b.conf.LevelStyle = style
+	return b
+}
+
+// WithSeverityMapper sets the mapper translating severities to syslog priorities, see Config.SeverityMapper.
+func (b *Builder) WithSeverityMapper(mapper SeverityMapper) *Builder {
+	b.conf.SeverityMapper = mapper
+	return b
+}
+
+// WithFacility sets the facility and the tag of syslog messages, see Config.Facility.
+func (b *Builder) WithFacility(facility, tag string) *Builder {
+	b.conf.Facility = facility
+	b.conf.Tag = tag
+	return b
+}
+
+// WithAddress makes a syslog logger send messages to a remote server, and a gelf logger send
+// them to another Graylog input, see Config.Address.
+func (b *Builder) WithAddress(network, address string) *Builder {
+	b.conf.Network = network
+	b.conf.Address = address
+	return b
+}
+
+// WithFormat sets the format of messages written by console and file loggers, see Config.Format.
+func (b *Builder) WithFormat(format string) *Builder {
+	b.conf.Format = format
+	return b
+}
+
+// WithKeyNames sets the standard keys of JSON messages, see Config.KeyNames.
+func (b *Builder) WithKeyNames(keys KeyNames) *Builder {
+	b.conf.KeyNames = keys
+	return b
+}
+
+// WithColor turns on or off coloring the severities of console messages, see Config.Color.
+func (b *Builder) WithColor(color bool) *Builder {
+	b.conf.Color = color
+	return b
+}
+
+// WithIndent sets the prefix of continuation lines of multi-line messages, see Config.Indent.
+func (b *Builder) WithIndent(indent string) *Builder {
+	b.conf.Indent = indent
+	return b
+}
+
+// WithWriter makes a console logger write all messages to w instead of the standard streams.
+// Other loggers do not support it.
+func (b *Builder) WithWriter(w io.Writer) *Builder {
+	b.w = w
+	return b
+}
+
+// Build makes the logger.
+func (b *Builder) Build() (Logger, error) {
+	if b.w != nil && b.conf.Name != Console {
+		return nil, fmt.Errorf("%s logger does not support writers", b.conf.Name)
+	}
+
+	conf := b.conf
+	conf.Severity = b.sev.String()
+	l, err := NewLogger(conf)
+	if err != nil || b.w == nil {
+		return l, err
+	}
+
+	console, ok := l.(*consoleLogger)
+	if !ok {
+		return nil, fmt.Errorf("console logger does not support writers on this platform")
+	}
+	console.w, console.errW = b.w, nil
+	return console, nil
+}
@@ -0,0 +1,88 @@
+package log
+
+import (
+	"bytes"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type BuilderSuite struct {
+}
+
+var _ = Suite(&BuilderSuite{})
+
+func (s *BuilderSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *BuilderSuite) TestConsole(c *C) {
+	built, err := NewConsole().WithSeverity(SeverityWarning).WithColor(true).WithLevelStyle(LevelStyleShort3).WithIndent("  ").Build()
+	c.Assert(err, IsNil)
+
+	configured, err := NewLogger(Config{Name: Console, Severity: "warn", Color: true, LevelStyle: LevelStyleShort3, Indent: "  "})
+	c.Assert(err, IsNil)
+	c.Assert(built, DeepEquals, configured)
+
+	_, err = NewConsole().WithFormat("xml").Build()
+	c.Assert(err, NotNil)
+}
+
+func (s *BuilderSuite) TestConsoleWriter(c *C) {
+	var buf bytes.Buffer
+	l, err := NewConsole().WithSeverity(SeverityWarning).WithFormat(FormatJSON).WithWriter(&buf).Build()
+	c.Assert(err, IsNil)
+	Init(l)
+
+	Infof("hello %s", "info")
+	Errorf("hello %s", "error")
+	c.Assert(buf.String(), Matches, `\{.*"message":"hello error".*\}`+"\n")
+}
+
+func (s *BuilderSuite) TestFile(c *C) {
+	path := filepath.Join(c.MkDir(), "app.log")
+	built, err := NewFile(path).WithSeverity(SeverityError).WithFormat(FormatJSON).Build()
+	c.Assert(err, IsNil)
+	defer built.(*fileLogger).Close()
+
+	configured, err := NewLogger(Config{Name: File, Severity: "error", Format: FormatJSON, Path: path})
+	c.Assert(err, IsNil)
+	defer configured.(*fileLogger).Close()
+
+	c.Assert(built.(*fileLogger).Severity(), Equals, configured.(*fileLogger).Severity())
+	c.Assert(built.(*fileLogger).format, Equals, configured.(*fileLogger).format)
+	c.Assert(built.(*fileLogger).file.path, Equals, configured.(*fileLogger).file.path)
+
+	_, err = NewFile("").Build()
+	c.Assert(err, NotNil)
+}
+
+func (s *BuilderSuite) TestSyslog(c *C) {
+	built, err := NewSyslog().WithSeverity(SeverityInfo).WithLevelStyle(LevelStyleChar).Build()
+	c.Assert(err, IsNil)
+
+	configured, err := NewLogger(Config{Name: Syslog, Severity: "info", LevelStyle: LevelStyleChar})
+	c.Assert(err, IsNil)
+	c.Assert(built.(*sysLogger).sev, Equals, configured.(*sysLogger).sev)
+	c.Assert(built.(*sysLogger).levelStyle, Equals, configured.(*sysLogger).levelStyle)
+
+	built, err = NewSyslog().WithFacility("local1", "app").WithAddress("udp", "127.0.0.1:514").Build()
+	c.Assert(err, IsNil)
+	c.Assert(built.(*sysLogger).remote, Equals, true)
+	c.Assert(built.(*sysLogger).tag, Equals, "app")
+	c.Assert(built.(*sysLogger).Close(), IsNil)
+
+	_, err = NewSyslog().WithWriter(&bytes.Buffer{}).Build()
+	c.Assert(err, ErrorMatches, "syslog logger does not support writers")
+}
+
+func (s *BuilderSuite) TestUDP(c *C) {
+	built, err := NewUDP().WithSeverity(SeverityOff).Build()
+	c.Assert(err, IsNil)
+	defer built.(*udpLogger).Close()
+
+	configured, err := NewLogger(Config{Name: UDPLog, Severity: "off"})
+	c.Assert(err, IsNil)
+	defer configured.(*udpLogger).Close()
+	c.Assert(built.(*udpLogger).sev, Equals, configured.(*udpLogger).sev)
+}
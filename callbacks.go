@@ -0,0 +1,114 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fatalCallbackTimeout bounds the time callbacks can take on a fatal message before
+// the program exits.
+var fatalCallbackTimeout = 5 * time.Second
+
+// callbackBudget is the time in nanoseconds a callback can take before it is reported as slow.
+var callbackBudget = int64(100 * time.Millisecond)
+
+var (
+	callbacksMu sync.RWMutex
+	callbacks   []severityCallback
+)
+
+// severityCallback is a callback registered for messages at or above a severity.
+type severityCallback struct {
+	sev Severity
+	cb  func(msg string)
+
+	// site is where the callback was registered and warned is set once it has been reported as slow.
+	site   string
+	warned *int32
+}
+
+// OnSeverity registers a callback invoked with the text of every message logged at or above
+// the provided severity, for example to notify an incident webhook before Fatalf exits the
+// program.
+//
+// Callbacks run in registration order on the goroutine logging the message, after the message
+// has been sent to the loggers. On FATAL messages they are given at most 5 seconds in total
+// so that a slow callback cannot prevent the program from exiting. A callback running longer
+// than the budget, see SetCallbackBudget, is reported in a WARN message once.
+func OnSeverity(sev Severity, cb func(msg string)) {
+	caller := getCallerInfo(1)
+
+	callbacksMu.Lock()
+	defer callbacksMu.Unlock()
+
+	callbacks = append(callbacks, severityCallback{sev, cb, fmt.Sprintf("%s:%d", caller.FilePath, caller.LineNo), new(int32)})
+}
+
+// SetCallbackBudget sets the time a callback registered with OnSeverity can take on a message
+// before it is reported as slow, 100ms by default. A non-positive budget turns reporting off.
+func SetCallbackBudget(budget time.Duration) {
+	atomic.StoreInt64(&callbackBudget, int64(budget))
+}
+
+// calledBack tells whether any callback is registered for the severity.
+func calledBack(sev Severity) bool {
+	callbacksMu.RLock()
+	defer callbacksMu.RUnlock()
+
+	for _, c := range callbacks {
+		if sev >= c.sev {
+			return true
+		}
+	}
+	return false
+}
+
+// runCallbacks invokes the callbacks registered for the severity with the message and returns
+// the warnings about the callbacks that were found slow for the first time.
+func runCallbacks(sev Severity, m *lazyMessage) []string {
+	callbacksMu.RLock()
+	var matching []severityCallback
+	for _, c := range callbacks {
+		if sev >= c.sev {
+			matching = append(matching, c)
+		}
+	}
+	callbacksMu.RUnlock()
+
+	if len(matching) == 0 {
+		return nil
+	}
+
+	message := m.text()
+	budget := time.Duration(atomic.LoadInt64(&callbackBudget))
+	var warnings []string
+	run := func() {
+		for _, c := range matching {
+			start := now()
+			c.cb(message)
+			took := now().Sub(start)
+			if budget > 0 && took > budget && atomic.CompareAndSwapInt32(c.warned, 0, 1) {
+				warnings = append(warnings, fmt.Sprintf("slow callback registered at %s took %v, over the budget of %v", c.site, took, budget))
+			}
+		}
+	}
+
+	if sev < SeverityFatal {
+		run()
+		return warnings
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		run()
+	}()
+	select {
+	case <-done:
+	case <-time.After(fatalCallbackTimeout):
+	}
+	// the program is exiting, there is no point in reporting slow callbacks
+	return nil
+}
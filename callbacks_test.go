@@ -0,0 +1,104 @@
+package log
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type CallbacksSuite struct {
+	exitCode int
+}
+
+var _ = Suite(&CallbacksSuite{})
+
+func (s *CallbacksSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	callbacks = nil
+
+	s.exitCode = -1
+	exit = func(code int) { s.exitCode = code }
+}
+
+func (s *CallbacksSuite) TearDownTest(c *C) {
+	callbacks = nil
+	exit = os.Exit
+	fatalCallbackTimeout = 5 * time.Second
+	SetCallbackBudget(100 * time.Millisecond)
+	SetClock(nil)
+}
+
+func (s *CallbacksSuite) TestSlowCallback(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	t := time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return t }))
+	SetCallbackBudget(50 * time.Millisecond)
+
+	calls := 0
+	OnSeverity(SeverityError, func(msg string) {
+		// the callback takes longer than the budget
+		t = t.Add(80 * time.Millisecond)
+		calls++
+	})
+	Errorf("hello %s", "error")
+	Errorf("hello %s", "again")
+
+	c.Assert(calls, Equals, 2)
+	c.Assert(logger.b.String(), Matches, "ERROR hello error\n"+
+		"WARN slow callback registered at .*callbacks_test.go:[0-9]+ took 80ms, over the budget of 50ms\n"+
+		"ERROR hello again\n")
+}
+
+func (s *CallbacksSuite) TestOnSeverity(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	var messages []string
+	OnSeverity(SeverityError, func(msg string) {
+		// the message has already been logged
+		c.Assert(logger.b.Len() > 0, Equals, true)
+		messages = append(messages, msg)
+	})
+
+	Infof("hello %s", "info")
+	Warningf("hello %s", "warning")
+	c.Assert(messages, HasLen, 0)
+
+	Errorf("hello %s", "error")
+	WithFields(Fields{"user": "bob"}).Error("hello ", "entry")
+	Fatalf("hello %s", "fatal")
+	c.Assert(messages, HasLen, 3)
+	c.Assert(messages[:2], DeepEquals, []string{"hello error", "hello entry"})
+	c.Assert(strings.HasPrefix(messages[2], "hello fatal\ngoroutine "), Equals, true)
+	c.Assert(s.exitCode, Equals, 255)
+}
+
+func (s *CallbacksSuite) TestOnSeverityOrder(c *C) {
+	Init(newTestLogger("log"))
+
+	var order []string
+	OnSeverity(SeverityInfo, func(msg string) { order = append(order, "first") })
+	OnSeverity(SeverityWarning, func(msg string) { order = append(order, "second") })
+	OnSeverity(SeverityDebug, func(msg string) { order = append(order, "third") })
+
+	Warningf("hello %s", "world")
+	c.Assert(order, DeepEquals, []string{"first", "second", "third"})
+}
+
+func (s *CallbacksSuite) TestOnSeverityFatalTimeout(c *C) {
+	Init(newTestLogger("log"))
+	fatalCallbackTimeout = 50 * time.Millisecond
+
+	release := make(chan struct{})
+	defer close(release)
+	OnSeverity(SeverityFatal, func(msg string) { <-release })
+
+	start := time.Now()
+	Fatal("hello world")
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+	c.Assert(s.exitCode, Equals, 255)
+}
@@ -4,12 +4,52 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 var (
 	pid      = os.Getpid()
 	hostname = "" // will be set in the init function
 	appname  = filepath.Base(os.Args[0])
+
+	// started is the baseline of the "uptime" field, it carries a monotonic clock reading.
+	started = time.Now()
+
+	// callerInfos caches the information about the callers by program counter, there are only
+	// so many call sites.
+	callerInfosMu sync.RWMutex
+	callerInfos   = make(map[uintptr]CallerInfo)
+)
+
+// Names of the fields carrying process information.
+const (
+	HostField   = "host"
+	PIDField    = "pid"
+	UptimeField = "uptime"
+
+	VersionField      = "version"
+	RevisionField     = "vcs.revision"
+	RevisionTimeField = "vcs.time"
+)
+
+var (
+	// includeHost, includePID and includeUptime are non-zero when the respective fields are
+	// attached to messages.
+	includeHost   int32
+	includePID    int32
+	includeUptime int32
+
+	// excludeCaller is non-zero when the callers of messages are not looked up.
+	excludeCaller int32
+
+	// buildFields holds the fields read from the build info when they are attached to messages.
+	buildFields atomic.Value
+
+	// readBuildInfo reads the build info of the binary, it is replaced in tests.
+	readBuildInfo = debug.ReadBuildInfo
 )
 
 func init() {
@@ -20,6 +60,92 @@ func init() {
 	}
 }
 
+// SetIncludeHost turns on or off attaching a "host" field with the host name to every message.
+func SetIncludeHost(include bool) {
+	setFlag(&includeHost, include)
+}
+
+// SetIncludePID turns on or off attaching a "pid" field with the process ID to every message.
+func SetIncludePID(include bool) {
+	setFlag(&includePID, include)
+}
+
+// SetIncludeUptime turns on or off attaching an "uptime" field with the time.Duration elapsed
+// since the package was initialized to every message, which helps profiling startup. The
+// monotonic clock is used unless the clock is replaced, see SetClock.
+func SetIncludeUptime(include bool) {
+	setFlag(&includeUptime, include)
+}
+
+// SetIncludeCaller turns on or off looking up the file, function and line of the callers of
+// messages, which is on by default. Services logging heavily can turn it off to spare the cost
+// of walking the stack, their messages then being reported as logged from "unknown_file" at
+// line 0, and sampling telling call sites apart by their format strings only, see SetSampling.
+func SetIncludeCaller(include bool) {
+	setFlag(&excludeCaller, !include)
+}
+
+// SetIncludeBuildInfo turns on or off attaching the build info of the binary to every message:
+// the module version as the "version" field and the VCS revision and its commit time as the
+// "vcs.revision" and "vcs.time" fields. The build info is read when turned on, the fields that
+// are not available, e.g. for binaries built with go run or out of a VCS checkout, are omitted.
+func SetIncludeBuildInfo(include bool) {
+	fields := Fields{}
+	if include {
+		fields = buildInfoFields()
+	}
+	buildFields.Store(fields)
+}
+
+// buildInfoFields returns the fields carrying the build info of the binary.
+func buildInfoFields() Fields {
+	fields := Fields{}
+	info, ok := readBuildInfo()
+	if !ok {
+		return fields
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		fields[VersionField] = v
+	}
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case RevisionField, RevisionTimeField:
+			if setting.Value != "" {
+				fields[setting.Key] = setting.Value
+			}
+		}
+	}
+	return fields
+}
+
+// uptime returns the time elapsed since the package was initialized according to the clock in use.
+func uptime() time.Duration {
+	return now().Sub(started)
+}
+
+// withProcessFields returns fields with the host name, the process ID, the uptime and the build
+// info attached if configured. Fields of the same name that are already present take precedence.
+func withProcessFields(fields Fields) Fields {
+	build, _ := buildFields.Load().(Fields)
+	if !flagSet(&includeHost) && !flagSet(&includePID) && !flagSet(&includeUptime) && len(build) == 0 {
+		return fields
+	}
+	process := make(Fields, len(build)+3)
+	for k, v := range build {
+		process[k] = v
+	}
+	if flagSet(&includeHost) {
+		process[HostField] = hostname
+	}
+	if flagSet(&includePID) {
+		process[PIDField] = pid
+	}
+	if flagSet(&includeUptime) {
+		process[UptimeField] = uptime()
+	}
+	return mergeFields(process, fields)
+}
+
 // CallerInfo encapsulates information about a piece of code that called a certain log function,
 // such as file name, line number, etc.
 type CallerInfo struct {
@@ -29,12 +155,39 @@ type CallerInfo struct {
 	LineNo   int
 }
 
+// unknownCaller is the caller of messages whose caller is not known.
+var unknownCaller = CallerInfo{"unknown_file", "unknown_path", "unknown_func", 0}
+
+// messageCaller returns information about the caller of a message unless caller lookup is
+// turned off.
+func messageCaller(depth int) *CallerInfo {
+	if flagSet(&excludeCaller) {
+		info := unknownCaller
+		return &info
+	}
+	return getCallerInfo(depth + 1)
+}
+
 // getCallerInfo returns information about a certain log function invoker
 // such as file name, function name and line number
 func getCallerInfo(depth int) *CallerInfo {
-	if pc, filePath, lineNo, ok := runtime.Caller(depth + 1); !ok {
-		return &CallerInfo{"unknown_file", "unknown_path", "unknown_func", 0}
-	} else {
-		return &CallerInfo{filepath.Base(filePath), filePath, runtime.FuncForPC(pc).Name(), lineNo}
+	var pcs [1]uintptr
+	if runtime.Callers(depth+2, pcs[:]) == 0 {
+		info := unknownCaller
+		return &info
+	}
+
+	callerInfosMu.RLock()
+	info, ok := callerInfos[pcs[0]]
+	callerInfosMu.RUnlock()
+	if !ok {
+		frame, _ := runtime.CallersFrames(pcs[:]).Next()
+		info = CallerInfo{filepath.Base(frame.File), frame.File, frame.Function, frame.Line}
+
+		callerInfosMu.Lock()
+		callerInfos[pcs[0]] = info
+		callerInfosMu.Unlock()
 	}
+	// every message gets its own copy, which loggers are free to modify
+	return &info
 }
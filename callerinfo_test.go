@@ -0,0 +1,125 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"runtime/debug"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ProcessFieldsSuite struct {
+}
+
+var _ = Suite(&ProcessFieldsSuite{})
+
+func (s *ProcessFieldsSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *ProcessFieldsSuite) TearDownTest(c *C) {
+	SetIncludeHost(false)
+	SetIncludePID(false)
+	SetIncludeUptime(false)
+	SetIncludeBuildInfo(false)
+	SetIncludeCaller(true)
+	readBuildInfo = debug.ReadBuildInfo
+}
+
+func (s *ProcessFieldsSuite) TestIncludeCaller(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	SetIncludeCaller(false)
+	Infof("hello %s", "world")
+	c.Assert(*logger.caller, Equals, CallerInfo{"unknown_file", "unknown_path", "unknown_func", 0})
+
+	SetIncludeCaller(true)
+	Infof("hello %s", "world")
+	c.Assert(logger.caller.FileName, Equals, "callerinfo_test.go")
+}
+
+func (s *ProcessFieldsSuite) TestProcessFields(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	host, _ := os.Hostname()
+
+	SetIncludeHost(true)
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, fmt.Sprintf("INFO hello world host=%s\n", host))
+
+	SetIncludePID(true)
+	logger.b.Reset()
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, fmt.Sprintf("INFO hello world host=%s pid=%d\n", host, os.Getpid()))
+
+	SetIncludeHost(false)
+	logger.b.Reset()
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, fmt.Sprintf("INFO hello world pid=%d\n", os.Getpid()))
+
+	SetIncludePID(false)
+	logger.b.Reset()
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world\n")
+}
+
+func (s *ProcessFieldsSuite) TestUptime(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	t := started.Add(250 * time.Millisecond)
+	SetClock(ClockFunc(func() time.Time { return t }))
+	defer SetClock(nil)
+
+	SetIncludeUptime(true)
+	Infof("hello %s", "world")
+	t = t.Add(1500 * time.Microsecond)
+	Infof("hello %s", "again")
+	c.Assert(logger.b.String(), Equals, "INFO hello world uptime=250ms\nINFO hello again uptime=251.5ms\n")
+}
+
+func (s *ProcessFieldsSuite) TestBuildInfo(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Path: "example.com/app", Version: "v1.2.3"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs", Value: "git"},
+				{Key: "vcs.revision", Value: "0123abc"},
+				{Key: "vcs.time", Value: "2014-03-05T07:09:11Z"},
+			},
+		}, true
+	}
+	SetIncludeBuildInfo(true)
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world vcs.revision=0123abc vcs.time=2014-03-05T07:09:11Z version=v1.2.3\n")
+
+	// go run builds have no version nor VCS info
+	readBuildInfo = func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Path: "example.com/app", Version: "(devel)"}}, true
+	}
+	SetIncludeBuildInfo(true)
+	logger.b.Reset()
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world\n")
+
+	readBuildInfo = func() (*debug.BuildInfo, bool) { return nil, false }
+	SetIncludeBuildInfo(true)
+	logger.b.Reset()
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world\n")
+}
+
+func (s *ProcessFieldsSuite) TestProcessFieldsPrecedence(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	SetIncludeHost(true)
+	WithFields(Fields{HostField: "upstream"}).Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world host=upstream\n")
+}
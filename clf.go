@@ -0,0 +1,97 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// clfTimeLayout is the layout of timestamps in Common Log Format lines.
+const clfTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// CLFFieldNames maps the components of Common Log Format lines to names of the message
+// fields holding them.
+type CLFFieldNames struct {
+	Remote  string
+	Ident   string
+	User    string
+	Time    string
+	Request string
+	Status  string
+	Bytes   string
+}
+
+// DefaultCLFFieldNames are the field names HTTP access logs use.
+var DefaultCLFFieldNames = CLFFieldNames{
+	Remote:  "remote",
+	Ident:   "ident",
+	User:    "user",
+	Time:    "time",
+	Request: "request",
+	Status:  "status",
+	Bytes:   "bytes",
+}
+
+// clfLogger is a type of writerLogger that sends Common Log Format (Apache access log)
+// lines built from message fields to the standard output.
+type clfLogger struct {
+	*writerLogger // provides Writer() through embedding
+
+	names CLFFieldNames
+}
+
+// NewCLFLogger makes a logger writing Common Log Format lines to the standard output.
+// The components of a line are taken from the message fields named by names, the message
+// itself is not logged.
+//
+// The request and status names are required. Components missing from a message are
+// rendered as "-", except for the time that defaults to the time the message was logged at.
+func NewCLFLogger(conf Config, names CLFFieldNames) (Logger, error) {
+	if names.Request == "" || names.Status == "" {
+		return nil, fmt.Errorf("request and status field names are required: %+v", names)
+	}
+
+	sev, err := severityFromString(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	return &clfLogger{&writerLogger{sev, os.Stdout}, names}, nil
+}
+
+func (l *clfLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.FormatMessageWithFields(sev, caller, nil, format, args...)
+}
+
+func (l *clfLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return l.formatTimed(sev, caller, now(), fields, nil)
+}
+
+func (l *clfLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	timestamp := t.Format(clfTimeLayout)
+	if t, ok := fields[l.names.Time].(time.Time); ok {
+		timestamp = t.Format(clfTimeLayout)
+	} else if v := clfValue(fields, l.names.Time); v != "-" {
+		timestamp = v
+	}
+
+	return fmt.Sprintf("%s %s %s [%s] \"%s\" %s %s%s",
+		clfValue(fields, l.names.Remote),
+		clfValue(fields, l.names.Ident),
+		clfValue(fields, l.names.User),
+		timestamp,
+		clfValue(fields, l.names.Request),
+		clfValue(fields, l.names.Status),
+		clfValue(fields, l.names.Bytes),
+		currentLineEnding())
+}
+
+// clfValue returns the value of the named field or "-" if the message does not have one.
+func clfValue(fields Fields, name string) string {
+	if v, ok := fields[name]; ok && name != "" {
+		if s := fmt.Sprint(v); s != "" {
+			return s
+		}
+	}
+	return "-"
+}
@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bytes"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type CLFLoggerSuite struct {
+}
+
+var _ = Suite(&CLFLoggerSuite{})
+
+func (s *CLFLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *CLFLoggerSuite) TestNewCLFLogger(c *C) {
+	l, err := NewCLFLogger(Config{Severity: "info"}, DefaultCLFFieldNames)
+	c.Assert(err, IsNil)
+	c.Assert(l.(*clfLogger).sev, Equals, SeverityInfo)
+
+	names := DefaultCLFFieldNames
+	names.Status = ""
+	l, err = NewCLFLogger(Config{Severity: "info"}, names)
+	c.Assert(err, NotNil)
+	c.Assert(l, IsNil)
+}
+
+func (s *CLFLoggerSuite) TestFormatMessage(c *C) {
+	b := &bytes.Buffer{}
+	Init(&clfLogger{&writerLogger{SeverityInfo, b}, DefaultCLFFieldNames})
+
+	WithFields(Fields{
+		"remote":  "127.0.0.1",
+		"ident":   "user-identifier",
+		"user":    "frank",
+		"time":    time.Date(2000, 10, 10, 13, 55, 36, 0, time.FixedZone("", -7*60*60)),
+		"request": "GET /apache_pb.gif HTTP/1.0",
+		"status":  200,
+		"bytes":   2326,
+	}).Infof("request completed")
+
+	c.Assert(b.String(), Equals, "127.0.0.1 user-identifier frank [10/Oct/2000:13:55:36 -0700] \"GET /apache_pb.gif HTTP/1.0\" 200 2326\n")
+}
+
+func (s *CLFLoggerSuite) TestFormatMessageMissingFields(c *C) {
+	b := &bytes.Buffer{}
+	names := CLFFieldNames{Remote: "ip", Request: "req", Status: "code", Time: "ts"}
+	Init(&clfLogger{&writerLogger{SeverityInfo, b}, names})
+
+	WithFields(Fields{
+		"ip":   "10.0.0.1",
+		"ts":   "10/Oct/2000:13:55:36 -0700",
+		"req":  "POST /upload HTTP/1.1",
+		"code": 413,
+	}).Infof("request completed")
+
+	c.Assert(b.String(), Equals, "10.0.0.1 - - [10/Oct/2000:13:55:36 -0700] \"POST /upload HTTP/1.1\" 413 -\n")
+}
+
+func (s *CLFLoggerSuite) TestFormatMessageWithoutFields(c *C) {
+	l := &clfLogger{&writerLogger{SeverityInfo, &bytes.Buffer{}}, DefaultCLFFieldNames}
+
+	message := l.FormatMessage(SeverityInfo, &CallerInfo{"filename", "filepath", "funcname", 42}, "hello %s", "world")
+	c.Assert(message, Matches, `- - - \[.+\] "-" - -`+"\n")
+}
@@ -0,0 +1,51 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock tells the current time to formatters and time-based features of the package
+// such as rate limiting. It can be replaced with SetClock to make them deterministic in tests.
+type Clock interface {
+	Now() time.Time
+}
+
+// ClockFunc is an adapter allowing the use of ordinary functions as clocks.
+type ClockFunc func() time.Time
+
+func (f ClockFunc) Now() time.Time {
+	return f()
+}
+
+// clock holds the Clock in use wrapped into a clockHolder.
+var clock atomic.Value
+
+// clockHolder allows storing clocks of different types in clock.
+type clockHolder struct {
+	Clock
+}
+
+func init() {
+	SetClock(nil)
+}
+
+// SetClock replaces the clock used by the package. A nil clock restores the system clock.
+func SetClock(c Clock) {
+	if c == nil {
+		c = ClockFunc(time.Now)
+	}
+	clock.Store(clockHolder{c})
+}
+
+// now returns the current time according to the clock in use.
+func now() time.Time {
+	return clock.Load().(clockHolder).Now()
+}
+
+// Now returns the current time according to the clock in use, for loggers implemented
+// outside of the package. Loggers formatting messages with the time they were logged at, so
+// that all loggers agree on it, get it in the records they format, see RecordFormatter.
+func Now() time.Time {
+	return now()
+}
@@ -0,0 +1,45 @@
+package log
+
+import (
+	"bytes"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ClockSuite struct {
+}
+
+var _ = Suite(&ClockSuite{})
+
+func (s *ClockSuite) TearDownTest(c *C) {
+	SetClock(nil)
+}
+
+func (s *ClockSuite) TestSetClock(c *C) {
+	t := time.Date(2014, 3, 5, 7, 9, 11, 123456789, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return t }))
+	c.Assert(now(), Equals, t)
+
+	SetClock(nil)
+	c.Assert(now().Sub(time.Now()) < time.Second, Equals, true)
+}
+
+func (s *ClockSuite) TestConsoleTimestamp(c *C) {
+	SetClock(ClockFunc(func() time.Time {
+		return time.Date(2014, 3, 5, 7, 9, 11, 123456789, time.FixedZone("", 3*60*60))
+	}))
+
+	l := &consoleLogger{writerLogger: &writerLogger{SeverityInfo, &bytes.Buffer{}}}
+	message := l.FormatMessage(SeverityInfo, &CallerInfo{"filename", "filepath", "funcname", 42}, "hello %s", "world")
+	c.Assert(strings.HasPrefix(message, "Mar  5 04:09:11.123 "), Equals, true, Commentf(message))
+}
+
+func (s *ClockSuite) TestUDPLogTimestamp(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Unix(1394003351, 500000000) }))
+
+	l := &udpLogger{writerLogger: &writerLogger{SeverityInfo, &bytes.Buffer{}}}
+	message := l.FormatMessage(SeverityInfo, &CallerInfo{"filename", "filepath", "funcname", 42}, "hello %s", "world")
+	c.Assert(strings.Contains(message, `"timestamp":1394003351.5}`), Equals, true, Commentf(message))
+}
@@ -0,0 +1,92 @@
+package log
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// ComponentField is the name of the field carrying the name of the component messages are
+// logged by, see Named.
+const ComponentField = "component"
+
+var (
+	// componentSeverities holds the severities loggers log the messages of components at, keyed
+	// by logger, see SetComponentSeverity.
+	componentMu         sync.RWMutex
+	componentSeverities = map[Logger]map[string]Severity{}
+)
+
+// Named returns an entry logging messages as coming from the named component, e.g.
+// "http.server", which lets loggers log them at their own severity, see SetComponentSeverity.
+// The name is attached to messages as the "component" field.
+func Named(name string) *Entry {
+	return (&Entry{}).Named(name)
+}
+
+// Named returns a new entry logging messages as coming from the named subcomponent of the
+// entry's component, if any: Named("http").Named("server") logs messages as coming from
+// "http.server".
+func (e *Entry) Named(name string) *Entry {
+	if e.component != "" {
+		name = e.component + "." + name
+	}
+	child := e.WithFields(Fields{ComponentField: name})
+	child.component = name
+	return child
+}
+
+// SetComponentSeverity makes the logger log the messages of the component, and of its
+// subcomponents that have no severity of their own, at or above sev instead of the severity the
+// logger is configured with, e.g. DEBUG for "storage" while the logger logs everything else at
+// WARN. Loggers configured with SeverityOff still do not log anything. Loggers of types that
+// cannot be compared, such as slices, are not supported. See also Config.Overrides.
+func SetComponentSeverity(l Logger, component string, sev Severity) {
+	if t := reflect.TypeOf(l); t == nil || !t.Comparable() {
+		return
+	}
+	componentMu.Lock()
+	defer componentMu.Unlock()
+
+	if componentSeverities[l] == nil {
+		componentSeverities[l] = map[string]Severity{}
+	}
+	componentSeverities[l][component] = sev
+}
+
+// componentSeverity returns the severity the logger logs the messages of the component at, that
+// of the closest enclosing component if the component has none, and whether there is one.
+func componentSeverity(l Logger, component string) (Severity, bool) {
+	if component == "" {
+		return 0, false
+	}
+	if t := reflect.TypeOf(l); t == nil || !t.Comparable() {
+		return 0, false
+	}
+	componentMu.RLock()
+	defer componentMu.RUnlock()
+
+	overrides := componentSeverities[l]
+	for len(overrides) > 0 {
+		if sev, ok := overrides[component]; ok {
+			return sev, true
+		}
+		i := strings.LastIndex(component, ".")
+		if i < 0 {
+			return 0, false
+		}
+		component = component[:i]
+	}
+	return 0, false
+}
+
+// forgetComponentSeverities forgets the component severities of the logger, once it is closed.
+func forgetComponentSeverities(l Logger) {
+	if t := reflect.TypeOf(l); t == nil || !t.Comparable() {
+		return
+	}
+	componentMu.Lock()
+	defer componentMu.Unlock()
+
+	delete(componentSeverities, l)
+}
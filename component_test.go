@@ -0,0 +1,74 @@
+package log
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type ComponentSuite struct {
+}
+
+var _ = Suite(&ComponentSuite{})
+
+func (s *ComponentSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *ComponentSuite) TearDownTest(c *C) {
+	componentSeverities = map[Logger]map[string]Severity{}
+}
+
+func (s *ComponentSuite) TestNamed(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	http := WithFields(Fields{"id": 1}).Named("http")
+	http.Named("server").Infof("listening")
+	http.Infof("ready")
+	c.Assert(logger.b.String(), Equals, "INFO listening component=http.server id=1\nINFO ready component=http id=1\n")
+}
+
+func (s *ComponentSuite) TestComponentSeverity(c *C) {
+	warn := newThresholdLogger("warn", SeverityWarning)
+	off := newThresholdLogger("off", SeverityOff)
+	Init(warn, off)
+	SetComponentSeverity(warn, "storage", SeverityDebug)
+	SetComponentSeverity(warn, "storage.cache", SeverityError)
+	SetComponentSeverity(off, "storage", SeverityDebug)
+
+	Named("storage").Debugf("1")
+	Named("storage").Tracef("2")
+	Named("storage").Named("s3").Debugf("3")
+	Named("storage").Named("cache").Warningf("4")
+	Named("http").Infof("5")
+	Named("http").Warningf("6")
+	Infof("7")
+
+	c.Assert(warn.b.String(), Equals, "DEBUG 1 component=storage\nDEBUG 3 component=storage.s3\nWARN 6 component=http\n")
+	c.Assert(off.b.String(), Equals, "")
+
+	// severity overrides of entries still apply
+	warn.b.Reset()
+	Named("storage").Named("cache").WithSeverityOverride(SeverityDebug).Debugf("8")
+	c.Assert(warn.b.String(), Equals, "DEBUG 8 component=storage.cache\n")
+}
+
+func (s *ComponentSuite) TestOverrides(c *C) {
+	l, err := NewLogger(Config{Name: Console, Severity: "warn", Overrides: map[string]string{"storage": "debug"}})
+	c.Assert(err, IsNil)
+	sev, ok := componentSeverity(l, "storage.s3")
+	c.Assert(ok, Equals, true)
+	c.Assert(sev, Equals, SeverityDebug)
+	_, ok = componentSeverity(l, "http")
+	c.Assert(ok, Equals, false)
+
+	// mirrored loggers take the mirror's overrides
+	l, err = NewLogger(Config{Name: Mirror, Severity: "warn", Overrides: map[string]string{"storage": "debug"},
+		Mirror: []Config{{Name: Console}}})
+	c.Assert(err, IsNil)
+	sev, ok = componentSeverity(l.(*MultiLogger).Loggers()[0], "storage")
+	c.Assert(ok, Equals, true)
+	c.Assert(sev, Equals, SeverityDebug)
+
+	_, err = NewLogger(Config{Name: Console, Severity: "warn", Overrides: map[string]string{"storage": "loud"}})
+	c.Assert(err, ErrorMatches, "component storage: .*")
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"reflect"
+)
+
+// MergeConfigs merges logger configs from several sources, such as defaults, a config file
+// and environment overrides, into one list for InitWithConfig. Configs are matched by Name,
+// the non-empty fields of configs from later sources overriding those of earlier ones, so an
+// overlay only needs to carry the fields it changes:
+//
+//	log.InitWithConfig(log.MergeConfigs(defaults, file, env)...)
+//
+// Configs keep the order their names first appear in. Configs of the same name within a
+// source are merged as well.
+func MergeConfigs(sources ...[]Config) []Config {
+	var merged []Config
+	index := make(map[string]int)
+	for _, source := range sources {
+		for _, conf := range source {
+			i, ok := index[conf.Name]
+			if !ok {
+				index[conf.Name] = len(merged)
+				merged = append(merged, conf)
+				continue
+			}
+			overrideConfig(&merged[i], conf)
+		}
+	}
+	return merged
+}
+
+// overrideConfig sets the fields of dst to the non-empty fields of src.
+func overrideConfig(dst *Config, src Config) {
+	d, s := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src)
+	for i := 0; i < s.NumField(); i++ {
+		if f := s.Field(i); !f.IsZero() {
+			d.Field(i).Set(f)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package log
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ConfigSuite struct {
+}
+
+var _ = Suite(&ConfigSuite{})
+
+func (s *ConfigSuite) TestMergeConfigs(c *C) {
+	file := []Config{
+		{Name: Console, Severity: "info", LevelStyle: LevelStyleShort3, SplitAt: "warn"},
+		{Name: Syslog, Severity: "error", SeverityMapper: JournaldSeverityMapper},
+	}
+	env := []Config{
+		{Name: Syslog, Severity: "debug"},
+	}
+
+	merged := MergeConfigs(file, env)
+	c.Assert(merged, HasLen, 2)
+	c.Assert(merged[0], DeepEquals, file[0])
+	c.Assert(merged[1].Name, Equals, Syslog)
+	c.Assert(merged[1].Severity, Equals, "debug")
+	c.Assert(merged[1].SeverityMapper, NotNil)
+
+	// the sources are left untouched
+	c.Assert(file[1].Severity, Equals, "error")
+}
+
+func (s *ConfigSuite) TestMergeConfigsOrder(c *C) {
+	defaults := []Config{{Name: Console, Severity: "info"}}
+	file := []Config{{Name: UDPLog, Severity: "warn"}, {Name: Console, LevelStyle: LevelStyleChar}}
+	env := []Config{{Name: Syslog, Severity: "error"}, {Name: Console, Severity: "debug"}}
+
+	c.Assert(MergeConfigs(defaults, file, env), DeepEquals, []Config{
+		{Name: Console, Severity: "debug", LevelStyle: LevelStyleChar},
+		{Name: UDPLog, Severity: "warn"},
+		{Name: Syslog, Severity: "error"},
+	})
+	c.Assert(MergeConfigs(), HasLen, 0)
+}
+
+// writeConfigFile writes the config file to a temporary directory and returns its path.
+func writeConfigFile(c *C, name, content string) string {
+	path := filepath.Join(c.MkDir(), name)
+	c.Assert(ioutil.WriteFile(path, []byte(content), 0644), IsNil)
+	return path
+}
+
+func (s *ConfigSuite) TestConfigsFromFile(c *C) {
+	expected := []Config{
+		{Name: Console, Severity: "info", Overrides: map[string]string{"storage": "debug"}, KeyNames: KeyNames{Timestamp: "@timestamp"}},
+		{Name: File, Path: "/var/log/app.json", MaxSize: 1048576, MaxAge: 24 * time.Hour, Compress: true,
+			Mirror: []Config{{Name: Console, Color: true}}},
+	}
+
+	configs, err := ConfigsFromFile(writeConfigFile(c, "app.json", `{"loggers": [
+  {"name": "console", "severity": "info", "overrides": {"storage": "debug"}, "keyNames": {"timestamp": "@timestamp"}},
+  {"name": "file", "path": "/var/log/app.json", "max_size": 1048576, "maxAge": "24h", "compress": true,
+   "mirror": [{"name": "console", "color": true}]}
+]}`))
+	c.Assert(err, IsNil)
+	c.Assert(configs, DeepEquals, expected)
+}
+
+func (s *ConfigSuite) TestConfigsFromFileErrors(c *C) {
+	for _, t := range []struct {
+		content string
+		err     string
+	}{
+		{`{"loggers": [{"name": "console", "severity": "loud"}]}`, `.*app.json: loggers\[0\]\.severity: unsupported severity: LOUD`},
+		{`{"loggers": [{"name": "console"}, {"name": "file", "max_age": 1}]}`, `.*: loggers\[1\]\.max_age: expected a duration such as "10s", got 1`},
+		{`{"loggers": [{"name": "file", "max_size": "big"}]}`, `.*: loggers\[0\]\.max_size: expected an integer, got big`},
+		{`{"loggers": [{"name": "console", "colour": true}]}`, `.*: loggers\[0\]\.colour: unknown field`},
+		{`{"loggers": [{"name": "console", "overrides": {"http": "loud"}}]}`, `.*: loggers\[0\]\.overrides\.http: unsupported severity: LOUD`},
+		{`{"loggers": [{"name": "console", "mirror": [{"severity": "info"}]}]}`, `.*: loggers\[0\]\.mirror\[0\]\.name: missing`},
+		{`{"loggers": [{"name": "syslog", "severity_mapper": "journald"}]}`, `.*: loggers\[0\]\.severity_mapper: cannot be configured declaratively`},
+		{`{"outputs": []}`, `.*: outputs: unknown field`},
+		{`{"loggers": {"name": "console"}}`, `.*: loggers: expected a list of loggers, .*`},
+	} {
+		_, err := ConfigsFromFile(writeConfigFile(c, "app.json", t.content))
+		c.Assert(err, ErrorMatches, t.err)
+	}
+
+	_, err := ConfigsFromFile(writeConfigFile(c, "app.ini", ""))
+	c.Assert(err, ErrorMatches, `.*app.ini: unsupported config file format: ".ini"`)
+}
+
+func (s *ConfigSuite) TestRegisterConfigFormat(c *C) {
+	defer delete(configFormats, ".conf")
+	RegisterConfigFormat(".Conf", func(data []byte) (interface{}, error) {
+		return map[string]interface{}{"loggers": []interface{}{
+			map[string]interface{}{"name": string(data), "severity": "warn"},
+		}}, nil
+	})
+
+	configs, err := ConfigsFromFile(writeConfigFile(c, "app.conf", "console"))
+	c.Assert(err, IsNil)
+	c.Assert(configs, DeepEquals, []Config{{Name: Console, Severity: "warn"}})
+
+	c.Assert(func() { RegisterConfigFormat(".conf", nil) }, PanicMatches, "log: .conf config format registered twice")
+	c.Assert(func() { RegisterConfigFormat(".json", nil) }, PanicMatches, "log: cannot register the built-in .json config format")
+}
+
+func (s *ConfigSuite) TestInitWithConfigFile(c *C) {
+	defer ReplaceLoggers(ReplaceLoggers()...)
+
+	c.Assert(InitWithConfigFile(writeConfigFile(c, "app.json", `{"loggers": [{"name": "console", "severity": "warn"}]}`)), IsNil)
+	c.Assert(describe(loggers[0]).Type, Equals, Console)
+
+	err := InitWithConfigFile(writeConfigFile(c, "app.json", `{"loggers": [{"name": "console", "severity": "info"}, {"name": "kafka"}]}`))
+	c.Assert(err, ErrorMatches, `.*app.json: loggers\[1\]: unknown logger: .*`)
+}
+
+// setEnv sets the environment variables and returns a function restoring them.
+func setEnv(vars map[string]string) func() {
+	saved := make(map[string]*string)
+	for k, v := range vars {
+		if old, ok := os.LookupEnv(k); ok {
+			saved[k] = &old
+		} else {
+			saved[k] = nil
+		}
+		os.Setenv(k, v)
+	}
+	return func() {
+		for k, v := range saved {
+			if v == nil {
+				os.Unsetenv(k)
+			} else {
+				os.Setenv(k, *v)
+			}
+		}
+	}
+}
+
+func (s *ConfigSuite) TestConfigsFromEnv(c *C) {
+	defer setEnv(map[string]string{
+		EnvOutputs:              "console:debug, file",
+		"LOG_CONSOLE_FORMAT":    "json",
+		"LOG_CONSOLE_OVERRIDES": "storage:trace, http:info",
+		"LOG_FILE_PATH":         "/var/log/app.log",
+		"LOG_FILE_MAX_AGE":      "24h",
+		"LOG_FILE_MAX_BACKUPS":  "3",
+		"LOG_FILE_COMPRESS":     "true",
+	})()
+
+	configs, err := ConfigsFromEnv()
+	c.Assert(err, IsNil)
+	c.Assert(configs, DeepEquals, []Config{
+		{Name: Console, Severity: "debug", Format: "json", Overrides: map[string]string{"storage": "trace", "http": "info"}},
+		{Name: File, Path: "/var/log/app.log", MaxAge: 24 * time.Hour, MaxBackups: 3, Compress: true},
+	})
+}
+
+func (s *ConfigSuite) TestConfigsFromEnvErrors(c *C) {
+	for _, t := range []struct {
+		vars map[string]string
+		err  string
+	}{
+		{map[string]string{EnvOutputs: "console:loud"}, "LOG_OUTPUTS: console: unsupported severity: LOUD"},
+		{map[string]string{EnvOutputs: "console,"}, `LOG_OUTPUTS: empty logger name in "console,"`},
+		{map[string]string{EnvOutputs: "file", "LOG_FILE_MAX_BACKUPS": "many"}, "LOG_FILE_MAX_BACKUPS: .*invalid syntax"},
+		{map[string]string{EnvOutputs: "file", "LOG_FILE_SPLIT_AT": "loud"}, "LOG_FILE_SPLIT_AT: unsupported severity: LOUD"},
+		{map[string]string{EnvOutputs: "file", "LOG_FILE_PATHS": "/tmp"}, "LOG_FILE_PATHS: unknown field"},
+	} {
+		restore := setEnv(t.vars)
+		_, err := ConfigsFromEnv()
+		restore()
+		c.Assert(err, ErrorMatches, t.err)
+	}
+
+	defer setEnv(map[string]string{EnvOutputs: ""})()
+	configs, err := ConfigsFromEnv()
+	c.Assert(err, IsNil)
+	c.Assert(configs, HasLen, 0)
+	c.Assert(InitFromEnv(), ErrorMatches, "LOG_OUTPUTS is not set")
+}
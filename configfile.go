@@ -0,0 +1,392 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EnvOutputs is the environment variable InitFromEnv reads the loggers from.
+const EnvOutputs = "LOG_OUTPUTS"
+
+var (
+	configType   = reflect.TypeOf(Config{})
+	durationType = reflect.TypeOf(time.Duration(0))
+)
+
+var (
+	configFormatsMu sync.RWMutex
+	configFormats   = map[string]func([]byte) (interface{}, error){}
+)
+
+// RegisterConfigFormat makes ConfigsFromFile read the files with the extension, e.g. ".yaml",
+// with decode, which decodes the content of a file into maps, slices and plain values like
+// encoding/json does, so that packages kept apart from this one, such as configfile, provide
+// formats without this package depending on their parsers. Registering an extension twice,
+// or .json, panics.
+func RegisterConfigFormat(ext string, decode func(data []byte) (interface{}, error)) {
+	configFormatsMu.Lock()
+	defer configFormatsMu.Unlock()
+
+	ext = strings.ToLower(ext)
+	if ext == ".json" {
+		panic("log: cannot register the built-in .json config format")
+	}
+	if _, ok := configFormats[ext]; ok {
+		panic(fmt.Sprintf("log: %s config format registered twice", ext))
+	}
+	configFormats[ext] = decode
+}
+
+// InitWithConfigFile reads logger configs from the file, see ConfigsFromFile, and initializes
+// the package with them like InitWithConfig. The loggers are replaced by those of the file
+// when it is reloaded, see ReloadConfigFile.
+func InitWithConfigFile(path string) error {
+	configs, err := ConfigsFromFile(path)
+	if err != nil {
+		return err
+	}
+	l, err := initWithConfigs(configs, func(i int) string {
+		return fmt.Sprintf("%s: loggers[%d]", path, i)
+	})
+
+	loggersMu.Lock()
+	fileLoggers = append(fileLoggers, l...)
+	loggersMu.Unlock()
+	return err
+}
+
+// InitFromEnv reads logger configs from the environment, see ConfigsFromEnv, and initializes
+// the package with them like InitWithConfig.
+func InitFromEnv() error {
+	configs, err := ConfigsFromEnv()
+	if err != nil {
+		return err
+	}
+	if len(configs) == 0 {
+		return fmt.Errorf("%s is not set", EnvOutputs)
+	}
+	_, err = initWithConfigs(configs, func(i int) string {
+		return fmt.Sprintf("%s: %s", EnvOutputs, configs[i].Name)
+	})
+	return err
+}
+
+// ConfigsFromFile reads logger configs from a JSON file, or from a file in a format registered
+// with RegisterConfigFormat, told apart by its extension; importing the configfile package
+// adds YAML (.yaml and .yml) and TOML (.toml). The file lists the loggers under "loggers",
+// their fields named after the fields of Config in any case, with or without underscores:
+//
+//	{"loggers": [
+//	  {"name": "console", "severity": "info", "overrides": {"storage": "debug"}},
+//	  {"name": "file", "path": "/var/log/app.json", "format": "json",
+//	   "max_size": 104857600, "max_age": "24h"}
+//	]}
+//
+// Durations are strings such as "24h". Severity mappers and TLS configs cannot be set in
+// files. Errors name the offending field, e.g. "app.json: loggers[1].max_age: ...".
+func ConfigsFromFile(path string) ([]Config, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var v interface{}
+	if ext := strings.ToLower(filepath.Ext(path)); ext == ".json" {
+		d := json.NewDecoder(bytes.NewReader(data))
+		d.UseNumber()
+		err = d.Decode(&v)
+	} else {
+		configFormatsMu.RLock()
+		decode, ok := configFormats[ext]
+		configFormatsMu.RUnlock()
+		if !ok {
+			return nil, fmt.Errorf("%s: unsupported config file format: %q", path, ext)
+		}
+		v, err = decode(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+
+	configs, err := decodeConfigFile(v)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return configs, nil
+}
+
+// ConfigsFromEnv reads logger configs from the environment: LOG_OUTPUTS lists the names of the
+// loggers, each optionally followed by a colon and its severity, and LOG_<NAME>_<FIELD>
+// variables set the other fields of the loggers, for example:
+//
+//	LOG_OUTPUTS=console:debug,file:warn
+//	LOG_CONSOLE_FORMAT=json
+//	LOG_CONSOLE_OVERRIDES=storage:trace,http:info
+//	LOG_FILE_PATH=/var/log/app.log
+//	LOG_FILE_MAX_AGE=24h
+//
+// Maps such as Overrides are lists of key:value pairs. It returns no configs if LOG_OUTPUTS
+// is not set, so that they can be merged over others, see MergeConfigs. Errors name the
+// offending variable.
+func ConfigsFromEnv() ([]Config, error) {
+	outputs := strings.TrimSpace(os.Getenv(EnvOutputs))
+	if outputs == "" {
+		return nil, nil
+	}
+
+	var configs []Config
+	for _, output := range strings.Split(outputs, ",") {
+		name, sev := strings.TrimSpace(output), ""
+		if i := strings.Index(name, ":"); i >= 0 {
+			name, sev = strings.TrimSpace(name[:i]), strings.TrimSpace(name[i+1:])
+		}
+		if name == "" {
+			return nil, fmt.Errorf("%s: empty logger name in %q", EnvOutputs, outputs)
+		}
+		if sev != "" {
+			if _, err := severityFromString(sev); err != nil {
+				return nil, fmt.Errorf("%s: %s: %v", EnvOutputs, name, err)
+			}
+		}
+		configs = append(configs, Config{Name: name, Severity: sev})
+	}
+
+	// sort the variables so that errors do not depend on the order of the environment
+	env := os.Environ()
+	sort.Strings(env)
+	for _, kv := range env {
+		i := strings.Index(kv, "=")
+		if i < 0 {
+			continue
+		}
+		key, value := kv[:i], kv[i+1:]
+		for c := range configs {
+			prefix := "LOG_" + strings.ToUpper(configs[c].Name) + "_"
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			f, field, ok := configField(reflect.ValueOf(&configs[c]).Elem(), key[len(prefix):])
+			if !ok {
+				return nil, fmt.Errorf("%s: unknown field", key)
+			}
+			v, err := envValue(f.Type(), value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", key, err)
+			}
+			if err := setConfigField(key, f, v); err != nil {
+				return nil, err
+			}
+			if err := checkSeverities(key, field, f); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return configs, nil
+}
+
+// decodeConfigFile decodes the configs of a decoded config file.
+func decodeConfigFile(v interface{}) ([]Config, error) {
+	top, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expected the loggers under \"loggers\"")
+	}
+	var configs []Config
+	for key, value := range top {
+		if normalizeFieldName(key) != "loggers" {
+			return nil, fmt.Errorf("%s: unknown field", key)
+		}
+		f := reflect.ValueOf(&configs).Elem()
+		if err := setConfigField(key, f, value); err != nil {
+			return nil, err
+		}
+	}
+	return configs, nil
+}
+
+// setConfigField sets the field of a config, or of a struct within it, named path in errors,
+// to the decoded value.
+func setConfigField(path string, f reflect.Value, v interface{}) error {
+	switch t := f.Type(); {
+	case t == durationType:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a duration such as \"10s\", got %v", path, v)
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+		f.SetInt(int64(d))
+
+	case t.Kind() == reflect.String:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %v", path, v)
+		}
+		f.SetString(s)
+
+	case t.Kind() == reflect.Bool:
+		b, ok := v.(bool)
+		if !ok {
+			return fmt.Errorf("%s: expected true or false, got %v", path, v)
+		}
+		f.SetBool(b)
+
+	case t.Kind() == reflect.Int || t.Kind() == reflect.Int64:
+		n, ok := configInt(v)
+		if !ok || f.OverflowInt(n) {
+			return fmt.Errorf("%s: expected an integer, got %v", path, v)
+		}
+		f.SetInt(n)
+
+	case t.Kind() == reflect.Map && t.Key().Kind() == reflect.String && t.Elem().Kind() == reflect.String:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a table, got %v", path, v)
+		}
+		values := reflect.MakeMapWithSize(t, len(m))
+		for key, value := range m {
+			s, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("%s.%s: expected a string, got %v", path, key, value)
+			}
+			values.SetMapIndex(reflect.ValueOf(key), reflect.ValueOf(s))
+		}
+		f.Set(values)
+
+	case t.Kind() == reflect.Struct:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected a table, got %v", path, v)
+		}
+		for _, key := range sortedKeys(m) {
+			value := m[key]
+			sf, sfield, ok := configField(f, key)
+			if !ok {
+				return fmt.Errorf("%s.%s: unknown field", path, key)
+			}
+			if err := setConfigField(path+"."+key, sf, value); err != nil {
+				return err
+			}
+			if t == configType {
+				if err := checkSeverities(path+"."+key, sfield, sf); err != nil {
+					return err
+				}
+			}
+		}
+		if t == configType && f.FieldByName("Name").String() == "" {
+			return fmt.Errorf("%s.name: missing", path)
+		}
+
+	case t.Kind() == reflect.Slice && t.Elem() == configType:
+		list := reflect.ValueOf(v)
+		if v == nil || list.Kind() != reflect.Slice {
+			return fmt.Errorf("%s: expected a list of loggers, got %v", path, v)
+		}
+		configs := reflect.MakeSlice(t, list.Len(), list.Len())
+		for i := 0; i < list.Len(); i++ {
+			if err := setConfigField(fmt.Sprintf("%s[%d]", path, i), configs.Index(i), list.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		f.Set(configs)
+
+	default:
+		return fmt.Errorf("%s: cannot be configured declaratively", path)
+	}
+	return nil
+}
+
+// checkSeverities checks the names of the severities set in the field of a config.
+func checkSeverities(path string, field reflect.StructField, f reflect.Value) error {
+	switch field.Name {
+	case "Severity", "SplitAt":
+		if _, err := severityFromString(f.String()); err != nil {
+			return fmt.Errorf("%s: %v", path, err)
+		}
+	case "Overrides":
+		for component, name := range f.Interface().(map[string]string) {
+			if _, err := severityFromString(name); err != nil {
+				return fmt.Errorf("%s.%s: %v", path, component, err)
+			}
+		}
+	}
+	return nil
+}
+
+// configField returns the field of the struct with the name, in any case and with or without
+// underscores.
+func configField(v reflect.Value, name string) (reflect.Value, reflect.StructField, bool) {
+	name = normalizeFieldName(name)
+	for i := 0; i < v.NumField(); i++ {
+		if field := v.Type().Field(i); strings.ToLower(field.Name) == name {
+			return v.Field(i), field, true
+		}
+	}
+	return reflect.Value{}, reflect.StructField{}, false
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(name))
+}
+
+// configInt returns the integer decoded by one of the decoders.
+func configInt(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int:
+		return int64(n), true
+	case int64:
+		return n, true
+	case uint64:
+		return int64(n), n <= 1<<63-1
+	case float64:
+		return int64(n), n == float64(int64(n))
+	case json.Number:
+		i, err := n.Int64()
+		return i, err == nil
+	}
+	return 0, false
+}
+
+// envValue parses the value of an environment variable setting a field of the type into what
+// setConfigField expects.
+func envValue(t reflect.Type, s string) (interface{}, error) {
+	switch {
+	case t == durationType || t.Kind() == reflect.String:
+		return s, nil
+	case t.Kind() == reflect.Bool:
+		return strconv.ParseBool(s)
+	case t.Kind() == reflect.Int || t.Kind() == reflect.Int64:
+		return strconv.ParseInt(s, 10, 64)
+	case t.Kind() == reflect.Map:
+		m := make(map[string]interface{})
+		for _, pair := range strings.Split(s, ",") {
+			i := strings.Index(pair, ":")
+			if i < 0 {
+				return nil, fmt.Errorf("expected key:value pairs, got %q", pair)
+			}
+			m[strings.TrimSpace(pair[:i])] = strings.TrimSpace(pair[i+1:])
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("cannot be configured declaratively")
+}
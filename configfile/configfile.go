@@ -0,0 +1,36 @@
+// Package configfile lets the log package read its config files in YAML and TOML along with
+// JSON, for example:
+//
+//	import _ "github.com/mailgun/log/configfile"
+//	...
+//	err := log.InitWithConfigFile("/etc/app/logging.yaml")
+//
+// The package is kept apart from the log package so that programs not using it do not depend
+// on YAML and TOML parsers. Importing it registers the .yaml, .yml and .toml formats, see
+// log.RegisterConfigFormat.
+package configfile
+
+import (
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mailgun/log"
+)
+
+func init() {
+	log.RegisterConfigFormat(".yaml", decodeYAML)
+	log.RegisterConfigFormat(".yml", decodeYAML)
+	log.RegisterConfigFormat(".toml", decodeTOML)
+}
+
+func decodeYAML(data []byte) (interface{}, error) {
+	var v interface{}
+	err := yaml.Unmarshal(data, &v)
+	return v, err
+}
+
+func decodeTOML(data []byte) (interface{}, error) {
+	var v interface{}
+	_, err := toml.Decode(string(data), &v)
+	return v, err
+}
@@ -0,0 +1,92 @@
+package configfile
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/mailgun/log"
+)
+
+// writeConfigFile writes the config file to a temporary directory and returns its path.
+func writeConfigFile(t *testing.T, name, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestConfigsFromFile(t *testing.T) {
+	want := []log.Config{
+		{Name: log.Console, Severity: "info", Overrides: map[string]string{"storage": "debug"}, KeyNames: log.KeyNames{Timestamp: "@timestamp"}},
+		{Name: log.File, Path: "/var/log/app.json", MaxSize: 1048576, MaxAge: 24 * time.Hour, Compress: true,
+			Mirror: []log.Config{{Name: log.Console, Color: true}}},
+	}
+
+	yamlContent := `
+loggers:
+  - name: console
+    severity: info
+    overrides: {storage: debug}
+    key_names: {timestamp: "@timestamp"}
+  - name: file
+    path: /var/log/app.json
+    max_size: 1048576
+    max_age: 24h
+    compress: true
+    mirror:
+      - name: console
+        color: true
+`
+	files := map[string]string{
+		"app.yaml": yamlContent,
+		"app.yml":  yamlContent,
+		"app.toml": `
+[[loggers]]
+name = "console"
+severity = "info"
+overrides = {storage = "debug"}
+KeyNames = {Timestamp = "@timestamp"}
+
+[[loggers]]
+name = "file"
+path = "/var/log/app.json"
+max_size = 1048576
+max_age = "24h"
+compress = true
+
+[[loggers.mirror]]
+name = "console"
+color = true
+`,
+	}
+	for name, content := range files {
+		configs, err := log.ConfigsFromFile(writeConfigFile(t, name, content))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+		} else if !reflect.DeepEqual(configs, want) {
+			t.Errorf("%s: configs = %+v, want %+v", name, configs, want)
+		}
+	}
+}
+
+func TestConfigsFromFileErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, content, err string
+	}{
+		{"app.yaml", "loggers: [{name: console, severity: loud}]", `app.yaml: loggers\[0\]\.severity: unsupported severity: LOUD`},
+		{"app.yaml", "loggers: [{name: console}, {name: file, max_age: 1}]", `app.yaml: loggers\[1\]\.max_age: expected a duration such as "10s", got 1`},
+		{"app.yaml", "loggers: [", `app.yaml: yaml: .*`},
+		{"app.toml", "[[loggers]]\nname = \"file\"\nmax_size = \"big\"", `app.toml: loggers\[0\]\.max_size: expected an integer, got big`},
+		{"app.toml", "[[loggers]", `app.toml: toml: .*`},
+	} {
+		_, err := log.ConfigsFromFile(writeConfigFile(t, tt.name, tt.content))
+		if err == nil || !regexp.MustCompile(tt.err).MatchString(err.Error()) {
+			t.Errorf("%s: error = %v, want %s", tt.content, err, tt.err)
+		}
+	}
+}
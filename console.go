@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"time"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 )
 
 // writerLogger is a generic type of a logger that sends messages to the underlying io.Writer.
@@ -16,15 +20,68 @@ type writerLogger struct {
 
 func (l *writerLogger) Writer(sev Severity) io.Writer {
 	// is this logger configured to log at the provided severity?
-	if sev >= l.sev {
+	if sev >= l.Severity() {
 		return l.w
 	}
 	return nil
 }
 
-// consoleLogger is a type of writerLogger that sends messages to the standard output.
+func (l *writerLogger) Severity() Severity {
+	return Severity(atomic.LoadInt32((*int32)(&l.sev)))
+}
+
+func (l *writerLogger) SetSeverity(sev Severity) {
+	atomic.StoreInt32((*int32)(&l.sev), int32(sev))
+}
+
+// Console logger streams, see Config.OutputStream and Config.ErrorStream.
+const (
+	StreamStdout = "stdout"
+	StreamStderr = "stderr"
+	StreamBoth   = "both"
+)
+
+// stdout and stderr are the streams behind the console logger stream names, they are replaced in tests.
+var (
+	stdout io.Writer = os.Stdout
+	stderr io.Writer = os.Stderr
+)
+
+// consoleLogger is a type of writerLogger that sends messages to the standard output,
+// or to the configured streams.
+//
+// When compiled for js/wasm the console logger sends messages to the browser console instead,
+// see console_js.go.
 type consoleLogger struct {
 	*writerLogger // provides Writer() through embedding
+
+	levelStyle string
+
+	// format is the message format, see Config.Format, and color is set if severities are colored.
+	// forcePretty keeps the pretty format when the streams are not terminals.
+	format      string
+	color       bool
+	forcePretty bool
+
+	// keys are the key names of JSON messages.
+	keys KeyNames
+
+	// formatter formats messages in the formats the logger does not render itself, such as
+	// templates, it is nil otherwise.
+	formatter Formatter
+
+	// callerWidth is the width of the widest caller column of pretty messages so far.
+	callerWidth int32
+
+	// indent is the prefix of continuation lines of multi-line text messages.
+	indent string
+
+	// outputStream and errorStream are the names of the streams messages below and at or
+	// above splitAt go to, errW is the writer of the latter once the logger is made.
+	outputStream string
+	errorStream  string
+	splitAt      Severity
+	errW         io.Writer
 }
 
 func NewConsoleLogger(conf Config) (Logger, error) {
@@ -32,10 +89,186 @@ func NewConsoleLogger(conf Config) (Logger, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &consoleLogger{&writerLogger{sev, os.Stdout}}, nil
+	if err := validateLevelStyle(conf.LevelStyle); err != nil {
+		return nil, err
+	}
+	if err := validateFormat(conf.Format); err != nil {
+		return nil, err
+	}
+	if err := conf.KeyNames.validate(); err != nil {
+		return nil, err
+	}
+	formatter, format, err := newFormatter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &consoleLogger{
+		writerLogger: &writerLogger{sev: sev},
+		levelStyle:   conf.LevelStyle,
+		format:       format,
+		formatter:    formatter,
+		color:        conf.Color,
+		forcePretty:  conf.ForcePretty,
+		keys:         conf.KeyNames,
+		indent:       conf.Indent,
+		outputStream: StreamStdout,
+		errorStream:  StreamStderr,
+		splitAt:      SeverityOff,
+	}
+	if conf.OutputStream != "" {
+		l.outputStream = conf.OutputStream
+	}
+	if conf.ErrorStream != "" {
+		l.errorStream = conf.ErrorStream
+	}
+	for _, stream := range []string{l.outputStream, l.errorStream} {
+		if consoleStream(stream) == nil {
+			return nil, fmt.Errorf("unsupported console stream: %s", stream)
+		}
+	}
+	if conf.SplitAt != "" {
+		if l.splitAt, err = severityFromString(conf.SplitAt); err != nil {
+			return nil, err
+		}
+	}
+	return newConsoleLogger(l), nil
+}
+
+func (l *consoleLogger) Writer(sev Severity) io.Writer {
+	if sev >= l.splitAt && l.errW != nil {
+		if sev >= l.Severity() {
+			return l.errW
+		}
+		return nil
+	}
+	return l.writerLogger.Writer(sev)
+}
+
+// consoleStream returns the writer behind the stream name, nil for unsupported names.
+func consoleStream(name string) io.Writer {
+	switch name {
+	case StreamStdout:
+		return stdout
+	case StreamStderr:
+		return stderr
+	case StreamBoth:
+		return io.MultiWriter(stdout, stderr)
+	}
+	return nil
 }
 
 func (l *consoleLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
-	return fmt.Sprintf("%v %s %s PID:%d [%s:%d:%s] %s\n",
-		time.Now().UTC().Format(time.StampMilli), appname, sev, pid, caller.FileName, caller.LineNo, caller.FuncName, fmt.Sprintf(format, args...))
+	return l.FormatMessageWithFields(sev, caller, nil, format, args...)
+}
+
+func (l *consoleLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return l.FormatRecord(NewRecord(sev, caller, fields, format, args...))
+}
+
+func (l *consoleLogger) FormatRecord(r Record) string {
+	// drop the message's own trailing newline so that the line ending appears exactly once
+	r.Message = strings.TrimRight(r.Message, "\r\n")
+	if l.formatter != nil {
+		return l.formatter.Format(r) + currentLineEnding()
+	}
+	if l.format == FormatJSON {
+		return formatJSON(r, l.keys) + currentLineEnding()
+	}
+
+	level := r.Severity.format(l.levelStyle)
+	if l.format == FormatPretty {
+		return l.formatPretty(r, level)
+	}
+	message := messageWithFields(r)
+	if l.indent != "" {
+		message = strings.Replace(message, "\n", "\n"+l.indent, -1)
+	}
+	if l.color {
+		level = colorize(r.Severity, level)
+	}
+	return formatText(r, level, message, currentLineEnding())
+}
+
+// messageWithFields returns the message of the record followed by its fields, if any.
+func messageWithFields(r Record) string {
+	if len(r.Fields) == 0 {
+		return r.Message
+	}
+	return r.Message + " " + formatFields(r.Fields)
+}
+
+// maxPooledBuffer bounds the capacity of the buffers put back in textBuffers so that a huge
+// message does not keep its buffer alive.
+const maxPooledBuffer = 64 << 10
+
+// textBuffers holds the buffers messages are rendered into in the text format.
+var textBuffers = sync.Pool{New: func() interface{} { return new([]byte) }}
+
+// formatText renders the message in the text format with the severity name, followed by the
+// line ending.
+func formatText(r Record, level, message, ending string) string {
+	buf := textBuffers.Get().(*[]byte)
+	b := r.Time.UTC().AppendFormat((*buf)[:0], timestampLayout(r.Severity))
+	b = append(b, ' ')
+	b = append(b, appname...)
+	b = append(b, ' ')
+	b = append(b, level...)
+	b = append(b, " PID:"...)
+	b = strconv.AppendInt(b, int64(pid), 10)
+	b = append(b, " ["...)
+	b = append(b, r.File...)
+	b = append(b, ':')
+	b = strconv.AppendInt(b, int64(r.Line), 10)
+	b = append(b, ':')
+	b = append(b, r.Func...)
+	b = append(b, "] "...)
+	b = append(b, message...)
+	b = append(b, ending...)
+
+	text := string(b)
+	if cap(b) <= maxPooledBuffer {
+		*buf = b
+		textBuffers.Put(buf)
+	}
+	return text
+}
+
+// formatPretty renders the message for reading in a terminal or a pager such as less -R:
+// colored severities padded to a fixed width followed by the caller padded to the widest
+// caller so far, so that the columns line up across messages. The caller column only ever
+// widens, which keeps it stable once the callers of a program have been seen. The fields
+// follow one per line sorted by key, aligned with the message.
+func (l *consoleLogger) formatPretty(r Record, level string) string {
+	level = colorize(r.Severity, fmt.Sprintf("%-*s", levelWidth(l.levelStyle), level))
+	at := fmt.Sprintf("[%s:%d]", r.File, r.Line)
+
+	width := atomic.LoadInt32(&l.callerWidth)
+	for int(width) < len(at) {
+		if atomic.CompareAndSwapInt32(&l.callerWidth, width, int32(len(at))) {
+			width = int32(len(at))
+			break
+		}
+		width = atomic.LoadInt32(&l.callerWidth)
+	}
+
+	timestamp := r.Time.UTC().Format(timestampLayout(r.Severity))
+	message := r.Message
+	if l.indent != "" {
+		message = strings.Replace(message, "\n", "\n"+l.indent, -1)
+	}
+	if len(r.Fields) > 0 {
+		// the fields line up under the message, as do the continuation lines of their values
+		align := "\n" + strings.Repeat(" ", len(timestamp)+levelWidth(l.levelStyle)+int(width)+3)
+		keys := make([]string, 0, len(r.Fields))
+		for k := range r.Fields {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			value := strings.Replace(fmt.Sprint(r.Fields[k]), "\n", align+"  ", -1)
+			message += align + colorize(SeverityTrace, k+"=") + value
+		}
+	}
+	return fmt.Sprintf("%s %s %-*s %s%s", timestamp, level, width, at, message, currentLineEnding())
 }
@@ -0,0 +1,55 @@
+//go:build js && wasm
+
+package log
+
+import (
+	"io"
+	"strings"
+	"syscall/js"
+)
+
+// jsConsoleLogger is a type of consoleLogger that sends messages to the browser console
+// using console.log, console.warn or console.error depending on the message severity.
+type jsConsoleLogger struct {
+	*consoleLogger // provides FormatMessage() through embedding
+
+	logW   io.Writer
+	warnW  io.Writer
+	errorW io.Writer
+}
+
+// newConsoleLogger makes the configured console logger write to the browser console.
+// The configured streams do not apply, the console method is picked by severity instead.
+func newConsoleLogger(l *consoleLogger) Logger {
+	return &jsConsoleLogger{
+		consoleLogger: l,
+		logW:          jsConsoleWriter("log"),
+		warnW:         jsConsoleWriter("warn"),
+		errorW:        jsConsoleWriter("error"),
+	}
+}
+
+func (l *jsConsoleLogger) Writer(sev Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= l.Severity() {
+		// return an appropriate writer
+		switch sev {
+		case SeverityTrace, SeverityDebug, SeverityInfo:
+			return l.logW
+		case SeverityWarning:
+			return l.warnW
+		default:
+			return l.errorW
+		}
+	}
+	return nil
+}
+
+// jsConsoleWriter writes messages by calling the named method of the browser console.
+type jsConsoleWriter string
+
+func (w jsConsoleWriter) Write(p []byte) (int, error) {
+	// the browser console separates messages on its own
+	js.Global().Get("console").Call(string(w), strings.TrimRight(string(p), "\r\n"))
+	return len(p), nil
+}
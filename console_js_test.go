@@ -0,0 +1,31 @@
+//go:build js && wasm
+
+package log
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type JSConsoleLoggerSuite struct {
+}
+
+var _ = Suite(&JSConsoleLoggerSuite{})
+
+var _ Logger = (*jsConsoleLogger)(nil)
+
+func (s *JSConsoleLoggerSuite) TestNewConsoleLogger(c *C) {
+	l, err := NewLogger(Config{Name: Console, Severity: "info"})
+	c.Assert(err, IsNil)
+
+	console, ok := l.(*jsConsoleLogger)
+	c.Assert(ok, Equals, true)
+	c.Assert(console.sev, Equals, SeverityInfo)
+}
+
+func (s *JSConsoleLoggerSuite) TestWriter(c *C) {
+	l := newConsoleLogger(&consoleLogger{writerLogger: &writerLogger{sev: SeverityInfo}})
+	c.Assert(l.Writer(SeverityDebug), IsNil)
+	c.Assert(l.Writer(SeverityInfo), Equals, jsConsoleWriter("log"))
+	c.Assert(l.Writer(SeverityWarning), Equals, jsConsoleWriter("warn"))
+	c.Assert(l.Writer(SeverityError), Equals, jsConsoleWriter("error"))
+}
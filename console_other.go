@@ -0,0 +1,50 @@
+//go:build !js || !wasm
+
+package log
+
+import (
+	"io"
+	"os"
+)
+
+// newConsoleLogger makes the configured console logger write to its streams, falling back from
+// the pretty format to the text format unless they are terminals.
+func newConsoleLogger(l *consoleLogger) Logger {
+	l.w = consoleStream(l.outputStream)
+	streams := []string{l.outputStream}
+	if l.splitAt != SeverityOff {
+		l.errW = consoleStream(l.errorStream)
+		streams = append(streams, l.errorStream)
+	}
+	if l.format == FormatPretty && !l.forcePretty {
+		for _, stream := range streams {
+			if !streamIsTerminal(stream) {
+				l.format = FormatText
+			}
+		}
+	}
+	return l
+}
+
+// streamIsTerminal tells whether the console stream writes to terminals.
+func streamIsTerminal(name string) bool {
+	switch name {
+	case StreamStdout:
+		return isTerminal(stdout)
+	case StreamStderr:
+		return isTerminal(stderr)
+	case StreamBoth:
+		return isTerminal(stdout) && isTerminal(stderr)
+	}
+	return false
+}
+
+// isTerminal tells whether the writer is a terminal, it is replaced in tests.
+var isTerminal = func(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,44 @@
+//go:build !js || !wasm
+
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ConsoleLoggerSuite) TestPrettyTerminal(c *C) {
+	var out, errs bytes.Buffer
+	stdout, stderr = &out, &errs
+	defer func() { stdout, stderr = os.Stdout, os.Stderr }()
+	defer func(f func(io.Writer) bool) { isTerminal = f }(isTerminal)
+	terminals := map[io.Writer]bool{}
+	isTerminal = func(w io.Writer) bool { return terminals[w] }
+
+	// the pretty format falls back to the text format unless every stream is a terminal
+	for _, t := range []struct {
+		terminals []io.Writer
+		config    Config
+		format    string
+	}{
+		{nil, Config{}, FormatText},
+		{[]io.Writer{&out}, Config{}, FormatPretty},
+		{[]io.Writer{&out}, Config{SplitAt: "error"}, FormatText},
+		{[]io.Writer{&out, &errs}, Config{SplitAt: "error"}, FormatPretty},
+		{[]io.Writer{&out}, Config{OutputStream: StreamBoth}, FormatText},
+		{nil, Config{ForcePretty: true}, FormatPretty},
+	} {
+		terminals = map[io.Writer]bool{}
+		for _, w := range t.terminals {
+			terminals[w] = true
+		}
+		conf := t.config
+		conf.Name, conf.Severity, conf.Format = Console, "info", FormatPretty
+		l, err := NewConsoleLogger(conf)
+		c.Assert(err, IsNil)
+		c.Assert(describe(l).Format, Equals, t.format, Commentf("%+v", t))
+	}
+}
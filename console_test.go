@@ -2,7 +2,14 @@ package log
 
 import (
 	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -30,6 +37,13 @@ func (s *WriterLoggerSuite) TestWriter(c *C) {
 	c.Assert(l.Writer(SeverityInfo), IsNil)
 	c.Assert(l.Writer(SeverityWarning), IsNil)
 	c.Assert(l.Writer(SeverityError), NotNil)
+
+	// OFF logger should not log at all
+	l = &writerLogger{SeverityOff, &bytes.Buffer{}}
+	c.Assert(l.Writer(SeverityDebug), IsNil)
+	c.Assert(l.Writer(SeverityInfo), IsNil)
+	c.Assert(l.Writer(SeverityWarning), IsNil)
+	c.Assert(l.Writer(SeverityError), IsNil)
 }
 
 type ConsoleLoggerSuite struct {
@@ -38,7 +52,7 @@ type ConsoleLoggerSuite struct {
 var _ = Suite(&ConsoleLoggerSuite{})
 
 func (s *ConsoleLoggerSuite) TestNewConsoleLogger(c *C) {
-	l, err := NewConsoleLogger(Config{Console, "info"})
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info"})
 	c.Assert(err, IsNil)
 	c.Assert(l, NotNil)
 
@@ -46,3 +60,247 @@ func (s *ConsoleLoggerSuite) TestNewConsoleLogger(c *C) {
 	c.Assert(console.sev, Equals, SeverityInfo)
 	c.Assert(console.w, Equals, os.Stdout)
 }
+
+func (s *ConsoleLoggerSuite) TestStreams(c *C) {
+	defer func() { stdout, stderr = os.Stdout, os.Stderr }()
+
+	cases := []struct {
+		conf           Config
+		stdout, stderr string
+	}{
+		// everything to stdout unless split
+		{Config{}, "DIWE", ""},
+		{Config{ErrorStream: StreamStderr}, "DIWE", ""},
+		{Config{OutputStream: StreamStderr}, "", "DIWE"},
+		{Config{OutputStream: StreamBoth}, "DIWE", "DIWE"},
+		{Config{SplitAt: "warn"}, "DI", "WE"},
+		{Config{SplitAt: "error", ErrorStream: StreamBoth}, "DIWE", "E"},
+		{Config{SplitAt: "info", OutputStream: StreamStderr, ErrorStream: StreamStdout}, "IWE", "D"},
+		{Config{Severity: "info", SplitAt: "warn", OutputStream: StreamBoth}, "I", "IWE"},
+	}
+	for _, t := range cases {
+		var out, err bytes.Buffer
+		stdout, stderr = &out, &err
+
+		if t.conf.Severity == "" {
+			t.conf.Severity = "debug"
+		}
+		l, e := NewConsoleLogger(t.conf)
+		c.Assert(e, IsNil)
+		for _, sev := range []Severity{SeverityDebug, SeverityInfo, SeverityWarning, SeverityError} {
+			if w := l.Writer(sev); w != nil {
+				w.Write([]byte(sev.format(LevelStyleChar)))
+			}
+		}
+		c.Assert(out.String(), Equals, t.stdout, Commentf("%+v", t.conf))
+		c.Assert(err.String(), Equals, t.stderr, Commentf("%+v", t.conf))
+	}
+}
+
+func (s *ConsoleLoggerSuite) TestStreamsValidation(c *C) {
+	for _, conf := range []Config{
+		{Severity: "info", OutputStream: "stdin"},
+		{Severity: "info", ErrorStream: "syslog"},
+		{Severity: "info", SplitAt: "loud"},
+	} {
+		l, err := NewConsoleLogger(conf)
+		c.Assert(err, NotNil, Commentf("%+v", conf))
+		c.Assert(l, IsNil)
+	}
+}
+
+func (s *ConsoleLoggerSuite) TestFormatMessageLevelStyle(c *C) {
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	for style, expected := range map[string]string{"": " WARN ", "full": " WARN ", "short3": " WRN ", "char": " W "} {
+		l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", LevelStyle: style})
+		c.Assert(err, IsNil)
+
+		message := l.FormatMessage(SeverityWarning, caller, "hello %s", "world")
+		c.Assert(strings.Contains(message, expected), Equals, true, Commentf("style %q: %s", style, message))
+	}
+
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", LevelStyle: "tiny"})
+	c.Assert(err, NotNil)
+	c.Assert(l, IsNil)
+}
+
+func (s *ConsoleLoggerSuite) TestFormatJSON(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 500000000, time.UTC) }))
+	defer SetClock(nil)
+
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON})
+	c.Assert(err, IsNil)
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	message := l.(FieldFormatter).FormatMessageWithFields(SeverityWarning, caller, Fields{"user": "bob", "message": "ignored", "err": errors.New("oops")}, "hello %s\n", "world")
+	c.Assert(message, Equals, `{"timestamp":"2014-03-05T07:09:11.5Z","severity":"WARN","message":"hello world","file":"filename","func":"funcname","line":42,"err":"oops","user":"bob"}`+"\n")
+
+	// messages spanning lines still make one object per line
+	message = l.(FieldFormatter).FormatMessageWithFields(SeverityError, caller, nil, "failed:\n%s", "goroutine 1 [running]:")
+	c.Assert(strings.Count(message, "\n"), Equals, 1)
+	var m map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(message), &m), IsNil)
+	c.Assert(m, DeepEquals, map[string]interface{}{
+		"timestamp": "2014-03-05T07:09:11.5Z", "severity": "ERROR", "message": "failed:\ngoroutine 1 [running]:",
+		"file": "filename", "func": "funcname", "line": float64(42),
+	})
+
+	_, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: "xml"})
+	c.Assert(err, NotNil)
+}
+
+func (s *ConsoleLoggerSuite) TestKeyNames(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+	defer SetClock(nil)
+
+	keys := KeyNames{Timestamp: "@timestamp", Severity: "level", Message: "msg", Line: "lineno"}
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON, KeyNames: keys})
+	c.Assert(err, IsNil)
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	message := l.(FieldFormatter).FormatMessageWithFields(SeverityWarning, caller, Fields{"message": "kept", "msg": "ignored"}, "hello %s", "world")
+	c.Assert(message, Equals, `{"@timestamp":"2014-03-05T07:09:11Z","level":"WARN","msg":"hello world","file":"filename","func":"funcname","lineno":42,"message":"kept"}`+"\n")
+
+	// every standard part needs its own key, the defaults included
+	for _, keys := range []KeyNames{{Severity: "msg", Message: "msg"}, {Timestamp: "severity"}} {
+		_, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON, KeyNames: keys})
+		c.Assert(err, NotNil, Commentf("%+v", keys))
+	}
+	_, err = NewFileLogger(Config{Name: File, Severity: "info", Path: filepath.Join(c.MkDir(), "app.log"), KeyNames: KeyNames{File: "func"}})
+	c.Assert(err, ErrorMatches, "duplicate key name: func")
+}
+
+func (s *ConsoleLoggerSuite) TestJSONKeyOrder(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+	defer SetClock(nil)
+
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+	fields := Fields{"zone": "b", "attempt": 2, "id": "x", "func": "ignored"}
+	leading := `{"timestamp":"2014-03-05T07:09:11Z","severity":"INFO","message":"hello","file":"filename","func":"funcname","line":42,`
+
+	// the standard keys lead and the fields follow sorted, however often the message is rendered
+	for i := 0; i < 10; i++ {
+		message := formatJSON(newRecord(SeverityInfo, caller, now(), fields, "hello"), KeyNames{})
+		c.Assert(message, Equals, leading+`"attempt":2,"id":"x","zone":"b"}`)
+	}
+	c.Assert(formatJSON(newRecord(SeverityInfo, caller, now(), nil, "hello"), KeyNames{}), Equals, strings.TrimSuffix(leading, ",")+"}")
+}
+
+// unencodable is a field value failing to encode as JSON.
+type unencodable struct{}
+
+func (unencodable) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("unencodable")
+}
+
+func (unencodable) String() string {
+	return "opaque"
+}
+
+func (s *ConsoleLoggerSuite) TestJSONUnencodable(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+	defer SetClock(nil)
+
+	// values that fail to encode are rendered fmt's way rather than dropping the message
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+	message := formatJSON(newRecord(SeverityInfo, caller, now(), Fields{"ratio": math.NaN(), "raw": unencodable{}, "user": "bob"}, "hello"), KeyNames{})
+	c.Assert(message, Equals, `{"timestamp":"2014-03-05T07:09:11Z","severity":"INFO","message":"hello","file":"filename","func":"funcname","line":42,"ratio":"NaN","raw":"opaque","user":"bob"}`)
+}
+
+func (s *ConsoleLoggerSuite) TestColor(c *C) {
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	l, _ := NewConsoleLogger(Config{Name: Console, Severity: "info", Color: true})
+	c.Assert(strings.Contains(l.FormatMessage(SeverityError, caller, "hello"), " \x1b[31mERROR\x1b[0m "), Equals, true)
+	c.Assert(strings.Contains(l.FormatMessage(SeverityWarning, caller, "hello"), " \x1b[33mWARN\x1b[0m "), Equals, true)
+
+	l, _ = NewConsoleLogger(Config{Name: Console, Severity: "info"})
+	c.Assert(strings.Contains(l.FormatMessage(SeverityError, caller, "hello"), "\x1b["), Equals, false)
+}
+
+func (s *ConsoleLoggerSuite) TestIndent(c *C) {
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	l, _ := NewConsoleLogger(Config{Name: Console, Severity: "info", Indent: "    "})
+	lines := strings.Split(l.FormatMessage(SeverityError, caller, "hello %s\nat main.go:42\n", "world"), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(strings.HasSuffix(lines[0], " ERROR PID:"+strconv.Itoa(pid)+" [filename:42:funcname] hello world"), Equals, true)
+	c.Assert(lines[1], Equals, "    at main.go:42")
+	c.Assert(lines[2], Equals, "")
+
+	// continuation lines run flush-left by default
+	l, _ = NewConsoleLogger(Config{Name: Console, Severity: "info"})
+	lines = strings.Split(l.FormatMessage(SeverityError, caller, "hello %s\nat main.go:42", "world"), "\n")
+	c.Assert(lines[1], Equals, "at main.go:42")
+}
+
+func (s *ConsoleLoggerSuite) TestPretty(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+	defer SetClock(nil)
+
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatPretty, ForcePretty: true})
+	c.Assert(err, IsNil)
+	short := &CallerInfo{"a.go", "a.go", "funcname", 7}
+	long := &CallerInfo{"server.go", "server.go", "funcname", 1042}
+
+	// severities are padded to a fixed width
+	c.Assert(l.FormatMessage(SeverityInfo, short, "hello"), Equals, "Mar  5 07:09:11.000 \x1b[32mINFO \x1b[0m [a.go:7] hello\n")
+	c.Assert(l.FormatMessage(SeverityError, short, "hello"), Equals, "Mar  5 07:09:11.000 \x1b[31mERROR\x1b[0m [a.go:7] hello\n")
+
+	// the caller column widens to the widest caller and stays that wide
+	c.Assert(l.FormatMessage(SeverityWarning, long, "hello"), Equals, "Mar  5 07:09:11.000 \x1b[33mWARN \x1b[0m [server.go:1042] hello\n")
+	c.Assert(l.FormatMessage(SeverityInfo, short, "hello"), Equals, "Mar  5 07:09:11.000 \x1b[32mINFO \x1b[0m [a.go:7]         hello\n")
+
+	l, _ = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatPretty, ForcePretty: true, LevelStyle: LevelStyleChar})
+	c.Assert(l.FormatMessage(SeverityInfo, short, "hello"), Equals, "Mar  5 07:09:11.000 \x1b[32mI\x1b[0m [a.go:7] hello\n")
+}
+
+func (s *ConsoleLoggerSuite) TestPrettyFields(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+	defer SetClock(nil)
+
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatPretty, ForcePretty: true})
+	c.Assert(err, IsNil)
+	caller := &CallerInfo{"a.go", "a.go", "funcname", 7}
+
+	// the fields line up under the message, one per line
+	message := l.(FieldFormatter).FormatMessageWithFields(SeverityInfo, caller, Fields{"user": "bob", "stack": "main.main\nruntime.main"}, "hello")
+	c.Assert(message, Equals, "Mar  5 07:09:11.000 \x1b[32mINFO \x1b[0m [a.go:7] hello\n"+
+		"                                   \x1b[2mstack=\x1b[0mmain.main\n"+
+		"                                     runtime.main\n"+
+		"                                   \x1b[2muser=\x1b[0mbob\n")
+}
+
+func (s *ConsoleLoggerSuite) TestNewConsoleLoggerOff(c *C) {
+	for _, sev := range []string{"off", "none"} {
+		l, err := NewConsoleLogger(Config{Name: Console, Severity: sev})
+		c.Assert(err, IsNil)
+		c.Assert(l.Writer(SeverityError), IsNil)
+	}
+}
+
+func (s *ConsoleLoggerSuite) TestFormatMessageLineEnding(c *C) {
+	defer SetLineEnding(LineEndingLF)
+
+	l, _ := NewConsoleLogger(Config{Name: Console, Severity: "info"})
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	for _, ending := range []string{LineEndingLF, LineEndingCRLF} {
+		c.Assert(SetLineEnding(ending), IsNil)
+
+		message := l.FormatMessage(SeverityInfo, caller, "hello %s", "world")
+		c.Assert(strings.HasSuffix(message, "hello world"+ending), Equals, true)
+		c.Assert(strings.Count(message, ending), Equals, 1)
+
+		// a message ending with its own newline should not get a second one
+		message = l.FormatMessage(SeverityInfo, caller, "hello %s\n", "world")
+		c.Assert(strings.HasSuffix(message, "hello world"+ending), Equals, true)
+		c.Assert(strings.Count(message, ending), Equals, 1)
+	}
+
+	c.Assert(SetLineEnding(LineEndingNone), IsNil)
+	message := l.FormatMessage(SeverityInfo, caller, "hello %s\n", "world")
+	c.Assert(strings.HasSuffix(message, "hello world"), Equals, true)
+	c.Assert(strings.ContainsAny(message, "\r\n"), Equals, false)
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"context"
+	"sync"
+)
+
+// contextKey is the type of the key the package stores entries in contexts under.
+type contextKey struct{}
+
+// ContextExtractor returns fields found in a context, such as the IDs of the trace span it
+// carries, or nil if there are none.
+type ContextExtractor func(ctx context.Context) Fields
+
+var (
+	extractorsMu sync.RWMutex
+	extractors   []ContextExtractor
+)
+
+// AddContextExtractor makes FromContext, and so the Ctx functions and FromRequest, add the
+// fields the extractor finds in the context to the entry it returns, over the fields of the
+// entry stored in the context. For example, the otellog package extracts the IDs of the
+// OpenTelemetry span of the context so that messages can be correlated with traces.
+func AddContextExtractor(x ContextExtractor) {
+	extractorsMu.Lock()
+	defer extractorsMu.Unlock()
+
+	extractors = append(extractors, x)
+}
+
+// extractFields returns the fields the extractors find in the context, or nil.
+func extractFields(ctx context.Context) Fields {
+	extractorsMu.RLock()
+	defer extractorsMu.RUnlock()
+
+	var fields Fields
+	for _, x := range extractors {
+		for k, v := range x(ctx) {
+			if fields == nil {
+				fields = Fields{}
+			}
+			fields[k] = v
+		}
+	}
+	return fields
+}
+
+// NewContext returns a copy of the context carrying the provided entry,
+// which can be retrieved with FromContext.
+func NewContext(ctx context.Context, e *Entry) context.Context {
+	return context.WithValue(ctx, contextKey{}, e)
+}
+
+// FromContext returns the entry stored in the context by NewContext, or an entry
+// without any fields if there is none, with the fields of the context extractors added, see
+// AddContextExtractor.
+func FromContext(ctx context.Context) *Entry {
+	e, ok := ctx.Value(contextKey{}).(*Entry)
+	if !ok {
+		e = &Entry{}
+	}
+	if fields := extractFields(ctx); fields != nil {
+		return e.WithFields(fields)
+	}
+	return e
+}
+
+// ContextWithFields returns a copy of the context carrying the entry stored in it, if any,
+// with the provided fields added, e.g. to attach a trace ID to every message logged with one
+// of the Ctx functions while handling a request.
+func ContextWithFields(ctx context.Context, fields Fields) context.Context {
+	return NewContext(ctx, FromContext(ctx).WithFields(fields))
+}
+
+// TracefCtx logs to the TRACE log with the fields of the entry stored in the context.
+func TracefCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityTrace, format, args...)
+}
+
+// DebugfCtx logs to the DEBUG log with the fields of the entry stored in the context.
+func DebugfCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityDebug, format, args...)
+}
+
+// InfofCtx logs to the INFO log with the fields of the entry stored in the context.
+func InfofCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityInfo, format, args...)
+}
+
+// WarningfCtx logs to the WARN and INFO logs with the fields of the entry stored in the context.
+func WarningfCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityWarning, format, args...)
+}
+
+// ErrorfCtx logs to the ERROR, WARN, and INFO logs with the fields of the entry stored in the
+// context.
+func ErrorfCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityError, format, args...)
+}
+
+// FatalfCtx is like Fatalf with the fields of the entry stored in the context.
+func FatalfCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityFatal, format, args...)
+}
+
+// PanicfCtx is like Panicf with the fields of the entry stored in the context.
+func PanicfCtx(ctx context.Context, format string, args ...interface{}) {
+	output(2, FromContext(ctx), SeverityPanic, format, args...)
+}
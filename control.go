@@ -0,0 +1,288 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Flusher is an optional interface implemented by loggers buffering messages, see Flush.
+type Flusher interface {
+	Flush() error
+}
+
+// Syncer is an optional interface implemented by loggers writing to files, which commits
+// what has been written to stable storage. Loggers are synced before fatal messages exit
+// the program.
+type Syncer interface {
+	Sync() error
+}
+
+// Reopener is an optional interface implemented by loggers writing to files or connections
+// that can be reopened, for example after the files have been rotated, see Reopen.
+type Reopener interface {
+	Reopen() error
+}
+
+// Flush makes the loggers implementing Flusher, including the sinks of the audit channel, see
+// InitAudit, write out the messages they buffer and returns the first error encountered.
+func Flush() error {
+	var first error
+	for _, l := range allLoggers() {
+		if f, ok := l.(Flusher); ok {
+			if err := f.Flush(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Reopen makes the loggers implementing Reopener reopen their outputs and returns the first
+// error encountered.
+func Reopen() error {
+	var first error
+	for _, l := range currentLoggers() {
+		if r, ok := l.(Reopener); ok {
+			if err := r.Reopen(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// SetLoggerSeverity changes the severity of the loggers configured with the provided name,
+// see InitWithConfig. The loggers must implement LeveledLogger. Changes are sent to the
+// subscribers, see SubscribeSeverityChanges.
+func SetLoggerSeverity(name string, sev Severity) error {
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+
+	found := false
+	for _, l := range loggers {
+		if loggerName(l) != name {
+			continue
+		}
+		leveled, ok := l.(LeveledLogger)
+		if !ok {
+			return fmt.Errorf("logger %s does not support changing its severity", name)
+		}
+		if old := leveled.Severity(); old != sev {
+			leveled.SetSeverity(sev)
+			notifySeverityChange(SeverityChange{name, old, sev})
+		}
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("no such logger: %s", name)
+	}
+	return nil
+}
+
+// SetSeverity changes the severity of every logger in the chain implementing LeveledLogger,
+// including the loggers MultiLoggers send messages to, e.g. to turn on debug logging in a
+// running process. Changes are sent to the subscribers, see SubscribeSeverityChanges, under
+// the names of the loggers in the chain.
+func SetSeverity(sev Severity) {
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+
+	for _, l := range loggers {
+		setSeverity(loggerName(l), l, sev)
+	}
+}
+
+func setSeverity(name string, l Logger, sev Severity) {
+	if m, ok := l.(*MultiLogger); ok {
+		for _, child := range m.loggers {
+			setSeverity(name, child, sev)
+		}
+		return
+	}
+	if leveled, ok := l.(LeveledLogger); ok {
+		if old := leveled.Severity(); old != sev {
+			leveled.SetSeverity(sev)
+			notifySeverityChange(SeverityChange{name, old, sev})
+		}
+	}
+}
+
+// GetSeverity returns the lowest severity the loggers in the chain implementing LeveledLogger
+// log at, that is the severity messages are logged at by at least one of them, or SeverityOff
+// if there are no such loggers.
+func GetSeverity() Severity {
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+
+	lowest := SeverityOff
+	for _, l := range loggers {
+		if sev := lowestSeverity(l); sev < lowest {
+			lowest = sev
+		}
+	}
+	return lowest
+}
+
+func lowestSeverity(l Logger) Severity {
+	lowest := SeverityOff
+	if m, ok := l.(*MultiLogger); ok {
+		for _, child := range m.loggers {
+			if sev := lowestSeverity(child); sev < lowest {
+				lowest = sev
+			}
+		}
+	} else if leveled, ok := l.(LeveledLogger); ok {
+		lowest = leveled.Severity()
+	}
+	return lowest
+}
+
+// currentLoggers returns the loggers the package is initialized with.
+func currentLoggers() []Logger {
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+
+	return loggers
+}
+
+// ControlServer accepts commands controlling the package over a Unix socket, see ListenControl.
+type ControlServer struct {
+	listener net.Listener
+
+	mu     sync.Mutex
+	conns  map[net.Conn]struct{}
+	closed bool
+	wg     sync.WaitGroup
+}
+
+// ListenControl starts a control server listening on a Unix socket at the provided path,
+// which lets ops tooling control logging without signals, e.g. with socat. The server reads
+// commands, one per line, and replies to each with any output followed by "OK" or "ERR <reason>":
+//
+//	flush                  // calls Flush
+//	reopen                 // calls Reopen
+//	level <name> <sev>     // calls SetLoggerSeverity, e.g. "level console debug"
+//	dump                   // lists the loggers along with their severities, one per line
+//
+// A stale socket left at the path by a previous process is removed.
+func ListenControl(path string) (*ControlServer, error) {
+	if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		os.Remove(path)
+	}
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &ControlServer{listener: listener, conns: make(map[net.Conn]struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Close stops the server, closing the connections of the clients and removing the socket.
+// It can be called multiple times.
+func (s *ControlServer) Close() error {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return nil
+	}
+	s.closed = true
+	err := s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+func (s *ControlServer) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			// closed
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+// handle executes the commands read from the connection until the client disconnects.
+func (s *ControlServer) handle(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		output, err := execute(strings.Fields(scanner.Text()))
+		for _, line := range output {
+			fmt.Fprintln(conn, line)
+		}
+		if err != nil {
+			fmt.Fprintf(conn, "ERR %s\n", err)
+		} else {
+			fmt.Fprintln(conn, "OK")
+		}
+	}
+}
+
+// execute runs the control command and returns its output.
+func execute(command []string) ([]string, error) {
+	if len(command) == 0 {
+		return nil, fmt.Errorf("empty command")
+	}
+
+	switch command[0] {
+	case "flush":
+		return nil, Flush()
+	case "reopen":
+		return nil, Reopen()
+	case "level":
+		if len(command) != 3 {
+			return nil, fmt.Errorf("usage: level <name> <severity>")
+		}
+		sev, err := severityFromString(command[2])
+		if err != nil {
+			return nil, err
+		}
+		return nil, SetLoggerSeverity(command[1], sev)
+	case "dump":
+		loggersMu.RLock()
+		defer loggersMu.RUnlock()
+
+		var output []string
+		for _, l := range loggers {
+			sev := "-"
+			if leveled, ok := l.(LeveledLogger); ok {
+				sev = leveled.Severity().String()
+			}
+			output = append(output, fmt.Sprintf("%s %s", loggerName(l), sev))
+		}
+		return output, nil
+	}
+	return nil, fmt.Errorf("unknown command: %s", command[0])
+}
@@ -0,0 +1,150 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type ControlSuite struct {
+	server *ControlServer
+	path   string
+}
+
+var _ = Suite(&ControlSuite{})
+
+func (s *ControlSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	loggerNames = nil
+
+	s.path = filepath.Join(c.MkDir(), "log.sock")
+	var err error
+	s.server, err = ListenControl(s.path)
+	c.Assert(err, IsNil)
+}
+
+func (s *ControlSuite) TearDownTest(c *C) {
+	s.server.Close()
+}
+
+// flushingLogger records the calls to Flush and Reopen.
+type flushingLogger struct {
+	*testLogger
+	flushes, reopens int
+	err              error
+}
+
+func (l *flushingLogger) Flush() error {
+	l.flushes++
+	return l.err
+}
+
+func (l *flushingLogger) Reopen() error {
+	l.reopens++
+	return l.err
+}
+
+// send sends the command to the control server and returns the reply.
+func (s *ControlSuite) send(c *C, command string) []string {
+	conn, err := net.Dial("unix", s.path)
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	fmt.Fprintln(conn, command)
+	var reply []string
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		reply = append(reply, scanner.Text())
+		if line := scanner.Text(); line == "OK" || strings.HasPrefix(line, "ERR ") {
+			return reply
+		}
+	}
+	c.Fatalf("no status in reply: %v", reply)
+	return nil
+}
+
+func (s *ControlSuite) TestFlushAndReopen(c *C) {
+	l := &flushingLogger{testLogger: newTestLogger("log")}
+	Init(newTestLogger("other"), l)
+
+	c.Assert(s.send(c, "flush"), DeepEquals, []string{"OK"})
+	c.Assert(l.flushes, Equals, 1)
+	c.Assert(s.send(c, "reopen"), DeepEquals, []string{"OK"})
+	c.Assert(l.reopens, Equals, 1)
+
+	l.err = errors.New("disk full")
+	c.Assert(s.send(c, "flush"), DeepEquals, []string{"ERR disk full"})
+	c.Assert(s.send(c, "reopen"), DeepEquals, []string{"ERR disk full"})
+}
+
+func (s *ControlSuite) TestLevelAndDump(c *C) {
+	defer func() { stdout = os.Stdout }()
+	stdout = &bytes.Buffer{}
+	c.Assert(InitWithConfig(Config{Name: Console, Severity: "info"}), IsNil)
+	Init(newTestLogger("log"))
+
+	c.Assert(s.send(c, "dump"), DeepEquals, []string{"console INFO", "*log.testLogger -", "OK"})
+	c.Assert(loggers[0].Writer(SeverityDebug), IsNil)
+
+	c.Assert(s.send(c, "level console debug"), DeepEquals, []string{"OK"})
+	c.Assert(loggers[0].Writer(SeverityDebug), NotNil)
+	c.Assert(s.send(c, "dump")[0], Equals, "console DEBUG")
+
+	c.Assert(s.send(c, "level syslog debug"), DeepEquals, []string{"ERR no such logger: syslog"})
+	c.Assert(s.send(c, "level console loud"), DeepEquals, []string{"ERR unsupported severity: LOUD"})
+	c.Assert(s.send(c, "level *log.testLogger debug")[0], Matches, "ERR .* does not support changing its severity")
+	c.Assert(s.send(c, "level console"), DeepEquals, []string{"ERR usage: level <name> <severity>"})
+}
+
+func (s *ControlSuite) TestSetSeverity(c *C) {
+	memory, err := NewMemoryLogger(Config{Severity: "warn"})
+	c.Assert(err, IsNil)
+	defer func() { stdout = os.Stdout }()
+	stdout = &bytes.Buffer{}
+	c.Assert(InitWithConfig(Config{Name: Console, Severity: "info"}), IsNil)
+	Init(NewMultiLogger(memory, newTestLogger("log")))
+	c.Assert(GetSeverity(), Equals, SeverityInfo)
+
+	changes, unsubscribe := SubscribeSeverityChanges()
+	defer unsubscribe()
+
+	SetSeverity(SeverityDebug)
+	c.Assert(GetSeverity(), Equals, SeverityDebug)
+	c.Assert(loggers[0].Writer(SeverityDebug), NotNil)
+	c.Assert(memory.Severity(), Equals, SeverityDebug)
+	c.Assert(<-changes, Equals, SeverityChange{Console, SeverityInfo, SeverityDebug})
+	c.Assert(<-changes, Equals, SeverityChange{"*log.MultiLogger", SeverityWarning, SeverityDebug})
+
+	// loggers whose severity cannot be looked up are left out
+	loggers = []Logger{newTestLogger("log")}
+	c.Assert(GetSeverity(), Equals, SeverityOff)
+}
+
+func (s *ControlSuite) TestUnknownCommand(c *C) {
+	c.Assert(s.send(c, "rotate"), DeepEquals, []string{"ERR unknown command: rotate"})
+	c.Assert(s.send(c, ""), DeepEquals, []string{"ERR empty command"})
+}
+
+func (s *ControlSuite) TestClose(c *C) {
+	conn, err := net.Dial("unix", s.path)
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	c.Assert(s.server.Close(), IsNil)
+	c.Assert(s.server.Close(), IsNil)
+
+	// the connected client is disconnected and nobody else can connect
+	_, err = bufio.NewReader(conn).ReadString('\n')
+	c.Assert(err, NotNil)
+	_, err = net.Dial("unix", s.path)
+	c.Assert(err, NotNil)
+	_, err = os.Stat(s.path)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
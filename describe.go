@@ -0,0 +1,206 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Describer is an optional interface implemented by loggers reporting their settings,
+// see DescribeConfig.
+type Describer interface {
+	Describe() LoggerDescription
+}
+
+// LoggerDescription describes the settings of a logger.
+type LoggerDescription struct {
+	// Name is the name of the logger, see InitWithConfig, it is set by DescribeConfigStruct.
+	Name string
+
+	// Type is the type of the logger, e.g. "console".
+	Type string
+
+	// Severity is the severity the logger logs at, set by DescribeConfigStruct for the
+	// loggers implementing LeveledLogger unless they set it themselves.
+	Severity string
+
+	// Format is the message format, see Config.Format, and Destination is where messages
+	// go, e.g. a stream or a path.
+	Format      string
+	Destination string
+
+	// Dropped is the number of messages dropped by the logger, set by DescribeConfigStruct
+	// for the loggers implementing Droppable.
+	Dropped uint64
+
+	// Loggers describes the loggers messages are passed on to by loggers wrapping others.
+	Loggers []LoggerDescription
+}
+
+// ConfigDescription describes the active logging configuration.
+type ConfigDescription struct {
+	Loggers []LoggerDescription
+
+	// RateLimitDropped is the number of messages dropped by the global rate limiter, see
+	// SetGlobalRateLimit.
+	RateLimitDropped uint64
+}
+
+// DescribeConfigStruct describes the loggers in the chain and their settings.
+func DescribeConfigStruct() ConfigDescription {
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+
+	d := ConfigDescription{RateLimitDropped: RateLimitDropped()}
+	for _, l := range loggers {
+		desc := describe(l)
+		desc.Name = loggerName(l)
+		d.Loggers = append(d.Loggers, desc)
+	}
+	return d
+}
+
+// DescribeConfig renders the description of the active logging configuration, a line per
+// logger, e.g. to print it at startup or to attach it to a support ticket:
+//
+//	console destination=stdout dropped=0 format=text severity=INFO type=console
+//	ratelimit dropped=0
+func DescribeConfig() string {
+	d := DescribeConfigStruct()
+
+	var b strings.Builder
+	for _, l := range d.Loggers {
+		writeDescription(&b, l, "")
+	}
+	fmt.Fprintf(&b, "%s dropped=%d\n", RateLimiterDrops, d.RateLimitDropped)
+	return b.String()
+}
+
+// describe describes the logger, filling in what the package knows about it.
+func describe(l Logger) LoggerDescription {
+	var d LoggerDescription
+	if describer, ok := l.(Describer); ok {
+		d = describer.Describe()
+	}
+	if d.Type == "" {
+		d.Type = fmt.Sprintf("%T", l)
+	}
+	if leveled, ok := l.(LeveledLogger); ok && d.Severity == "" {
+		d.Severity = leveled.Severity().String()
+	}
+	if droppable, ok := l.(Droppable); ok {
+		d.Dropped = droppable.Dropped()
+	}
+	return d
+}
+
+// describeAll describes the loggers wrapped by another one, naming them after their types.
+func describeAll(loggers ...Logger) []LoggerDescription {
+	descriptions := make([]LoggerDescription, len(loggers))
+	for i, l := range loggers {
+		descriptions[i] = describe(l)
+		descriptions[i].Name = descriptions[i].Type
+	}
+	return descriptions
+}
+
+// writeDescription renders the description of the logger, and those of the loggers it wraps
+// indented below it.
+func writeDescription(b *strings.Builder, d LoggerDescription, indent string) {
+	fields := Fields{"type": d.Type, "dropped": d.Dropped}
+	for k, v := range map[string]string{"severity": d.Severity, "format": d.Format, "destination": d.Destination} {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	fmt.Fprintf(b, "%s%s %s\n", indent, d.Name, formatFields(fields))
+	for _, wrapped := range d.Loggers {
+		writeDescription(b, wrapped, indent+"  ")
+	}
+}
+
+func (l *consoleLogger) Describe() LoggerDescription {
+	destination := l.outputStream
+	if l.splitAt != SeverityOff {
+		destination = fmt.Sprintf("%s below %s, %s from then on", l.outputStream, l.splitAt, l.errorStream)
+	}
+	return LoggerDescription{Type: Console, Format: l.formatName(), Destination: destination}
+}
+
+// formatName returns the format of the logger's messages, the default one included.
+func (l *consoleLogger) formatName() string {
+	if l.format == "" {
+		return FormatText
+	}
+	return l.format
+}
+
+func (l *fileLogger) Describe() LoggerDescription {
+	return LoggerDescription{Type: File, Format: l.formatName(), Destination: l.file.path}
+}
+
+func (l *RingLogger) Describe() LoggerDescription {
+	l.mu.Lock()
+	size := cap(l.lines)
+	l.mu.Unlock()
+	return LoggerDescription{Type: "ring", Format: FormatText, Destination: fmt.Sprintf("memory, last %d messages", size)}
+}
+
+func (l *sysLogger) Describe() LoggerDescription {
+	destination := "local syslog server"
+	if l.remote {
+		destination = l.address
+	}
+	return LoggerDescription{Type: Syslog, Destination: destination}
+}
+
+func (l *udpLogger) Describe() LoggerDescription {
+	destination := l.conn.String()
+	if l.batch != nil {
+		destination += fmt.Sprintf(", batched in datagrams of up to %d bytes", l.maxSize)
+	}
+	return LoggerDescription{Type: UDPLog, Format: l.encoding, Destination: destination}
+}
+
+func (l *clfLogger) Describe() LoggerDescription {
+	return LoggerDescription{Type: "clf", Format: "common log format", Destination: StreamStdout}
+}
+
+func (l *MemoryLogger) Describe() LoggerDescription {
+	return LoggerDescription{Type: "memory", Destination: "memory"}
+}
+
+func (m *MultiLogger) Describe() LoggerDescription {
+	return LoggerDescription{Type: Mirror, Loggers: describeAll(m.loggers...)}
+}
+
+func (l *FailoverLogger) Describe() LoggerDescription {
+	destination := "primary"
+	if l.Active() != l.primary {
+		destination = "secondary"
+	}
+	return LoggerDescription{Type: "failover", Destination: destination, Loggers: describeAll(l.primary, l.secondary)}
+}
+
+func (l *SampledLogger) Describe() LoggerDescription {
+	return LoggerDescription{Type: "sampled", Destination: fmt.Sprintf("1 in %d messages below ERROR", l.n), Loggers: describeAll(l.logger)}
+}
+
+func (l *SpillLogger) Describe() LoggerDescription {
+	destination := fmt.Sprintf("queue of %d bytes in memory, %d on disk", l.memoryLimit, l.limit)
+	return LoggerDescription{Type: "spill", Destination: destination, Loggers: describeAll(l.logger)}
+}
+
+func (l *RetryLogger) Describe() LoggerDescription {
+	destination := fmt.Sprintf("spool of %d bytes on disk, retried after %v to %v", l.policy.SpoolSize, l.policy.Backoff, l.policy.MaxBackoff)
+	loggers := []Logger{l.logger}
+	if l.policy.Fallback != nil {
+		destination += ", falling back to the second logger"
+		loggers = append(loggers, l.policy.Fallback)
+	}
+	return LoggerDescription{Type: "retry", Destination: destination, Loggers: describeAll(loggers...)}
+}
+
+func (l *AsyncLogger) Describe() LoggerDescription {
+	destination := fmt.Sprintf("queue of %d messages, %s on overflow", l.size, l.overflow)
+	return LoggerDescription{Type: "async", Destination: destination, Loggers: describeAll(l.logger)}
+}
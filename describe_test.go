@@ -0,0 +1,51 @@
+package log
+
+import (
+	"path/filepath"
+	"regexp"
+
+	. "gopkg.in/check.v1"
+)
+
+type DescribeSuite struct {
+}
+
+var _ = Suite(&DescribeSuite{})
+
+func (s *DescribeSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *DescribeSuite) TestDescribeConfig(c *C) {
+	path := filepath.Join(c.MkDir(), "app.log")
+	err := InitWithConfig(
+		Config{Name: Console, Severity: "warn", Format: FormatJSON, SplitAt: "error"},
+		Config{Name: File, Severity: "debug", Path: path},
+	)
+	c.Assert(err, IsNil)
+	defer loggers[1].(*fileLogger).Close()
+
+	d := DescribeConfigStruct()
+	c.Assert(d.Loggers, DeepEquals, []LoggerDescription{
+		{Name: Console, Type: Console, Severity: "WARN", Format: FormatJSON, Destination: "stdout below ERROR, stderr from then on"},
+		{Name: File, Type: File, Severity: "DEBUG", Format: FormatText, Destination: path},
+	})
+
+	c.Assert(DescribeConfig(), Matches, ""+
+		"console destination=\"stdout below ERROR, stderr from then on\" dropped=0 format=json severity=WARN type=console\n"+
+		"file destination="+regexp.QuoteMeta(path)+" dropped=0 format=text severity=DEBUG type=file\n"+
+		"ratelimit dropped=[0-9]+\n")
+}
+
+func (s *DescribeSuite) TestWrapped(c *C) {
+	memory, err := NewMemoryLogger(Config{Severity: "info"})
+	c.Assert(err, IsNil)
+	Init(NewMultiLogger(NewSampledLogger(memory, 10), newTestLogger("test")))
+
+	c.Assert(DescribeConfig(), Matches, ""+
+		`\*log.MultiLogger dropped=0 type=mirror\n`+
+		"  sampled destination=\"1 in 10 messages below ERROR\" dropped=0 type=sampled\n"+
+		"    memory destination=memory dropped=0 severity=INFO type=memory\n"+
+		`  \*log.testLogger dropped=0 type=\*log.testLogger\n`+
+		"ratelimit dropped=[0-9]+\n")
+}
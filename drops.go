@@ -0,0 +1,56 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// RateLimiterDrops is the key DroppedCounts reports the messages dropped by the global rate
+// limiter under, see SetGlobalRateLimit.
+const RateLimiterDrops = "ratelimit"
+
+// Droppable is an optional interface implemented by lossy loggers, those dropping messages
+// rather than blocking or failing the program, e.g. when their buffers are full or their
+// connections are down.
+type Droppable interface {
+	// Dropped returns the number of messages the logger has dropped since it was made.
+	Dropped() uint64
+}
+
+// DroppedCounts returns the number of messages dropped by every logger implementing Droppable,
+// keyed by the logger's name, see InitWithConfig, and summed over loggers sharing a name. The
+// messages dropped by the global rate limiter are reported under RateLimiterDrops.
+//
+// It is meant to be exported to monitoring.
+func DroppedCounts() map[string]uint64 {
+	loggersMu.RLock()
+	defer loggersMu.RUnlock()
+
+	counts := map[string]uint64{RateLimiterDrops: RateLimitDropped()}
+	for _, l := range loggers {
+		if d, ok := l.(Droppable); ok {
+			counts[loggerName(l)] += d.Dropped()
+		}
+	}
+	return counts
+}
+
+// dropCountingWriter is a writer counting the writes that fail as dropped messages,
+// for loggers that do not report write errors since nobody would handle them.
+type dropCountingWriter struct {
+	w       io.Writer
+	dropped uint64
+}
+
+func (d *dropCountingWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	if err != nil {
+		atomic.AddUint64(&d.dropped, 1)
+	}
+	return n, err
+}
+
+// Dropped returns the number of writes that failed.
+func (d *dropCountingWriter) Dropped() uint64 {
+	return atomic.LoadUint64(&d.dropped)
+}
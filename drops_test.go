@@ -0,0 +1,89 @@
+package log
+
+import (
+	"fmt"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type DropsSuite struct {
+}
+
+var _ = Suite(&DropsSuite{})
+
+func (s *DropsSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	loggerNames = nil
+}
+
+func (s *DropsSuite) TearDownTest(c *C) {
+	loggerNames = nil
+	SetGlobalRateLimit(0)
+	SetClock(nil)
+}
+
+// droppingLogger is a test logger reporting a fixed number of dropped messages.
+type droppingLogger struct {
+	*testLogger
+	dropped uint64
+}
+
+func (l *droppingLogger) Dropped() uint64 {
+	return l.dropped
+}
+
+func (s *DropsSuite) TestUDPLogger(c *C) {
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info"})
+	c.Assert(err, IsNil)
+	c.Assert(l.(Droppable).Dropped(), Equals, uint64(0))
+
+	// messages that cannot be sent over the closed connection are dropped
+	l.(*udpLogger).Close()
+	Init(l)
+	Infof("hello %s", "world")
+	Errorf("hello %s", "world")
+	c.Assert(l.(Droppable).Dropped(), Equals, uint64(2))
+}
+
+func (s *DropsSuite) TestRingLogger(c *C) {
+	l, err := NewRingLogger(Config{Name: Console, Severity: "info"}, 10)
+	c.Assert(err, IsNil)
+	Init(l)
+
+	_, tailer, ok := l.subscribe()
+	c.Assert(ok, Equals, true)
+	defer l.unsubscribe(tailer)
+
+	// the tailer does not read anything so that messages beyond its buffer are dropped for it
+	for i := 0; i < tailerBufferSize+3; i++ {
+		Infof("hello %d", i)
+	}
+	c.Assert(l.Dropped(), Equals, uint64(3))
+}
+
+func (s *DropsSuite) TestMultiLogger(c *C) {
+	m := NewMultiLogger(&droppingLogger{newTestLogger("a"), 2}, newTestLogger("b"), &droppingLogger{newTestLogger("c"), 3})
+	c.Assert(m.Dropped(), Equals, uint64(5))
+}
+
+func (s *DropsSuite) TestDroppedCounts(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC) }))
+	SetGlobalRateLimit(1)
+	rateLimited := RateLimitDropped()
+
+	first := &droppingLogger{newTestLogger("first"), 2}
+	second := &droppingLogger{newTestLogger("second"), 3}
+	other := &droppingLogger{newTestLogger("other"), 7}
+	loggerNames = []namedLogger{{UDPLog, first}, {UDPLog, second}}
+	Init(first, second, other, newTestLogger("lossless"))
+
+	Infof("hello %s", "world")
+	Infof("hello %s", "world")
+
+	c.Assert(DroppedCounts(), DeepEquals, map[string]uint64{
+		UDPLog:                   5,
+		fmt.Sprintf("%T", other): 7,
+		RateLimiterDrops:         rateLimited + 1,
+	})
+}
@@ -0,0 +1,103 @@
+package log
+
+import "sync/atomic"
+
+// Enabled tells whether a message logged at the severity could be written by any logger, or
+// be seen by a hook or a callback, which lets callers skip building messages that would be
+// discarded. Messages logged before the package is initialized are kept, see Init.
+func Enabled(sev Severity) bool {
+	return !discardable(sev, nil)
+}
+
+// Enabled is like the package-level Enabled for messages logged through the entry, taking
+// its severity override and component into account.
+func (e *Entry) Enabled(sev Severity) bool {
+	return !e.drops(sev) && !discardable(sev, e)
+}
+
+// discardable tells whether the message logged through the entry at the severity can be
+// discarded before any work is done on it: no logger would write it, and neither a severity
+// scoped with WithSeverity nor an escalation rule could make one write it, nor is a hook or a
+// callback waiting for it. Messages logged before the package is initialized are never
+// discardable, they are kept until it is.
+func discardable(sev Severity, e *Entry) bool {
+	if atomic.LoadInt32(&scopes) > 0 || escalations.escalates(sev) || hooked(sev) || calledBack(sev) {
+		return false
+	}
+	loggersMu.RLock()
+	chain := loggers
+	loggersMu.RUnlock()
+	return len(chain) > 0 && !e.enabledIn(chain, sev)
+}
+
+// enabledIn tells whether any logger of the chain would write a message logged through the
+// entry at the severity, looking into the loggers sendMessage looks into.
+func (e *Entry) enabledIn(chain []Logger, sev Severity) bool {
+	for _, logger := range chain {
+		switch l := logger.(type) {
+		case *MultiLogger:
+			if e.enabledIn(l.loggers, sev) {
+				return true
+			}
+		case *FailoverLogger:
+			if e.enabledIn([]Logger{l.primary, l.secondary}, sev) {
+				return true
+			}
+		default:
+			if e.enabled(logger, sev) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// enabled tells whether the logger would write a message logged through the entry at the
+// severity, deciding like writer does but without asking for writers when it can be avoided.
+func (e *Entry) enabled(logger Logger, sev Severity) bool {
+	on := logs(logger, sev)
+	if e == nil {
+		return on
+	}
+	overridden := e.overridden && sev >= e.override
+	if floor, ok := componentSeverity(logger, e.component); ok && !overridden {
+		if sev < floor {
+			return false
+		}
+		overridden = true
+	}
+	if on || !overridden {
+		return on
+	}
+	for _, s := range severities {
+		if s > sev && logs(logger, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// logs tells whether the logger logs at the severity. The writers of the loggers wrapping
+// others are not asked for, as they allocate or, for SampledLoggers, count the messages.
+func logs(logger Logger, sev Severity) bool {
+	switch l := logger.(type) {
+	case *SampledLogger:
+		return logs(l.logger, sev)
+	case *AsyncLogger:
+		return logs(l.logger, sev)
+	case *SpillLogger:
+		return logs(l.logger, sev)
+	case *RetryLogger:
+		return logs(l.logger, sev)
+	case *MultiLogger:
+		for _, child := range l.loggers {
+			if logs(child, sev) {
+				return true
+			}
+		}
+		return false
+	case *FailoverLogger:
+		return logs(l.primary, sev) || logs(l.secondary, sev)
+	}
+	return logger.Writer(sev) != nil
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"sync"
+	"time"
+)
+
+// EscalatedField is the name of the field marking escalated messages.
+const EscalatedField = "escalated"
+
+// maxEscalationKeys bounds the number of format strings tracked by an escalation rule
+// before expired ones are pruned.
+const maxEscalationKeys = 1024
+
+// escalations holds the escalation rules by the severity they apply to.
+var escalations = &escalator{rules: make(map[Severity]*escalationRule)}
+
+// EscalateAfter makes messages logged at sev escalate to the severity to once the same
+// message has been logged count times within window: further occurrences are logged at
+// the higher severity with an "escalated=true" field until the window is over. Messages
+// are told apart by their format string.
+//
+// A non-positive count removes the rule for the severity.
+func EscalateAfter(sev Severity, count int, window time.Duration, to Severity) {
+	escalations.setRule(sev, count, window, to)
+}
+
+type escalator struct {
+	mu    sync.Mutex
+	rules map[Severity]*escalationRule
+}
+
+type escalationRule struct {
+	count  int
+	window time.Duration
+	to     Severity
+
+	occurrences map[string]*occurrences
+}
+
+// occurrences counts how many times a message has been logged in the current window.
+type occurrences struct {
+	start time.Time
+	n     int
+}
+
+func (e *escalator) setRule(sev Severity, count int, window time.Duration, to Severity) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if count <= 0 {
+		delete(e.rules, sev)
+		return
+	}
+	e.rules[sev] = &escalationRule{count, window, to, make(map[string]*occurrences)}
+}
+
+// escalates tells whether a rule applies to messages logged at the severity.
+func (e *escalator) escalates(sev Severity) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	_, ok := e.rules[sev]
+	return ok
+}
+
+// escalate records an occurrence of the message and returns the severity and the fields
+// it should be logged with.
+func (e *escalator) escalate(sev Severity, fields Fields, format string) (Severity, Fields) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	rule, ok := e.rules[sev]
+	if !ok {
+		return sev, fields
+	}
+
+	t := now()
+	occ, ok := rule.occurrences[format]
+	if !ok || t.Sub(occ.start) > rule.window {
+		if len(rule.occurrences) >= maxEscalationKeys {
+			rule.prune(t)
+		}
+		occ = &occurrences{start: t}
+		rule.occurrences[format] = occ
+	}
+
+	occ.n++
+	if occ.n <= rule.count {
+		return sev, fields
+	}
+	return rule.to, mergeFields(fields, Fields{EscalatedField: true})
+}
+
+// prune forgets messages whose window is over.
+func (r *escalationRule) prune(t time.Time) {
+	for format, occ := range r.occurrences {
+		if t.Sub(occ.start) > r.window {
+			delete(r.occurrences, format)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type EscalationSuite struct {
+	now time.Time
+}
+
+var _ = Suite(&EscalationSuite{})
+
+func (s *EscalationSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	ResetHighestSeverity()
+
+	s.now = time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return s.now }))
+}
+
+func (s *EscalationSuite) TearDownTest(c *C) {
+	EscalateAfter(SeverityWarning, 0, 0, SeverityError)
+	SetClock(nil)
+}
+
+func (s *EscalationSuite) TestEscalateAfter(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	EscalateAfter(SeverityWarning, 3, time.Minute, SeverityError)
+
+	for i := 0; i < 5; i++ {
+		Warningf("disk %d%% full", 95+i)
+		s.now = s.now.Add(time.Second)
+	}
+	// other messages are counted on their own
+	Warningf("hello %s", "world")
+
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, DeepEquals, []string{
+		"WARN disk 95% full",
+		"WARN disk 96% full",
+		"WARN disk 97% full",
+		"ERROR disk 98% full escalated=true",
+		"ERROR disk 99% full escalated=true",
+		"WARN hello world",
+	})
+}
+
+func (s *EscalationSuite) TestEscalateAfterWindow(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	EscalateAfter(SeverityWarning, 1, time.Minute, SeverityError)
+
+	Warningf("hello %s", "world")
+	Warningf("hello %s", "world")
+
+	// the count is reset once the window is over
+	s.now = s.now.Add(time.Minute + time.Second)
+	Warningf("hello %s", "world")
+	Warningf("hello %s", "world")
+
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, DeepEquals, []string{
+		"WARN hello world",
+		"ERROR hello world escalated=true",
+		"WARN hello world",
+		"ERROR hello world escalated=true",
+	})
+}
+
+func (s *EscalationSuite) TestEscalateAfterOtherSeverities(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	EscalateAfter(SeverityWarning, 1, time.Minute, SeverityError)
+
+	Infof("hello %s", "world")
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world\nINFO hello world\n")
+	c.Assert(HighestSeverity() < SeverityError, Equals, true)
+}
+
+func (s *EscalationSuite) TestEscalateAfterRemove(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	EscalateAfter(SeverityWarning, 1, time.Minute, SeverityError)
+	EscalateAfter(SeverityWarning, 0, time.Minute, SeverityError)
+
+	Warningf("hello %s", "world")
+	Warningf("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "WARN hello world\nWARN hello world\n")
+}
+
+func (s *EscalationSuite) TestPrune(c *C) {
+	EscalateAfter(SeverityWarning, 1, time.Minute, SeverityError)
+	rule := escalations.rules[SeverityWarning]
+
+	for i := 0; i < maxEscalationKeys; i++ {
+		escalations.escalate(SeverityWarning, nil, strings.Repeat("x", i))
+	}
+	c.Assert(rule.occurrences, HasLen, maxEscalationKeys)
+
+	s.now = s.now.Add(2 * time.Minute)
+	escalations.escalate(SeverityWarning, nil, "hello")
+	c.Assert(rule.occurrences, HasLen, 1)
+}
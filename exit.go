@@ -0,0 +1,102 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+var (
+	// exit terminates the program after a fatal message, it is replaced in tests, see SetExitFunc.
+	exit = os.Exit
+
+	exitMu       sync.Mutex
+	exitHandlers []exitHandler
+)
+
+// exitHandler is a function run before the program exits, along with where it was registered.
+type exitHandler struct {
+	f    func()
+	site string
+}
+
+// RegisterExitHandler registers a function run before fatal messages, see Fatalf, and Exit
+// exit the program, e.g. to release locks or to report a crash. Handlers run in registration
+// order once the message has been logged, while the loggers are still open, so that they can
+// log messages of their own. A handler that panics is reported in an ERROR message and the
+// others still run.
+func RegisterExitHandler(f func()) {
+	caller := getCallerInfo(1)
+
+	exitMu.Lock()
+	defer exitMu.Unlock()
+
+	exitHandlers = append(exitHandlers, exitHandler{f, fmt.Sprintf("%s:%d", caller.FilePath, caller.LineNo)})
+}
+
+// SetExitFunc replaces the function fatal messages and Exit terminate the program with, which
+// lets tests intercept fatal paths, for example:
+//
+//	log.SetExitFunc(func(code int) { panic(code) })
+//	defer log.SetExitFunc(nil)
+//
+// A nil function restores os.Exit.
+func SetExitFunc(f func(code int)) {
+	if f == nil {
+		f = os.Exit
+	}
+	exitMu.Lock()
+	defer exitMu.Unlock()
+
+	exit = f
+}
+
+// Exit runs the exit handlers, see RegisterExitHandler, makes the loggers and the sinks of the
+// audit channel write out what they buffer and commit it to stable storage, closes them and
+// exits the program with the code.
+// Unlike os.Exit, which skips deferred calls, it does not lose the messages buffered by
+// asynchronous or batching loggers.
+func Exit(code int) {
+	exitMu.Lock()
+	handlers := exitHandlers
+	// handlers run once even if several goroutines exit at once
+	exitHandlers = nil
+	exitMu.Unlock()
+
+	for _, h := range handlers {
+		runExitHandler(h)
+	}
+	for _, l := range allLoggers() {
+		if f, ok := l.(Flusher); ok {
+			f.Flush()
+		}
+		if s, ok := l.(Syncer); ok {
+			s.Sync()
+		}
+		if c, ok := l.(io.Closer); ok {
+			c.Close()
+		}
+	}
+
+	exitMu.Lock()
+	f := exit
+	exitMu.Unlock()
+	f(code)
+}
+
+// runExitHandler runs the handler, reporting it if it panics.
+func runExitHandler(h exitHandler) {
+	defer func() {
+		if r := recover(); r != nil {
+			writeMessage(1, SeverityError, nil, "exit handler registered at %s panicked: %v", h.site, r)
+		}
+	}()
+	h.f()
+}
+
+// exitFatal exits the program with status 255 after a fatal message, see Exit, so that
+// fatal messages are not lost.
+func exitFatal() {
+	Exit(255)
+}
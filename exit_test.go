@@ -0,0 +1,74 @@
+package log
+
+import (
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type ExitSuite struct {
+	exitCodes []int
+}
+
+var _ = Suite(&ExitSuite{})
+
+func (s *ExitSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	exitHandlers = nil
+	s.exitCodes = nil
+	SetExitFunc(func(code int) { s.exitCodes = append(s.exitCodes, code) })
+	SetFatalStackMode(FatalStackNone)
+}
+
+func (s *ExitSuite) TearDownTest(c *C) {
+	SetExitFunc(nil)
+	SetFatalStackMode(FatalStackAll)
+	exitHandlers = nil
+}
+
+func (s *ExitSuite) TestExitHandlers(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	var ran []string
+	RegisterExitHandler(func() {
+		ran = append(ran, "first")
+		Infof("releasing the lock")
+	})
+	RegisterExitHandler(func() { panic("oops") })
+	RegisterExitHandler(func() { ran = append(ran, "third") })
+
+	Fatalf("cannot go on")
+	c.Assert(ran, DeepEquals, []string{"first", "third"})
+	c.Assert(s.exitCodes, DeepEquals, []int{255})
+
+	// handlers log while the loggers are still open, after the fatal message
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[0], Equals, "FATAL cannot go on")
+	c.Assert(lines[1], Equals, "INFO releasing the lock")
+	c.Assert(lines[2], Matches, "ERROR exit handler registered at .*exit_test.go:[0-9]+ panicked: oops")
+
+	// handlers run once
+	Exit(3)
+	c.Assert(ran, DeepEquals, []string{"first", "third"})
+	c.Assert(s.exitCodes, DeepEquals, []int{255, 3})
+}
+
+func (s *ExitSuite) TestExitFlushesLoggers(c *C) {
+	memory, _ := NewMemoryLogger(Config{Severity: "info"})
+	async, err := NewAsyncLogger(memory, 100, OverflowBlock)
+	c.Assert(err, IsNil)
+	Init(async)
+
+	for i := 0; i < 50; i++ {
+		Infof("message %d", i)
+	}
+	Exit(1)
+	c.Assert(memory.Messages(), HasLen, 50)
+	c.Assert(s.exitCodes, DeepEquals, []int{1})
+
+	// the loggers are closed
+	Infof("dropped")
+	c.Assert(memory.Messages(), HasLen, 50)
+}
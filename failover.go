@@ -0,0 +1,150 @@
+package log
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// failoverProbeInterval is how often a FailoverLogger that failed over tries its primary again.
+var failoverProbeInterval = 10 * time.Second
+
+// FailoverLogger is a logger sending messages to a primary logger and, once writing to it fails,
+// to a secondary one, e.g. a local file standing in for syslog. While failed over the primary is
+// tried again at most every 10 seconds, with the message being logged at the time, and messages
+// go back to it as soon as it accepts one.
+//
+// Loggers used directly through Writer and FormatMessage rather than by the package get the
+// message formatted by the active logger, see Active, and write it out as is on failover.
+type FailoverLogger struct {
+	primary   Logger
+	secondary Logger
+
+	mu       sync.Mutex
+	failed   bool      // set while messages go to the secondary logger
+	probedAt time.Time // when the primary logger was last tried while failed over
+}
+
+// NewFailoverLogger makes a logger sending messages to the secondary logger when the primary fails.
+func NewFailoverLogger(primary, secondary Logger) *FailoverLogger {
+	return &FailoverLogger{primary: primary, secondary: secondary}
+}
+
+// Active returns the logger messages currently go to.
+func (l *FailoverLogger) Active() Logger {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.failed {
+		return l.secondary
+	}
+	return l.primary
+}
+
+func (l *FailoverLogger) Writer(sev Severity) io.Writer {
+	if l.primary.Writer(sev) == nil && l.secondary.Writer(sev) == nil {
+		return nil
+	}
+	return &failoverWriter{l, sev}
+}
+
+func (l *FailoverLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.Active().FormatMessage(sev, caller, format, args...)
+}
+
+// Close closes the loggers implementing io.Closer and returns the first error encountered.
+func (l *FailoverLogger) Close() error {
+	var first error
+	for _, logger := range []Logger{l.primary, l.secondary} {
+		if c, ok := logger.(io.Closer); ok {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// send sends the message to the logger messages go to, each logger formatting it its own way.
+// Write hooks are told about the writes as writes to the top logger of the chain.
+func (l *FailoverLogger) send(top Logger, e *Entry, sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) {
+	l.route(func(logger Logger) (bool, error) {
+		w := e.writer(logger, sev)
+		if w == nil {
+			return false, nil
+		}
+		_, err := io.WriteString(w, formatLazyMessage(logger, sev, caller, t, fields, m))
+		runWriteHooks(top, sev, err)
+		return true, err
+	})
+}
+
+// route writes a message with write, to the primary logger unless it has failed and it is not
+// time to try it again, and to the secondary logger if the primary fails. Write reports whether
+// the logger logs at the message's severity along with the error writing to it.
+func (l *FailoverLogger) route(write func(Logger) (bool, error)) {
+	failed, probing := l.state()
+	if !failed || probing {
+		wrote, err := write(l.primary)
+		switch {
+		case err != nil:
+			l.fail()
+		case wrote:
+			l.failBack()
+			return
+		case !failed:
+			// the primary logger does not log at the severity
+			return
+		}
+	}
+	write(l.secondary)
+}
+
+// state tells whether the primary logger has failed and, if so, whether to try it again.
+func (l *FailoverLogger) state() (failed, probing bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.failed {
+		return false, false
+	}
+	if t := now(); t.Sub(l.probedAt) >= failoverProbeInterval {
+		l.probedAt = t
+		return true, true
+	}
+	return true, false
+}
+
+func (l *FailoverLogger) fail() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.failed {
+		l.failed, l.probedAt = true, now()
+	}
+}
+
+func (l *FailoverLogger) failBack() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.failed = false
+}
+
+// failoverWriter writes messages formatted by the callers to the writers of a FailoverLogger.
+type failoverWriter struct {
+	l   *FailoverLogger
+	sev Severity
+}
+
+func (w *failoverWriter) Write(p []byte) (int, error) {
+	w.l.route(func(logger Logger) (bool, error) {
+		lw := logger.Writer(w.sev)
+		if lw == nil {
+			return false, nil
+		}
+		_, err := lw.Write(p)
+		return true, err
+	})
+	return len(p), nil
+}
@@ -0,0 +1,122 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type FailoverSuite struct {
+	now time.Time
+}
+
+var _ = Suite(&FailoverSuite{})
+
+func (s *FailoverSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+
+	s.now = time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return s.now }))
+}
+
+func (s *FailoverSuite) TearDownTest(c *C) {
+	SetClock(nil)
+}
+
+// brokenWriter fails writes while broken is set.
+type brokenWriter struct {
+	b      bytes.Buffer
+	broken bool
+}
+
+func (w *brokenWriter) Write(p []byte) (int, error) {
+	if w.broken {
+		return 0, errors.New("broken pipe")
+	}
+	return w.b.Write(p)
+}
+
+func (w *brokenWriter) String() string {
+	return w.b.String()
+}
+
+// brokenLogger is a test logger writing to a brokenWriter.
+type brokenLogger struct {
+	*thresholdLogger
+	w *brokenWriter
+}
+
+func newBrokenLogger(sev Severity) *brokenLogger {
+	return &brokenLogger{newThresholdLogger("primary", sev), &brokenWriter{}}
+}
+
+func (l *brokenLogger) Writer(sev Severity) io.Writer {
+	if l.thresholdLogger.Writer(sev) == nil {
+		return nil
+	}
+	return l.w
+}
+
+func (s *FailoverSuite) TestFailover(c *C) {
+	primary, secondary := newBrokenLogger(SeverityInfo), newTestLogger("secondary")
+	l := NewFailoverLogger(primary, secondary)
+	Init(l)
+
+	Infof("hello %s", "primary")
+	Debugf("hello %s", "debug")
+	c.Assert(primary.w.String(), Equals, "INFO hello primary\n")
+	c.Assert(secondary.b.Len(), Equals, 0)
+
+	// the failed message and the following ones go to the secondary logger
+	primary.w.broken = true
+	Infof("hello %s", "failed")
+	primary.w.broken = false
+	Infof("hello %s", "secondary")
+	Debugf("hello %s", "debug")
+	c.Assert(l.Active(), Equals, Logger(secondary))
+	c.Assert(primary.w.String(), Equals, "INFO hello primary\n")
+	c.Assert(secondary.b.String(), Equals, "INFO hello failed\nINFO hello secondary\nDEBUG hello debug\n")
+}
+
+func (s *FailoverSuite) TestFailback(c *C) {
+	primary, secondary := newBrokenLogger(SeverityInfo), newTestLogger("secondary")
+	l := NewFailoverLogger(primary, secondary)
+	Init(l)
+
+	primary.w.broken = true
+	Infof("hello %s", "failed")
+
+	// the primary logger is tried again once the probe interval has passed
+	s.now = s.now.Add(failoverProbeInterval)
+	Infof("hello %s", "still failed")
+	c.Assert(l.Active(), Equals, Logger(secondary))
+
+	primary.w.broken = false
+	s.now = s.now.Add(failoverProbeInterval / 2)
+	Infof("hello %s", "not probed")
+	c.Assert(l.Active(), Equals, Logger(secondary))
+
+	s.now = s.now.Add(failoverProbeInterval / 2)
+	Infof("hello %s", "recovered")
+	Infof("hello %s", "primary")
+	c.Assert(l.Active(), Equals, Logger(primary))
+	c.Assert(primary.w.String(), Equals, "INFO hello recovered\nINFO hello primary\n")
+	c.Assert(secondary.b.String(), Equals, "INFO hello failed\nINFO hello still failed\nINFO hello not probed\n")
+}
+
+func (s *FailoverSuite) TestWriter(c *C) {
+	primary, secondary := newBrokenLogger(SeverityInfo), newTestLogger("secondary")
+	l := NewFailoverLogger(primary, secondary)
+	c.Assert(l.Writer(SeverityDebug), NotNil)
+
+	primary.w.broken = true
+	io.WriteString(l.Writer(SeverityInfo), "hello\n")
+	c.Assert(secondary.b.String(), Equals, "hello\n")
+	c.Assert(l.FormatMessage(SeverityInfo, &CallerInfo{}, "hello %s", "world"), Equals, "INFO hello world\n")
+
+	l = NewFailoverLogger(newBrokenLogger(SeverityError), newThresholdLogger("secondary", SeverityError))
+	c.Assert(l.Writer(SeverityInfo), IsNil)
+}
@@ -0,0 +1,379 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Fields is a set of key/value pairs attached to log messages.
+type Fields map[string]interface{}
+
+// Names of the fields attached by WithError.
+const (
+	ErrorField      = "error"
+	ErrorTypeField  = "error_type"
+	ErrorChainField = "error_chain"
+	StackField      = "stack"
+)
+
+// ErrorTyper is implemented by errors exposing their type or code, which WithError attaches
+// as the "error_type" field.
+type ErrorTyper interface {
+	ErrorType() string
+}
+
+// Entry is a logging handle that attaches its fields to every message logged through it.
+type Entry struct {
+	fields Fields
+
+	// floor is the severity below which messages are dropped.
+	floor Severity
+
+	// override is the severity down to which messages are logged regardless of the loggers'
+	// configured severities if overridden is set.
+	override   Severity
+	overridden bool
+
+	// at is the time messages are logged at, the zero time meaning now, see At.
+	at time.Time
+
+	// component is the name of the component messages are logged by, see Named.
+	component string
+
+	// err is the error attached by WithError, messages logged with one carry a stack trace.
+	err error
+
+	// callerSkip is the number of stack frames skipped when determining the caller, see
+	// WithCallerSkip.
+	callerSkip int
+}
+
+// WithFields returns an entry attaching the provided fields to messages logged through it.
+func WithFields(fields Fields) *Entry {
+	return (&Entry{}).WithFields(fields)
+}
+
+// WithMinSeverity returns an entry dropping messages below the provided severity, no matter
+// what severities the loggers are configured with.
+func WithMinSeverity(sev Severity) *Entry {
+	return (&Entry{}).WithMinSeverity(sev)
+}
+
+// WithError returns an entry attaching the error as the "error" field to messages logged
+// through it, see Entry.WithError.
+func WithError(err error) *Entry {
+	return (&Entry{}).WithError(err)
+}
+
+// WithCallerSkip returns an entry reporting the caller n stack frames up from the call to its
+// logging methods as the caller of messages, see Entry.WithCallerSkip.
+func WithCallerSkip(n int) *Entry {
+	return (&Entry{}).WithCallerSkip(n)
+}
+
+// WithFields returns a new entry carrying both the entry's fields and the provided ones,
+// the latter taking precedence.
+func (e *Entry) WithFields(fields Fields) *Entry {
+	merged := make(Fields, len(e.fields)+len(fields))
+	for k, v := range e.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	child := *e
+	child.fields = merged
+	return &child
+}
+
+// WithError returns a new entry attaching the error message as the "error" field, along with
+// the "error_type" field if the error, or one it wraps, implements ErrorTyper, and the
+// "error_chain" field listing the messages of the errors it wraps, outermost first, see
+// errors.Unwrap. Messages logged through the entry at or above the error stack severity also
+// carry the stack trace of their call site as the "stack" field, see SetErrorStackSeverity. A
+// nil error attaches nothing.
+func (e *Entry) WithError(err error) *Entry {
+	if err == nil {
+		child := *e
+		return &child
+	}
+	fields := Fields{ErrorField: err.Error()}
+	var typer ErrorTyper
+	if errors.As(err, &typer) {
+		fields[ErrorTypeField] = typer.ErrorType()
+	}
+	var chain []string
+	for cause := errors.Unwrap(err); cause != nil; cause = errors.Unwrap(cause) {
+		chain = append(chain, cause.Error())
+	}
+	if len(chain) > 0 {
+		fields[ErrorChainField] = chain
+	}
+	child := e.WithFields(fields)
+	child.err = err
+	return child
+}
+
+// WithMinSeverity returns a new entry dropping messages below the provided severity, no matter
+// what severities the loggers are configured with. It is handy to quiet down a noisy subsystem.
+// The floor is kept by children of the entry and can only be raised by them.
+func (e *Entry) WithMinSeverity(sev Severity) *Entry {
+	child := *e
+	if sev > child.floor {
+		child.floor = sev
+	}
+	return &child
+}
+
+// WithSeverityOverride returns a new entry whose messages at or above the provided severity
+// are logged by every logger in the chain, even by those configured to log only at higher
+// severities. Loggers configured with SeverityOff still do not log anything.
+//
+// It allows, for example, debug logging of a single request.
+func (e *Entry) WithSeverityOverride(sev Severity) *Entry {
+	child := *e
+	child.override, child.overridden = sev, true
+	return &child
+}
+
+// WithCallerSkip returns a new entry skipping n more stack frames when determining the caller
+// of the messages logged through it, which lets packages wrapping this one report the callers
+// of their own functions rather than themselves, for example:
+//
+//	var entry = log.WithCallerSkip(1)
+//
+//	func Infof(format string, args ...interface{}) {
+//		entry.Infof(format, args...)
+//	}
+//
+// The skips of nested wrappers add up, see also Output for wrappers of the package-level
+// functions.
+func (e *Entry) WithCallerSkip(n int) *Entry {
+	child := *e
+	if child.callerSkip += n; child.callerSkip < 0 {
+		child.callerSkip = 0
+	}
+	return &child
+}
+
+// Fields returns a copy of the fields attached by the entry.
+func (e *Entry) Fields() Fields {
+	fields := make(Fields, len(e.fields))
+	for k, v := range e.fields {
+		fields[k] = v
+	}
+	return fields
+}
+
+// Tracef logs to the TRACE log.
+func (e *Entry) Tracef(format string, args ...interface{}) {
+	writeMessage(1, SeverityTrace, e, format, args...)
+}
+
+// Debugf logs to the DEBUG log.
+func (e *Entry) Debugf(format string, args ...interface{}) {
+	writeMessage(1, SeverityDebug, e, format, args...)
+}
+
+// Infof logs to the INFO log.
+func (e *Entry) Infof(format string, args ...interface{}) {
+	writeMessage(1, SeverityInfo, e, format, args...)
+}
+
+// Warningf logs to the WARN and INFO logs.
+func (e *Entry) Warningf(format string, args ...interface{}) {
+	writeMessage(1, SeverityWarning, e, format, args...)
+}
+
+// Errorf logs to the ERROR, WARN, and INFO logs.
+func (e *Entry) Errorf(format string, args ...interface{}) {
+	writeMessage(1, SeverityError, e, format, args...)
+}
+
+// Fatalf logs to the FATAL, ERROR, WARN, and INFO logs along with stack traces
+// (see SetFatalStackMode) and exits the program with status 255.
+func (e *Entry) Fatalf(format string, args ...interface{}) {
+	format, args = withStackTraces(1, format, args)
+	writeMessage(1, SeverityFatal, e, format, args...)
+	exitFatal()
+}
+
+// Trace logs to the TRACE log. Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Trace(args ...interface{}) {
+	writeMessage(1, SeverityTrace, e, "%s", newSprintArgs(args))
+}
+
+// Debug logs to the DEBUG log. Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Debug(args ...interface{}) {
+	writeMessage(1, SeverityDebug, e, "%s", newSprintArgs(args))
+}
+
+// Info logs to the INFO log. Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Info(args ...interface{}) {
+	writeMessage(1, SeverityInfo, e, "%s", newSprintArgs(args))
+}
+
+// Warning logs to the WARN and INFO logs. Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Warning(args ...interface{}) {
+	writeMessage(1, SeverityWarning, e, "%s", newSprintArgs(args))
+}
+
+// Error logs to the ERROR, WARN, and INFO logs. Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Error(args ...interface{}) {
+	writeMessage(1, SeverityError, e, "%s", newSprintArgs(args))
+}
+
+// Fatal logs to the FATAL, ERROR, WARN, and INFO logs along with stack traces
+// (see SetFatalStackMode) and exits the program with status 255.
+// Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Fatal(args ...interface{}) {
+	format, args := withStackTraces(1, "%s", []interface{}{fmt.Sprint(args...)})
+	writeMessage(1, SeverityFatal, e, format, args...)
+	exitFatal()
+}
+
+// Panicf logs to the PANIC log, flushes the loggers, see Flush, and panics with the message.
+func (e *Entry) Panicf(format string, args ...interface{}) {
+	writeMessage(1, SeverityPanic, e, format, args...)
+	panicWith(format, args...)
+}
+
+// Panic logs to the PANIC log, flushes the loggers, see Flush, and panics with the message.
+// Arguments are handled in the manner of fmt.Sprint.
+func (e *Entry) Panic(args ...interface{}) {
+	message := fmt.Sprint(args...)
+	writeMessage(1, SeverityPanic, e, "%s", message)
+	panicWith("%s", message)
+}
+
+// getFields returns the fields of the entry, which may be nil.
+func (e *Entry) getFields() Fields {
+	if e == nil {
+		return nil
+	}
+	return e.fields
+}
+
+// getCallerSkip returns the number of stack frames the entry skips when determining the caller.
+func (e *Entry) getCallerSkip() int {
+	if e == nil {
+		return 0
+	}
+	return e.callerSkip
+}
+
+// stacked tells whether messages logged through the entry at the provided severity carry the
+// stack trace of their call site.
+func (e *Entry) stacked(sev Severity) bool {
+	return e != nil && e.err != nil && sev >= errorStackSeverity()
+}
+
+// drops tells whether messages logged through the entry at the provided severity are dropped.
+func (e *Entry) drops(sev Severity) bool {
+	return e != nil && sev < e.floor
+}
+
+// writer returns the writer the logger should write messages logged through the entry at
+// the provided severity to, taking the entry's severity override and the severity the logger
+// logs the entry's component at, see SetComponentSeverity, into account.
+func (e *Entry) writer(logger Logger, sev Severity) io.Writer {
+	w := logger.Writer(sev)
+	if e == nil {
+		return w
+	}
+	overridden := e.overridden && sev >= e.override
+	if floor, ok := componentSeverity(logger, e.component); ok && !overridden {
+		// the component's severity replaces the logger's
+		if sev < floor {
+			return nil
+		}
+		overridden = true
+	}
+	if w != nil || !overridden {
+		return w
+	}
+	// use the writer of the lowest severity the logger logs at
+	for _, s := range severities {
+		if s > sev && w == nil {
+			w = logger.Writer(s)
+		}
+	}
+	return w
+}
+
+// mergeFields returns the fields merged over the base ones. Neither argument is modified,
+// but one of them may be returned as is if the other is empty.
+func mergeFields(base, fields Fields) Fields {
+	if len(base) == 0 {
+		return fields
+	}
+	if len(fields) == 0 {
+		return base
+	}
+	merged := make(Fields, len(base)+len(fields))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return merged
+}
+
+// formatFields renders fields as space-separated key=value pairs sorted by key,
+// quoting values that would otherwise be ambiguous.
+func formatFields(fields Fields) string {
+	buf := fieldBuffers.Get().(*fieldBuffer)
+	keys := buf.keys[:0]
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	b := buf.text[:0]
+	for i, k := range keys {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = append(append(b, k...), '=')
+		b = append(b, logfmtValue(fields[k])...)
+	}
+	text := string(b)
+
+	if cap(b) <= maxPooledBuffer {
+		for i := range keys {
+			keys[i] = ""
+		}
+		buf.keys, buf.text = keys[:0], b[:0]
+		fieldBuffers.Put(buf)
+	}
+	return text
+}
+
+// fieldBuffer holds the sorted keys and the text of the fields being rendered.
+type fieldBuffer struct {
+	keys []string
+	text []byte
+}
+
+// fieldBuffers pools the buffers fields are rendered in by formatFields.
+var fieldBuffers = sync.Pool{New: func() interface{} { return new(fieldBuffer) }}
+
+// logfmtValue renders the value of a key=value pair, quoting it if it would otherwise be
+// ambiguous.
+func logfmtValue(value interface{}) string {
+	v, ok := value.(string)
+	if !ok {
+		v = fmt.Sprint(value)
+	}
+	if v == "" || strings.ContainsAny(v, " =\"\t\r\n") {
+		v = strconv.Quote(v)
+	}
+	return v
+}
@@ -0,0 +1,227 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type FieldsSuite struct {
+}
+
+var _ = Suite(&FieldsSuite{})
+
+func (s *FieldsSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *FieldsSuite) TestWithFields(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	WithFields(Fields{"user": "bob", "id": 42}).Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world id=42 user=bob\n")
+}
+
+func (s *FieldsSuite) TestWithFieldsMerge(c *C) {
+	parent := WithFields(Fields{"user": "bob", "id": 42})
+	child := parent.WithFields(Fields{"id": 43, "shard": "a"})
+
+	c.Assert(parent.Fields(), DeepEquals, Fields{"user": "bob", "id": 42})
+	c.Assert(child.Fields(), DeepEquals, Fields{"user": "bob", "id": 43, "shard": "a"})
+}
+
+func (s *FieldsSuite) TestEntryPrint(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	WithFields(Fields{"progress": "50%"}).Warning("half ", "way")
+	c.Assert(logger.b.String(), Equals, "WARN half way progress=50%\n")
+	c.Assert(logger.caller.FuncName, Matches, ".*TestEntryPrint")
+}
+
+func (s *FieldsSuite) TestWithSeverityOverride(c *C) {
+	info := newThresholdLogger("info", SeverityInfo)
+	errors := newThresholdLogger("error", SeverityError)
+	Init(info, errors)
+
+	e := WithFields(Fields{"user": "bob"}).WithSeverityOverride(SeverityDebug)
+	e.Debugf("hello %s", "world")
+	c.Assert(info.b.String(), Equals, "DEBUG hello world user=bob\n")
+	c.Assert(errors.b.String(), Equals, "DEBUG hello world user=bob\n")
+
+	// the override is kept by children but does not affect other entries
+	info.b.Reset()
+	e.WithFields(Fields{"id": 1}).Debugf("hello %s", "world")
+	WithFields(Fields{"user": "alice"}).Debugf("hello %s", "world")
+	Debugf("hello %s", "world")
+	c.Assert(info.b.String(), Equals, "DEBUG hello world id=1 user=bob\n")
+}
+
+func (s *FieldsSuite) TestWithMinSeverity(c *C) {
+	logger := newThresholdLogger("log", SeverityDebug)
+	Init(logger)
+
+	e := WithMinSeverity(SeverityWarning)
+	e.Debugf("hello %s", "debug")
+	e.Infof("hello %s", "info")
+	e.Warningf("hello %s", "warning")
+	e.Error("hello error")
+	c.Assert(logger.b.String(), Equals, "WARN hello warning\nERROR hello error\n")
+
+	// other entries are not affected
+	logger.b.Reset()
+	Debugf("hello %s", "debug")
+	WithFields(Fields{"user": "bob"}).Debugf("hello %s", "debug")
+	c.Assert(logger.b.String(), Equals, "DEBUG hello debug\nDEBUG hello debug user=bob\n")
+}
+
+func (s *FieldsSuite) TestWithMinSeverityComposition(c *C) {
+	logger := newThresholdLogger("log", SeverityDebug)
+	Init(logger)
+
+	// children keep the floor and can only raise it
+	e := WithFields(Fields{"subsystem": "storage"}).WithMinSeverity(SeverityError)
+	e.WithFields(Fields{"user": "bob"}).Warningf("hello %s", "warning")
+	e.WithMinSeverity(SeverityInfo).Infof("hello %s", "info")
+	e.WithSeverityOverride(SeverityDebug).Debugf("hello %s", "debug")
+	c.Assert(logger.b.Len(), Equals, 0)
+
+	e.WithFields(Fields{"user": "bob"}).Errorf("hello %s", "error")
+	c.Assert(logger.b.String(), Equals, "ERROR hello error subsystem=storage user=bob\n")
+}
+
+func (s *FieldsSuite) TestWithSeverityOverrideOff(c *C) {
+	off := newThresholdLogger("off", SeverityOff)
+	Init(off)
+
+	WithFields(Fields{}).WithSeverityOverride(SeverityDebug).Errorf("hello %s", "world")
+	c.Assert(off.b.Len(), Equals, 0)
+}
+
+type codedError struct {
+	code string
+}
+
+func (e *codedError) Error() string {
+	return "failed with " + e.code
+}
+
+func (e *codedError) ErrorType() string {
+	return e.code
+}
+
+func (s *FieldsSuite) TestWithError(c *C) {
+	c.Assert(WithError(nil).Fields(), DeepEquals, Fields{})
+	c.Assert(WithFields(Fields{"user": "bob"}).WithError(nil).Fields(), DeepEquals, Fields{"user": "bob"})
+
+	c.Assert(WithError(errors.New("no such file")).Fields(), DeepEquals, Fields{ErrorField: "no such file"})
+
+	err := fmt.Errorf("opening config: %w", &codedError{"ENOENT"})
+	c.Assert(WithFields(Fields{"user": "bob"}).WithError(err).Fields(), DeepEquals, Fields{
+		"user":          "bob",
+		ErrorField:      "opening config: failed with ENOENT",
+		ErrorTypeField:  "ENOENT",
+		ErrorChainField: []string{"failed with ENOENT"},
+	})
+
+	logger := newTestLogger("log")
+	Init(logger)
+	WithError(&codedError{"EPERM"}).Errorf("hello %s", "world")
+	c.Assert(logger.b.String(), Matches, `ERROR hello world error="failed with EPERM" error_type=EPERM stack=".*TestWithError.*"`+"\n")
+}
+
+func (s *FieldsSuite) TestErrorStack(c *C) {
+	defer SetErrorStackSeverity(SeverityError)
+	memory, _ := NewMemoryLogger(Config{Severity: "info"})
+	Init(memory)
+	hooked := make(chan Record, 10)
+	AddHook(HookFunc(func(r Record) error {
+		hooked <- r
+		return nil
+	}))
+	defer func() { hooks = nil }()
+
+	err := fmt.Errorf("loading: %w", fmt.Errorf("opening: %w", errors.New("no such file")))
+	ErrorE(err, "failed to start", "path", "/etc/app.yaml")
+	r := <-hooked
+	c.Assert(r.Message, Equals, "failed to start")
+	c.Assert(r.Fields["path"], Equals, "/etc/app.yaml")
+	c.Assert(r.Fields[ErrorField], Equals, "loading: opening: no such file")
+	c.Assert(r.Fields[ErrorChainField], DeepEquals, []string{"opening: no such file", "no such file"})
+
+	// the stack starts at the call site
+	lines := strings.Split(r.Fields[StackField].(string), "\n")
+	c.Assert(lines[0], Equals, "github.com/mailgun/log.(*FieldsSuite).TestErrorStack")
+	c.Assert(lines[1], Matches, "\t.*fields_test.go:[0-9]+")
+
+	// below the error stack severity, or without an error, messages carry no stack
+	WithError(err).Warningf("retrying")
+	c.Assert((<-hooked).Fields[StackField], IsNil)
+	WithFields(Fields{"user": "bob"}).Errorf("failed")
+	c.Assert((<-hooked).Fields[StackField], IsNil)
+	SetErrorStackSeverity(SeverityWarning)
+	WithFields(Fields{"user": "bob"}).WithError(err).Warningf("retrying")
+	c.Assert((<-hooked).Fields[StackField], NotNil)
+	SetErrorStackSeverity(SeverityOff)
+	WithError(err).ErrorE(err, "failed")
+	c.Assert((<-hooked).Fields[StackField], IsNil)
+}
+
+func (s *FieldsSuite) TestFormatFields(c *C) {
+	c.Assert(formatFields(Fields{}), Equals, "")
+	c.Assert(formatFields(Fields{"b": 1, "a": "x"}), Equals, "a=x b=1")
+	c.Assert(formatFields(Fields{"err": errors.New("no such file")}), Equals, `err="no such file"`)
+	c.Assert(formatFields(Fields{"empty": ""}), Equals, `empty=""`)
+}
+
+func (s *FieldsSuite) TestContext(c *C) {
+	c.Assert(FromContext(context.Background()).Fields(), DeepEquals, Fields{})
+
+	e := WithFields(Fields{"request_id": "abc"})
+	ctx := NewContext(context.Background(), e)
+	c.Assert(FromContext(ctx), Equals, e)
+}
+
+func (s *FieldsSuite) TestLogWithContext(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	ctx := ContextWithFields(context.Background(), Fields{"trace_id": "t1"})
+	ctx = ContextWithFields(ctx, Fields{"user_id": 7})
+	InfofCtx(ctx, "hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world trace_id=t1 user_id=7\n")
+	c.Assert(logger.caller.FuncName, Matches, ".*TestLogWithContext")
+
+	// contexts without an entry log without fields
+	logger.b.Reset()
+	WarningfCtx(context.Background(), "no fields")
+	c.Assert(logger.b.String(), Equals, "WARN no fields\n")
+}
+
+func (s *FieldsSuite) TestContextExtractor(c *C) {
+	defer func() { extractors = nil }()
+	logger := newTestLogger("log")
+	Init(logger)
+
+	type spanKey struct{}
+	AddContextExtractor(func(ctx context.Context) Fields {
+		if span, ok := ctx.Value(spanKey{}).(string); ok {
+			return Fields{"span_id": span}
+		}
+		return nil
+	})
+
+	// the extracted fields win over the stored ones
+	ctx := ContextWithFields(context.WithValue(context.Background(), spanKey{}, "s1"), Fields{"user_id": 7})
+	ctx = context.WithValue(ctx, spanKey{}, "s2")
+	InfofCtx(ctx, "hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world span_id=s2 user_id=7\n")
+
+	// contexts without fields to extract are left alone
+	e := WithFields(Fields{"request_id": "abc"})
+	c.Assert(FromContext(NewContext(context.Background(), e)), Equals, e)
+}
@@ -0,0 +1,216 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// fileLogger is a type of consoleLogger that appends messages to a file.
+type fileLogger struct {
+	*consoleLogger // provides Writer() and FormatMessage() through embedding
+
+	file *reopenableFile
+}
+
+// NewFileLogger makes a logger appending messages to the file at conf.Path, in the text or
+// JSON format depending on conf.Format. The file is created if it does not exist, and rotated
+// as configured by conf.MaxSize and conf.MaxAge.
+func NewFileLogger(conf Config) (Logger, error) {
+	if conf.Path == "" {
+		return nil, fmt.Errorf("file logger needs a path")
+	}
+
+	sev, err := severityFromString(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLevelStyle(conf.LevelStyle); err != nil {
+		return nil, err
+	}
+	if err := validateFormat(conf.Format); err != nil {
+		return nil, err
+	}
+	if err := conf.KeyNames.validate(); err != nil {
+		return nil, err
+	}
+	formatter, format, err := newFormatter(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	rotation, err := newRotation(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &reopenableFile{path: conf.Path, rotation: rotation}
+	if err := file.Reopen(); err != nil {
+		return nil, err
+	}
+
+	return &fileLogger{
+		consoleLogger: &consoleLogger{
+			writerLogger: &writerLogger{sev, file},
+			levelStyle:   conf.LevelStyle,
+			format:       format,
+			formatter:    formatter,
+			indent:       conf.Indent,
+			keys:         conf.KeyNames,
+			splitAt:      SeverityOff,
+		},
+		file: file,
+	}, nil
+}
+
+// Reopen reopens the file, which lets external tools rotate it.
+func (l *fileLogger) Reopen() error {
+	return l.file.Reopen()
+}
+
+// Sync commits the messages written to the file to stable storage.
+func (l *fileLogger) Sync() error {
+	return l.file.Sync()
+}
+
+// Close closes the file. It is safe to call it more than once.
+func (l *fileLogger) Close() error {
+	return l.file.Close()
+}
+
+// reopenableFile is a file opened for appending that can be reopened while it is written to,
+// and that rotates itself according to its rotation policy.
+type reopenableFile struct {
+	path     string
+	rotation rotation
+
+	mu       sync.Mutex
+	f        *os.File
+	size     int64     // size of the open file
+	openedAt time.Time // when the open file was opened, or started over after a failed rotation
+
+	// compressed is closed once the last backup has been compressed, nil if there is none
+	compressed chan struct{}
+}
+
+func (f *reopenableFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	if f.f == nil {
+		f.mu.Unlock()
+		return 0, os.ErrClosed
+	}
+
+	var rotateErr error
+	if f.rotation.due(f.size, int64(len(p)), now().Sub(f.openedAt)) {
+		if rotateErr = f.rotate(); rotateErr != nil {
+			// keep writing to the file rather than retrying with every message
+			f.size, f.openedAt = 0, now()
+		}
+	}
+	n, err := f.f.Write(p)
+	f.size += int64(n)
+	f.mu.Unlock()
+
+	if rotateErr != nil {
+		Errorf("failed to rotate %s: %v", f.path, rotateErr)
+	}
+	return n, err
+}
+
+// rotate moves the open file to the most recent backup and opens a new one in its place, it
+// must be called with mu held. The file is open afterwards even if rotating it failed. The
+// backup is compressed in the background, once the previous one has been.
+func (f *reopenableFile) rotate() error {
+	f.f.Close()
+	f.waitCompressed()
+	backup, err := f.rotation.rotate(f.path)
+	if backup != "" {
+		f.compressed = make(chan struct{})
+		go f.compress(backup, f.compressed)
+	}
+	file, size, openErr := f.open()
+	if openErr != nil {
+		// messages have to go somewhere, keep appending to the file wherever it ended up
+		file, openErr = os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0)
+	}
+	if openErr != nil {
+		f.f = nil
+		return openErr
+	}
+	f.f, f.size, f.openedAt = file, size, now()
+	return err
+}
+
+// compress compresses the backup and closes done. Failures are logged after closing done, so
+// that rotating again or closing the file need not wait for the message to be written.
+func (f *reopenableFile) compress(backup string, done chan struct{}) {
+	err := compressBackup(backup)
+	close(done)
+	if err != nil {
+		Errorf("failed to compress %s: %v", backup, err)
+	}
+}
+
+// waitCompressed waits for the last backup to be compressed.
+func (f *reopenableFile) waitCompressed() {
+	if f.compressed != nil {
+		<-f.compressed
+		f.compressed = nil
+	}
+}
+
+// open opens the file at the path for appending and returns it along with its size.
+func (f *reopenableFile) open() (*os.File, int64, error) {
+	file, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, err
+	}
+	return file, info.Size(), nil
+}
+
+// Reopen opens the file at the path, closing the one open before, if any.
+func (f *reopenableFile) Reopen() error {
+	file, size, err := f.open()
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f != nil {
+		f.f.Close()
+	}
+	f.f, f.size, f.openedAt = file, size, now()
+	return nil
+}
+
+func (f *reopenableFile) Sync() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.f == nil {
+		return os.ErrClosed
+	}
+	return f.f.Sync()
+}
+
+// Close closes the file once the last backup has been compressed.
+func (f *reopenableFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.waitCompressed()
+	if f.f == nil {
+		return nil
+	}
+	err := f.f.Close()
+	f.f = nil
+	return err
+}
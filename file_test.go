@@ -0,0 +1,204 @@
+package log
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type FileLoggerSuite struct {
+	path string
+}
+
+var _ = Suite(&FileLoggerSuite{})
+
+func (s *FileLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	s.path = filepath.Join(c.MkDir(), "app.log")
+}
+
+func (s *FileLoggerSuite) read(c *C, path string) string {
+	b, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	return string(b)
+}
+
+func (s *FileLoggerSuite) TestNewFileLogger(c *C) {
+	l, err := NewLogger(Config{Name: File, Severity: "info", Path: s.path})
+	c.Assert(err, IsNil)
+	defer l.(*fileLogger).Close()
+	Init(l)
+
+	Debugf("hello %s", "debug")
+	WithFields(Fields{"user": "bob"}).Infof("hello %s", "info")
+
+	content := s.read(c, s.path)
+	c.Assert(strings.Count(content, "\n"), Equals, 1)
+	c.Assert(content, Matches, ".* INFO .*hello info user=bob\n")
+
+	for _, conf := range []Config{
+		{Name: File, Severity: "info"},
+		{Name: File, Severity: "info", Path: s.path, Format: "xml"},
+		{Name: File, Severity: "loud", Path: s.path},
+		{Name: File, Severity: "info", Path: filepath.Join(s.path, "nested")},
+	} {
+		_, err := NewLogger(conf)
+		c.Assert(err, NotNil, Commentf("%+v", conf))
+	}
+}
+
+func (s *FileLoggerSuite) TestReopen(c *C) {
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: s.path, Format: FormatJSON})
+	c.Assert(err, IsNil)
+	defer l.(*fileLogger).Close()
+	Init(l)
+
+	Infof("before %s", "rotation")
+	c.Assert(os.Rename(s.path, s.path+".1"), IsNil)
+	Infof("during %s", "rotation")
+	c.Assert(Reopen(), IsNil)
+	Infof("after %s", "rotation")
+
+	rotated := s.read(c, s.path+".1")
+	c.Assert(strings.Contains(rotated, `"message":"before rotation"`), Equals, true)
+	c.Assert(strings.Contains(rotated, `"message":"during rotation"`), Equals, true)
+	c.Assert(s.read(c, s.path), Matches, `\{.*"message":"after rotation".*\}`+"\n")
+}
+
+func (s *FileLoggerSuite) TestClose(c *C) {
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: s.path})
+	c.Assert(err, IsNil)
+
+	closer := l.(*fileLogger)
+	c.Assert(closer.Close(), IsNil)
+	c.Assert(closer.Close(), IsNil)
+	_, err = l.Writer(SeverityInfo).Write([]byte("hello"))
+	c.Assert(err, NotNil)
+}
+
+func (s *FileLoggerSuite) TestFatal(c *C) {
+	defer func() { exit = os.Exit }()
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+	defer SetFatalStackMode(FatalStackAll)
+	SetFatalStackMode(FatalStackNone)
+
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: s.path})
+	c.Assert(err, IsNil)
+	Init(l)
+
+	Infof("hello %s", "world")
+	Fatalf("hello %s", "fatal")
+	c.Assert(exitCode, Equals, 255)
+
+	// the file is synced and closed before exiting
+	c.Assert(s.read(c, s.path), Matches, ".* INFO .*hello world\n.* FATAL .*hello fatal\n")
+	c.Assert(l.(*fileLogger).Sync(), Equals, os.ErrClosed)
+}
+
+func (s *FileLoggerSuite) TestRotateSize(c *C) {
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: s.path, Format: FormatJSON, MaxSize: 500, MaxBackups: 2})
+	c.Assert(err, IsNil)
+	defer l.(*fileLogger).Close()
+	Init(l)
+
+	for i := 10; i < 30; i++ {
+		Infof("message %d", i)
+	}
+
+	// every file holds whole messages within the limit, the oldest backups being removed
+	var messages []string
+	for _, path := range []string{s.path + ".2", s.path + ".1", s.path} {
+		content := s.read(c, path)
+		c.Assert(len(content) <= 500, Equals, true, Commentf("%s: %d bytes", path, len(content)))
+		for _, line := range strings.SplitAfter(content, "\n") {
+			if line != "" {
+				c.Assert(line, Matches, `\{.*"message":"message [0-9]+".*\}`+"\n")
+				messages = append(messages, line[strings.Index(line, `"message":"`)+19:][:2])
+			}
+		}
+	}
+	_, err = os.Stat(s.path + ".3")
+	c.Assert(os.IsNotExist(err), Equals, true)
+	c.Assert(len(messages) > 3, Equals, true)
+	for i, m := range messages {
+		c.Assert(m, Equals, strconv.Itoa(30-len(messages)+i))
+	}
+
+	for _, conf := range []Config{
+		{Name: File, Severity: "info", Path: s.path, MaxSize: -1},
+		{Name: File, Severity: "info", Path: s.path, MaxBackups: -1},
+	} {
+		_, err := NewLogger(conf)
+		c.Assert(err, NotNil, Commentf("%+v", conf))
+	}
+}
+
+func (s *FileLoggerSuite) TestRotateCompressed(c *C) {
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: s.path, MaxSize: 200, MaxBackups: 2, Compress: true})
+	c.Assert(err, IsNil)
+	Init(l)
+
+	for i := 10; i < 30; i++ {
+		Infof("message %d", i)
+	}
+	c.Assert(l.(*fileLogger).Close(), IsNil)
+
+	// every rotation waits for the previous backup to be compressed before shifting it
+	var backups []string
+	for _, path := range []string{s.path + ".2.gz", s.path + ".1.gz"} {
+		f, err := os.Open(path)
+		c.Assert(err, IsNil)
+		gz, err := gzip.NewReader(f)
+		c.Assert(err, IsNil)
+		backup, err := ioutil.ReadAll(gz)
+		f.Close()
+		c.Assert(err, IsNil)
+		backups = append(backups, string(backup))
+	}
+	c.Assert(backups[0], Matches, "(.* INFO .*message [0-9]+\n)+")
+	c.Assert(backups[1], Matches, "(.* INFO .*message [0-9]+\n)+")
+	c.Assert(s.read(c, s.path), Matches, "(.* INFO .*message [0-9]+\n)*.* INFO .*message 29\n")
+	for _, path := range []string{s.path + ".1", s.path + ".2", s.path + ".3.gz"} {
+		_, err = os.Stat(path)
+		c.Assert(os.IsNotExist(err), Equals, true, Commentf(path))
+	}
+}
+
+func (s *FileLoggerSuite) TestRotateAge(c *C) {
+	t := time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return t }))
+	defer SetClock(nil)
+
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: s.path, MaxAge: time.Hour, Compress: true})
+	c.Assert(err, IsNil)
+	defer l.(*fileLogger).Close()
+	Init(l)
+
+	Infof("first")
+	t = t.Add(59 * time.Minute)
+	Infof("second")
+	t = t.Add(time.Minute)
+	Infof("third")
+
+	c.Assert(s.read(c, s.path), Matches, ".* INFO .*third\n")
+
+	// the backup is compressed in the background, closing the logger waits for it
+	c.Assert(l.(*fileLogger).Close(), IsNil)
+	f, err := os.Open(s.path + ".1.gz")
+	c.Assert(err, IsNil)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	c.Assert(err, IsNil)
+	backup, err := ioutil.ReadAll(gz)
+	c.Assert(err, IsNil)
+	c.Assert(string(backup), Matches, ".* INFO .*first\n.* INFO .*second\n")
+	_, err = os.Stat(s.path + ".1")
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
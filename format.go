@@ -0,0 +1,212 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Message formats, see Config.Format.
+const (
+	FormatText     = "text"
+	FormatJSON     = "json"
+	FormatPretty   = "pretty"
+	FormatLogfmt   = "logfmt"
+	FormatTemplate = "template"
+)
+
+func validateFormat(format string) error {
+	switch format {
+	case "", FormatText, FormatJSON, FormatPretty, FormatLogfmt, FormatTemplate:
+		return nil
+	}
+	return fmt.Errorf("unsupported format: %s", format)
+}
+
+// Caller styles of JSON and logfmt messages, see KeyNames.CallerStyle.
+const (
+	CallerSplit    = "split"
+	CallerCombined = "combined"
+)
+
+// KeyNames are the keys of the standard parts of JSON messages, see Config.KeyNames, which
+// lets messages match what an ingestion pipeline expects, e.g. "@timestamp" and "level".
+// Empty names take the defaults: "timestamp", "severity", "message", "file", "func", "line"
+// and "caller".
+type KeyNames struct {
+	Timestamp string
+	Severity  string
+	Message   string
+
+	// File, Func and Line are the keys of the caller.
+	File string
+	Func string
+	Line string
+
+	// Caller is the key of the caller rendered as file:line in the CallerCombined style.
+	Caller string
+
+	// CallerStyle is how the caller is rendered: CallerSplit (default) renders its file,
+	// function and line under their own keys, which JSON consumers can query separately, and
+	// CallerCombined renders it as file:line under the Caller key.
+	CallerStyle string
+}
+
+// withDefaults returns the key names with the empty ones set to the defaults.
+func (k KeyNames) withDefaults() KeyNames {
+	for _, key := range []struct {
+		name *string
+		def  string
+	}{
+		{&k.Timestamp, "timestamp"},
+		{&k.Severity, "severity"},
+		{&k.Message, "message"},
+		{&k.File, "file"},
+		{&k.Func, "func"},
+		{&k.Line, "line"},
+		{&k.Caller, "caller"},
+		{&k.CallerStyle, CallerSplit},
+	} {
+		if *key.name == "" {
+			*key.name = key.def
+		}
+	}
+	return k
+}
+
+// validate makes sure the caller style is supported and no two standard parts of a message
+// would share a key.
+func (k KeyNames) validate() error {
+	k = k.withDefaults()
+	if k.CallerStyle != CallerSplit && k.CallerStyle != CallerCombined {
+		return fmt.Errorf("unsupported caller style: %s", k.CallerStyle)
+	}
+	seen := make(map[string]bool, 6)
+	for _, part := range k.standardParts(Record{}) {
+		if seen[part.key] {
+			return fmt.Errorf("duplicate key name: %s", part.key)
+		}
+		seen[part.key] = true
+	}
+	return nil
+}
+
+// standardPart is a standard part of a message under its key.
+type standardPart struct {
+	key   string
+	value interface{}
+}
+
+// standardParts returns the standard parts of the message in the order they lead JSON and
+// logfmt messages: timestamp, severity, message and caller, in the style of the key names,
+// which have their defaults set.
+func (k KeyNames) standardParts(r Record) []standardPart {
+	parts := []standardPart{
+		{k.Timestamp, r.Time.UTC().Format(time.RFC3339Nano)},
+		{k.Severity, r.Severity.String()},
+		{k.Message, r.Message},
+	}
+	if k.CallerStyle == CallerCombined {
+		return append(parts, standardPart{k.Caller, r.File + ":" + strconv.Itoa(r.Line)})
+	}
+	return append(parts, standardPart{k.File, r.File}, standardPart{k.Func, r.Func}, standardPart{k.Line, r.Line})
+}
+
+// formatJSON renders the message as a JSON object with the fields as additional keys,
+// which give way to the keys of the message itself on collisions. The keys of the message
+// lead in a fixed order, timestamp, severity, message and caller, so that lines are easy to
+// scan, followed by the fields sorted by key.
+func formatJSON(r Record, keys KeyNames) string {
+	standard := keys.withDefaults().standardParts(r)
+
+	var b bytes.Buffer
+	b.WriteByte('{')
+	reserved := make(map[string]bool, len(standard))
+	for _, part := range standard {
+		reserved[part.key] = true
+		if !writeJSONPair(&b, part.key, part.value) {
+			writeJSONPair(&b, part.key, fmt.Sprint(part.value))
+		}
+	}
+
+	names := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		if !reserved[k] {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if !writeJSONPair(&b, k, jsonValue(r.Fields[k])) {
+			// keep the message, rendering the value fmt's way
+			writeJSONPair(&b, k, fmt.Sprint(r.Fields[k]))
+		}
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// writeJSONPair appends the key and the encoded value to the object being written,
+// it returns false if the value cannot be encoded.
+func writeJSONPair(b *bytes.Buffer, key string, value interface{}) bool {
+	k, err := json.Marshal(key)
+	if err != nil {
+		return false
+	}
+	v, err := json.Marshal(value)
+	if err != nil {
+		return false
+	}
+	if b.Len() > 1 {
+		b.WriteByte(',')
+	}
+	b.Write(k)
+	b.WriteByte(':')
+	b.Write(v)
+	return true
+}
+
+// jsonValue returns the field value in a form encoding/json renders meaningfully: errors
+// as their messages and values it cannot encode, such as channels, as fmt renders them.
+func jsonValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case error:
+		return v.Error()
+	case string, json.Marshaler:
+		return v
+	}
+	if isScalar(v) {
+		return v
+	}
+	if _, err := json.Marshal(v); err != nil {
+		return fmt.Sprint(v)
+	}
+	return v
+}
+
+// severityColors are the ANSI colors of severities in colored messages: faint, gray, green,
+// yellow, red, magenta and bright magenta.
+var severityColors = []int{2, 90, 32, 33, 31, 35, 95}
+
+// levelWidth returns the width of the widest severity name in the level style.
+func levelWidth(style string) int {
+	width := 0
+	for _, sev := range severities {
+		if n := len(sev.format(style)); n > width {
+			width = n
+		}
+	}
+	return width
+}
+
+// colorize wraps the text into the ANSI escape codes of the severity's color.
+func colorize(sev Severity, text string) string {
+	i := sev.index()
+	if i < 0 {
+		return text
+	}
+	return fmt.Sprintf("\x1b[%dm%s\x1b[0m", severityColors[i], text)
+}
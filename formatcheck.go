@@ -0,0 +1,106 @@
+package log
+
+import (
+	"fmt"
+	"strings"
+)
+
+// skipFormatCheck is non-zero when format strings are not checked against their arguments.
+var skipFormatCheck int32
+
+// SetFormatCheck turns on or off checking that the verbs of a format string match the
+// arguments passed along with it. A mismatched call is still logged the way fmt renders it,
+// e.g. with %!s(MISSING) in place of a missing argument, followed by a WARN message
+// pointing at the malformed call site. Checking is on by default.
+func SetFormatCheck(check bool) {
+	setFlag(&skipFormatCheck, !check)
+}
+
+// formatProblem returns a description of how the format string of the message does not match
+// the arguments, or an empty string if it does or if checking is turned off.
+func formatProblem(m *lazyMessage) string {
+	if flagSet(&skipFormatCheck) {
+		return ""
+	}
+
+	format, args := m.format, m.args
+	var buf [16]byte
+	verbs, ok := argVerbs(buf[:0], format)
+	if !ok {
+		// explicit argument indexes, leave it to fmt
+		return ""
+	}
+	switch {
+	case len(verbs) > len(args):
+		return fmt.Sprintf("%d arguments for %d verbs, missing arguments", len(args), len(verbs))
+	case len(verbs) < len(args):
+		return fmt.Sprintf("%d arguments for %d verbs, extra arguments", len(args), len(verbs))
+	}
+
+	// the counts match, fmt reports arguments of the wrong type with %!verb(type=value)
+	// and a trailing % with %!(NOVERB)
+	if !strings.Contains(format, "%") {
+		return ""
+	}
+	if format == "%s" {
+		if _, ok := args[0].(string); ok {
+			// the common case of Info() and friends
+			return ""
+		}
+	}
+	message := m.text()
+	if !strings.Contains(message, "%!") || strings.Contains(fmt.Sprint(args...), "%!") {
+		return ""
+	}
+	if strings.HasSuffix(message, "%!(NOVERB)") {
+		return "missing verb at the end of the format"
+	}
+	return "arguments of the wrong type for their verbs"
+}
+
+// argVerbs returns the verb of every argument the format string consumes, '*' for the
+// arguments of * widths and precisions. Only the first byte of non-ASCII verbs is returned.
+// The verbs are appended to dst. It returns false if the format string uses explicit argument
+// indexes.
+func argVerbs(dst []byte, format string) ([]byte, bool) {
+	verbs := dst
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' {
+			continue
+		}
+		i++
+
+		// flags
+		for i < len(format) && strings.IndexByte("+-# 0", format[i]) >= 0 {
+			i++
+		}
+		// width and precision
+		for _, part := range []string{"width", "precision"} {
+			if part == "precision" {
+				if i >= len(format) || format[i] != '.' {
+					break
+				}
+				i++
+			}
+			if i < len(format) && format[i] == '[' {
+				return nil, false
+			}
+			if i < len(format) && format[i] == '*' {
+				verbs = append(verbs, '*')
+				i++
+				continue
+			}
+			for i < len(format) && format[i] >= '0' && format[i] <= '9' {
+				i++
+			}
+		}
+		if i < len(format) && format[i] == '[' {
+			return nil, false
+		}
+
+		if i < len(format) && format[i] != '%' {
+			verbs = append(verbs, format[i])
+		}
+	}
+	return verbs, true
+}
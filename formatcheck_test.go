@@ -0,0 +1,69 @@
+package log
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1"
+)
+
+type FormatCheckSuite struct {
+}
+
+var _ = Suite(&FormatCheckSuite{})
+
+func (s *FormatCheckSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	SetFormatCheck(true)
+}
+
+func (s *FormatCheckSuite) TestFormatProblem(c *C) {
+	cases := []struct {
+		format  string
+		args    []interface{}
+		problem string
+	}{
+		{"hello", nil, ""},
+		{"hello %s", []interface{}{"world"}, ""},
+		{"100%% done %d", []interface{}{1}, ""},
+		{"%-8.3f|%*d|%.*s|%+v", []interface{}{1.0, 3, 1, 2, "ab", struct{}{}}, ""},
+		{"%[2]s %[1]s", []interface{}{"a"}, ""},
+		{"héllo %s", []interface{}{"wörld"}, ""},
+		{"%s", []interface{}{"%!s(MISSING)"}, ""},
+		{"hello %s %s", []interface{}{"world"}, "1 arguments for 2 verbs, missing arguments"},
+		{"hello", []interface{}{"world"}, "1 arguments for 0 verbs, extra arguments"},
+		{"hello %d", []interface{}{"world"}, "arguments of the wrong type for their verbs"},
+		{"100%", nil, "missing verb at the end of the format"},
+	}
+	for _, t := range cases {
+		c.Assert(formatProblem(&lazyMessage{format: t.format, args: t.args}), Equals, t.problem, Commentf(t.format))
+	}
+
+	SetFormatCheck(false)
+	c.Assert(formatProblem(&lazyMessage{format: "hello %s %s"}), Equals, "")
+}
+
+func (s *FormatCheckSuite) TestMalformedCallWarning(c *C) {
+	tl := newTestLogger("test")
+	Init(tl)
+
+	format := "hello %s, you are %d"
+	Infof(format, "world")
+
+	lines := bytes.Split(bytes.TrimSpace(tl.b.Bytes()), []byte("\n"))
+	c.Assert(lines, HasLen, 2)
+	c.Assert(string(lines[0]), Equals, "INFO hello world, you are %!d(MISSING)")
+	c.Assert(string(lines[1]), Matches, "WARN malformed log call at .*formatcheck_test.go:[0-9]+: 1 arguments for 2 verbs, missing arguments")
+	c.Assert(tl.caller.FileName, Equals, "formatcheck_test.go")
+}
+
+func (s *FormatCheckSuite) TestFormatCheckOff(c *C) {
+	SetFormatCheck(false)
+	defer SetFormatCheck(true)
+	tl := newTestLogger("test")
+	Init(tl)
+
+	format, args := "hello %s", []interface{}{}
+	Infof(format, args...)
+
+	c.Assert(tl.b.String(), Equals, "INFO hello %!s(MISSING)\n")
+}
@@ -0,0 +1,190 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Timestamp layouts of template formatters, see NewTemplateFormatter. Any other layout is a
+// time.Format layout.
+const (
+	TimeLayoutRFC3339     = "rfc3339"
+	TimeLayoutRFC3339Nano = "rfc3339nano"
+	TimeLayoutUnix        = "unix"
+	TimeLayoutUnixMilli   = "unixmilli"
+	TimeLayoutUnixNano    = "unixnano"
+)
+
+// Formatter formats the records of messages into the text the console and file loggers write,
+// without the line ending, which lets messages match a log schema without writing a whole
+// Logger, see Config.Formatter.
+type Formatter interface {
+	Format(Record) string
+}
+
+// FormatterFunc is an adapter allowing the use of ordinary functions as formatters.
+type FormatterFunc func(Record) string
+
+func (f FormatterFunc) Format(r Record) string {
+	return f(r)
+}
+
+// TextFormatter formats messages like the console logger's text format. LevelStyle is the
+// style of severity names, see Config.LevelStyle, and Color colors them.
+type TextFormatter struct {
+	LevelStyle string
+	Color      bool
+}
+
+func (f TextFormatter) Format(r Record) string {
+	level := r.Severity.format(f.LevelStyle)
+	if f.Color {
+		level = colorize(r.Severity, level)
+	}
+	return formatText(r, level, messageWithFields(r), "")
+}
+
+// JSONFormatter formats messages like the console logger's JSON format, with the standard keys
+// renamed by Keys.
+type JSONFormatter struct {
+	Keys KeyNames
+}
+
+func (f JSONFormatter) Format(r Record) string {
+	return formatJSON(r, f.Keys)
+}
+
+// LogfmtFormatter formats messages as logfmt key=value pairs, the standard ones named by Keys
+// leading in the order of JSON messages and the fields following sorted by key:
+//
+//	timestamp=2014-03-05T07:09:11.5Z severity=WARN message="hello world" file=main.go func=main.main line=42 user=bob
+type LogfmtFormatter struct {
+	Keys KeyNames
+}
+
+func (f LogfmtFormatter) Format(r Record) string {
+	standard := f.Keys.withDefaults().standardParts(r)
+
+	pairs := make([]string, 0, len(standard)+len(r.Fields))
+	reserved := make(map[string]bool, len(standard))
+	for _, part := range standard {
+		reserved[part.key] = true
+		pairs = append(pairs, part.key+"="+logfmtValue(part.value))
+	}
+	names := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		if !reserved[k] {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		pairs = append(pairs, k+"="+logfmtValue(r.Fields[k]))
+	}
+	return strings.Join(pairs, " ")
+}
+
+// TemplateData is what the templates of template formatters are executed with: the record of
+// the message along with its time in the formatter's layout and the program it is logged by.
+type TemplateData struct {
+	Record
+
+	// Timestamp is the time of the message in the formatter's layout.
+	Timestamp string
+
+	App  string
+	Host string
+	PID  int
+}
+
+// templateFuncs are the functions templates can use besides the predefined ones: fields
+// renders fields as key=value pairs and json encodes a value as JSON.
+var templateFuncs = template.FuncMap{
+	"fields": formatFields,
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(jsonValue(v))
+		return string(b), err
+	},
+}
+
+// templateFormatter formats messages with a text/template.
+type templateFormatter struct {
+	tmpl   *template.Template
+	layout string
+}
+
+// NewTemplateFormatter returns a formatter executing the text/template with the TemplateData
+// of messages, their timestamps in the layout: TimeLayoutRFC3339 (default),
+// TimeLayoutRFC3339Nano, TimeLayoutUnix, TimeLayoutUnixMilli, TimeLayoutUnixNano or a
+// time.Format layout, always in UTC. For example:
+//
+//	{{.Timestamp}} {{.Severity}} {{.File}}:{{.Line}} {{.Message}} {{fields .Fields}}
+//
+// Fields can be looked up by key, e.g. {{.Fields.user}}, rendered as key=value pairs with
+// fields, and values encoded as JSON with json. Templates that fail on a message render the
+// message as the text format does, followed by the error.
+func NewTemplateFormatter(text, layout string) (Formatter, error) {
+	tmpl, err := template.New("log").Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	if layout == "" {
+		layout = TimeLayoutRFC3339
+	}
+	return &templateFormatter{tmpl: tmpl, layout: layout}, nil
+}
+
+func (f *templateFormatter) Format(r Record) string {
+	var b bytes.Buffer
+	if err := f.tmpl.Execute(&b, TemplateData{Record: r, Timestamp: formatTimestamp(r.Time, f.layout), App: appname, Host: hostname, PID: pid}); err != nil {
+		return TextFormatter{}.Format(r) + " " + fmt.Sprintf("(template failed: %v)", err)
+	}
+	return b.String()
+}
+
+// formatTimestamp renders the time in UTC in the layout of a template formatter.
+func formatTimestamp(t time.Time, layout string) string {
+	t = t.UTC()
+	switch layout {
+	case TimeLayoutRFC3339:
+		return t.Format(time.RFC3339)
+	case TimeLayoutRFC3339Nano:
+		return t.Format(time.RFC3339Nano)
+	case TimeLayoutUnix:
+		return strconv.FormatInt(t.Unix(), 10)
+	case TimeLayoutUnixMilli:
+		return strconv.FormatInt(t.UnixNano()/int64(time.Millisecond), 10)
+	case TimeLayoutUnixNano:
+		return strconv.FormatInt(t.UnixNano(), 10)
+	}
+	return t.Format(layout)
+}
+
+// newFormatter returns the formatter of the messages of the configured console or file logger,
+// nil for the formats the logger renders itself, along with the name of the format.
+func newFormatter(conf Config) (Formatter, string, error) {
+	switch {
+	case conf.Formatter != nil:
+		return conf.Formatter, "custom", nil
+	case conf.Template != "":
+		if conf.Format != "" && conf.Format != FormatTemplate {
+			return nil, "", fmt.Errorf("templates cannot be used with the %s format", conf.Format)
+		}
+		f, err := NewTemplateFormatter(conf.Template, conf.TimeLayout)
+		if err != nil {
+			return nil, "", fmt.Errorf("bad template: %v", err)
+		}
+		return f, FormatTemplate, nil
+	case conf.Format == FormatTemplate:
+		return nil, "", fmt.Errorf("the template format needs a template")
+	case conf.Format == FormatLogfmt:
+		return LogfmtFormatter{Keys: conf.KeyNames}, FormatLogfmt, nil
+	}
+	return nil, conf.Format, nil
+}
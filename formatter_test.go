@@ -0,0 +1,142 @@
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type FormatterSuite struct {
+}
+
+var _ = Suite(&FormatterSuite{})
+
+func (s *FormatterSuite) SetUpTest(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 500000000, time.UTC) }))
+}
+
+func (s *FormatterSuite) TearDownTest(c *C) {
+	SetClock(nil)
+}
+
+func (s *FormatterSuite) record(fields Fields) Record {
+	return NewRecord(SeverityWarning, &CallerInfo{"main.go", "/src/main.go", "main.main", 42}, fields, "hello %s", "world")
+}
+
+func (s *FormatterSuite) TestLogfmt(c *C) {
+	r := s.record(Fields{"user": "bob smith", "attempt": 2, "line": "ignored"})
+	c.Assert(LogfmtFormatter{}.Format(r), Equals,
+		`timestamp=2014-03-05T07:09:11.5Z severity=WARN message="hello world" file=main.go func=main.main line=42 attempt=2 user="bob smith"`)
+	c.Assert(LogfmtFormatter{Keys: KeyNames{Timestamp: "ts", Severity: "level", Message: "msg"}}.Format(s.record(nil)), Equals,
+		`ts=2014-03-05T07:09:11.5Z level=WARN msg="hello world" file=main.go func=main.main line=42`)
+}
+
+func (s *FormatterSuite) TestCallerStyle(c *C) {
+	r := s.record(Fields{"caller": "ignored"})
+
+	// the caller is split by default, its line a number
+	c.Assert(JSONFormatter{}.Format(r), Equals,
+		`{"timestamp":"2014-03-05T07:09:11.5Z","severity":"WARN","message":"hello world","file":"main.go","func":"main.main","line":42,"caller":"ignored"}`)
+	c.Assert(LogfmtFormatter{}.Format(r), Equals,
+		`timestamp=2014-03-05T07:09:11.5Z severity=WARN message="hello world" file=main.go func=main.main line=42 caller=ignored`)
+
+	combined := KeyNames{CallerStyle: CallerCombined}
+	c.Assert(JSONFormatter{Keys: combined}.Format(r), Equals,
+		`{"timestamp":"2014-03-05T07:09:11.5Z","severity":"WARN","message":"hello world","caller":"main.go:42"}`)
+	c.Assert(LogfmtFormatter{Keys: combined}.Format(r), Equals,
+		`timestamp=2014-03-05T07:09:11.5Z severity=WARN message="hello world" caller=main.go:42`)
+	c.Assert(LogfmtFormatter{Keys: KeyNames{Caller: "src", CallerStyle: CallerCombined}}.Format(s.record(nil)), Equals,
+		`timestamp=2014-03-05T07:09:11.5Z severity=WARN message="hello world" src=main.go:42`)
+
+	_, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON, KeyNames: KeyNames{CallerStyle: "nested"}})
+	c.Assert(err, ErrorMatches, "unsupported caller style: nested")
+	_, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON, KeyNames: KeyNames{Caller: "message", CallerStyle: CallerCombined}})
+	c.Assert(err, ErrorMatches, "duplicate key name: message")
+}
+
+func (s *FormatterSuite) TestCallerTypes(c *C) {
+	decode := func(keys KeyNames) map[string]interface{} {
+		var m map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(JSONFormatter{Keys: keys}.Format(s.record(nil))), &m), IsNil)
+		return m
+	}
+
+	// split callers decode as two strings and a number
+	m := decode(KeyNames{})
+	c.Assert(m["file"], Equals, "main.go")
+	c.Assert(m["func"], Equals, "main.main")
+	c.Assert(m["line"], Equals, float64(42))
+	c.Assert(m["caller"], IsNil)
+
+	// combined ones as a single string
+	m = decode(KeyNames{CallerStyle: CallerCombined})
+	c.Assert(m["caller"], Equals, "main.go:42")
+	for _, key := range []string{"file", "func", "line"} {
+		c.Assert(m[key], IsNil, Commentf(key))
+	}
+}
+
+func (s *FormatterSuite) TestBuiltin(c *C) {
+	r := s.record(Fields{"user": "bob"})
+	c.Assert(JSONFormatter{}.Format(r), Equals, formatJSON(r, KeyNames{}))
+	c.Assert(TextFormatter{LevelStyle: LevelStyleShort3}.Format(r), Matches, `Mar  5 07:09:11.500 .* WRN PID:[0-9]+ \[main.go:42:main.main\] hello world user=bob`)
+	c.Assert(FormatterFunc(func(r Record) string { return r.Message }).Format(r), Equals, "hello world")
+}
+
+func (s *FormatterSuite) TestTemplate(c *C) {
+	r := s.record(Fields{"user": "bob", "ids": []int{1, 2}})
+	for layout, timestamp := range map[string]string{
+		"":                    "2014-03-05T07:09:11Z",
+		TimeLayoutRFC3339Nano: "2014-03-05T07:09:11.5Z",
+		TimeLayoutUnix:        "1394003351",
+		TimeLayoutUnixMilli:   "1394003351500",
+		TimeLayoutUnixNano:    "1394003351500000000",
+		"2006/01/02 15:04":    "2014/03/05 07:09",
+	} {
+		f, err := NewTemplateFormatter("{{.Timestamp}} {{.Severity}} {{.File}}:{{.Line}} {{.Message}} {{fields .Fields}}", layout)
+		c.Assert(err, IsNil)
+		c.Assert(f.Format(r), Equals, timestamp+" WARN main.go:42 hello world ids=\"[1 2]\" user=bob", Commentf(layout))
+	}
+
+	f, err := NewTemplateFormatter(`{"user":{{json .Fields.user}},"ids":{{json .Fields.ids}},"app":{{json .App}},"pid":{{.PID}}}`, "")
+	c.Assert(err, IsNil)
+	c.Assert(f.Format(r), Equals, fmt.Sprintf(`{"user":"bob","ids":[1,2],"app":%q,"pid":%d}`, appname, pid))
+
+	// templates failing on a message do not lose it
+	f, err = NewTemplateFormatter("{{.Message.Missing}}", "")
+	c.Assert(err, IsNil)
+	c.Assert(f.Format(r), Matches, `.*hello world ids="\[1 2\]" user=bob \(template failed: .*\)`)
+
+	_, err = NewTemplateFormatter("{{.Message", "")
+	c.Assert(err, NotNil)
+}
+
+func (s *FormatterSuite) TestConfig(c *C) {
+	caller := &CallerInfo{"main.go", "/src/main.go", "main.main", 42}
+
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Template: "{{.Severity}} {{.Message}}"})
+	c.Assert(err, IsNil)
+	c.Assert(l.FormatMessage(SeverityInfo, caller, "hello"), Equals, "INFO hello\n")
+	c.Assert(describe(l).Format, Equals, FormatTemplate)
+
+	l, err = NewFileLogger(Config{Name: File, Severity: "info", Path: filepath.Join(c.MkDir(), "app.log"), Format: FormatLogfmt})
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(l.FormatMessage(SeverityInfo, caller, "hello"), "timestamp=2014-03-05T07:09:11.5Z severity=INFO message=hello"), Equals, true)
+	c.Assert(describe(l).Format, Equals, FormatLogfmt)
+
+	// a formatter overrides the format
+	l, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON, Formatter: FormatterFunc(func(r Record) string { return "custom " + r.Message })})
+	c.Assert(err, IsNil)
+	c.Assert(l.FormatMessage(SeverityInfo, caller, "hello"), Equals, "custom hello\n")
+
+	_, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatTemplate})
+	c.Assert(err, ErrorMatches, "the template format needs a template")
+	_, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON, Template: "{{.Message}}"})
+	c.Assert(err, ErrorMatches, "templates cannot be used with the json format")
+	_, err = NewConsoleLogger(Config{Name: Console, Severity: "info", Template: "{{.Message"})
+	c.Assert(err, ErrorMatches, "bad template: .*")
+}
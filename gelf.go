@@ -0,0 +1,232 @@
+package log
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// formatGELF encodes the record as a GELF 1.1 message at the level, the fields becoming
+// additional fields.
+func formatGELF(r Record, level int) []byte {
+	m := map[string]interface{}{
+		"version":       "1.1",
+		"host":          hostname,
+		"short_message": r.Message,
+		"timestamp":     float64(r.Time.UnixNano()) / 1000000000,
+		"level":         level,
+		"_app":          appname,
+		"_file":         r.File,
+		"_line":         r.Line,
+		"_func":         r.Func,
+	}
+	for k, v := range r.Fields {
+		if name := gelfFieldName(k); m[name] == nil {
+			m[name] = jsonValue(v)
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil
+	}
+	return b
+}
+
+// gelfFieldName returns the name of the additional field carrying the field: the name of the
+// field prefixed with an underscore, with the characters GELF does not allow replaced with
+// underscores. The reserved "_id" becomes "__id".
+func gelfFieldName(name string) string {
+	name = "_" + strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, name)
+	if name == "_id" {
+		return "__id"
+	}
+	return name
+}
+
+// DefaultGELFPort is the port Graylog's GELF inputs listen on by default.
+const DefaultGELFPort = 12201
+
+const (
+	// gelfChunkSize is the default size of the chunks GELF messages are split in over UDP.
+	gelfChunkSize = 8192
+
+	// gelfMaxChunks is the largest number of chunks a GELF message can be split in.
+	gelfMaxChunks = 128
+
+	// gelfChunkHeaderSize is the size of the header of a chunk: the magic bytes, the message
+	// ID, the sequence number and the sequence count.
+	gelfChunkHeaderSize = 12
+)
+
+// gelfLogger is a type of writerLogger that sends GELF messages to Graylog.
+type gelfLogger struct {
+	*writerLogger // provides Writer() through embedding
+
+	conn *netConn
+
+	// drops counts the messages that could not be sent as dropped
+	drops Droppable
+
+	mapper SeverityMapper
+
+	// maxSize is the size of the largest message sent over UDP, 0 over TCP and TLS
+	maxSize  int
+	compress bool
+
+	closeOnce sync.Once
+}
+
+// NewGELFLogger returns a logger sending GELF 1.1 messages to Graylog, mapping severities to
+// syslog levels and sending the fields of messages as additional fields.
+func NewGELFLogger(conf Config) (Logger, error) {
+	sev, err := severityFromString(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	network := conf.Network
+	switch network {
+	case "":
+		network = "udp"
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported gelf network: %s", network)
+	}
+	chunkSize := conf.MaxDatagramSize
+	if chunkSize == 0 {
+		chunkSize = gelfChunkSize
+	}
+	if chunkSize <= gelfChunkHeaderSize || chunkSize > maxDatagramSize {
+		return nil, fmt.Errorf("gelf chunks must be between %d and %d bytes: %d", gelfChunkHeaderSize+1, maxDatagramSize, chunkSize)
+	}
+	mapper := conf.SeverityMapper
+	if mapper == nil {
+		mapper = GELFSeverityMapper
+	}
+
+	address := conf.Address
+	if address == "" {
+		address = fmt.Sprintf("%s:%v", DefaultHost, DefaultGELFPort)
+	}
+	conn, err := dialNet(network, address, conf.TLSConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &gelfLogger{conn: conn, mapper: mapper}
+	var w io.Writer = conn
+	if network == "udp" {
+		l.maxSize, l.compress = gelfMaxChunks*(chunkSize-gelfChunkHeaderSize), conf.Compress
+		w = &gelfChunker{w: conn, size: chunkSize, compress: conf.Compress}
+	} else {
+		conn.frame = func(p []byte) []byte { return append(p[:len(p):len(p)], 0) }
+	}
+	counted := &dropCountingWriter{w: w}
+	l.writerLogger, l.drops = &writerLogger{sev, counted}, counted
+	return l, nil
+}
+
+// Close closes the connection to Graylog. It is safe to call it more than once.
+func (l *gelfLogger) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		err = l.conn.Close()
+	})
+	return err
+}
+
+// Dropped returns the number of messages that could not be sent to Graylog.
+func (l *gelfLogger) Dropped() uint64 {
+	return l.drops.Dropped()
+}
+
+func (l *gelfLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.FormatRecord(NewRecord(sev, caller, nil, format, args...))
+}
+
+// FormatRecord encodes the record as a GELF message, cutting its message short over UDP so
+// that it fits in the chunks it is split in.
+func (l *gelfLogger) FormatRecord(r Record) string {
+	encode := func(r Record) []byte {
+		return formatGELF(r, l.mapper.Priority(r.Severity))
+	}
+	if l.maxSize == 0 {
+		return string(encode(r))
+	}
+	return string(fitDatagram(r, l.maxSize, encode))
+}
+
+// HealthCheck makes sure the connection to Graylog is open, see udpLogger.HealthCheck for
+// what it tells over UDP.
+func (l *gelfLogger) HealthCheck() error {
+	return l.conn.HealthCheck()
+}
+
+func (l *gelfLogger) Describe() LoggerDescription {
+	destination := l.conn.String()
+	if l.compress {
+		destination += ", gzipped"
+	}
+	return LoggerDescription{Type: GELF, Format: EncodingGELF, Destination: destination}
+}
+
+// gelfChunker sends the GELF messages written to it in datagrams, gzipping them if told to
+// and splitting those longer than size bytes in chunks.
+type gelfChunker struct {
+	w        io.Writer
+	size     int
+	compress bool
+}
+
+func (g *gelfChunker) Write(p []byte) (int, error) {
+	message := p
+	if g.compress {
+		var b bytes.Buffer
+		z := gzip.NewWriter(&b)
+		z.Write(p)
+		if err := z.Close(); err != nil {
+			return 0, err
+		}
+		message = b.Bytes()
+	}
+	if len(message) <= g.size {
+		if _, err := g.w.Write(message); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+
+	payload := g.size - gelfChunkHeaderSize
+	count := (len(message) + payload - 1) / payload
+	if count > gelfMaxChunks {
+		return 0, fmt.Errorf("gelf message of %d bytes does not fit in %d chunks of %d", len(message), gelfMaxChunks, g.size)
+	}
+	chunk := make([]byte, gelfChunkHeaderSize, g.size)
+	chunk[0], chunk[1] = 0x1e, 0x0f
+	if _, err := rand.Read(chunk[2:10]); err != nil {
+		return 0, err
+	}
+	chunk[11] = byte(count)
+	for seq := 0; seq < count; seq++ {
+		end := (seq + 1) * payload
+		if end > len(message) {
+			end = len(message)
+		}
+		chunk[10] = byte(seq)
+		if _, err := g.w.Write(append(chunk[:gelfChunkHeaderSize], message[seq*payload:end]...)); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
@@ -0,0 +1,147 @@
+package log
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type GELFLoggerSuite struct {
+}
+
+var _ = Suite(&GELFLoggerSuite{})
+
+func (s *GELFLoggerSuite) TestNewGELFLogger(c *C) {
+	l, err := NewLogger(Config{Name: GELF, Severity: "info"})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	c.Assert(typeOf(l), Equals, "*log.gelfLogger")
+	c.Assert(describe(l).Destination, Equals, "udp://127.0.0.1:12201")
+
+	_, err = NewGELFLogger(Config{Name: GELF, Severity: "info", Network: "unix"})
+	c.Assert(err, ErrorMatches, "unsupported gelf network: unix")
+	_, err = NewGELFLogger(Config{Name: GELF, Severity: "info", MaxDatagramSize: 12})
+	c.Assert(err, ErrorMatches, "gelf chunks must be between 13 and 65507 bytes: 12")
+}
+
+func (s *GELFLoggerSuite) TestUDP(c *C) {
+	server, read := listenUDP(c)
+	defer server.Close()
+
+	l, err := NewGELFLogger(Config{Name: GELF, Severity: "info", Address: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+	io.WriteString(l.Writer(SeverityWarning), formatMessage(l, SeverityWarning, caller, now(), Fields{"user": "bob"}, "hello %s", "world"))
+
+	var gelf map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(read()), &gelf), IsNil)
+	c.Assert(gelf["version"], Equals, "1.1")
+	c.Assert(gelf["short_message"], Equals, "hello world")
+	c.Assert(gelf["level"], Equals, float64(4))
+	c.Assert(gelf["_line"], Equals, float64(42))
+	c.Assert(gelf["_user"], Equals, "bob")
+}
+
+func (s *GELFLoggerSuite) TestUDPChunked(c *C) {
+	server, read := listenUDP(c)
+	defer server.Close()
+
+	l, err := NewGELFLogger(Config{Name: GELF, Severity: "info", Address: server.LocalAddr().String(), MaxDatagramSize: 100, Compress: true})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	c.Assert(describe(l).Destination, Matches, "udp://.*, gzipped")
+
+	// random text does not compress below the size of a chunk
+	var message bytes.Buffer
+	for i := 0; message.Len() < 1000; i++ {
+		json.NewEncoder(&message).Encode(now().UnixNano() * int64(i+1))
+	}
+	io.WriteString(l.Writer(SeverityInfo), formatMessage(l, SeverityInfo, &CallerInfo{}, now(), nil, "%s", message.String()))
+
+	// the chunks of the message share an ID and are numbered, the last one may be shorter
+	var gzipped []byte
+	first := read()
+	count := int(first[11])
+	c.Assert(count > 1, Equals, true)
+	for seq, chunk := 0, first; seq < count; seq++ {
+		if seq > 0 {
+			chunk = read()
+		}
+		c.Assert(chunk[:2], Equals, "\x1e\x0f")
+		c.Assert(chunk[2:10], Equals, first[2:10])
+		c.Assert(int(chunk[10]), Equals, seq)
+		c.Assert(int(chunk[11]), Equals, count)
+		c.Assert(len(chunk) <= 100, Equals, true)
+		gzipped = append(gzipped, chunk[12:]...)
+	}
+
+	z, err := gzip.NewReader(bytes.NewReader(gzipped))
+	c.Assert(err, IsNil)
+	b, err := ioutil.ReadAll(z)
+	c.Assert(err, IsNil)
+	var gelf map[string]interface{}
+	c.Assert(json.Unmarshal(b, &gelf), IsNil)
+	c.Assert(gelf["short_message"], Equals, message.String())
+}
+
+func (s *GELFLoggerSuite) TestUDPTruncated(c *C) {
+	l, err := NewGELFLogger(Config{Name: GELF, Severity: "info", MaxDatagramSize: 20})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+
+	// messages are cut short to fit in 128 chunks
+	message := l.FormatMessage(SeverityInfo, &CallerInfo{}, "%s", strings.Repeat("a", 2000))
+	c.Assert(len(message) <= 128*8, Equals, true)
+	var gelf map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(message), &gelf), IsNil)
+	c.Assert(strings.HasSuffix(gelf["short_message"].(string), TruncatedMarker), Equals, true)
+}
+
+func (s *GELFLoggerSuite) TestTCP(c *C) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer server.Close()
+
+	messages := make(chan string, 2)
+	go func() {
+		conn, err := server.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		for i := 0; i < 2; i++ {
+			message, err := r.ReadString(0)
+			if err != nil {
+				return
+			}
+			messages <- message
+		}
+	}()
+
+	l, err := NewGELFLogger(Config{Name: GELF, Severity: "info", Network: "tcp", Address: server.Addr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	for _, text := range []string{"hello", "world"} {
+		io.WriteString(l.Writer(SeverityInfo), formatMessage(l, SeverityInfo, &CallerInfo{}, now(), nil, "%s", text))
+	}
+
+	// messages are delimited by null bytes
+	for _, text := range []string{"hello", "world"} {
+		message := <-messages
+		c.Assert(strings.HasSuffix(message, "\x00"), Equals, true)
+		var gelf map[string]interface{}
+		c.Assert(json.Unmarshal([]byte(strings.TrimSuffix(message, "\x00")), &gelf), IsNil)
+		c.Assert(gelf["short_message"], Equals, text)
+		c.Assert(gelf["level"], Equals, float64(6))
+	}
+	c.Assert(l.(Droppable).Dropped(), Equals, uint64(0))
+}
@@ -0,0 +1,259 @@
+// Package grpcstream provides a logger streaming messages as protobuf LogEntry records over a
+// bidirectional gRPC stream, see the logpb package for the protocol. It is kept apart from the
+// log package so that programs not using it do not depend on gRPC.
+package grpcstream
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/mailgun/log"
+	"github.com/mailgun/log/grpcstream/logpb"
+)
+
+const (
+	// queueSize is the number of entries buffered while the stream is being (re)established.
+	queueSize = 1000
+
+	// minBackoff and maxBackoff bound the delay between attempts to reestablish the stream.
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 5 * time.Second
+
+	// closeTimeout bounds the time Close waits for queued entries to be received.
+	closeTimeout = 5 * time.Second
+)
+
+// Logger streams messages to a LogBus service. Messages logged while the stream is down are
+// buffered, up to 1000 of them, and sent once it is reestablished; messages beyond that are
+// dropped and counted, see Dropped.
+type Logger struct {
+	sev    log.Severity
+	client logpb.LogBusClient
+
+	queue   chan *logpb.LogEntry
+	dropped uint64
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewLogger makes a logger streaming messages over the provided connection, which is left
+// for the caller to dial with the credentials and options of their choice.
+func NewLogger(conf log.Config, cc grpc.ClientConnInterface) (*Logger, error) {
+	sev, err := log.ParseSeverity(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Logger{
+		sev:     sev,
+		client:  logpb.NewLogBusClient(cc),
+		queue:   make(chan *logpb.LogEntry, queueSize),
+		done:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+	go l.run()
+	return l, nil
+}
+
+func (l *Logger) Writer(sev log.Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= l.sev {
+		return &streamWriter{l}
+	}
+	return nil
+}
+
+// FormatMessage returns the serialized LogEntry of the message.
+func (l *Logger) FormatMessage(sev log.Severity, caller *log.CallerInfo, format string, args ...interface{}) string {
+	return l.FormatMessageWithFields(sev, caller, nil, format, args...)
+}
+
+// FormatMessageWithFields returns the serialized LogEntry of the message with the fields.
+func (l *Logger) FormatMessageWithFields(sev log.Severity, caller *log.CallerInfo, fields log.Fields, format string, args ...interface{}) string {
+	return l.FormatRecord(log.NewRecord(sev, caller, fields, format, args...))
+}
+
+// FormatRecord returns the serialized LogEntry of the message, stamped with the time it was
+// logged at.
+func (l *Logger) FormatRecord(r log.Record) string {
+	entry := &logpb.LogEntry{
+		Severity:  r.Severity.String(),
+		Timestamp: timestamppb.New(r.Time),
+		Caller: &logpb.Caller{
+			File:     r.File,
+			Line:     int32(r.Line),
+			Function: r.Func,
+		},
+		Message: r.Message,
+	}
+	if len(r.Fields) > 0 {
+		entry.Fields = make(map[string]string, len(r.Fields))
+		for k, v := range r.Fields {
+			entry.Fields[k] = fmt.Sprint(v)
+		}
+	}
+
+	b, err := proto.Marshal(entry)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// Dropped returns the number of messages dropped because the buffer was full.
+func (l *Logger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
+
+// Close stops streaming after sending the buffered messages if the stream is up. It can be
+// called multiple times.
+func (l *Logger) Close() error {
+	l.closeOnce.Do(func() {
+		close(l.done)
+	})
+	<-l.stopped
+	return nil
+}
+
+// enqueue buffers the entry for sending unless the buffer is full or the logger is closed.
+func (l *Logger) enqueue(entry *logpb.LogEntry) {
+	select {
+	case <-l.done:
+		atomic.AddUint64(&l.dropped, 1)
+		return
+	default:
+	}
+
+	select {
+	case l.queue <- entry:
+	default:
+		atomic.AddUint64(&l.dropped, 1)
+	}
+}
+
+// run keeps the stream up and sends buffered entries over it until the logger is closed.
+func (l *Logger) run() {
+	defer close(l.stopped)
+
+	var pending *logpb.LogEntry
+	backoff := minBackoff
+	for {
+		var sent bool
+		ctx, cancel := context.WithCancel(context.Background())
+		opened := make(chan struct{})
+		go func() {
+			// give a connection attempt in progress a chance to flush the buffered
+			// entries on Close but do not let it hold Close up
+			select {
+			case <-l.done:
+			case <-opened:
+				return
+			}
+			select {
+			case <-opened:
+			case <-time.After(closeTimeout):
+				cancel()
+			}
+		}()
+		stream, err := l.client.Stream(ctx)
+		close(opened)
+		if err == nil {
+			pending, sent, err = l.send(stream, pending)
+		}
+		cancel()
+		if err == nil {
+			// closed
+			return
+		}
+
+		if sent {
+			backoff = minBackoff
+		}
+		select {
+		case <-l.done:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// send sends the pending entry, if any, followed by the buffered ones over the stream until it
+// breaks or the logger is closed. It returns the entry that failed to be sent, whether any entry
+// was sent and the error that broke the stream.
+func (l *Logger) send(stream logpb.LogBus_StreamClient, pending *logpb.LogEntry) (*logpb.LogEntry, bool, error) {
+	broken := make(chan error, 1)
+	go func() {
+		for {
+			if _, err := stream.Recv(); err != nil {
+				broken <- err
+				return
+			}
+		}
+	}()
+
+	sent := false
+	for {
+		if pending != nil {
+			if err := stream.Send(pending); err != nil {
+				return pending, sent, err
+			}
+			pending, sent = nil, true
+		}
+
+		select {
+		case pending = <-l.queue:
+		case err := <-broken:
+			if err == io.EOF {
+				err = fmt.Errorf("stream closed by the server")
+			}
+			return nil, sent, err
+		case <-l.done:
+			l.flush(stream, broken)
+			return nil, sent, nil
+		}
+	}
+}
+
+// flush sends the buffered entries and waits for the server to receive them.
+func (l *Logger) flush(stream logpb.LogBus_StreamClient, broken <-chan error) {
+	for len(l.queue) > 0 {
+		if err := stream.Send(<-l.queue); err != nil {
+			return
+		}
+	}
+	if err := stream.CloseSend(); err != nil {
+		return
+	}
+
+	select {
+	case <-broken:
+	case <-time.After(closeTimeout):
+	}
+}
+
+// streamWriter buffers serialized entries written to it for sending.
+type streamWriter struct {
+	l *Logger
+}
+
+func (w *streamWriter) Write(p []byte) (int, error) {
+	entry := &logpb.LogEntry{}
+	if err := proto.Unmarshal(p, entry); err != nil {
+		return 0, err
+	}
+	w.l.enqueue(entry)
+	return len(p), nil
+}
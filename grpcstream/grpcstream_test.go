@@ -0,0 +1,177 @@
+package grpcstream
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/mailgun/log"
+	"github.com/mailgun/log/grpcstream/logpb"
+	"github.com/mailgun/log/testutil"
+)
+
+var _ log.Droppable = (*Logger)(nil)
+
+// bus is an in-process LogBus receiving entries over a bufconn listener, which refuses
+// connections while the bus is down.
+type bus struct {
+	logpb.UnimplementedLogBusServer
+	entries chan *logpb.LogEntry
+	lis     *bufconn.Listener
+	down    int32
+}
+
+func newBus(t *testing.T) *bus {
+	b := &bus{entries: make(chan *logpb.LogEntry, 100), lis: bufconn.Listen(1 << 20)}
+	server := grpc.NewServer()
+	logpb.RegisterLogBusServer(server, b)
+	go server.Serve(b.lis)
+	t.Cleanup(server.Stop)
+	return b
+}
+
+func (b *bus) Stream(stream logpb.LogBus_StreamServer) error {
+	var received uint64
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		b.entries <- entry
+		received++
+		if err := stream.Send(&logpb.Ack{Received: received}); err != nil {
+			return err
+		}
+	}
+}
+
+func (b *bus) dial(t *testing.T) *grpc.ClientConn {
+	cc, err := grpc.NewClient("passthrough:///bus",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			if atomic.LoadInt32(&b.down) != 0 {
+				return nil, errors.New("bus is down")
+			}
+			return b.lis.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return cc
+}
+
+func (b *bus) receive(t *testing.T) *logpb.LogEntry {
+	select {
+	case entry := <-b.entries:
+		return entry
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for an entry")
+		return nil
+	}
+}
+
+func newLogger(t *testing.T, b *bus) *Logger {
+	l, err := NewLogger(log.Config{Severity: "info"}, b.dial(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	restore := log.CaptureOutput(ioutil.Discard)
+	t.Cleanup(restore)
+	log.Init(l)
+	return l
+}
+
+func TestLogEntry(t *testing.T) {
+	ts := time.Date(2014, 3, 5, 7, 9, 11, 500000000, time.UTC)
+	log.SetClock(log.ClockFunc(func() time.Time { return ts }))
+	defer log.SetClock(nil)
+
+	b := newBus(t)
+	newLogger(t, b)
+
+	log.Debugf("hello %s", "debug")
+	log.WithFields(log.Fields{"user": "bob", "attempt": 2}).Warningf("hello %s", "world")
+
+	entry := b.receive(t)
+	if entry.Severity != "WARN" {
+		t.Errorf("severity = %q, want WARN", entry.Severity)
+	}
+	if !entry.Timestamp.AsTime().Equal(ts) {
+		t.Errorf("timestamp = %v, want %v", entry.Timestamp.AsTime(), ts)
+	}
+	if entry.Caller.File != "grpcstream_test.go" || entry.Caller.Line == 0 || !strings.HasSuffix(entry.Caller.Function, "TestLogEntry") {
+		t.Errorf("caller = %v, want the test", entry.Caller)
+	}
+	if entry.Message != "hello world" {
+		t.Errorf("message = %q, want %q", entry.Message, "hello world")
+	}
+	if len(entry.Fields) != 2 || entry.Fields["user"] != "bob" || entry.Fields["attempt"] != "2" {
+		t.Errorf("fields = %v, want user=bob attempt=2", entry.Fields)
+	}
+}
+
+func TestBufferWhileReconnecting(t *testing.T) {
+	b := newBus(t)
+	atomic.StoreInt32(&b.down, 1)
+	l := newLogger(t, b)
+
+	for i := 0; i < 3; i++ {
+		log.Infof("message %d", i)
+	}
+	time.Sleep(200 * time.Millisecond)
+	atomic.StoreInt32(&b.down, 0)
+
+	for i := 0; i < 3; i++ {
+		if entry, want := b.receive(t), fmt.Sprintf("message %d", i); entry.Message != want {
+			t.Errorf("message = %q, want %q", entry.Message, want)
+		}
+	}
+	if l.Dropped() != 0 {
+		t.Errorf("dropped %d messages", l.Dropped())
+	}
+}
+
+func TestCloseFlushes(t *testing.T) {
+	b := newBus(t)
+	l := newLogger(t, b)
+
+	log.Infof("hello %s", "world")
+	l.Close()
+
+	if entry := b.receive(t); entry.Message != "hello world" {
+		t.Errorf("message = %q, want %q", entry.Message, "hello world")
+	}
+	log.Infof("hello %s", "again")
+	if l.Dropped() != 1 {
+		t.Errorf("dropped %d messages after Close, want 1", l.Dropped())
+	}
+}
+
+func TestConformance(t *testing.T) {
+	b := newBus(t)
+	cc := b.dial(t)
+	testutil.TestLoggerConformance(t, func(sev log.Severity) log.Logger {
+		l, err := NewLogger(log.Config{Severity: sev.String()}, cc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	})
+}
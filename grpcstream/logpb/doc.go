@@ -0,0 +1,4 @@
+// Package logpb defines the protocol of the grpcstream logger.
+package logpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative log.proto
@@ -0,0 +1,121 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: log.proto
+
+package logpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	LogBus_Stream_FullMethodName = "/mailgun.log.v1.LogBus/Stream"
+)
+
+// LogBusClient is the client API for LogBus service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// LogBus receives log entries from programs.
+type LogBusClient interface {
+	// Stream sends log entries to the bus, which acknowledges them as they are received.
+	Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogEntry, Ack], error)
+}
+
+type logBusClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLogBusClient(cc grpc.ClientConnInterface) LogBusClient {
+	return &logBusClient{cc}
+}
+
+func (c *logBusClient) Stream(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[LogEntry, Ack], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &LogBus_ServiceDesc.Streams[0], LogBus_Stream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[LogEntry, Ack]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogBus_StreamClient = grpc.BidiStreamingClient[LogEntry, Ack]
+
+// LogBusServer is the server API for LogBus service.
+// All implementations must embed UnimplementedLogBusServer
+// for forward compatibility.
+//
+// LogBus receives log entries from programs.
+type LogBusServer interface {
+	// Stream sends log entries to the bus, which acknowledges them as they are received.
+	Stream(grpc.BidiStreamingServer[LogEntry, Ack]) error
+	mustEmbedUnimplementedLogBusServer()
+}
+
+// UnimplementedLogBusServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLogBusServer struct{}
+
+func (UnimplementedLogBusServer) Stream(grpc.BidiStreamingServer[LogEntry, Ack]) error {
+	return status.Error(codes.Unimplemented, "method Stream not implemented")
+}
+func (UnimplementedLogBusServer) mustEmbedUnimplementedLogBusServer() {}
+func (UnimplementedLogBusServer) testEmbeddedByValue()                {}
+
+// UnsafeLogBusServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LogBusServer will
+// result in compilation errors.
+type UnsafeLogBusServer interface {
+	mustEmbedUnimplementedLogBusServer()
+}
+
+func RegisterLogBusServer(s grpc.ServiceRegistrar, srv LogBusServer) {
+	// If the following call panics, it indicates UnimplementedLogBusServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&LogBus_ServiceDesc, srv)
+}
+
+func _LogBus_Stream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(LogBusServer).Stream(&grpc.GenericServerStream[LogEntry, Ack]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type LogBus_StreamServer = grpc.BidiStreamingServer[LogEntry, Ack]
+
+// LogBus_ServiceDesc is the grpc.ServiceDesc for LogBus service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var LogBus_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "mailgun.log.v1.LogBus",
+	HandlerType: (*LogBusServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Stream",
+			Handler:       _LogBus_Stream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "log.proto",
+}
@@ -0,0 +1,131 @@
+package log
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// HealthChecker is an optional interface implemented by loggers that can check that messages
+// would reach their destination, e.g. that a socket is reachable or a file is writable,
+// without logging anything.
+type HealthChecker interface {
+	HealthCheck() error
+}
+
+// HealthCheckAll checks the health of the loggers implementing HealthChecker, which lets
+// readiness probes make sure logging works. The results are keyed by the loggers' names,
+// see InitWithConfig, a nil error meaning the logger is healthy. Of loggers sharing a name
+// the first unhealthy one is reported.
+func HealthCheckAll() map[string]error {
+	results := make(map[string]error)
+	for _, l := range currentLoggers() {
+		c, ok := l.(HealthChecker)
+		if !ok {
+			continue
+		}
+		err := c.HealthCheck()
+
+		loggersMu.RLock()
+		name := loggerName(l)
+		loggersMu.RUnlock()
+		if results[name] == nil {
+			results[name] = err
+		}
+	}
+	return results
+}
+
+// HealthCheck makes sure the file is open and still at its path, as it would not be if it
+// had been rotated without reopening the logger, and that the path is writable.
+func (l *fileLogger) HealthCheck() error {
+	return l.file.HealthCheck()
+}
+
+func (f *reopenableFile) HealthCheck() error {
+	f.mu.Lock()
+	open := f.f
+	f.mu.Unlock()
+	if open == nil {
+		return os.ErrClosed
+	}
+
+	opened, err := open.Stat()
+	if err != nil {
+		return err
+	}
+	current, err := os.Stat(f.path)
+	if err != nil {
+		return err
+	}
+	if !os.SameFile(opened, current) {
+		return fmt.Errorf("%s has been replaced since it was opened", f.path)
+	}
+
+	probe, err := os.OpenFile(f.path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	return probe.Close()
+}
+
+// syslogSockets are the sockets log/syslog connects to the local syslog server over.
+var syslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// HealthCheck makes sure the syslog server is reachable by connecting to the local one, or
+// by checking the connection to the remote one like the udplog logger does.
+func (l *sysLogger) HealthCheck() error {
+	if l.remote {
+		return l.debugW.(*netConn).HealthCheck()
+	}
+	var err error
+	for _, network := range []string{"unixgram", "unix"} {
+		for _, path := range syslogSockets {
+			var conn net.Conn
+			if conn, err = net.Dial(network, path); err == nil {
+				return conn.Close()
+			}
+		}
+	}
+	return fmt.Errorf("syslog is unreachable: %v", err)
+}
+
+// HealthCheck makes sure the connection to the udplog server is open. Nothing tells whether
+// the server receives what is sent over UDP, but sending fails once the server is known to be
+// unreachable, which counts the messages as dropped, see Dropped.
+func (l *udpLogger) HealthCheck() error {
+	return l.conn.HealthCheck()
+}
+
+// HealthCheck makes sure the connection is open, reconnecting if the last write failed.
+func (c *netConn) HealthCheck() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return fmt.Errorf("connection to %s is closed", c)
+	}
+	if c.conn == nil {
+		conn, err := c.dial()
+		if err != nil {
+			return err
+		}
+		c.conn = conn
+	}
+	// only an open connection has its deadline set
+	return c.conn.SetWriteDeadline(time.Time{})
+}
+
+// HealthCheck checks the health of the loggers implementing HealthChecker and returns the
+// first error encountered.
+func (m *MultiLogger) HealthCheck() error {
+	for _, l := range m.loggers {
+		if c, ok := l.(HealthChecker); ok {
+			if err := c.HealthCheck(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
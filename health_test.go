@@ -0,0 +1,76 @@
+package log
+
+import (
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type HealthSuite struct {
+}
+
+var _ = Suite(&HealthSuite{})
+
+func (s *HealthSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	loggerNames = nil
+}
+
+func (s *HealthSuite) TearDownTest(c *C) {
+	loggerNames = nil
+}
+
+func (s *HealthSuite) TestFileLogger(c *C) {
+	path := filepath.Join(c.MkDir(), "app.log")
+	l, err := NewFileLogger(Config{Name: File, Severity: "info", Path: path})
+	c.Assert(err, IsNil)
+	defer l.(*fileLogger).Close()
+	c.Assert(l.(HealthChecker).HealthCheck(), IsNil)
+
+	// the file has been rotated away and its path cannot be written to anymore
+	c.Assert(os.Remove(path), IsNil)
+	c.Assert(l.(HealthChecker).HealthCheck(), NotNil)
+	c.Assert(os.Mkdir(path, 0755), IsNil)
+	c.Assert(l.(HealthChecker).HealthCheck(), ErrorMatches, ".*app.log has been replaced since it was opened")
+
+	l.(*fileLogger).Close()
+	c.Assert(l.(HealthChecker).HealthCheck(), Equals, os.ErrClosed)
+}
+
+func (s *HealthSuite) TestUDPLogger(c *C) {
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info"})
+	c.Assert(err, IsNil)
+	c.Assert(l.(HealthChecker).HealthCheck(), IsNil)
+
+	l.(*udpLogger).Close()
+	c.Assert(l.(HealthChecker).HealthCheck(), NotNil)
+}
+
+func (s *HealthSuite) TestSysLogger(c *C) {
+	l, err := NewSysLogger(Config{Name: Syslog, Severity: "info"})
+	c.Assert(err, IsNil)
+	defer l.(*sysLogger).Close()
+	c.Assert(l.(HealthChecker).HealthCheck(), IsNil)
+}
+
+func (s *HealthSuite) TestHealthCheckAll(c *C) {
+	dir := c.MkDir()
+	good, err := NewFileLogger(Config{Name: File, Severity: "info", Path: filepath.Join(dir, "good.log")})
+	c.Assert(err, IsNil)
+	defer good.(*fileLogger).Close()
+	bad, err := NewFileLogger(Config{Name: File, Severity: "info", Path: filepath.Join(dir, "bad.log")})
+	c.Assert(err, IsNil)
+	bad.(*fileLogger).Close()
+	mirror := NewMultiLogger(good)
+
+	loggerNames = []namedLogger{{File, good}, {File, bad}, {Mirror, mirror}}
+	Init(good, bad, mirror, newTestLogger("unchecked"))
+
+	results := HealthCheckAll()
+	c.Assert(results, HasLen, 2)
+	c.Assert(results[File], Equals, os.ErrClosed)
+	c.Assert(results[Mirror], IsNil)
+	_, ok := results[Mirror]
+	c.Assert(ok, Equals, true)
+}
@@ -0,0 +1,172 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Hook is invoked with the messages logged at the severities it is registered for, see AddHook,
+// for example to send errors to an error tracker or to count messages by severity.
+type Hook interface {
+	// Fire is called with the record of the message before the message is sent to the loggers.
+	Fire(Record) error
+}
+
+// HookFunc is an adapter allowing the use of ordinary functions as hooks.
+type HookFunc func(Record) error
+
+func (f HookFunc) Fire(r Record) error {
+	return f(r)
+}
+
+// WriteHook is told about the writes of messages to the loggers, see AddWriteHook, for example
+// to count the messages every logger writes and the writes that fail.
+type WriteHook interface {
+	// Written is called after a message logged at the severity has been written to the logger
+	// of the name, see InitWithConfig, with the error writing it, if any.
+	Written(logger string, sev Severity, err error)
+}
+
+// WriteHookFunc is an adapter allowing the use of ordinary functions as write hooks.
+type WriteHookFunc func(logger string, sev Severity, err error)
+
+func (f WriteHookFunc) Written(logger string, sev Severity, err error) {
+	f(logger, sev, err)
+}
+
+var (
+	hooksMu    sync.RWMutex
+	hooks      []registeredHook
+	writeHooks []registeredWriteHook
+)
+
+// registeredHook is a hook registered for messages at some or all severities.
+type registeredHook struct {
+	hook       Hook
+	severities []Severity // nil for all severities
+
+	// site is where the hook was registered and warned is set once it has been reported as failing.
+	site   string
+	warned *int32
+}
+
+// AddHook registers a hook fired with the record of every message logged at one of the provided
+// severities, or at any severity if none is provided, whichever loggers the message goes to.
+//
+// Hooks run in registration order on the goroutine logging the message, before the message is
+// sent to the loggers. Messages they log themselves are written to the standard error rather
+// than sent to the loggers. The first error a hook returns is reported in a WARN message.
+func AddHook(h Hook, severities ...Severity) {
+	caller := getCallerInfo(1)
+
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	hooks = append(hooks, registeredHook{h, severities, fmt.Sprintf("%s:%d", caller.FilePath, caller.LineNo), new(int32)})
+}
+
+// registeredWriteHook is a write hook registered for messages at some or all severities.
+type registeredWriteHook struct {
+	hook       WriteHook
+	severities []Severity // nil for all severities
+}
+
+// AddWriteHook registers a write hook told about every write of a message logged at one of the
+// provided severities, or at any severity if none is provided, to a logger of the chain. Writes
+// to the loggers wrapped by others, such as those of a MultiLogger, are reported as writes to
+// the wrapping logger. Loggers writing asynchronously, such as AsyncLoggers, report the writes
+// they queue, they count the messages they fail to write as dropped, see DroppedCounts.
+//
+// Write hooks run in registration order on the goroutine logging the message, after every
+// write. Unlike hooks registered with AddHook, they do not keep the messages no logger would
+// write from being discarded early. Messages they log themselves are written to the standard
+// error rather than sent to the loggers.
+func AddWriteHook(h WriteHook, severities ...Severity) {
+	hooksMu.Lock()
+	defer hooksMu.Unlock()
+
+	writeHooks = append(writeHooks, registeredWriteHook{h, severities})
+}
+
+// firesAt returns true if the hook is registered for the severity.
+func (h registeredHook) firesAt(sev Severity) bool {
+	if h.severities == nil {
+		return true
+	}
+	for _, s := range h.severities {
+		if s == sev {
+			return true
+		}
+	}
+	return false
+}
+
+// firesAt returns true if the write hook is registered for the severity.
+func (h registeredWriteHook) firesAt(sev Severity) bool {
+	return registeredHook{severities: h.severities}.firesAt(sev)
+}
+
+// hooked tells whether any hook is registered for the severity.
+func hooked(sev Severity) bool {
+	hooksMu.RLock()
+	defer hooksMu.RUnlock()
+
+	for _, h := range hooks {
+		if h.firesAt(sev) {
+			return true
+		}
+	}
+	return false
+}
+
+// runHooks fires the hooks registered for the severity with the record of the message and
+// returns the warnings about the hooks that failed for the first time.
+func runHooks(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) []string {
+	hooksMu.RLock()
+	var matching []registeredHook
+	for _, h := range hooks {
+		if h.firesAt(sev) {
+			matching = append(matching, h)
+		}
+	}
+	hooksMu.RUnlock()
+
+	if len(matching) == 0 {
+		return nil
+	}
+
+	r := newRecord(sev, caller, t, fields, m.text())
+	var warnings []string
+	for _, h := range matching {
+		if err := h.hook.Fire(r); err != nil && atomic.CompareAndSwapInt32(h.warned, 0, 1) {
+			warnings = append(warnings, fmt.Sprintf("hook registered at %s failed: %v", h.site, err))
+		}
+	}
+	return warnings
+}
+
+// runWriteHooks tells the write hooks registered for the severity about the write of the
+// message to the logger of the chain.
+func runWriteHooks(logger Logger, sev Severity, err error) {
+	hooksMu.RLock()
+	var matching []WriteHook
+	for _, h := range writeHooks {
+		if h.firesAt(sev) {
+			matching = append(matching, h.hook)
+		}
+	}
+	hooksMu.RUnlock()
+
+	if len(matching) == 0 {
+		return
+	}
+
+	loggersMu.RLock()
+	name := loggerName(logger)
+	loggersMu.RUnlock()
+	for _, h := range matching {
+		h.Written(name, sev, err)
+	}
+}
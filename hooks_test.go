@@ -0,0 +1,117 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type HooksSuite struct {
+}
+
+var _ = Suite(&HooksSuite{})
+
+func (s *HooksSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	hooks, writeHooks = nil, nil
+}
+
+func (s *HooksSuite) TearDownTest(c *C) {
+	hooks, writeHooks = nil, nil
+}
+
+func (s *HooksSuite) TestFire(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	var all, errs []Record
+	AddHook(HookFunc(func(r Record) error {
+		// hooks run before the message is sent to the loggers
+		c.Assert(strings.Contains(logger.b.String(), r.Message), Equals, false)
+		all = append(all, r)
+		return nil
+	}))
+	AddHook(HookFunc(func(r Record) error {
+		errs = append(errs, r)
+		return nil
+	}), SeverityError, SeverityFatal)
+
+	WithFields(Fields{"user": "bob"}).Infof("hello %s", "world")
+	Errorf("oops")
+
+	c.Assert(len(all), Equals, 2)
+	c.Assert(all[0].Severity, Equals, SeverityInfo)
+	c.Assert(all[0].Message, Equals, "hello world")
+	c.Assert(all[0].Fields, DeepEquals, Fields{"user": "bob"})
+	c.Assert(all[0].Func, Matches, ".*TestFire")
+	c.Assert(len(errs), Equals, 1)
+	c.Assert(errs[0].Message, Equals, "oops")
+}
+
+func (s *HooksSuite) TestError(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	AddHook(HookFunc(func(r Record) error {
+		return errors.New("tracker unreachable")
+	}))
+
+	Infof("first")
+	Infof("second")
+
+	// the first failure is reported, the messages are logged anyway
+	c.Assert(logger.b.String(), Matches, "INFO first\nWARN hook registered at .*hooks_test.go:[0-9]+ failed: tracker unreachable\nINFO second\n")
+}
+
+func (s *HooksSuite) TestLogFromHook(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	var fallback bytes.Buffer
+	saved := stderr
+	stderr = &fallback
+	defer func() { stderr = saved }()
+
+	AddHook(HookFunc(func(r Record) error {
+		Warningf("seen %s", r.Message)
+		return nil
+	}), SeverityInfo)
+
+	Infof("hello")
+	c.Assert(logger.b.String(), Equals, "INFO hello\n")
+	c.Assert(fallback.String(), Equals, "WARN seen hello (logged while logging)\n")
+}
+
+func (s *HooksSuite) TestWriteHook(c *C) {
+	logger, broken := newTestLogger("log"), newBrokenLogger(SeverityWarning)
+	Init(logger, NewMultiLogger(broken))
+
+	var writes []string
+	AddWriteHook(WriteHookFunc(func(logger string, sev Severity, err error) {
+		writes = append(writes, fmt.Sprintf("%s %s %v", logger, sev, err))
+	}))
+	AddWriteHook(WriteHookFunc(func(logger string, sev Severity, err error) {
+		writes = append(writes, "error "+logger)
+	}), SeverityError)
+
+	Infof("hello")
+	broken.w.broken = true
+	Errorf("oops")
+
+	// writes go to the loggers of the chain
+	c.Assert(writes, DeepEquals, []string{
+		"*log.testLogger INFO <nil>",
+		"*log.testLogger ERROR <nil>",
+		"error *log.testLogger",
+		"*log.MultiLogger ERROR broken pipe",
+		"error *log.MultiLogger",
+	})
+
+	// write hooks do not keep messages from being discarded
+	c.Assert(discardable(SeverityDebug, nil), Equals, false)
+	ReplaceLoggers(broken)
+	c.Assert(discardable(SeverityDebug, nil), Equals, true)
+}
@@ -0,0 +1,315 @@
+package log
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Headers HTTPMiddleware takes request information from.
+const (
+	RequestIDHeader  = "X-Request-Id"
+	DebugLevelHeader = "X-Debug-Level"
+)
+
+var (
+	// debugHeaderAllowlist holds the networks of clients allowed to use the debug level header.
+	debugHeaderAllowlistMu sync.RWMutex
+	debugHeaderAllowlist   []*net.IPNet
+)
+
+// SetDebugHeaderAllowlist allows clients from the provided networks, given in CIDR notation
+// or as single IP addresses, to raise the verbosity of logging for their requests with the
+// X-Debug-Level header, see HTTPMiddleware. The header is ignored for everyone by default.
+func SetDebugHeaderAllowlist(networks ...string) error {
+	allowlist := make([]*net.IPNet, 0, len(networks))
+	for _, network := range networks {
+		if !strings.Contains(network, "/") {
+			if ip := net.ParseIP(network); ip != nil && ip.To4() != nil {
+				network += "/32"
+			} else {
+				network += "/128"
+			}
+		}
+		_, ipnet, err := net.ParseCIDR(network)
+		if err != nil {
+			return err
+		}
+		allowlist = append(allowlist, ipnet)
+	}
+
+	debugHeaderAllowlistMu.Lock()
+	debugHeaderAllowlist = allowlist
+	debugHeaderAllowlistMu.Unlock()
+	return nil
+}
+
+// HTTPMiddleware wraps an HTTP handler so that messages logged through the entry
+// of the request's context (see FromContext and FromRequest) carry the request's method, path
+// and ID. The start and completion of every request are logged at INFO, the latter as the
+// access log of the request along with the client's address, the request line, the response
+// status, the number of bytes of the response body and the time it took to handle the request:
+//
+//	request completed bytes=5 duration=1.2ms method=GET path=/foo remote=192.0.2.1 request="GET /foo HTTP/1.1" request_id=abc status=200
+//
+// The fields are named after DefaultCLFFieldNames, so that a logger made with NewCLFLogger
+// renders the completions as Common Log Format lines. The request line is logged without the
+// query, which often carries secrets such as API keys and tokens, unless MiddlewareQuery is
+// passed. Requests whose handlers panic are logged as well, with the status 500, before the
+// panic goes on to the server.
+//
+// The request ID is taken from the X-Request-Id header or generated if it is absent.
+//
+// Clients allowed by SetDebugHeaderAllowlist can set the X-Debug-Level header to a severity
+// name to have messages at or above it logged for their request by all loggers, see
+// Entry.WithSeverityOverride.
+func HTTPMiddleware(next http.Handler, options ...MiddlewareOption) http.Handler {
+	var o middlewareOptions
+	for _, option := range options {
+		option(&o)
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := now()
+
+		entry := FromContext(r.Context()).WithFields(Fields{
+			"method":     r.Method,
+			"path":       r.URL.Path,
+			"request_id": requestID(r),
+		})
+		if sev, ok := debugLevel(r); ok {
+			entry = entry.WithSeverityOverride(sev)
+		}
+		r = r.WithContext(NewContext(r.Context(), entry))
+
+		entry.Infof("request started")
+
+		sw := &statusWriter{ResponseWriter: w}
+		defer func() {
+			status := sw.statusCode()
+			p := recover()
+			if p != nil {
+				status = http.StatusInternalServerError
+			}
+			u := *r.URL
+			if !o.query {
+				u.RawQuery, u.ForceQuery = "", false
+			}
+			entry.WithFields(Fields{
+				"remote":   remoteHost(r),
+				"request":  fmt.Sprintf("%s %s %s", r.Method, u.RequestURI(), r.Proto),
+				"status":   status,
+				"bytes":    sw.bytes,
+				"duration": now().Sub(start),
+			}).Infof("request completed")
+			if p != nil {
+				panic(p)
+			}
+		}()
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// MiddlewareOption customizes HTTPMiddleware.
+type MiddlewareOption func(*middlewareOptions)
+
+// middlewareOptions are the options of HTTPMiddleware.
+type middlewareOptions struct {
+	query bool
+}
+
+// MiddlewareQuery makes HTTPMiddleware log the queries of request lines, which are left out
+// by default since they often carry secrets such as API keys and tokens.
+func MiddlewareQuery() MiddlewareOption {
+	return func(o *middlewareOptions) {
+		o.query = true
+	}
+}
+
+// FromRequest returns the entry stored in the context of the request, see FromContext. Within
+// handlers wrapped by HTTPMiddleware it carries the request's fields.
+func FromRequest(r *http.Request) *Entry {
+	return FromContext(r.Context())
+}
+
+// RequestWithFields returns a shallow copy of the request whose context carries the entry of
+// the request's context with the fields attached, so that messages logged through it by the
+// handlers the request is passed on to carry them, e.g. the user an authentication middleware
+// has identified.
+func RequestWithFields(r *http.Request, fields Fields) *http.Request {
+	return r.WithContext(ContextWithFields(r.Context(), fields))
+}
+
+// requestID returns the ID of the request from its header or generates a new one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(RequestIDHeader); id != "" {
+		return id
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "unknown"
+	}
+	return hex.EncodeToString(b)
+}
+
+// debugLevel returns the severity requested by the debug level header if the client
+// is allowed to use it.
+func debugLevel(r *http.Request) (Severity, bool) {
+	header := r.Header.Get(DebugLevelHeader)
+	if header == "" {
+		return 0, false
+	}
+
+	sev, err := severityFromString(header)
+	if err != nil {
+		return 0, false
+	}
+
+	ip := net.ParseIP(remoteHost(r))
+	if ip == nil {
+		return 0, false
+	}
+
+	debugHeaderAllowlistMu.RLock()
+	defer debugHeaderAllowlistMu.RUnlock()
+	for _, network := range debugHeaderAllowlist {
+		if network.Contains(ip) {
+			return sev, true
+		}
+	}
+	return 0, false
+}
+
+// remoteHost returns the host of the client's address.
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// statusWriter is a http.ResponseWriter remembering the status code of the response and
+// counting the bytes of its body.
+type statusWriter struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int64
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends the response written so far to the client if the wrapped writer supports it,
+// which streaming handlers asserting http.Flusher rely on.
+func (w *statusWriter) Flush() {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap returns the wrapped writer, which lets http.ResponseController reach its Flush and
+// Hijack methods.
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusCode returns the status code of the response, which is 200 if the handler
+// has not set it explicitly.
+func (w *statusWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// loggingRoundTripper is a http.RoundTripper logging outbound requests.
+type loggingRoundTripper struct {
+	inner http.RoundTripper
+	query bool
+}
+
+// RoundTripperOption customizes a logging round tripper, see NewLoggingRoundTripper.
+type RoundTripperOption func(*loggingRoundTripper)
+
+// RoundTripperQuery makes a logging round tripper log the queries of request URLs, which are
+// left out by default since they often carry secrets such as API keys and tokens.
+func RoundTripperQuery() RoundTripperOption {
+	return func(t *loggingRoundTripper) {
+		t.query = true
+	}
+}
+
+// NewLoggingRoundTripper returns a http.RoundTripper logging every request made through
+// the inner round tripper, or http.DefaultTransport if it is nil.
+//
+// Requests are logged at DEBUG when sent and at INFO once the response arrives, along with
+// the method, URL, response status and duration. Failed requests are logged at ERROR.
+// URLs are logged without their query, unless RoundTripperQuery is passed, and with their
+// password masked. Fields of the entry stored in the request context (see NewContext) are
+// attached as well. The response body is left untouched.
+func NewLoggingRoundTripper(inner http.RoundTripper, options ...RoundTripperOption) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+	t := &loggingRoundTripper{inner: inner}
+	for _, option := range options {
+		option(t)
+	}
+	return t
+}
+
+func (t *loggingRoundTripper) RoundTrip(r *http.Request) (*http.Response, error) {
+	start := now()
+
+	entry := FromContext(r.Context()).WithFields(Fields{
+		"method": r.Method,
+		"url":    t.loggedURL(r.URL),
+	})
+	entry.Debugf("request sent")
+
+	resp, err := t.inner.RoundTrip(r)
+	if err != nil {
+		entry.WithError(err).WithFields(Fields{
+			"duration": now().Sub(start),
+		}).Errorf("request failed")
+		return nil, err
+	}
+
+	entry.WithFields(Fields{
+		"status":   resp.StatusCode,
+		"duration": now().Sub(start),
+	}).Infof("response received")
+	return resp, nil
+}
+
+// loggedURL returns the URL as logged: with its password masked and its fragment, along with
+// its query unless the round tripper logs queries, left out.
+func (t *loggingRoundTripper) loggedURL(u *url.URL) string {
+	logged := *u
+	logged.Fragment, logged.RawFragment = "", ""
+	if !t.query {
+		logged.RawQuery, logged.ForceQuery = "", false
+	}
+	return logged.Redacted()
+}
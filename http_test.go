@@ -0,0 +1,253 @@
+package log
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type HTTPSuite struct {
+}
+
+var _ = Suite(&HTTPSuite{})
+
+func (s *HTTPSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *HTTPSuite) TestHTTPMiddleware(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Infof("handling %s", "request")
+		w.WriteHeader(http.StatusTeapot)
+	}))
+
+	r := httptest.NewRequest("GET", "/foo?bar=1", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[0], Equals, "INFO request started method=GET path=/foo request_id=abc")
+	c.Assert(lines[1], Equals, "INFO handling request method=GET path=/foo request_id=abc")
+	c.Assert(lines[2], Matches, `INFO request completed bytes=0 duration=.+ method=GET path=/foo remote=192.0.2.1 request="GET /foo HTTP/1.1" request_id=abc status=418`)
+
+	// the query is left out unless asked for
+	logger.b.Reset()
+	HTTPMiddleware(http.NotFoundHandler(), MiddlewareQuery()).ServeHTTP(httptest.NewRecorder(), r)
+	c.Assert(logger.b.String(), Matches, `(?s).*INFO request completed .* request="GET /foo\?bar=1 HTTP/1.1" request_id=abc status=404\n`)
+}
+
+func (s *HTTPSuite) TestHTTPMiddlewarePanic(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic("oops")
+	}))
+	func() {
+		defer func() {
+			// the panic goes on to the server
+			c.Assert(recover(), Equals, "oops")
+		}()
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil))
+	}()
+	c.Assert(logger.b.String(), Matches, `(?s).*INFO request completed bytes=7 .* status=500\n`)
+}
+
+func (s *HTTPSuite) TestHTTPMiddlewareDefaults(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("POST", "/", nil))
+
+	// the request ID is generated and the status defaults to 200
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[1], Matches, `INFO request completed bytes=5 duration=.+ method=POST path=/ remote=192.0.2.1 request="POST / HTTP/1.1" request_id=[0-9a-f]{16} status=200`)
+}
+
+func (s *HTTPSuite) TestHTTPMiddlewareStreaming(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte("event"))
+		flusher.Flush()
+	}))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/tail", nil))
+
+	c.Assert(rec.Flushed, Equals, true)
+	c.Assert(rec.Body.String(), Equals, "event")
+	c.Assert(logger.b.String(), Matches, `(?s).*INFO request completed bytes=5 .* status=200\n`)
+}
+
+func (s *HTTPSuite) TestHTTPMiddlewareAccessLog(c *C) {
+	b := &bytes.Buffer{}
+	Init(&clfLogger{&writerLogger{SeverityInfo, b}, DefaultCLFFieldNames})
+
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusForbidden)
+	}))
+	r := httptest.NewRequest("DELETE", "/foo", nil)
+	r.RemoteAddr = "[2001:db8::1]:1234"
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	// the completion of the request renders as its access log line
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[1], Matches, `2001:db8::1 - - \[.+\] "DELETE /foo HTTP/1.1" 403 5`)
+}
+
+func (s *HTTPSuite) TestRequestWithFields(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	authenticate := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, RequestWithFields(r, Fields{"user": "bob"}))
+		})
+	}
+	handler := HTTPMiddleware(authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromRequest(r).Infof("handling %s", "request")
+	})))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set(RequestIDHeader, "abc")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, 3)
+	c.Assert(lines[1], Equals, "INFO handling request method=GET path=/ request_id=abc user=bob")
+}
+
+func (s *HTTPSuite) TestHTTPMiddlewareDebugLevel(c *C) {
+	logger := newThresholdLogger("log", SeverityInfo)
+	Init(logger)
+	c.Assert(SetDebugHeaderAllowlist("192.0.2.0/24", "2001:db8::1"), IsNil)
+	defer SetDebugHeaderAllowlist()
+
+	handler := HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Debugf("debugging")
+	}))
+
+	for _, t := range []struct {
+		remoteAddr string
+		header     string
+		debug      bool
+	}{
+		{"192.0.2.1:1234", "debug", true},
+		{"[2001:db8::1]:1234", "DEBUG", true},
+		{"192.0.2.1:1234", "", false},
+		{"192.0.2.1:1234", "verbose", false},
+		{"198.51.100.1:1234", "debug", false},
+		{"[2001:db8::2]:1234", "debug", false},
+	} {
+		logger.b.Reset()
+		r := httptest.NewRequest("GET", "/", nil)
+		r.RemoteAddr = t.remoteAddr
+		if t.header != "" {
+			r.Header.Set(DebugLevelHeader, t.header)
+		}
+		handler.ServeHTTP(httptest.NewRecorder(), r)
+
+		c.Assert(strings.Contains(logger.b.String(), "DEBUG debugging"), Equals, t.debug, Commentf("%+v", t))
+		c.Assert(strings.Contains(logger.b.String(), "INFO request completed"), Equals, true)
+	}
+
+	// other code keeps logging at the configured severity
+	logger.b.Reset()
+	Debugf("hello %s", "world")
+	c.Assert(logger.b.Len(), Equals, 0)
+}
+
+func (s *HTTPSuite) TestSetDebugHeaderAllowlist(c *C) {
+	defer SetDebugHeaderAllowlist()
+	c.Assert(SetDebugHeaderAllowlist("10.0.0.0/8", "127.0.0.1", "::1"), IsNil)
+	c.Assert(SetDebugHeaderAllowlist("localhost"), NotNil)
+	c.Assert(SetDebugHeaderAllowlist("10.0.0.0/33"), NotNil)
+}
+
+func (s *HTTPSuite) TestLoggingRoundTripper(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		w.Write([]byte("hello"))
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: NewLoggingRoundTripper(nil)}
+	r, _ := http.NewRequest("PUT", server.URL+"/foo", nil)
+	r = r.WithContext(NewContext(r.Context(), WithFields(Fields{"request_id": "abc"})))
+
+	resp, err := client.Do(r)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+
+	// the body is left for the caller
+	body, err := ioutil.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, "hello")
+
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[0], Equals, "DEBUG request sent method=PUT request_id=abc url="+server.URL+"/foo")
+	c.Assert(lines[1], Matches, "INFO response received duration=.+ method=PUT request_id=abc status=202 url="+server.URL+"/foo")
+}
+
+func (s *HTTPSuite) TestLoggingRoundTripperURL(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	secret := strings.Replace(server.URL, "://", "://bob:hunter2@", 1) + "/foo?token=abc#top"
+	redacted := strings.Replace(server.URL, "://", "://bob:xxxxx@", 1) + "/foo"
+
+	// the query is left out and the password masked
+	resp, err := (&http.Client{Transport: NewLoggingRoundTripper(nil)}).Get(secret)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(logger.b.String(), Matches, "(?s)DEBUG request sent method=GET url="+regexp.QuoteMeta(redacted)+"\n.*")
+
+	logger.b.Reset()
+	resp, err = (&http.Client{Transport: NewLoggingRoundTripper(nil, RoundTripperQuery())}).Get(secret)
+	c.Assert(err, IsNil)
+	resp.Body.Close()
+	c.Assert(logger.b.String(), Matches, "(?s)DEBUG request sent method=GET url=\""+regexp.QuoteMeta(redacted+"?token=abc")+"\"\n.*")
+}
+
+func (s *HTTPSuite) TestLoggingRoundTripperError(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	client := &http.Client{Transport: NewLoggingRoundTripper(http.DefaultTransport)}
+	_, err := client.Get(url)
+	c.Assert(err, NotNil)
+
+	lines := strings.Split(strings.TrimSpace(logger.b.String()), "\n")
+	c.Assert(lines, HasLen, 2)
+	c.Assert(lines[1], Matches, `ERROR request failed duration=.+ error=".+" error_chain=".+" method=GET stack=".+" url=`+url)
+}
@@ -0,0 +1,193 @@
+package log
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// DefaultJournalSocket is the socket systemd-journald receives native protocol messages on.
+const DefaultJournalSocket = "/run/systemd/journal/socket"
+
+// journalFieldNames are the journal fields every message carries, fields of the same names are
+// not sent.
+var journalFieldNames = []string{"MESSAGE", "PRIORITY", "SYSLOG_IDENTIFIER", "CODE_FILE", "CODE_LINE", "CODE_FUNC"}
+
+// journaldLogger is a type of writerLogger that sends messages to systemd-journald over its
+// native protocol, so that their priorities, callers and fields become journal fields.
+type journaldLogger struct {
+	*writerLogger // provides Writer() through embedding
+
+	conn *journalConn
+
+	// drops counts the messages that could not be sent as dropped
+	drops Droppable
+
+	mapper     SeverityMapper
+	identifier string
+
+	closeOnce sync.Once
+}
+
+// NewJournaldLogger returns a logger sending messages to the journal, with their PRIORITY
+// mapped from their severity, their callers as CODE_FILE, CODE_LINE and CODE_FUNC, and their
+// fields as journal fields named after them in upper case, e.g. "user_id" as USER_ID, so that
+// they can be matched with journalctl, e.g. journalctl -u myservice -p err USER_ID=42.
+func NewJournaldLogger(conf Config) (Logger, error) {
+	sev, err := severityFromString(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+	mapper := conf.SeverityMapper
+	if mapper == nil {
+		mapper = JournaldSeverityMapper
+	}
+	identifier := conf.Tag
+	if identifier == "" {
+		identifier = appname
+	}
+
+	path := conf.Path
+	if path == "" {
+		path = DefaultJournalSocket
+	}
+	conn, err := dialNet("unixgram", path, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &journaldLogger{conn: &journalConn{conn, path}, mapper: mapper, identifier: identifier}
+	counted := &dropCountingWriter{w: l.conn}
+	l.writerLogger, l.drops = &writerLogger{sev, counted}, counted
+	return l, nil
+}
+
+// Close closes the connection to the journal. It is safe to call it more than once.
+func (l *journaldLogger) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		err = l.conn.Close()
+	})
+	return err
+}
+
+// Dropped returns the number of messages that could not be sent to the journal.
+func (l *journaldLogger) Dropped() uint64 {
+	return l.drops.Dropped()
+}
+
+// HealthCheck makes sure the connection to the journal is open.
+func (l *journaldLogger) HealthCheck() error {
+	return l.conn.HealthCheck()
+}
+
+func (l *journaldLogger) Describe() LoggerDescription {
+	return LoggerDescription{Type: Journald, Format: "journal native protocol", Destination: l.conn.path}
+}
+
+func (l *journaldLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.FormatRecord(NewRecord(sev, caller, nil, format, args...))
+}
+
+// FormatRecord encodes the record as a message of the journal's native protocol.
+func (l *journaldLogger) FormatRecord(r Record) string {
+	return string(formatJournal(r, l.mapper.Priority(r.Severity), l.identifier))
+}
+
+// formatJournal encodes the record as a message of the journal's native protocol at the
+// priority: the message, its priority, identifier and caller, then its fields in order of
+// name. Fields whose names clash with those of others are left out.
+func formatJournal(r Record, priority int, identifier string) []byte {
+	var b []byte
+	b = appendJournalField(b, "MESSAGE", r.Message)
+	b = appendJournalField(b, "PRIORITY", strconv.Itoa(priority))
+	b = appendJournalField(b, "SYSLOG_IDENTIFIER", identifier)
+	b = appendJournalField(b, "CODE_FILE", r.File)
+	b = appendJournalField(b, "CODE_LINE", strconv.Itoa(r.Line))
+	b = appendJournalField(b, "CODE_FUNC", r.Func)
+	if len(r.Fields) == 0 {
+		return b
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sent := make(map[string]bool, len(journalFieldNames)+len(keys))
+	for _, name := range journalFieldNames {
+		sent[name] = true
+	}
+	for _, k := range keys {
+		name := journalFieldName(k)
+		if sent[name] {
+			continue
+		}
+		sent[name] = true
+		b = appendJournalField(b, name, fmt.Sprint(r.Fields[k]))
+	}
+	return b
+}
+
+// appendJournalField appends the field to the message, as NAME=value, or in the binary form
+// of the protocol for values spanning several lines.
+func appendJournalField(b []byte, name, value string) []byte {
+	b = append(b, name...)
+	if !strings.Contains(value, "\n") {
+		b = append(b, '=')
+		b = append(b, value...)
+		return append(b, '\n')
+	}
+	b = append(b, '\n')
+	var size [8]byte
+	binary.LittleEndian.PutUint64(size[:], uint64(len(value)))
+	b = append(b, size[:]...)
+	b = append(b, value...)
+	return append(b, '\n')
+}
+
+// journalFieldName returns the name of the journal field carrying the field: the name of the
+// field in upper case, with the characters the journal does not allow replaced with
+// underscores, without the leading underscores of the fields only the journal may set, and
+// prefixed with "F_" if it would start with a digit. Names are at most 64 characters long.
+func journalFieldName(name string) string {
+	name = strings.TrimLeft(strings.Map(func(r rune) rune {
+		switch {
+		case 'a' <= r && r <= 'z':
+			return r - 'a' + 'A'
+		case ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9'):
+			return r
+		}
+		return '_'
+	}, name), "_")
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		name = "F_" + name
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+	return name
+}
+
+// journalConn is the connection to the journal, sending messages too long for a datagram in
+// files, see sendJournalFile.
+type journalConn struct {
+	*netConn
+
+	path string
+}
+
+func (c *journalConn) Write(p []byte) (int, error) {
+	n, err := c.netConn.Write(p)
+	if errors.Is(err, syscall.EMSGSIZE) {
+		if err = sendJournalFile(c.path, p); err == nil {
+			n = len(p)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,46 @@
+//go:build linux
+
+package log
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"syscall"
+)
+
+// sendJournalFile sends the message to the journal socket at path in a file descriptor, the
+// way the journal takes messages too long for a datagram: the message is written to an
+// unlinked file on the /dev/shm tmpfs, whose descriptor is passed over the socket.
+func sendJournalFile(path string, p []byte) error {
+	f, err := ioutil.TempFile("/dev/shm", "journal")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	os.Remove(f.Name())
+	if _, err := f.Write(p); err != nil {
+		return err
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: path, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	// WriteMsgUnix refuses connected datagram sockets
+	rights := syscall.UnixRights(int(f.Fd()))
+	var sendErr error
+	if err := raw.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), nil, rights, nil, 0)
+		return sendErr != syscall.EAGAIN
+	}); err != nil {
+		return err
+	}
+	return sendErr
+}
@@ -0,0 +1,40 @@
+package log
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *JournaldLoggerSuite) TestLongMessage(c *C) {
+	server, _ := listenJournal(c)
+	defer server.Close()
+
+	l, err := NewJournaldLogger(Config{Name: Journald, Severity: "info", Path: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	// far longer than the datagrams the default socket buffers hold
+	long := strings.Repeat("a", 1<<20)
+	_, err = io.WriteString(l.Writer(SeverityInfo), formatMessage(l, SeverityInfo, &CallerInfo{}, now(), nil, "%s", long))
+	c.Assert(err, IsNil)
+
+	// messages too long for a datagram come in files
+	oob := make([]byte, syscall.CmsgSpace(4))
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, oobn, _, _, err := server.ReadMsgUnix(nil, oob)
+	c.Assert(err, IsNil)
+	messages, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	c.Assert(err, IsNil)
+	fds, err := syscall.ParseUnixRights(&messages[0])
+	c.Assert(err, IsNil)
+	f := os.NewFile(uintptr(fds[0]), "journal")
+	defer f.Close()
+	b, err := ioutil.ReadAll(io.NewSectionReader(f, 0, 1<<21))
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(string(b), "MESSAGE="+long+"\n"), Equals, true)
+}
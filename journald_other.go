@@ -0,0 +1,10 @@
+//go:build !linux
+
+package log
+
+import "fmt"
+
+// sendJournalFile fails, messages are passed to the journal in files only on Linux.
+func sendJournalFile(path string, p []byte) error {
+	return fmt.Errorf("message of %d bytes does not fit in a datagram to the journal at %s", len(p), path)
+}
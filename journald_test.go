@@ -0,0 +1,73 @@
+package log
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type JournaldLoggerSuite struct {
+}
+
+var _ = Suite(&JournaldLoggerSuite{})
+
+// listenJournal returns a journal socket journald loggers can be pointed at and a function
+// reading the next message it receives.
+func listenJournal(c *C) (*net.UnixConn, func() string) {
+	path := filepath.Join(c.MkDir(), "socket")
+	server, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	c.Assert(err, IsNil)
+	return server, func() string {
+		buf := make([]byte, 1<<16)
+		server.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, err := server.Read(buf)
+		c.Assert(err, IsNil)
+		return string(buf[:n])
+	}
+}
+
+func (s *JournaldLoggerSuite) TestJournald(c *C) {
+	server, read := listenJournal(c)
+	defer server.Close()
+
+	l, err := NewLogger(Config{Name: Journald, Severity: "info", Path: server.LocalAddr().String(), Tag: "myservice"})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	c.Assert(describe(l).Destination, Equals, server.LocalAddr().String())
+
+	caller := &CallerInfo{"file.go", "/src/file.go", "main.run", 42}
+	fields := Fields{"user_id": 7, "_pid": 1, "message": "clash", "err": fmt.Errorf("oops")}
+	io.WriteString(l.Writer(SeverityError), formatMessage(l, SeverityError, caller, now(), fields, "hello %s", "world"))
+	c.Assert(read(), Equals, "MESSAGE=hello world\nPRIORITY=3\nSYSLOG_IDENTIFIER=myservice\n"+
+		"CODE_FILE=file.go\nCODE_LINE=42\nCODE_FUNC=main.run\nPID=1\nERR=oops\nUSER_ID=7\n")
+	c.Assert(l.(Droppable).Dropped(), Equals, uint64(0))
+}
+
+func (s *JournaldLoggerSuite) TestMultiline(c *C) {
+	message := string(formatJournal(Record{Severity: SeverityInfo, Message: "hello\nworld"}, 6, "app"))
+
+	// values spanning several lines are sent with their sizes
+	size := make([]byte, 8)
+	binary.LittleEndian.PutUint64(size, 11)
+	c.Assert(strings.HasPrefix(message, "MESSAGE\n"+string(size)+"hello\nworld\nPRIORITY=6\n"), Equals, true)
+}
+
+func (s *JournaldLoggerSuite) TestFieldNames(c *C) {
+	for name, expected := range map[string]string{
+		"user":       "USER",
+		"http.path":  "HTTP_PATH",
+		"__cursor":   "CURSOR",
+		"2fa":        "F_2FA",
+		"":           "F_",
+		"request-id": "REQUEST_ID",
+	} {
+		c.Assert(journalFieldName(name), Equals, expected)
+	}
+	c.Assert(len(journalFieldName(strings.Repeat("a", 100))), Equals, 64)
+}
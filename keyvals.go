@@ -0,0 +1,134 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+)
+
+// MissingValue is the value attached to a key passed without one, see With.
+const MissingValue = "(MISSING)"
+
+// warnedKeyvalSites holds the call sites warned about for passing a key without a value.
+var warnedKeyvalSites sync.Map
+
+// With returns an entry attaching the alternating keys and values as fields, see Entry.With.
+func With(keyvals ...interface{}) *Entry {
+	e := (&Entry{}).WithFields(keyvalFields(keyvals))
+	warnOddKeyvals(1, keyvals)
+	return e
+}
+
+// Tracew logs the message to the TRACE log with the alternating keys and values as fields.
+func Tracew(msg string, keyvals ...interface{}) {
+	(&Entry{}).logw(1, SeverityTrace, msg, keyvals)
+}
+
+// Debugw logs the message to the DEBUG log with the alternating keys and values as fields.
+func Debugw(msg string, keyvals ...interface{}) {
+	(&Entry{}).logw(1, SeverityDebug, msg, keyvals)
+}
+
+// Infow logs the message to the INFO log with the alternating keys and values as fields.
+func Infow(msg string, keyvals ...interface{}) {
+	(&Entry{}).logw(1, SeverityInfo, msg, keyvals)
+}
+
+// Warningw logs the message to the WARN and INFO logs with the alternating keys and values as fields.
+func Warningw(msg string, keyvals ...interface{}) {
+	(&Entry{}).logw(1, SeverityWarning, msg, keyvals)
+}
+
+// Errorw logs the message to the ERROR, WARN, and INFO logs with the alternating keys and values as fields.
+func Errorw(msg string, keyvals ...interface{}) {
+	(&Entry{}).logw(1, SeverityError, msg, keyvals)
+}
+
+// ErrorE logs the message to the ERROR, WARN, and INFO logs with the error and the alternating
+// keys and values as fields, see Entry.ErrorE.
+func ErrorE(err error, msg string, keyvals ...interface{}) {
+	(&Entry{}).WithError(err).logw(1, SeverityError, msg, keyvals)
+}
+
+// With returns a new entry attaching the alternating keys and values as fields along with the
+// entry's fields, for example:
+//
+//	log.With("user", "bob", "id", 42).Infof("logged in")
+//
+// Keys are rendered with fmt.Sprint. A key passed without a value, a common mistake, is attached
+// with MissingValue and the call site is reported in a WARN message once.
+func (e *Entry) With(keyvals ...interface{}) *Entry {
+	child := e.WithFields(keyvalFields(keyvals))
+	warnOddKeyvals(1, keyvals)
+	return child
+}
+
+// Tracew logs the message to the TRACE log with the alternating keys and values as fields, see With.
+func (e *Entry) Tracew(msg string, keyvals ...interface{}) {
+	e.logw(1, SeverityTrace, msg, keyvals)
+}
+
+// Debugw logs the message to the DEBUG log with the alternating keys and values as fields, see With.
+func (e *Entry) Debugw(msg string, keyvals ...interface{}) {
+	e.logw(1, SeverityDebug, msg, keyvals)
+}
+
+// Infow logs the message to the INFO log with the alternating keys and values as fields, see With.
+func (e *Entry) Infow(msg string, keyvals ...interface{}) {
+	e.logw(1, SeverityInfo, msg, keyvals)
+}
+
+// Warningw logs the message to the WARN and INFO logs with the alternating keys and values as
+// fields, see With.
+func (e *Entry) Warningw(msg string, keyvals ...interface{}) {
+	e.logw(1, SeverityWarning, msg, keyvals)
+}
+
+// Errorw logs the message to the ERROR, WARN, and INFO logs with the alternating keys and values
+// as fields, see With.
+func (e *Entry) Errorw(msg string, keyvals ...interface{}) {
+	e.logw(1, SeverityError, msg, keyvals)
+}
+
+// ErrorE logs the message to the ERROR, WARN, and INFO logs with the error, its chain and the
+// stack trace of the call site attached as by WithError, and the alternating keys and values as
+// fields, for example:
+//
+//	log.ErrorE(err, "failed to load config", "path", path)
+func (e *Entry) ErrorE(err error, msg string, keyvals ...interface{}) {
+	e.WithError(err).logw(1, SeverityError, msg, keyvals)
+}
+
+// logw logs the message verbatim with the keys and values as fields.
+func (e *Entry) logw(callDepth int, sev Severity, msg string, keyvals []interface{}) {
+	writeMessage(callDepth+1, sev, e.WithFields(keyvalFields(keyvals)), "%s", msg)
+	warnOddKeyvals(callDepth+1+e.getCallerSkip(), keyvals)
+}
+
+// keyvalFields turns alternating keys and values into fields. A key missing its value gets
+// MissingValue instead.
+func keyvalFields(keyvals []interface{}) Fields {
+	fields := make(Fields, len(keyvals)/2+1)
+	for i := 0; i < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		if i+1 < len(keyvals) {
+			fields[key] = keyvals[i+1]
+		} else {
+			fields[key] = MissingValue
+		}
+	}
+	return fields
+}
+
+// warnOddKeyvals logs a WARN message pointing at the call site if a key is missing its value,
+// once per call site.
+func warnOddKeyvals(callDepth int, keyvals []interface{}) {
+	if len(keyvals)%2 == 0 {
+		return
+	}
+	caller := getCallerInfo(callDepth + 1)
+	site := fmt.Sprintf("%s:%d", caller.FilePath, caller.LineNo)
+	if _, warned := warnedKeyvalSites.LoadOrStore(site, struct{}{}); warned {
+		return
+	}
+	writeMessage(callDepth+1, SeverityWarning, nil, "malformed log call at %s: key %v is missing its value", site, keyvals[len(keyvals)-1])
+}
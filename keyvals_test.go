@@ -0,0 +1,60 @@
+package log
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type KeyvalsSuite struct {
+}
+
+var _ = Suite(&KeyvalsSuite{})
+
+func (s *KeyvalsSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	resetKeyvalWarnings()
+}
+
+// resetKeyvalWarnings forgets the call sites warned about, which every test expects to be
+// warned about again however many times it runs.
+func resetKeyvalWarnings() {
+	warnedKeyvalSites = sync.Map{}
+}
+
+func (s *KeyvalsSuite) TestWith(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	With("user", "bob", "id", 42).Infof("hello %s", "world")
+	WithFields(Fields{"shard": "a"}).With("user", "bob").Warning("hello world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world id=42 user=bob\nWARN hello world shard=a user=bob\n")
+}
+
+func (s *KeyvalsSuite) TestLogw(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	Infow("hello 100%", "user", "bob")
+	With("shard", "a").Errorw("hello world", "id", 42)
+	c.Assert(logger.b.String(), Equals, "INFO hello 100% user=bob\nERROR hello world id=42 shard=a\n")
+	c.Assert(logger.caller.FuncName, Matches, ".*TestLogw")
+}
+
+func (s *KeyvalsSuite) TestOddKeyvals(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	for i := 0; i < 2; i++ {
+		Infow("hello world", "user", "bob", "id")
+	}
+	c.Assert(logger.b.String(), Matches, "INFO hello world id=\\(MISSING\\) user=bob\n"+
+		"WARN malformed log call at .*keyvals_test.go:[0-9]+: key id is missing its value\n"+
+		"INFO hello world id=\\(MISSING\\) user=bob\n")
+	c.Assert(logger.caller.FuncName, Matches, ".*TestOddKeyvals")
+
+	logger.b.Reset()
+	e := With("user")
+	c.Assert(e.Fields(), DeepEquals, Fields{"user": MissingValue})
+	c.Assert(logger.b.String(), Matches, "WARN malformed log call at .*keyvals_test.go:[0-9]+: key user is missing its value\n")
+}
@@ -1,19 +1,48 @@
 package log
 
 import (
+	"crypto/tls"
 	"fmt"
 	"io"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
-var loggers []Logger
+var (
+	// loggersMu guards the logger chain so it can be swapped while messages are logged.
+	loggersMu sync.RWMutex
+	loggers   []Logger
+
+	// loggerNames are the names loggers made by InitWithConfig were configured with.
+	loggerNames []namedLogger
+)
 
 // Supported log types.
 const (
-	Console = "console"
-	Syslog  = "syslog"
-	UDPLog  = "udplog"
+	Console  = "console"
+	Syslog   = "syslog"
+	UDPLog   = "udplog"
+	File     = "file"
+	Mirror   = "mirror"
+	GELF     = "gelf"
+	Journald = "journald"
+)
+
+// Supported line endings.
+const (
+	LineEndingLF   = "\n"
+	LineEndingCRLF = "\r\n"
+	LineEndingNone = ""
 )
 
+// lineEnding holds the line ending terminating every message written by stream-based loggers.
+var lineEnding atomic.Value
+
+func init() {
+	lineEnding.Store(LineEndingLF)
+}
+
 // Logger is an interface that should be implemented by all loggers wishing to participate
 // in the logger chain initialized by this package.
 type Logger interface {
@@ -30,6 +59,25 @@ type Logger interface {
 	FormatMessage(Severity, *CallerInfo, string, ...interface{}) string
 }
 
+// FieldFormatter is an optional interface implemented by loggers that render message fields
+// on their own. Fields of messages sent to other loggers are rendered as key=value pairs
+// following the message.
+type FieldFormatter interface {
+	// FormatMessageWithFields is like Logger.FormatMessage but also receives the message fields.
+	FormatMessageWithFields(Severity, *CallerInfo, Fields, string, ...interface{}) string
+}
+
+// LeveledLogger is an optional interface implemented by loggers whose severity can be looked up
+// and changed after they are made, for example through the control server, see ListenControl.
+type LeveledLogger interface {
+	// Severity returns the minimum severity the logger is logging messages at.
+	Severity() Severity
+
+	// SetSeverity changes the minimum severity the logger is logging messages at. It is safe
+	// to call it while messages are being logged.
+	SetSeverity(Severity)
+}
+
 // Config represents a configuration of an individual logger.
 type Config struct {
 	// Name is a logger's identificator used to instantiate a proper logger type
@@ -38,30 +86,264 @@ type Config struct {
 
 	// Severity indicates the minimum severity a logger will be logging messages at.
 	Severity string
+
+	// Overrides maps the names of components, see Named, to the severities the logger logs
+	// their messages at instead of Severity, e.g. {"storage": "debug"}, see
+	// SetComponentSeverity. Mirrored configs without overrides take the mirror's.
+	Overrides map[string]string
+
+	// LevelStyle is the style of severity names in messages formatted by the console and
+	// syslog loggers: "full" (default), "short3" or "char".
+	LevelStyle string
+
+	// SeverityMapper translates severities to the backend's numeric priorities.
+	// Leave empty to use the backend's default mapper.
+	SeverityMapper SeverityMapper
+
+	// Facility is the facility of the syslog logger's messages, e.g. "daemon" or "local0",
+	// it defaults to "mail". Tag is the name the messages are tagged with, it defaults to the
+	// program's name. The journald logger sends Tag as SYSLOG_IDENTIFIER.
+	Facility string
+	Tag      string
+
+	// Network and Address are where the syslog logger sends messages to. Leave them empty to
+	// log to the local syslog server, or set Address to host:port and Network to "udp"
+	// (default), "tcp" or "tls" to send RFC 5424 messages carrying the message fields as
+	// structured data, e.g. to a central rsyslog or Fluentd. TLSConfig configures "tls"
+	// connections, leave it nil to verify the server against the system roots.
+	//
+	// The udplog logger sends messages to Address over UDP, it defaults to the local udplog
+	// server at DefaultHost:DefaultPort.
+	//
+	// The gelf logger sends GELF messages to Address over Network, "udp" (default), "tcp" or
+	// "tls" with messages delimited by null bytes, it defaults to a local Graylog input at
+	// DefaultHost:DefaultGELFPort.
+	Network   string
+	Address   string
+	TLSConfig *tls.Config
+
+	// Encoding is the wire format of the udplog logger's messages: "udplog" (default)
+	// for udplog servers, "json" for the same JSON records without the category prefix, or
+	// "gelf" for GELF 1.1 messages, e.g. for Graylog.
+	Encoding string
+
+	// FlushInterval makes the udplog logger batch messages, sending them newline-separated
+	// in datagrams filled up to MaxDatagramSize bytes, at least every FlushInterval. GELF
+	// messages cannot be batched.
+	FlushInterval time.Duration
+
+	// MaxDatagramSize is the size of the largest datagram the udplog logger sends, 65507
+	// bytes (the largest UDP payload) by default. Longer messages are cut short, see
+	// TruncatedMarker. The gelf logger splits longer messages over UDP in up to 128
+	// chunks of MaxDatagramSize bytes, 8192 by default, cutting short those that do not fit.
+	MaxDatagramSize int
+
+	// OutputStream and ErrorStream are the streams the console logger writes messages below
+	// and at or above the SplitAt severity to: "stdout", "stderr" or "both". They default to
+	// "stdout" and "stderr" respectively. Leave SplitAt empty to write all messages to
+	// OutputStream, e.g. set it to "warn" to send warnings and errors to ErrorStream.
+	OutputStream string
+	ErrorStream  string
+	SplitAt      string
+
+	// Format is the format of messages written by the console and file loggers: "text"
+	// (default), "json" for one JSON object per line, "logfmt" for key=value pairs, see
+	// LogfmtFormatter, "pretty" for colored text with aligned columns and the fields one per
+	// line for viewing in a terminal or in less -R, see ForcePretty, or "template" for
+	// Template.
+	Format string
+
+	// Template makes the console and file loggers format messages with the text/template,
+	// e.g. "{{.Timestamp}} {{.Severity}} {{.File}}:{{.Line}} {{.Message}}", their timestamps
+	// in TimeLayout, see NewTemplateFormatter.
+	Template   string
+	TimeLayout string
+
+	// Formatter makes the console and file loggers format messages with it, overriding
+	// Format and Template.
+	Formatter Formatter
+
+	// KeyNames renames the standard keys of JSON messages, e.g. to "@timestamp" and "level",
+	// and sets whether the caller is split into file, func and line or combined as file:line.
+	KeyNames KeyNames
+
+	// Color turns on coloring the severities of text messages written by the console logger.
+	Color bool
+
+	// ForcePretty keeps the console logger writing messages in the pretty format when its
+	// streams are not terminals, e.g. when they are piped to a pager. Otherwise the console
+	// logger falls back to the text format, keeping machine-readable output in production.
+	ForcePretty bool
+
+	// Indent is the prefix the console and file loggers put in front of every line but the
+	// first of multi-line text messages, such as stack traces, so that they stand apart from
+	// the messages that follow. Leave it empty to keep continuation lines flush-left.
+	Indent string
+
+	// Path is the file the file logger appends messages to, and the socket the journald
+	// logger sends messages to, DefaultJournalSocket by default.
+	Path string
+
+	// MaxSize and MaxAge make the file logger rotate its file before it would grow beyond
+	// MaxSize bytes or once it has been written to for MaxAge, whichever comes first. The file
+	// is moved out of the way to path.1, older backups being shifted to path.2 and so on, and
+	// the logger goes on with a new file in its place without losing messages. Leave both
+	// zero to leave rotating the file to external tools, see Reopen.
+	MaxSize int64
+	MaxAge  time.Duration
+
+	// MaxBackups is the number of backups kept after rotations, the older ones being removed.
+	// Zero keeps them all. Compress gzips backups in the background, naming them path.1.gz and
+	// so on, the new backup staying path.1 until it has been compressed. It also makes the
+	// gelf logger gzip its messages over UDP.
+	MaxBackups int
+	Compress   bool
+
+	// Mirror lists the configs of the loggers a mirror logger sends every message to, each
+	// formatting it its own way, e.g. JSON to a file and colored text to the console.
+	// Mirrored configs without a severity take the mirror's.
+	Mirror []Config
+
+	// QueueSize makes the logger asynchronous: messages are queued, up to QueueSize of them,
+	// and written in the background, see AsyncLogger. Overflow is what happens to messages
+	// logged while the queue is full: "block" (default) waits for room in the queue,
+	// "drop-oldest" and "drop-newest" drop the oldest queued message or the new one. Leave
+	// QueueSize zero to write messages as they are logged.
+	QueueSize int
+	Overflow  string
+
+	// SpoolSize makes the logger retry the messages it fails to write, spooling up to
+	// SpoolSize bytes of them to disk until it recovers, see RetryLogger. RetryBackoff and
+	// RetryMaxBackoff are how long spooled messages wait before being written again, see
+	// RetryPolicy. Fallback lists the configs of the loggers sent the messages while they are
+	// spooled, e.g. a console logger, setting it without SpoolSize spools DefaultSpoolSize
+	// bytes. Fallback configs without a severity take the logger's. Leave SpoolSize zero and
+	// Fallback empty to let messages the logger fails to write go.
+	SpoolSize       int64
+	RetryBackoff    time.Duration
+	RetryMaxBackoff time.Duration
+	Fallback        []Config
 }
 
 // Init initializes the logging package with the provided loggers.
+//
+// Messages logged before the first call to Init are kept in a bounded buffer
+// and sent to the loggers it is called with.
 func Init(l ...Logger) {
-	for _, logger := range l {
-		loggers = append(loggers, logger)
-	}
+	loggersMu.Lock()
+	loggers = append(loggers, l...)
+	loggersMu.Unlock()
+
+	// writers logging what goes wrong while they write read the chain
+	bootstrap.flush(l)
 }
 
 // InitWithConfig instantiates loggers based on the provided configs and initializes
 // the package with them.
 func InitWithConfig(configs ...Config) error {
-	for _, config := range configs {
-		l, err := NewLogger(config)
+	_, err := initWithConfigs(configs, nil)
+	return err
+}
+
+// initWithConfigs is InitWithConfig, the errors of the loggers being prefixed with where they
+// are configured if where is not nil. It returns the loggers added to the chain.
+func initWithConfigs(configs []Config, where func(int) string) ([]Logger, error) {
+	var l []Logger
+	for i, config := range configs {
+		logger, err := NewLogger(config)
 		if err != nil {
-			return err
+			// keep the loggers that have been instantiated so far
+			Init(l...)
+			if where != nil {
+				return l, fmt.Errorf("%s: %v", where(i), err)
+			}
+			return l, err
 		}
-		loggers = append(loggers, l)
+		l = append(l, logger)
+
+		loggersMu.Lock()
+		loggerNames = append(loggerNames, namedLogger{config.Name, logger})
+		loggersMu.Unlock()
+	}
+	Init(l...)
+	return l, nil
+}
+
+// namedLogger is a logger along with the name it was configured with.
+type namedLogger struct {
+	name   string
+	logger Logger
+}
+
+// loggerName returns the name the logger was configured with or its type if it was not made
+// by InitWithConfig. It must be called with loggersMu held.
+func loggerName(l Logger) string {
+	for _, named := range loggerNames {
+		// loggers made by NewLogger are pointers so comparing them never panics
+		if named.logger == l {
+			return named.name
+		}
+	}
+	return fmt.Sprintf("%T", l)
+}
+
+// CaptureOutput temporarily routes all logging through a single console-style logger
+// writing messages of any severity to w. It is meant to be used in tests, for example:
+//
+//	defer log.CaptureOutput(&buf)()
+//
+// The returned function restores the previously initialized loggers.
+func CaptureOutput(w io.Writer) func() {
+	capture := &consoleLogger{writerLogger: &writerLogger{SeverityTrace, w}}
+
+	loggersMu.Lock()
+	saved := loggers
+	loggers = []Logger{capture}
+	loggersMu.Unlock()
+
+	return func() {
+		loggersMu.Lock()
+		loggers = saved
+		loggersMu.Unlock()
 	}
-	return nil
 }
 
 // NewLogger makes a proper logger from the given configuration.
 func NewLogger(config Config) (Logger, error) {
+	if config.QueueSize < 0 {
+		return nil, fmt.Errorf("queue size must not be negative: %d", config.QueueSize)
+	}
+	if err := validateOverflow(config.Overflow); err != nil {
+		return nil, err
+	}
+
+	overrides := make(map[string]Severity, len(config.Overrides))
+	for component, name := range config.Overrides {
+		sev, err := severityFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("component %s: %v", component, err)
+		}
+		overrides[component] = sev
+	}
+
+	l, err := newLogger(config)
+	if err == nil && (config.SpoolSize != 0 || len(config.Fallback) > 0) {
+		l, err = newRetryLogger(l, config)
+	}
+	if err == nil && config.QueueSize > 0 {
+		l, err = NewAsyncLogger(l, config.QueueSize, config.Overflow)
+	}
+	if err != nil {
+		return nil, err
+	}
+	for component, sev := range overrides {
+		SetComponentSeverity(l, component, sev)
+	}
+	return l, nil
+}
+
+// newLogger makes the logger of the type named in the configuration.
+func newLogger(config Config) (Logger, error) {
 	switch config.Name {
 	case Console:
 		return NewConsoleLogger(config)
@@ -69,42 +351,351 @@ func NewLogger(config Config) (Logger, error) {
 		return NewSysLogger(config)
 	case UDPLog:
 		return NewUDPLogger(config)
+	case File:
+		return NewFileLogger(config)
+	case GELF:
+		return NewGELFLogger(config)
+	case Journald:
+		return NewJournaldLogger(config)
+	case Mirror:
+		return NewMirrorLogger(config)
+	}
+
+	loggerTypesMu.RLock()
+	newLogger, ok := loggerTypes[config.Name]
+	loggerTypesMu.RUnlock()
+	if ok {
+		return newLogger(config)
 	}
 	return nil, fmt.Errorf("unknown logger: %v", config)
 }
 
+var (
+	loggerTypesMu sync.RWMutex
+	loggerTypes   = map[string]func(Config) (Logger, error){}
+)
+
+// RegisterLogger makes NewLogger and InitWithConfig make loggers of the type named name with
+// newLogger, so that packages kept apart from this one, such as otellog, provide loggers that
+// can be configured like the others. Registering a name twice, or that of a built-in type,
+// panics.
+func RegisterLogger(name string, newLogger func(Config) (Logger, error)) {
+	loggerTypesMu.Lock()
+	defer loggerTypesMu.Unlock()
+
+	switch name {
+	case Console, Syslog, UDPLog, File, GELF, Journald, Mirror:
+		panic(fmt.Sprintf("log: cannot register the built-in %s logger", name))
+	}
+	if _, ok := loggerTypes[name]; ok {
+		panic(fmt.Sprintf("log: %s logger registered twice", name))
+	}
+	loggerTypes[name] = newLogger
+}
+
+// SetLineEnding sets the line ending appended to messages by stream-based loggers
+// such as the console logger.
+//
+// Message-framed loggers (syslog, udplog) are not affected since every message
+// already travels in its own frame.
+func SetLineEnding(ending string) error {
+	switch ending {
+	case LineEndingLF, LineEndingCRLF, LineEndingNone:
+		lineEnding.Store(ending)
+		return nil
+	}
+	return fmt.Errorf("unsupported line ending: %q", ending)
+}
+
+// currentLineEnding returns the line ending appended to messages, see SetLineEnding.
+func currentLineEnding() string {
+	return lineEnding.Load().(string)
+}
+
+// Tracef logs to the TRACE log.
+func Tracef(format string, args ...interface{}) {
+	writeMessage(1, SeverityTrace, nil, format, args...)
+}
+
 // Debugf logs to the DEBUG log.
 func Debugf(format string, args ...interface{}) {
-	for _, logger := range loggers {
-		writeMessage(logger, 1, SeverityDebug, format, args...)
-	}
+	writeMessage(1, SeverityDebug, nil, format, args...)
 }
 
 // Infof logs to the INFO log.
 func Infof(format string, args ...interface{}) {
-	for _, logger := range loggers {
-		writeMessage(logger, 1, SeverityInfo, format, args...)
-	}
+	writeMessage(1, SeverityInfo, nil, format, args...)
 }
 
 // Warningf logs to the WARN and INFO logs.
 func Warningf(format string, args ...interface{}) {
-	for _, logger := range loggers {
-		writeMessage(logger, 1, SeverityWarning, format, args...)
-	}
+	writeMessage(1, SeverityWarning, nil, format, args...)
 }
 
 // Errorf logs to the ERROR, WARN, and INFO logs.
 func Errorf(format string, args ...interface{}) {
-	for _, logger := range loggers {
-		writeMessage(logger, 1, SeverityError, format, args...)
+	writeMessage(1, SeverityError, nil, format, args...)
+}
+
+// Fatalf logs to the FATAL, ERROR, WARN, and INFO logs along with stack traces
+// (see SetFatalStackMode) and exits the program with status 255, see Exit.
+func Fatalf(format string, args ...interface{}) {
+	format, args = withStackTraces(1, format, args)
+	writeMessage(1, SeverityFatal, nil, format, args...)
+	exitFatal()
+}
+
+// Trace logs to the TRACE log. Arguments are handled in the manner of fmt.Sprint, so the
+// message is logged verbatim without format parsing.
+func Trace(args ...interface{}) {
+	writeMessage(1, SeverityTrace, nil, "%s", newSprintArgs(args))
+}
+
+// Debug logs to the DEBUG log. Arguments are handled in the manner of fmt.Sprint, so the
+// message is logged verbatim without format parsing.
+func Debug(args ...interface{}) {
+	writeMessage(1, SeverityDebug, nil, "%s", newSprintArgs(args))
+}
+
+// Info logs to the INFO log. Arguments are handled in the manner of fmt.Sprint.
+func Info(args ...interface{}) {
+	writeMessage(1, SeverityInfo, nil, "%s", newSprintArgs(args))
+}
+
+// Warning logs to the WARN and INFO logs. Arguments are handled in the manner of fmt.Sprint.
+func Warning(args ...interface{}) {
+	writeMessage(1, SeverityWarning, nil, "%s", newSprintArgs(args))
+}
+
+// Error logs to the ERROR, WARN, and INFO logs. Arguments are handled in the manner of fmt.Sprint.
+func Error(args ...interface{}) {
+	writeMessage(1, SeverityError, nil, "%s", newSprintArgs(args))
+}
+
+// sprintArgs renders the arguments of Info and the like in the manner of fmt.Sprint when the
+// message is first formatted, sparing the work for messages no logger writes.
+type sprintArgs struct {
+	args []interface{}
+
+	once sync.Once
+	text string
+}
+
+func newSprintArgs(args []interface{}) *sprintArgs {
+	return &sprintArgs{args: args}
+}
+
+func (a *sprintArgs) Format(f fmt.State, verb rune) {
+	io.WriteString(f, a.String())
+}
+
+func (a *sprintArgs) String() string {
+	a.once.Do(func() {
+		a.text = fmt.Sprint(a.args...)
+	})
+	return a.text
+}
+
+// Fatal logs to the FATAL, ERROR, WARN, and INFO logs along with stack traces
+// (see SetFatalStackMode) and exits the program with status 255, see Exit.
+// Arguments are handled in the manner of fmt.Sprint.
+func Fatal(args ...interface{}) {
+	format, args := withStackTraces(1, "%s", []interface{}{fmt.Sprint(args...)})
+	writeMessage(1, SeverityFatal, nil, format, args...)
+	exitFatal()
+}
+
+// Panicf logs to the PANIC log, flushes the loggers, see Flush, and panics with the message.
+func Panicf(format string, args ...interface{}) {
+	writeMessage(1, SeverityPanic, nil, format, args...)
+	panicWith(format, args...)
+}
+
+// Panic logs to the PANIC log, flushes the loggers, see Flush, and panics with the message.
+// Arguments are handled in the manner of fmt.Sprint.
+func Panic(args ...interface{}) {
+	message := fmt.Sprint(args...)
+	writeMessage(1, SeverityPanic, nil, "%s", message)
+	panicWith("%s", message)
+}
+
+// Output logs the message at the provided severity. Calldepth is the number of stack frames
+// to skip when determining the caller, 1 being the caller of Output, which lets packages wrapping
+// this one report their own callers. A FATAL message is logged along with stack traces and
+// exits the program like Fatalf does, a PANIC message panics like Panicf does.
+func Output(calldepth int, sev Severity, format string, args ...interface{}) {
+	output(calldepth+1, nil, sev, format, args...)
+}
+
+// Output logs the message at the provided severity with the fields of the entry, like the
+// package-level Output does.
+func (e *Entry) Output(calldepth int, sev Severity, format string, args ...interface{}) {
+	output(calldepth+1, e, sev, format, args...)
+}
+
+// output logs the message through the entry at the provided severity, exiting the program
+// after FATAL messages and panicking after PANIC ones.
+func output(callDepth int, e *Entry, sev Severity, format string, args ...interface{}) {
+	switch {
+	case sev == SeverityPanic:
+		writeMessage(callDepth, sev, e, format, args...)
+		panicWith(format, args...)
+	case sev >= SeverityFatal:
+		format, args = withStackTraces(callDepth, format, args)
+		writeMessage(callDepth, sev, e, format, args...)
+		exitFatal()
+	default:
+		writeMessage(callDepth, sev, e, format, args...)
+	}
+}
+
+// panicWith makes the loggers write out what they buffer, then panics with the message.
+func panicWith(format string, args ...interface{}) {
+	Flush()
+	panic(fmt.Sprintf(format, args...))
+}
+
+// writeMessage sends the message along with the fields of the entry it is logged through,
+// if any, to every logger in the chain that is configured to log at the provided severity.
+func writeMessage(callDepth int, sev Severity, e *Entry, format string, args ...interface{}) {
+	if e.drops(sev) {
+		return
+	}
+	if reentered() {
+		writeFallback(sev, e, format, args...)
+		return
+	}
+	if discardable(sev, e) {
+		// no logger would write the message, spare the work of getting it to them
+		raiseHighestSeverity(sev)
+		return
+	}
+	if e = e.scoped(); e.drops(sev) {
+		return
+	}
+
+	sev, fields := escalations.escalate(sev, e.getFields(), format)
+	raiseHighestSeverity(sev)
+
+	if rateLimited(sev) {
+		return
+	}
+
+	// every logger gets the same caller and time so that they agree on them
+	skip := e.getCallerSkip()
+	caller := messageCaller(callDepth + 1 + skip)
+	if sampled(sev, caller, format) {
+		return
+	}
+	t, fields := messageTimeOf(e, fields)
+	if e.stacked(sev) {
+		fields = mergeFields(Fields{StackField: callerStack(callDepth + 1 + skip)}, fields)
+	}
+	fields = withSequence(withProcessFields(truncateFields(expandLoggables(fields))))
+	lazy := newLazyMessage(format, truncateArgs(format, expandLoggableArgs(format, args)))
+	problem := formatProblem(lazy)
+	fields, m := redact(fields, lazy)
+
+	epoch := beginSend()
+	loggersMu.RLock()
+	chain := loggers
+	if len(chain) == 0 {
+		// keep the message until the package is initialized
+		bootstrap.add(bufferedMessage{sev, caller, t, e, fields, m.text()})
+	}
+	loggersMu.RUnlock()
+
+	var warnings []string
+	guardSending(func() {
+		defer endSend(epoch)
+		warnings = runHooks(sev, caller, t, fields, m)
+		sendMessage(chain, sev, caller, t, e, fields, m)
+		warnings = append(warnings, runCallbacks(sev, m)...)
+	})
+	lazy.release()
+
+	for _, warning := range warnings {
+		writeMessage(callDepth+1+skip, SeverityWarning, nil, "%s", warning)
+	}
+
+	if problem != "" {
+		writeMessage(callDepth+1, SeverityWarning, e, "malformed log call at %s:%d: %s", caller.FilePath, caller.LineNo, problem)
+	}
+}
+
+// sendMessage sends the message logged at t to every logger that is configured to log at the
+// provided severity, each of them formatting it its own way.
+func sendMessage(chain []Logger, sev Severity, caller *CallerInfo, t time.Time, e *Entry, fields Fields, m *lazyMessage) {
+	for _, logger := range chain {
+		sendMessageTo(logger, logger, sev, caller, t, e, fields, m)
+	}
+}
+
+// sendMessageTo sends the message to the logger, or to the loggers it wraps, of the chain
+// the top logger is part of, which write hooks are told the writes are to.
+func sendMessageTo(top, logger Logger, sev Severity, caller *CallerInfo, t time.Time, e *Entry, fields Fields, m *lazyMessage) {
+	if multi, ok := logger.(*MultiLogger); ok {
+		// let every logger format the message its own way
+		for _, l := range multi.loggers {
+			sendMessageTo(top, l, sev, caller, t, e, fields, m)
+		}
+		return
+	}
+	if f, ok := logger.(*FailoverLogger); ok {
+		f.send(top, e, sev, caller, t, fields, m)
+		return
+	}
+	if r, ok := logger.(*RetryLogger); ok {
+		r.send(top, e, sev, caller, t, fields, m)
+		return
+	}
+	if w := e.writer(logger, sev); w != nil {
+		_, err := io.WriteString(w, formatLazyMessage(logger, sev, caller, t, fields, m))
+		runWriteHooks(top, sev, err)
+	}
+}
+
+// formatMessage lets the logger format the message with the fields, rendering the fields
+// after the message for loggers that are neither RecordFormatters nor FieldFormatters.
+func formatMessage(logger Logger, sev Severity, caller *CallerInfo, t time.Time, fields Fields, format string, args ...interface{}) string {
+	return formatLazyMessage(logger, sev, caller, t, fields, &lazyMessage{format: format, args: args})
+}
+
+// timedFormatter is implemented by the loggers of the package that format messages with the
+// time they were logged at but are not RecordFormatters, such as the loggers wrapping others.
+type timedFormatter interface {
+	// formatTimed formats the message logged at t.
+	formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string
+}
+
+// formatLazyMessage is like formatMessage, RecordFormatters getting the text of the message
+// formatted for the others before them.
+func formatLazyMessage(logger Logger, sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	if f, ok := logger.(timedFormatter); ok {
+		return f.formatTimed(sev, caller, t, fields, m)
 	}
+	if f, ok := logger.(RecordFormatter); ok {
+		return f.FormatRecord(newRecord(sev, caller, t, fields, m.text()))
+	}
+	if f, ok := logger.(FieldFormatter); ok {
+		return f.FormatMessageWithFields(sev, caller, fields, m.format, m.args...)
+	}
+	if len(fields) == 0 {
+		return logger.FormatMessage(sev, caller, m.format, m.args...)
+	}
+	return logger.FormatMessage(sev, caller, "%s %s", m.text(), formatFields(fields))
 }
 
-func writeMessage(logger Logger, callDepth int, sev Severity, format string, args ...interface{}) {
-	caller := getCallerInfo(callDepth + 1)
-	if w := logger.Writer(sev); w != nil {
-		message := logger.FormatMessage(sev, caller, format, args...)
-		io.WriteString(w, message)
+// setFlag atomically sets or clears the flag.
+func setFlag(flag *int32, set bool) {
+	var v int32
+	if set {
+		v = 1
 	}
+	atomic.StoreInt32(flag, v)
+}
+
+// flagSet atomically checks whether the flag is set.
+func flagSet(flag *int32) bool {
+	return atomic.LoadInt32(flag) != 0
 }
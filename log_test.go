@@ -4,7 +4,9 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
 
 	. "gopkg.in/check.v1"
@@ -19,6 +21,7 @@ var _ = Suite(&LogSuite{})
 func (s *LogSuite) SetUpTest(c *C) {
 	// reset global loggers chain before every test
 	loggers = []Logger{}
+	ResetHighestSeverity()
 }
 
 func (s *LogSuite) TestInit(c *C) {
@@ -29,30 +32,72 @@ func (s *LogSuite) TestInit(c *C) {
 }
 
 func (s *LogSuite) TestInitWithConfig(c *C) {
-	InitWithConfig(Config{Console, "info"}, Config{Syslog, "info"})
+	InitWithConfig(Config{Name: Console, Severity: "info"}, Config{Name: Syslog, Severity: "info"})
 	c.Assert(len(loggers), Equals, 2)
 	c.Assert(typeOf(loggers[0]), Equals, "*log.consoleLogger")
 	c.Assert(typeOf(loggers[1]), Equals, "*log.sysLogger")
 }
 
 func (s *LogSuite) TestNewLogger(c *C) {
-	l, err := NewLogger(Config{Console, "info"})
+	l, err := NewLogger(Config{Name: Console, Severity: "info"})
 	c.Assert(err, IsNil)
 	c.Assert(typeOf(l), Equals, "*log.consoleLogger")
 
-	l, err = NewLogger(Config{Syslog, "warn"})
+	l, err = NewLogger(Config{Name: Syslog, Severity: "warn"})
 	c.Assert(err, IsNil)
 	c.Assert(typeOf(l), Equals, "*log.sysLogger")
 
-	l, err = NewLogger(Config{UDPLog, "error"})
+	l, err = NewLogger(Config{Name: UDPLog, Severity: "error"})
 	c.Assert(err, IsNil)
 	c.Assert(typeOf(l), Equals, "*log.udpLogger")
 
-	l, err = NewLogger(Config{"SuperDuperLogger", "info"})
+	l, err = NewLogger(Config{Name: "SuperDuperLogger", Severity: "info"})
 	c.Assert(err, NotNil)
 	c.Assert(l, IsNil)
 }
 
+func (s *LogSuite) TestRegisterLogger(c *C) {
+	defer delete(loggerTypes, "test")
+	RegisterLogger("test", func(conf Config) (Logger, error) {
+		return newTestLogger(conf.Tag), nil
+	})
+
+	l, err := NewLogger(Config{Name: "test", Severity: "info", Tag: "registered"})
+	c.Assert(err, IsNil)
+	c.Assert(l.(*testLogger).id, Equals, "registered")
+
+	c.Assert(func() { RegisterLogger("test", nil) }, PanicMatches, "log: test logger registered twice")
+	c.Assert(func() { RegisterLogger(Console, nil) }, PanicMatches, "log: cannot register the built-in console logger")
+}
+
+func (s *LogSuite) TestTracef(c *C) {
+	all := newTestLogger("all")
+	debug := newThresholdLogger("debug", SeverityDebug)
+	Init(all, debug)
+
+	Tracef("hello %s", "world")
+	Trace("hello ", "trace")
+	WithFields(Fields{"user": "bob"}).Tracef("hello %s", "entry")
+	Tracew("hello keyvals", "user", "bob")
+	c.Assert(all.b.String(), Equals, "TRACE hello world\nTRACE hello trace\nTRACE hello entry user=bob\nTRACE hello keyvals user=bob\n")
+	c.Assert(debug.b.String(), Equals, "")
+}
+
+func (s *LogSuite) TestPanicf(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	for _, panicking := range []func(){
+		func() { Panicf("hello %s", "panic") },
+		func() { WithFields(Fields{"user": "bob"}).Panic("hello ", "panic") },
+		func() { Output(1, SeverityPanic, "hello %s", "panic") },
+	} {
+		c.Assert(panicking, PanicMatches, "hello panic")
+	}
+	c.Assert(logger.b.String(), Equals, "PANIC hello panic\nPANIC hello panic user=bob\nPANIC hello panic\n")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestPanicf.func3"), Equals, true)
+}
+
 func (s *LogSuite) TestDebugf(c *C) {
 	logger1 := newTestLogger("log1")
 	logger2 := newTestLogger("log2")
@@ -93,6 +138,203 @@ func (s *LogSuite) TestErrorf(c *C) {
 	c.Assert(logger2.b.String(), Equals, "ERROR hello world\n")
 }
 
+func (s *LogSuite) TestFatalf(c *C) {
+	defer func() { exit = os.Exit }()
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+
+	logger1 := newTestLogger("log1")
+	logger2 := newTestLogger("log2")
+	Init(logger1, logger2)
+
+	Fatalf("hello %s", "world")
+	c.Assert(strings.HasPrefix(logger1.b.String(), "FATAL hello world\ngoroutine "), Equals, true)
+	c.Assert(strings.Contains(logger1.b.String(), "TestFatalf"), Equals, true)
+	c.Assert(logger2.b.String(), Equals, logger1.b.String())
+	c.Assert(exitCode, Equals, 255)
+}
+
+func (s *LogSuite) TestSeverityOff(c *C) {
+	off := &bytes.Buffer{}
+	sibling := newTestLogger("log")
+	Init(&consoleLogger{writerLogger: &writerLogger{SeverityOff, off}}, sibling)
+
+	Debugf("hello %s", "world")
+	Infof("hello %s", "world")
+	Warningf("hello %s", "world")
+	Errorf("hello %s", "world")
+
+	c.Assert(off.Len(), Equals, 0)
+	c.Assert(sibling.b.String(), Equals, "DEBUG hello world\nINFO hello world\nWARN hello world\nERROR hello world\n")
+}
+
+func (s *LogSuite) TestPrint(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	Debug("100% of ", "/path?q=%20")
+	c.Assert(logger.b.String(), Equals, "DEBUG 100% of /path?q=%20\n")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestPrint"), Equals, true)
+
+	logger.b.Reset()
+	query := "SELECT * FROM t WHERE name LIKE '%foo%'"
+	Info(query)
+	c.Assert(logger.b.String(), Equals, "INFO SELECT * FROM t WHERE name LIKE '%foo%'\n")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestPrint"), Equals, true)
+
+	logger.b.Reset()
+	Warning("disk ", 95, "% full")
+	c.Assert(logger.b.String(), Equals, "WARN disk 95% full\n")
+
+	logger.b.Reset()
+	verbs := "%s %d"
+	Error(verbs)
+	c.Assert(logger.b.String(), Equals, "ERROR %s %d\n")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestPrint"), Equals, true)
+}
+
+func (s *LogSuite) TestOutput(c *C) {
+	defer func() { exit = os.Exit }()
+	exitCode := -1
+	exit = func(code int) { exitCode = code }
+
+	logger := newTestLogger("log")
+	Init(logger)
+
+	func() { Output(2, SeverityWarning, "hello %s", "world") }()
+	c.Assert(logger.b.String(), Equals, "WARN hello world\n")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestOutput"), Equals, true)
+	c.Assert(exitCode, Equals, -1)
+
+	logger.b.Reset()
+	Output(1, SeverityFatal, "hello %s", "world")
+	c.Assert(strings.HasPrefix(logger.b.String(), "FATAL hello world\ngoroutine "), Equals, true)
+	c.Assert(exitCode, Equals, 255)
+}
+
+// wrappedInfof stands for the logging function of a package wrapping this one.
+func wrappedInfof(e *Entry, format string, args ...interface{}) {
+	e.Infof(format, args...)
+}
+
+func (s *LogSuite) TestWithCallerSkip(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	wrappedInfof(WithCallerSkip(1), "hello %s", "world")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestWithCallerSkip"), Equals, true)
+
+	// children of the entry keep skipping, nested wrappers add up
+	entry := WithCallerSkip(1).WithFields(Fields{"user": "bob"})
+	func() { wrappedInfof(entry.WithCallerSkip(1), "hello %s", "world") }()
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestWithCallerSkip"), Equals, true)
+	c.Assert(logger.b.String(), Equals, "INFO hello world\nINFO hello world user=bob\n")
+
+	wrappedInfof(WithCallerSkip(1).WithCallerSkip(-5), "hello %s", "world")
+	c.Assert(logger.caller.FuncName, Matches, ".*wrappedInfof")
+}
+
+func (s *LogSuite) TestHighestSeverity(c *C) {
+	Init(newTestLogger("log"))
+	c.Assert(HighestSeverity(), Equals, SeverityTrace)
+
+	Debugf("hello %s", "world")
+	c.Assert(HighestSeverity(), Equals, SeverityDebug)
+
+	Infof("hello %s", "world")
+	c.Assert(HighestSeverity(), Equals, SeverityInfo)
+
+	Errorf("hello %s", "world")
+	Debugf("hello %s", "world")
+	Warningf("hello %s", "world")
+	c.Assert(HighestSeverity(), Equals, SeverityError)
+
+	ResetHighestSeverity()
+	c.Assert(HighestSeverity(), Equals, SeverityTrace)
+
+	Warningf("hello %s", "world")
+	c.Assert(HighestSeverity(), Equals, SeverityWarning)
+}
+
+func (s *LogSuite) TestEnabled(c *C) {
+	Init(newThresholdLogger("log", SeverityInfo))
+
+	c.Assert(Enabled(SeverityDebug), Equals, false)
+	c.Assert(Enabled(SeverityInfo), Equals, true)
+	c.Assert(WithFields(nil).WithSeverityOverride(SeverityDebug).Enabled(SeverityDebug), Equals, true)
+	c.Assert(WithFields(nil).WithMinSeverity(SeverityError).Enabled(SeverityWarning), Equals, false)
+
+	// the arguments of discarded messages are not rendered
+	calls := 0
+	arg := countedStringer{&calls}
+	Debug("hello ", arg)
+	WithFields(Fields{"user": "bob"}).Debug("hello ", arg)
+	c.Assert(calls, Equals, 0)
+	Info("hello ", arg)
+	c.Assert(calls, Equals, 1)
+}
+
+func (s *LogSuite) TestDiscardable(c *C) {
+	memory, err := NewMemoryLogger(Config{Severity: "info"})
+	c.Assert(err, IsNil)
+	sampled := NewSampledLogger(memory, 2)
+	Init(NewMultiLogger(sampled))
+
+	c.Assert(discardable(SeverityDebug, nil), Equals, true)
+	c.Assert(discardable(SeverityInfo, nil), Equals, false)
+	c.Assert(discardable(SeverityDebug, WithFields(nil).WithSeverityOverride(SeverityDebug)), Equals, false)
+
+	// telling does not count the messages of sampled loggers
+	Debugf("dropped")
+	Infof("first")
+	Infof("second")
+	Infof("third")
+	c.Assert(len(memory.Messages()), Equals, 2)
+	c.Assert(sampled.Dropped(), Equals, uint64(1))
+
+	// nor does it hide messages from hooks
+	var fired []string
+	AddHook(HookFunc(func(r Record) error {
+		fired = append(fired, r.Message)
+		return nil
+	}), SeverityDebug)
+	defer func() { hooks = nil }()
+	c.Assert(discardable(SeverityDebug, nil), Equals, false)
+	Debugf("hooked")
+	c.Assert(fired, DeepEquals, []string{"hooked"})
+}
+
+func (s *LogSuite) TestCaptureOutput(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	b := &bytes.Buffer{}
+	restore := CaptureOutput(b)
+	c.Assert(len(loggers), Equals, 1)
+
+	Debugf("hello %s", "captured")
+	c.Assert(strings.Contains(b.String(), "DEBUG"), Equals, true)
+	c.Assert(strings.HasSuffix(b.String(), "hello captured\n"), Equals, true)
+	c.Assert(logger.b.Len(), Equals, 0)
+
+	restore()
+	c.Assert(loggers, DeepEquals, []Logger{logger})
+
+	Infof("hello %s", "restored")
+	c.Assert(strings.Contains(b.String(), "restored"), Equals, false)
+	c.Assert(logger.b.String(), Equals, "INFO hello restored\n")
+}
+
+func (s *LogSuite) TestSetLineEnding(c *C) {
+	defer SetLineEnding(LineEndingLF)
+
+	c.Assert(SetLineEnding(LineEndingCRLF), IsNil)
+	c.Assert(currentLineEnding(), Equals, LineEndingCRLF)
+
+	c.Assert(SetLineEnding("\n\r"), NotNil)
+	c.Assert(currentLineEnding(), Equals, LineEndingCRLF)
+}
+
 func typeOf(o interface{}) string {
 	return reflect.TypeOf(o).String()
 }
@@ -101,10 +343,12 @@ func typeOf(o interface{}) string {
 type testLogger struct {
 	id string
 	b  *bytes.Buffer
+
+	caller *CallerInfo // caller of the last formatted message
 }
 
 func newTestLogger(id string) *testLogger {
-	return &testLogger{id, &bytes.Buffer{}}
+	return &testLogger{id: id, b: &bytes.Buffer{}}
 }
 
 func (l *testLogger) Writer(sev Severity) io.Writer {
@@ -112,5 +356,24 @@ func (l *testLogger) Writer(sev Severity) io.Writer {
 }
 
 func (l *testLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	l.caller = caller
 	return fmt.Sprintf("%s %s\n", sev, fmt.Sprintf(format, args...))
 }
+
+// thresholdLogger is a testLogger that logs only at and above its severity.
+type thresholdLogger struct {
+	*testLogger
+
+	sev Severity
+}
+
+func newThresholdLogger(id string, sev Severity) *thresholdLogger {
+	return &thresholdLogger{newTestLogger(id), sev}
+}
+
+func (l *thresholdLogger) Writer(sev Severity) io.Writer {
+	if sev >= l.sev {
+		return l.b
+	}
+	return nil
+}
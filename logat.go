@@ -0,0 +1,52 @@
+package log
+
+import (
+	"time"
+)
+
+// TimeField is the name of the field overriding the time of the messages it is attached to,
+// for events logged after the fact, e.g. when replaying or forwarding them. Only time.Time
+// values override the time, others are logged as any other field. The field itself is not
+// logged.
+const TimeField = "event_time"
+
+// LogAt logs the message at the provided severity as if it was logged at t rather than now,
+// see Entry.At. A FATAL message is logged along with stack traces and exits the program like
+// Fatalf does, a PANIC message panics like Panicf does.
+func LogAt(t time.Time, sev Severity, format string, args ...interface{}) {
+	output(2, (&Entry{}).At(t), sev, format, args...)
+}
+
+// At returns a new entry logging its messages as if they were logged at t rather than now.
+// The loggers render t as they would the current time. Sequence numbers and the uptime, see
+// SetIncludeSequence and SetIncludeUptime, still reflect when the messages are actually
+// logged, so that their true order can be told apart from the supplied times.
+func (e *Entry) At(t time.Time) *Entry {
+	child := *e
+	child.at = t
+	return &child
+}
+
+// LogAt logs the message at the provided severity as if it was logged at t rather than now,
+// see At.
+func (e *Entry) LogAt(t time.Time, sev Severity, format string, args ...interface{}) {
+	output(2, e.At(t), sev, format, args...)
+}
+
+// messageTimeOf returns the time the message is logged at: the one of the TimeField field,
+// which is removed from the fields, or else the one supplied to the entry, or else now.
+func messageTimeOf(e *Entry, fields Fields) (time.Time, Fields) {
+	if t, ok := fields[TimeField].(time.Time); ok {
+		rest := make(Fields, len(fields)-1)
+		for k, v := range fields {
+			if k != TimeField {
+				rest[k] = v
+			}
+		}
+		return t, rest
+	}
+	if e != nil && !e.at.IsZero() {
+		return e.at, fields
+	}
+	return now(), fields
+}
@@ -0,0 +1,94 @@
+package log
+
+// Loggable is implemented by types choosing what of them gets logged, e.g. a user logged as
+// its ID and role rather than as every field of the struct.
+//
+// A field value implementing Loggable is expanded into its fields, each named after the
+// field and its own key joined with a dot, so that the value { "id": 7 } of the "user" field
+// is logged as the "user.id" field. A format argument implementing Loggable is rendered as
+// its fields in the key=value form. The expanded fields are subject to the same rules as the
+// other fields, e.g. truncation, see SetMaxArgBytes.
+type Loggable interface {
+	LogFields() Fields
+}
+
+// maxLoggableDepth is how deep Loggable values whose fields are Loggable are expanded, it
+// keeps values whose fields refer back to them from being expanded forever.
+const maxLoggableDepth = 8
+
+// expandLoggables returns the fields with the Loggable values replaced by their fields.
+func expandLoggables(fields Fields) Fields {
+	expanded := false
+	for _, v := range fields {
+		if _, ok := v.(Loggable); ok {
+			expanded = true
+			break
+		}
+	}
+	if !expanded {
+		return fields
+	}
+
+	result := make(Fields, len(fields))
+	for k, v := range fields {
+		expandLoggable(result, k, v, 0)
+	}
+	return result
+}
+
+// expandLoggable adds the value to the fields under the key, expanding it if it is Loggable.
+// Fields of the value give way to fields of the message on collisions.
+func expandLoggable(fields Fields, key string, v interface{}, depth int) {
+	l, ok := v.(Loggable)
+	if !ok || depth == maxLoggableDepth {
+		fields[key] = v
+		return
+	}
+	for k, v := range l.LogFields() {
+		k = key + "." + k
+		if _, taken := fields[k]; !taken {
+			expandLoggable(fields, k, v, depth+1)
+		}
+	}
+}
+
+// expandLoggableArgs returns the arguments with the Loggable ones wrapped to be rendered as
+// their fields. Arguments of %T and %p, which would see the wrapper instead of the argument,
+// are left untouched.
+func expandLoggableArgs(format string, args []interface{}) []interface{} {
+	var expanded []interface{}
+	var verbs []byte
+	for i, arg := range args {
+		l, ok := arg.(Loggable)
+		if !ok {
+			continue
+		}
+		if verbs == nil {
+			verbs, _ = argVerbs(nil, format)
+		}
+		if i < len(verbs) && (verbs[i] == 'T' || verbs[i] == 'p') {
+			continue
+		}
+		if expanded == nil {
+			expanded = append([]interface{}(nil), args...)
+		}
+		expanded[i] = loggableArg{l}
+	}
+	if expanded == nil {
+		return args
+	}
+	return expanded
+}
+
+// loggableArg renders a Loggable argument as its expanded fields.
+type loggableArg struct {
+	l Loggable
+}
+
+func (a loggableArg) String() string {
+	fields := expandLoggables(a.l.LogFields())
+	if r := currentRedaction(); r != nil {
+		fields, _ = r.redactFields(fields)
+	}
+	return formatFields(fields)
+}
@@ -0,0 +1,75 @@
+package log
+
+import (
+	"bytes"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type LoggableSuite struct {
+}
+
+var _ = Suite(&LoggableSuite{})
+
+func (s *LoggableSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *LoggableSuite) TearDownTest(c *C) {
+	SetMaxArgBytes(0)
+	SetClock(nil)
+}
+
+type user struct {
+	id       int
+	name     string
+	password string
+	org      org
+}
+
+func (u user) LogFields() Fields {
+	return Fields{"id": u.id, "name": u.name, "org": u.org}
+}
+
+type org struct {
+	name string
+}
+
+func (o org) LogFields() Fields {
+	return Fields{"name": o.name}
+}
+
+func (s *LoggableSuite) TestJSON(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+	var out bytes.Buffer
+	defer func() { stdout = os.Stdout }()
+	stdout = &out
+
+	l, err := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON})
+	c.Assert(err, IsNil)
+	Init(l)
+
+	u := user{id: 7, name: "bob", password: "secret", org: org{"acme"}}
+	WithFields(Fields{"user": u, "user.name": "kept"}).Info("signed in")
+	c.Assert(out.String(), Matches, `\{"timestamp":"2014-03-05T07:09:11Z","severity":"INFO","message":"signed in",`+
+		`"file":"loggable_test.go","func":"[^"]*TestJSON","line":[0-9]+,"user.id":7,"user.name":"kept","user.org.name":"acme"\}`+"\n")
+}
+
+func (s *LoggableSuite) TestArgs(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	u := user{id: 7, name: "bob", password: "secret", org: org{"acme"}}
+	Infof("%v signed in as %T", u, u)
+	c.Assert(logger.b.String(), Equals, "INFO id=7 name=bob org.name=acme signed in as log.user\n")
+}
+
+func (s *LoggableSuite) TestTruncated(c *C) {
+	SetMaxArgBytes(4)
+	logger := newTestLogger("log")
+	Init(logger)
+	WithFields(Fields{"user": user{name: "bartholomew"}}).Info("hi")
+	c.Assert(logger.b.String(), Equals, "INFO hi user.id=0 user.name=bart"+TruncatedMarker+" user.org.name=\"\"\n")
+}
@@ -0,0 +1,190 @@
+// Package logtest provides an in-memory logger recording the messages logged through the log
+// package as structured entries, and helpers to check them in tests, for example:
+//
+//	func TestRetry(t *testing.T) {
+//		logs := logtest.Hijack(t)
+//		retry()
+//		logs.AssertContains(t, "retrying")
+//	}
+package logtest
+
+import (
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+// pendingPrefix starts the messages FormatRecord returns, which identify the records pending
+// being written.
+const pendingPrefix = "logtest:"
+
+// Logger is a logger recording the messages logged to it as entries, keeping their fields
+// along with their types.
+type Logger struct {
+	sev int32
+
+	mu      sync.Mutex
+	entries []log.Record
+
+	// pending are the records formatted but not written yet, keyed by the id of their messages
+	pending map[uint64]log.Record
+	next    uint64
+}
+
+// New makes a logger recording messages of any severity.
+func New() *Logger {
+	return &Logger{sev: int32(log.SeverityTrace), pending: make(map[uint64]log.Record)}
+}
+
+// Hijack makes a logger recording messages of any severity and makes it the only logger of the
+// log package for the duration of the test, the previous loggers being restored when the test
+// completes. Tests hijacking the loggers must not run in parallel.
+func Hijack(t testing.TB) *Logger {
+	l := New()
+	previous := log.ReplaceLoggers(l)
+	t.Cleanup(func() {
+		log.ReplaceLoggers(previous...)
+	})
+	return l
+}
+
+func (l *Logger) Writer(sev log.Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= l.Severity() {
+		return &writer{l, sev}
+	}
+	return nil
+}
+
+func (l *Logger) FormatMessage(sev log.Severity, caller *log.CallerInfo, format string, args ...interface{}) string {
+	return l.FormatRecord(log.NewRecord(sev, caller, nil, format, args...))
+}
+
+// FormatRecord keeps the record until the message it returns is written.
+func (l *Logger) FormatRecord(r log.Record) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.next++
+	l.pending[l.next] = r
+	return pendingPrefix + strconv.FormatUint(l.next, 10)
+}
+
+func (l *Logger) Severity() log.Severity {
+	return log.Severity(atomic.LoadInt32(&l.sev))
+}
+
+func (l *Logger) SetSeverity(sev log.Severity) {
+	atomic.StoreInt32(&l.sev, int32(sev))
+}
+
+// Entries returns the messages logged so far, oldest first.
+func (l *Logger) Entries() []log.Record {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]log.Record(nil), l.entries...)
+}
+
+// FilterSeverity returns the messages logged so far at one of the severities, oldest first.
+func (l *Logger) FilterSeverity(sevs ...log.Severity) []log.Record {
+	return l.filter(func(r log.Record) bool {
+		for _, sev := range sevs {
+			if r.Severity == sev {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// FilterField returns the messages logged so far with the field set to the value, oldest first.
+func (l *Logger) FilterField(key string, value interface{}) []log.Record {
+	return l.filter(func(r log.Record) bool {
+		v, ok := r.Fields[key]
+		return ok && reflect.DeepEqual(v, value)
+	})
+}
+
+// FilterMessage returns the messages logged so far containing the text, oldest first.
+func (l *Logger) FilterMessage(text string) []log.Record {
+	return l.filter(func(r log.Record) bool {
+		return strings.Contains(r.Message, text)
+	})
+}
+
+// AssertContains fails the test unless a message containing the text has been logged.
+func (l *Logger) AssertContains(t testing.TB, text string) {
+	t.Helper()
+	if len(l.FilterMessage(text)) == 0 {
+		t.Errorf("no message contains %q, logged:\n%s", text, l.dump())
+	}
+}
+
+// AssertNotContains fails the test if a message containing the text has been logged.
+func (l *Logger) AssertNotContains(t testing.TB, text string) {
+	t.Helper()
+	if len(l.FilterMessage(text)) > 0 {
+		t.Errorf("a message contains %q, logged:\n%s", text, l.dump())
+	}
+}
+
+// Reset discards the messages logged so far.
+func (l *Logger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.entries = nil
+}
+
+func (l *Logger) filter(keep func(log.Record) bool) []log.Record {
+	var entries []log.Record
+	for _, r := range l.Entries() {
+		if keep(r) {
+			entries = append(entries, r)
+		}
+	}
+	return entries
+}
+
+// dump returns the messages logged so far, one per line.
+func (l *Logger) dump() string {
+	var lines []string
+	for _, r := range l.Entries() {
+		lines = append(lines, "\t"+r.Severity.String()+" "+r.Message)
+	}
+	if len(lines) == 0 {
+		return "\t(nothing)"
+	}
+	return strings.Join(lines, "\n")
+}
+
+// writer records the messages written to it.
+type writer struct {
+	l   *Logger
+	sev log.Severity
+}
+
+func (w *writer) Write(p []byte) (int, error) {
+	w.l.mu.Lock()
+	defer w.l.mu.Unlock()
+
+	message := strings.TrimRight(string(p), "\r\n")
+	if strings.HasPrefix(message, pendingPrefix) {
+		id, _ := strconv.ParseUint(message[len(pendingPrefix):], 10, 64)
+		if r, ok := w.l.pending[id]; ok {
+			delete(w.l.pending, id)
+			w.l.entries = append(w.l.entries, r)
+			return len(p), nil
+		}
+	}
+	// messages not formatted by the logger only have a severity and a message
+	w.l.entries = append(w.l.entries, log.Record{Severity: w.sev, Message: message})
+	return len(p), nil
+}
@@ -0,0 +1,123 @@
+package logtest
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+func TestHijack(t *testing.T) {
+	var logs *Logger
+	t.Run("hijacked", func(t *testing.T) {
+		logs = Hijack(t)
+		log.WithFields(log.Fields{"attempt": 2, "user": "bob"}).Warningf("retrying %s", "upload")
+		log.Infof("uploaded")
+
+		entries := logs.Entries()
+		if len(entries) != 2 {
+			t.Fatalf("Entries() = %v, want 2 entries", entries)
+		}
+		r := entries[0]
+		if r.Severity != log.SeverityWarning || r.Message != "retrying upload" || r.Fields["attempt"] != 2 || r.File != "logtest_test.go" {
+			t.Errorf("Entries()[0] = %+v", r)
+		}
+		logs.AssertContains(t, "retrying")
+		logs.AssertNotContains(t, "failed")
+	})
+
+	// the previous loggers are restored
+	log.Infof("after")
+	if entries := logs.Entries(); len(entries) != 2 {
+		t.Errorf("Entries() = %v after the test, want 2 entries", entries)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	logs := Hijack(t)
+	log.Named("storage").Debugf("cache miss")
+	log.WithFields(log.Fields{"ids": []int{1, 2}}).Errorf("disk full")
+	log.Warningf("disk almost full")
+
+	if entries := logs.FilterSeverity(log.SeverityError, log.SeverityWarning); len(entries) != 2 || entries[0].Message != "disk full" {
+		t.Errorf("FilterSeverity() = %v", entries)
+	}
+	if entries := logs.FilterField(log.ComponentField, "storage"); len(entries) != 1 || entries[0].Message != "cache miss" {
+		t.Errorf("FilterField() = %v", entries)
+	}
+	if entries := logs.FilterField("ids", []int{1, 2}); len(entries) != 1 {
+		t.Errorf("FilterField() = %v", entries)
+	}
+	if entries := logs.FilterMessage("full"); len(entries) != 2 {
+		t.Errorf("FilterMessage() = %v", entries)
+	}
+
+	logs.Reset()
+	if entries := logs.Entries(); len(entries) != 0 {
+		t.Errorf("Entries() = %v after Reset", entries)
+	}
+}
+
+func TestConcurrentMessages(t *testing.T) {
+	logs := Hijack(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			log.WithFields(log.Fields{"i": i}).Infof("message %d", i)
+		}(i)
+	}
+	wg.Wait()
+
+	entries := logs.Entries()
+	if len(entries) != 10 {
+		t.Fatalf("Entries() = %v, want 10 entries", entries)
+	}
+	for _, r := range entries {
+		if r.Message != fmt.Sprintf("message %d", r.Fields["i"]) {
+			t.Errorf("entry %+v does not match its fields", r)
+		}
+	}
+	if len(logs.pending) != 0 {
+		t.Errorf("%d records are still pending", len(logs.pending))
+	}
+}
+
+// recordingT records the errors of a test.
+type recordingT struct {
+	testing.TB
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func TestAssertions(t *testing.T) {
+	logs := New()
+	logs.SetSeverity(log.SeverityInfo)
+	log.Init(logs)
+	defer log.RemoveLogger(logs)
+
+	log.Debugf("not logged")
+	log.Infof("hello")
+
+	rt := &recordingT{TB: t}
+	logs.AssertContains(rt, "hello")
+	logs.AssertNotContains(rt, "not logged")
+	if len(rt.errors) != 0 {
+		t.Errorf("assertions failed: %v", rt.errors)
+	}
+
+	logs.AssertContains(rt, "bye")
+	logs.AssertNotContains(rt, "hell")
+	if len(rt.errors) != 2 || !strings.Contains(rt.errors[0], `no message contains "bye", logged:`+"\n\tINFO hello") {
+		t.Errorf("assertions failed with %q", rt.errors)
+	}
+}
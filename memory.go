@@ -0,0 +1,83 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// MemoryLogger is a logger keeping the messages logged to it in memory. It is meant for tests
+// that check what gets logged.
+type MemoryLogger struct {
+	sev Severity
+
+	mu       sync.Mutex
+	messages []MemoryMessage
+}
+
+// MemoryMessage is a message kept by a MemoryLogger.
+type MemoryMessage struct {
+	Severity Severity
+	Message  string
+}
+
+// NewMemoryLogger makes a logger keeping messages in memory.
+func NewMemoryLogger(conf Config) (*MemoryLogger, error) {
+	sev, err := severityFromString(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryLogger{sev: sev}, nil
+}
+
+func (l *MemoryLogger) Writer(sev Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= l.Severity() {
+		return &memoryWriter{l, sev}
+	}
+	return nil
+}
+
+func (l *MemoryLogger) Severity() Severity {
+	return Severity(atomic.LoadInt32((*int32)(&l.sev)))
+}
+
+func (l *MemoryLogger) SetSeverity(sev Severity) {
+	atomic.StoreInt32((*int32)(&l.sev), int32(sev))
+}
+
+// FormatMessage formats the message alone, without a timestamp or caller information.
+func (l *MemoryLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return fmt.Sprintf(format, args...)
+}
+
+// Messages returns the messages logged so far, oldest first.
+func (l *MemoryLogger) Messages() []MemoryMessage {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]MemoryMessage(nil), l.messages...)
+}
+
+// Reset discards the messages logged so far.
+func (l *MemoryLogger) Reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.messages = nil
+}
+
+// memoryWriter adds messages written to it to a MemoryLogger.
+type memoryWriter struct {
+	l   *MemoryLogger
+	sev Severity
+}
+
+func (w *memoryWriter) Write(p []byte) (int, error) {
+	w.l.mu.Lock()
+	defer w.l.mu.Unlock()
+
+	w.l.messages = append(w.l.messages, MemoryMessage{w.sev, string(p)})
+	return len(p), nil
+}
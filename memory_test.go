@@ -0,0 +1,40 @@
+package log
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type MemoryLoggerSuite struct {
+}
+
+var _ = Suite(&MemoryLoggerSuite{})
+
+func (s *MemoryLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *MemoryLoggerSuite) TestNewMemoryLogger(c *C) {
+	l, err := NewMemoryLogger(Config{Severity: "warn"})
+	c.Assert(err, IsNil)
+	c.Assert(l.sev, Equals, SeverityWarning)
+
+	_, err = NewMemoryLogger(Config{Severity: "loud"})
+	c.Assert(err, NotNil)
+}
+
+func (s *MemoryLoggerSuite) TestMessages(c *C) {
+	l, _ := NewMemoryLogger(Config{Severity: "info"})
+	Init(l)
+
+	Debugf("hello %s", "debug")
+	Infof("hello %s", "info")
+	WithFields(Fields{"a": 1}).Errorf("hello %s", "error")
+
+	c.Assert(l.Messages(), DeepEquals, []MemoryMessage{
+		{Severity: SeverityInfo, Message: "hello info"},
+		{Severity: SeverityError, Message: "hello error a=1"},
+	})
+
+	l.Reset()
+	c.Assert(l.Messages(), HasLen, 0)
+}
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"fmt"
+	"io"
+)
+
+// MultiLogger is a logger sending every message to several loggers, each of them formatting
+// it its own way and logging it at its own severity.
+//
+// Loggers used directly through Writer and FormatMessage rather than by the package get
+// the message formatted by the first of the loggers logging at the severity.
+type MultiLogger struct {
+	loggers []Logger
+}
+
+// NewMultiLogger makes a logger sending every message to the provided loggers.
+func NewMultiLogger(l ...Logger) *MultiLogger {
+	return &MultiLogger{loggers: l}
+}
+
+// NewMirrorLogger makes a MultiLogger out of the loggers configured in conf.Mirror, mirrored
+// configs without a severity or overrides taking conf.Severity and conf.Overrides.
+func NewMirrorLogger(conf Config) (Logger, error) {
+	if len(conf.Mirror) == 0 {
+		return nil, fmt.Errorf("mirror logger needs loggers to mirror to")
+	}
+
+	m := &MultiLogger{}
+	for i, mirrored := range conf.Mirror {
+		if mirrored.Severity == "" {
+			mirrored.Severity = conf.Severity
+		}
+		if mirrored.Overrides == nil {
+			mirrored.Overrides = conf.Overrides
+		}
+		l, err := NewLogger(mirrored)
+		if err != nil {
+			m.Close()
+			return nil, fmt.Errorf("mirror %d (%s): %v", i, mirrored.Name, err)
+		}
+		m.loggers = append(m.loggers, l)
+	}
+	return m, nil
+}
+
+// Loggers returns the loggers messages are sent to.
+func (m *MultiLogger) Loggers() []Logger {
+	return m.loggers
+}
+
+func (m *MultiLogger) Writer(sev Severity) io.Writer {
+	var writers []io.Writer
+	for _, l := range m.loggers {
+		if w := l.Writer(sev); w != nil {
+			writers = append(writers, w)
+		}
+	}
+	switch len(writers) {
+	case 0:
+		return nil
+	case 1:
+		return writers[0]
+	}
+	return io.MultiWriter(writers...)
+}
+
+func (m *MultiLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	for _, l := range m.loggers {
+		if l.Writer(sev) != nil {
+			return l.FormatMessage(sev, caller, format, args...)
+		}
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Flush flushes the loggers implementing Flusher and returns the first error encountered.
+func (m *MultiLogger) Flush() error {
+	var first error
+	for _, l := range m.loggers {
+		if f, ok := l.(Flusher); ok {
+			if err := f.Flush(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Sync syncs the loggers implementing Syncer and returns the first error encountered.
+func (m *MultiLogger) Sync() error {
+	var first error
+	for _, l := range m.loggers {
+		if s, ok := l.(Syncer); ok {
+			if err := s.Sync(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Reopen reopens the loggers implementing Reopener and returns the first error encountered.
+func (m *MultiLogger) Reopen() error {
+	var first error
+	for _, l := range m.loggers {
+		if r, ok := l.(Reopener); ok {
+			if err := r.Reopen(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
+
+// Dropped returns the number of messages dropped by the loggers implementing Droppable.
+func (m *MultiLogger) Dropped() uint64 {
+	var dropped uint64
+	for _, l := range m.loggers {
+		if d, ok := l.(Droppable); ok {
+			dropped += d.Dropped()
+		}
+	}
+	return dropped
+}
+
+// Close closes the loggers implementing io.Closer and returns the first error encountered.
+func (m *MultiLogger) Close() error {
+	var first error
+	for _, l := range m.loggers {
+		if c, ok := l.(io.Closer); ok {
+			if err := c.Close(); err != nil && first == nil {
+				first = err
+			}
+		}
+	}
+	return first
+}
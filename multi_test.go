@@ -0,0 +1,120 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MultiLoggerSuite struct {
+}
+
+var _ = Suite(&MultiLoggerSuite{})
+
+func (s *MultiLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *MultiLoggerSuite) TestMultiLogger(c *C) {
+	info := newThresholdLogger("info", SeverityInfo)
+	errors := newThresholdLogger("error", SeverityError)
+	Init(NewMultiLogger(info, errors))
+
+	Infof("hello %s", "info")
+	WithFields(Fields{"user": "bob"}).Errorf("hello %s", "error")
+
+	c.Assert(info.b.String(), Equals, "INFO hello info\nERROR hello error user=bob\n")
+	c.Assert(errors.b.String(), Equals, "ERROR hello error user=bob\n")
+}
+
+func (s *MultiLoggerSuite) TestMirror(c *C) {
+	defer func() { stdout = os.Stdout }()
+	console := &bytes.Buffer{}
+	stdout = console
+	path := filepath.Join(c.MkDir(), "app.json")
+
+	c.Assert(InitWithConfig(Config{
+		Name:     Mirror,
+		Severity: "info",
+		Mirror: []Config{
+			{Name: File, Path: path, Format: FormatJSON},
+			{Name: Console, Color: true},
+		},
+	}), IsNil)
+	defer loggers[0].(*MultiLogger).Close()
+
+	Debugf("hello %s", "debug")
+	WithFields(Fields{"user": "bob"}).Warningf("hello %s", "world")
+
+	file, err := ioutil.ReadFile(path)
+	c.Assert(err, IsNil)
+	c.Assert(string(file), Matches, `\{"timestamp":"[^"]+","severity":"WARN","message":"hello world","file":"multi_test.go","func":".*TestMirror","line":[0-9]+,"user":"bob"\}`+"\n")
+	c.Assert(console.String(), Matches, `.* \x1b\[33mWARN\x1b\[0m .*\[multi_test.go:[0-9]+:.*TestMirror\] hello world user=bob`+"\n")
+}
+
+// nowLogger is a test logger formatting messages with the time of their records, the way
+// loggers implemented outside of the package do.
+type nowLogger struct {
+	*testLogger
+}
+
+func (l *nowLogger) FormatRecord(r Record) string {
+	caller := &CallerInfo{FileName: r.File, FuncName: r.Func, LineNo: r.Line}
+	return r.Time.Format(time.RFC3339Nano) + " " + l.testLogger.FormatMessage(r.Severity, caller, "%s", r.Message)
+}
+
+func (s *MultiLoggerSuite) TestSharedCallerAndTime(c *C) {
+	// the clock moves on every time it is read
+	t := time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time {
+		t = t.Add(time.Millisecond)
+		return t
+	}))
+	defer SetClock(nil)
+
+	var text, encoded bytes.Buffer
+	colored, err := NewConsole().WithColor(true).WithWriter(&text).Build()
+	c.Assert(err, IsNil)
+	structured, err := NewConsole().WithFormat(FormatJSON).WithWriter(&encoded).Build()
+	c.Assert(err, IsNil)
+	external := &nowLogger{newTestLogger("external")}
+	Init(NewMultiLogger(colored, structured, external))
+
+	Errorf("hello %s", "world")
+
+	var record struct {
+		Timestamp time.Time
+		Line      int
+	}
+	c.Assert(json.Unmarshal(encoded.Bytes(), &record), IsNil)
+	c.Assert(text.String(), Matches, record.Timestamp.Format(timestampLayout(SeverityError))+` .* \x1b\[31mERROR\x1b\[0m .*\[multi_test.go:`+strconv.Itoa(record.Line)+`:.*TestSharedCallerAndTime\] hello world`+"\n")
+	c.Assert(external.b.String(), Equals, record.Timestamp.Format(time.RFC3339Nano)+" ERROR hello world\n")
+	c.Assert(external.caller.LineNo, Equals, record.Line)
+}
+
+func (s *MultiLoggerSuite) TestMirrorValidation(c *C) {
+	path := filepath.Join(c.MkDir(), "app.json")
+	for _, conf := range []Config{
+		{Name: Mirror, Severity: "info"},
+		{Name: Mirror, Severity: "info", Mirror: []Config{{Name: File, Path: path}, {Name: Console, Format: "xml"}}},
+		{Name: Mirror, Mirror: []Config{{Name: File, Path: path}}},
+		{Name: Mirror, Severity: "info", Mirror: []Config{{Name: "carrier-pigeon"}}},
+	} {
+		l, err := NewLogger(conf)
+		c.Assert(err, NotNil, Commentf("%+v", conf))
+		c.Assert(l, IsNil)
+	}
+
+	l, err := NewLogger(Config{Name: Mirror, Severity: "info", Mirror: []Config{{Name: File, Path: path, Severity: "error"}}})
+	c.Assert(err, IsNil)
+	defer l.(*MultiLogger).Close()
+	c.Assert(l.Writer(SeverityWarning), IsNil)
+	c.Assert(strings.Contains(l.FormatMessage(SeverityError, &CallerInfo{}, "hello"), "hello"), Equals, true)
+}
@@ -0,0 +1,102 @@
+package log
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// netDialTimeout bounds the time spent connecting to a remote server.
+const netDialTimeout = 5 * time.Second
+
+// netConn is a connection to a server sending a message per write: a datagram over UDP and
+// Unix datagram sockets, an octet-counted frame (RFC 6587) over TCP and TLS unless framed
+// otherwise. It reconnects
+// when a write fails, so that messages go through again once the server is back.
+type netConn struct {
+	network   string
+	address   string
+	tlsConfig *tls.Config
+
+	// frame frames the messages sent over TCP and TLS, octet counting them if it is nil
+	frame func(p []byte) []byte
+
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+}
+
+// dialNet connects to the server at address over the network: "udp", "tcp", "tls" or
+// "unixgram".
+func dialNet(network, address string, tlsConfig *tls.Config) (*netConn, error) {
+	c := &netConn{network: network, address: address, tlsConfig: tlsConfig}
+	conn, err := c.dial()
+	if err != nil {
+		return nil, err
+	}
+	c.conn = conn
+	return c, nil
+}
+
+func (c *netConn) dial() (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: netDialTimeout}
+	if c.network == "tls" {
+		return tls.DialWithDialer(dialer, "tcp", c.address, c.tlsConfig)
+	}
+	return dialer.Dial(c.network, c.address)
+}
+
+// String returns where the connection goes, e.g. "udp://127.0.0.1:514".
+func (c *netConn) String() string {
+	return c.network + "://" + c.address
+}
+
+func (c *netConn) Write(p []byte) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.closed {
+		return 0, fmt.Errorf("connection to %s is closed", c)
+	}
+	frame := p
+	switch {
+	case c.network == "udp" || c.network == "unixgram":
+	case c.frame != nil:
+		frame = c.frame(p)
+	default:
+		frame = append([]byte(strconv.Itoa(len(p))+" "), p...)
+	}
+
+	// retry once over a new connection, the server may have closed the previous one
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		if c.conn == nil {
+			if c.conn, err = c.dial(); err != nil {
+				return 0, err
+			}
+		}
+		if _, err = c.conn.Write(frame); err == nil {
+			return len(p), nil
+		}
+		c.conn.Close()
+		c.conn = nil
+	}
+	return 0, err
+}
+
+// Close closes the connection. It is safe to call it more than once.
+func (c *netConn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.closed = true
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
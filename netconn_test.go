@@ -0,0 +1,68 @@
+package log
+
+import (
+	"bufio"
+	"net"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type NetConnSuite struct {
+}
+
+var _ = Suite(&NetConnSuite{})
+
+func (s *NetConnSuite) TestReconnect(c *C) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer server.Close()
+
+	// the server hangs up on the first connection and reads the frames sent over the second
+	frames := make(chan string, 1)
+	go func() {
+		conn, err := server.Accept()
+		if err != nil {
+			return
+		}
+		conn.Close()
+		if conn, err = server.Accept(); err != nil {
+			return
+		}
+		defer conn.Close()
+		frame, _ := bufio.NewReader(conn).ReadString('!')
+		frames <- frame
+	}()
+
+	conn, err := dialNet("tcp", server.Addr().String(), nil)
+	c.Assert(err, IsNil)
+	defer conn.Close()
+
+	// writes over the broken connection fail once the hang-up is noticed, then go through
+	// over a new one
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		conn.Write([]byte("hello!"))
+		select {
+		case frame := <-frames:
+			c.Assert(frame, Matches, "(6 hello!)+")
+			return
+		case <-time.After(10 * time.Millisecond):
+		}
+		c.Assert(time.Now().Before(deadline), Equals, true)
+	}
+}
+
+func (s *NetConnSuite) TestClosed(c *C) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer server.Close()
+
+	conn, err := dialNet("udp", server.LocalAddr().String(), nil)
+	c.Assert(err, IsNil)
+	c.Assert(conn.String(), Equals, "udp://"+server.LocalAddr().String())
+	c.Assert(conn.Close(), IsNil)
+	c.Assert(conn.Close(), IsNil)
+	_, err = conn.Write([]byte("hello"))
+	c.Assert(err, ErrorMatches, "connection to udp://.* is closed")
+}
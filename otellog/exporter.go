@@ -0,0 +1,494 @@
+package otellog
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/mailgun/log"
+)
+
+// OTLP is the name of the logger type exporting messages over OTLP, see NewExporter.
+const OTLP = "otlp"
+
+// Default addresses of the OTLP receivers of collectors.
+const (
+	DefaultGRPCAddress = "localhost:4317"
+	DefaultHTTPAddress = "localhost:4318"
+)
+
+const (
+	// maxBatchSize is the largest number of records exported at once.
+	maxBatchSize = 512
+
+	// queueSize is the number of records queued for export, those beyond are dropped.
+	queueSize = 4096
+
+	// defaultFlushInterval is how often records are exported unless configured otherwise.
+	defaultFlushInterval = time.Second
+
+	// exportTimeout bounds the time spent exporting a batch.
+	exportTimeout = 10 * time.Second
+
+	// scopeName names the instrumentation scope of the records.
+	scopeName = "github.com/mailgun/log"
+)
+
+// SeverityMapper maps severities to OpenTelemetry severity numbers, PANIC being FATAL2.
+var SeverityMapper log.SeverityMapper = log.SeverityMapperFunc(severityNumber)
+
+func severityNumber(sev log.Severity) int {
+	switch {
+	case sev >= log.SeverityPanic:
+		return int(logspb.SeverityNumber_SEVERITY_NUMBER_FATAL2)
+	case sev >= log.SeverityFatal:
+		return int(logspb.SeverityNumber_SEVERITY_NUMBER_FATAL)
+	case sev >= log.SeverityError:
+		return int(logspb.SeverityNumber_SEVERITY_NUMBER_ERROR)
+	case sev >= log.SeverityWarning:
+		return int(logspb.SeverityNumber_SEVERITY_NUMBER_WARN)
+	case sev >= log.SeverityInfo:
+		return int(logspb.SeverityNumber_SEVERITY_NUMBER_INFO)
+	case sev >= log.SeverityDebug:
+		return int(logspb.SeverityNumber_SEVERITY_NUMBER_DEBUG)
+	}
+	return int(logspb.SeverityNumber_SEVERITY_NUMBER_TRACE)
+}
+
+func init() {
+	log.RegisterLogger(OTLP, func(conf log.Config) (log.Logger, error) {
+		e, err := NewExporter(conf)
+		if err != nil {
+			return nil, err
+		}
+		return e, nil
+	})
+}
+
+// Exporter is a logger exporting messages as OpenTelemetry log records, batching them: records
+// are exported every FlushInterval of the config, every second by default, and as soon as 512
+// of them are queued. Up to 4096 records are queued, those beyond and those that could not be
+// exported are dropped and counted, see Dropped.
+//
+// The fields of the messages become attributes of the records, along with the callers as
+// code.file.path, code.line.number and code.function.name, but for TraceIDField and
+// SpanIDField, which become the trace and span IDs of the records, see Correlate. The
+// records are exported on behalf of the service named after the Tag of the config, or the
+// program's name.
+type Exporter struct {
+	sev      int32
+	mapper   log.SeverityMapper
+	resource *resourcepb.Resource
+	export   func(context.Context, *collogspb.ExportLogsServiceRequest) error
+	conn     io.Closer // the gRPC connection, nil over HTTP
+
+	mu      sync.Mutex
+	queue   []*logspb.LogRecord
+	dropped uint64
+
+	exportMu sync.Mutex // held while exporting, so that batches keep their order
+	full     chan struct{}
+
+	closeOnce sync.Once
+	done      chan struct{}
+	stopped   chan struct{}
+}
+
+// NewExporter makes a logger exporting messages to the OTLP receiver at the Address of the
+// config over its Network: "grpc" (default) or "http", which default to DefaultGRPCAddress
+// and DefaultHTTPAddress. The TLSConfig of the config secures the connection, leave it nil to
+// export in plain text, e.g. to a collector running alongside the program.
+func NewExporter(conf log.Config) (*Exporter, error) {
+	sev, err := log.ParseSeverity(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+	if conf.FlushInterval < 0 {
+		return nil, fmt.Errorf("flush interval must not be negative: %v", conf.FlushInterval)
+	}
+	interval := conf.FlushInterval
+	if interval == 0 {
+		interval = defaultFlushInterval
+	}
+	mapper := conf.SeverityMapper
+	if mapper == nil {
+		mapper = SeverityMapper
+	}
+
+	e := &Exporter{
+		sev:      int32(sev),
+		mapper:   mapper,
+		resource: newResource(conf.Tag),
+		full:     make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	switch conf.Network {
+	case "", "grpc":
+		err = e.dialGRPC(conf)
+	case "http":
+		e.useHTTP(conf)
+	default:
+		err = fmt.Errorf("unsupported otlp network: %s", conf.Network)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	go e.run(interval)
+	return e, nil
+}
+
+// dialGRPC makes the exporter export over gRPC.
+func (e *Exporter) dialGRPC(conf log.Config) error {
+	address := conf.Address
+	if address == "" {
+		address = DefaultGRPCAddress
+	}
+	creds := insecure.NewCredentials()
+	if conf.TLSConfig != nil {
+		creds = credentials.NewTLS(conf.TLSConfig)
+	}
+	cc, err := grpc.NewClient(address, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return err
+	}
+
+	client := collogspb.NewLogsServiceClient(cc)
+	e.conn = cc
+	e.export = func(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+		_, err := client.Export(ctx, req)
+		return err
+	}
+	return nil
+}
+
+// useHTTP makes the exporter export over HTTP, posting binary protobuf requests.
+func (e *Exporter) useHTTP(conf log.Config) {
+	address := conf.Address
+	if address == "" {
+		address = DefaultHTTPAddress
+	}
+	url := "http://" + address + "/v1/logs"
+	if conf.TLSConfig != nil {
+		url = "https://" + address + "/v1/logs"
+	}
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: conf.TLSConfig}}
+
+	e.export = func(ctx context.Context, req *collogspb.ExportLogsServiceRequest) error {
+		body, err := proto.Marshal(req)
+		if err != nil {
+			return err
+		}
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		r.Header.Set("Content-Type", "application/x-protobuf")
+		resp, err := client.Do(r)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(ioutil.Discard, resp.Body)
+		if resp.StatusCode/100 != 2 {
+			return fmt.Errorf("otlp export to %s failed: %s", url, resp.Status)
+		}
+		return nil
+	}
+}
+
+// newResource describes the program the records come from.
+func newResource(service string) *resourcepb.Resource {
+	if service == "" {
+		service = filepath.Base(os.Args[0])
+	}
+	attributes := []*commonpb.KeyValue{stringAttribute("service.name", service)}
+	if host, err := os.Hostname(); err == nil {
+		attributes = append(attributes, stringAttribute("host.name", host))
+	}
+	return &resourcepb.Resource{Attributes: attributes}
+}
+
+func (e *Exporter) Writer(sev log.Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= e.Severity() {
+		return exporterWriter{e}
+	}
+	return nil
+}
+
+// Severity returns the minimum severity the exporter is exporting messages at.
+func (e *Exporter) Severity() log.Severity {
+	return log.Severity(atomic.LoadInt32(&e.sev))
+}
+
+// SetSeverity changes the minimum severity the exporter is exporting messages at.
+func (e *Exporter) SetSeverity(sev log.Severity) {
+	atomic.StoreInt32(&e.sev, int32(sev))
+}
+
+// FormatMessage returns the serialized LogRecord of the message.
+func (e *Exporter) FormatMessage(sev log.Severity, caller *log.CallerInfo, format string, args ...interface{}) string {
+	return e.FormatRecord(log.NewRecord(sev, caller, nil, format, args...))
+}
+
+// FormatRecord returns the serialized LogRecord of the record.
+func (e *Exporter) FormatRecord(r log.Record) string {
+	record := &logspb.LogRecord{
+		TimeUnixNano:         uint64(r.Time.UnixNano()),
+		ObservedTimeUnixNano: uint64(log.Now().UnixNano()),
+		SeverityNumber:       logspb.SeverityNumber(e.mapper.Priority(r.Severity)),
+		SeverityText:         r.Severity.String(),
+		Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: r.Message}},
+		Attributes: []*commonpb.KeyValue{
+			stringAttribute("code.file.path", r.File),
+			{Key: "code.line.number", Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(r.Line)}}},
+			stringAttribute("code.function.name", r.Func),
+		},
+	}
+
+	keys := make([]string, 0, len(r.Fields))
+	for k := range r.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := r.Fields[k]
+		switch k {
+		case TraceIDField:
+			if id, ok := hexID(v, 16); ok {
+				record.TraceId = id
+				continue
+			}
+		case SpanIDField:
+			if id, ok := hexID(v, 8); ok {
+				record.SpanId = id
+				continue
+			}
+		}
+		record.Attributes = append(record.Attributes, &commonpb.KeyValue{Key: k, Value: anyValue(v)})
+	}
+
+	b, err := proto.Marshal(record)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
+// hexID decodes an ID of size bytes rendered in hexadecimal.
+func hexID(v interface{}, size int) ([]byte, bool) {
+	s, ok := v.(string)
+	if !ok || len(s) != 2*size {
+		return nil, false
+	}
+	id, err := hex.DecodeString(s)
+	return id, err == nil
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: key, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}}}
+}
+
+// anyValue converts the value of a field, keeping the types OTLP has and rendering the others.
+func anyValue(v interface{}) *commonpb.AnyValue {
+	switch v := v.(type) {
+	case string:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}
+	case bool:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: v}}
+	case int:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case int64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: v}}
+	case uint8:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint16:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+	case uint:
+		if uint64(v) <= math.MaxInt64 {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+		}
+	case uint64:
+		if v <= math.MaxInt64 {
+			return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: int64(v)}}
+		}
+	case float32:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: float64(v)}}
+	case float64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: v}}
+	case []byte:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BytesValue{BytesValue: v}}
+	case []string:
+		values := make([]*commonpb.AnyValue, len(v))
+		for i, s := range v {
+			values[i] = anyValue(s)
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{Values: values}}}
+	case log.Fields:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		values := make([]*commonpb.KeyValue, len(keys))
+		for i, k := range keys {
+			values[i] = &commonpb.KeyValue{Key: k, Value: anyValue(v[k])}
+		}
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_KvlistValue{KvlistValue: &commonpb.KeyValueList{Values: values}}}
+	}
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: fmt.Sprint(v)}}
+}
+
+// Dropped returns the number of messages dropped because the queue was full or they could
+// not be exported.
+func (e *Exporter) Dropped() uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.dropped
+}
+
+// Flush exports the queued records.
+func (e *Exporter) Flush() error {
+	e.exportMu.Lock()
+	defer e.exportMu.Unlock()
+
+	var first error
+	for {
+		e.mu.Lock()
+		n := len(e.queue)
+		if n > maxBatchSize {
+			n = maxBatchSize
+		}
+		batch := e.queue[:n:n]
+		e.queue = e.queue[n:]
+		e.mu.Unlock()
+		if len(batch) == 0 {
+			return first
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+		err := e.export(ctx, &collogspb.ExportLogsServiceRequest{
+			ResourceLogs: []*logspb.ResourceLogs{{
+				Resource: e.resource,
+				ScopeLogs: []*logspb.ScopeLogs{{
+					Scope:      &commonpb.InstrumentationScope{Name: scopeName},
+					LogRecords: batch,
+				}},
+			}},
+		})
+		cancel()
+		if err != nil {
+			e.mu.Lock()
+			e.dropped += uint64(len(batch))
+			e.mu.Unlock()
+			if first == nil {
+				first = err
+			}
+		}
+	}
+}
+
+// Close exports the queued records and closes the connection to the receiver. It can be
+// called multiple times.
+func (e *Exporter) Close() error {
+	var err error
+	e.closeOnce.Do(func() {
+		close(e.done)
+		<-e.stopped
+		err = e.Flush()
+		if e.conn != nil {
+			if cerr := e.conn.Close(); err == nil {
+				err = cerr
+			}
+		}
+	})
+	return err
+}
+
+// enqueue queues the record for export unless the queue is full or the exporter is closed.
+func (e *Exporter) enqueue(record *logspb.LogRecord) {
+	select {
+	case <-e.done:
+		e.mu.Lock()
+		e.dropped++
+		e.mu.Unlock()
+		return
+	default:
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.queue) >= queueSize {
+		e.dropped++
+		return
+	}
+	e.queue = append(e.queue, record)
+	if len(e.queue) >= maxBatchSize {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run exports the queued records every interval and whenever a batch is full until the
+// exporter is closed.
+func (e *Exporter) run(interval time.Duration) {
+	defer close(e.stopped)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.full:
+		case <-e.done:
+			return
+		}
+		e.Flush()
+	}
+}
+
+// exporterWriter queues the records serialized by FormatRecord for export.
+type exporterWriter struct {
+	e *Exporter
+}
+
+func (w exporterWriter) Write(p []byte) (int, error) {
+	record := &logspb.LogRecord{}
+	if err := proto.Unmarshal(p, record); err != nil {
+		return 0, err
+	}
+	w.e.enqueue(record)
+	return len(p), nil
+}
@@ -0,0 +1,50 @@
+// Package otellog correlates the messages logged through the log package with OpenTelemetry
+// traces, and provides the "otlp" logger exporting messages to an OpenTelemetry collector
+// over the OTLP logs protocol, for example:
+//
+//	otellog.Correlate()
+//	err := log.InitWithConfig(log.Config{Name: otellog.OTLP, Severity: "info", Address: "collector:4317"})
+//	...
+//	log.InfofCtx(ctx, "charged %s", customer) // carries the IDs of the span of ctx
+//
+// The package is kept apart from the log package so that programs not using it do not depend
+// on OpenTelemetry. Importing it registers the otlp logger, see log.RegisterLogger.
+package otellog
+
+import (
+	"context"
+	"sync"
+
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/mailgun/log"
+)
+
+// Names of the fields carrying the IDs of the span of the contexts messages are logged with,
+// see Correlate.
+const (
+	TraceIDField = "trace_id"
+	SpanIDField  = "span_id"
+)
+
+var correlateOnce sync.Once
+
+// Correlate makes the messages logged with a context carrying a valid span, with the Ctx
+// functions or the entries returned by log.FromContext, carry the IDs of the span in
+// hexadecimal as TraceIDField and SpanIDField fields. The otlp logger sends them as the
+// trace and span IDs of the log records. It is safe to call it more than once.
+func Correlate() {
+	correlateOnce.Do(func() {
+		log.AddContextExtractor(TraceFields)
+	})
+}
+
+// TraceFields returns the fields carrying the IDs of the span of the context, or nil if the
+// context carries no valid span.
+func TraceFields(ctx context.Context) log.Fields {
+	sc := trace.SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return nil
+	}
+	return log.Fields{TraceIDField: sc.TraceID().String(), SpanIDField: sc.SpanID().String()}
+}
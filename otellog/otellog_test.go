@@ -0,0 +1,187 @@
+package otellog
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/mailgun/log"
+)
+
+var spanContext = trace.NewSpanContext(trace.SpanContextConfig{
+	TraceID: trace.TraceID{0x4b, 0xf9, 0x2f, 0x35, 0x77, 0xb3, 0x4d, 0xa6, 0xa3, 0xce, 0x92, 0x9d, 0x0e, 0x0e, 0x47, 0x36},
+	SpanID:  trace.SpanID{0x00, 0xf0, 0x67, 0xaa, 0x0b, 0xa9, 0x02, 0xb7},
+})
+
+func TestTraceFields(t *testing.T) {
+	if fields := TraceFields(context.Background()); fields != nil {
+		t.Errorf("fields without a span: %v", fields)
+	}
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	want := log.Fields{TraceIDField: "4bf92f3577b34da6a3ce929d0e0e4736", SpanIDField: "00f067aa0ba902b7"}
+	if fields := TraceFields(ctx); len(fields) != 2 || fields[TraceIDField] != want[TraceIDField] || fields[SpanIDField] != want[SpanIDField] {
+		t.Errorf("fields = %v, want %v", fields, want)
+	}
+}
+
+// collector receives the records exported over gRPC.
+type collector struct {
+	collogspb.UnimplementedLogsServiceServer
+
+	mu      sync.Mutex
+	records []*logspb.LogRecord
+}
+
+func (c *collector) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rl := range req.ResourceLogs {
+		for _, sl := range rl.ScopeLogs {
+			c.records = append(c.records, sl.LogRecords...)
+		}
+	}
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
+
+func (c *collector) received() []*logspb.LogRecord {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.records
+}
+
+func TestExportGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, c := grpc.NewServer(), &collector{}
+	collogspb.RegisterLogsServiceServer(server, c)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	l, err := log.NewLogger(log.Config{Name: OTLP, Severity: "info", Address: lis.Addr().String(), FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.ReplaceLoggers(log.ReplaceLoggers(l)...)
+
+	Correlate()
+	ctx := trace.ContextWithSpanContext(context.Background(), spanContext)
+	log.FromContext(ctx).WithFields(log.Fields{"user": "bob", "attempt": 2}).Warningf("hello %s", "world")
+	log.Debugf("filtered")
+	if err := l.(log.Flusher).Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	records := c.received()
+	if len(records) != 1 {
+		t.Fatalf("received %d records, want 1", len(records))
+	}
+	r := records[0]
+	if got := r.Body.GetStringValue(); got != "hello world" {
+		t.Errorf("body = %q", got)
+	}
+	if r.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_WARN || r.SeverityText != "WARN" {
+		t.Errorf("severity = %v %q", r.SeverityNumber, r.SeverityText)
+	}
+	if tid := spanContext.TraceID(); string(r.TraceId) != string(tid[:]) {
+		t.Errorf("trace ID = %x", r.TraceId)
+	}
+	if sid := spanContext.SpanID(); string(r.SpanId) != string(sid[:]) {
+		t.Errorf("span ID = %x", r.SpanId)
+	}
+
+	attributes := map[string]*commonpb.AnyValue{}
+	for _, kv := range r.Attributes {
+		attributes[kv.Key] = kv.Value
+	}
+	if attributes["user"].GetStringValue() != "bob" || attributes["attempt"].GetIntValue() != 2 {
+		t.Errorf("attributes = %v", attributes)
+	}
+	if !strings.HasSuffix(attributes["code.function.name"].GetStringValue(), "TestExportGRPC") {
+		t.Errorf("function = %v", attributes["code.function.name"])
+	}
+	if _, ok := attributes[TraceIDField]; ok {
+		t.Errorf("trace ID sent as an attribute")
+	}
+	if err := l.(*Exporter).Close(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestExportHTTP(t *testing.T) {
+	requests := make(chan *collogspb.ExportLogsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Content-Type") != "application/x-protobuf" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		body, _ := ioutil.ReadAll(r.Body)
+		req := &collogspb.ExportLogsServiceRequest{}
+		if err := proto.Unmarshal(body, req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		requests <- req
+	}))
+	defer server.Close()
+
+	e, err := NewExporter(log.Config{Severity: "info", Network: "http", Address: strings.TrimPrefix(server.URL, "http://"), Tag: "billing"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := e.Writer(log.SeverityError)
+	w.Write([]byte(e.FormatMessage(log.SeverityError, &log.CallerInfo{}, "oops")))
+	if err := e.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	req := <-requests
+	resource := req.ResourceLogs[0].Resource
+	if kv := resource.Attributes[0]; kv.Key != "service.name" || kv.Value.GetStringValue() != "billing" {
+		t.Errorf("resource = %v", resource)
+	}
+	if r := req.ResourceLogs[0].ScopeLogs[0].LogRecords[0]; r.Body.GetStringValue() != "oops" || r.SeverityNumber != logspb.SeverityNumber_SEVERITY_NUMBER_ERROR {
+		t.Errorf("record = %v", r)
+	}
+	if e.Dropped() != 0 {
+		t.Errorf("dropped %d records", e.Dropped())
+	}
+}
+
+func TestExportFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	e, err := NewExporter(log.Config{Severity: "info", Network: "http", Address: strings.TrimPrefix(server.URL, "http://"), FlushInterval: time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer e.Close()
+	e.Writer(log.SeverityInfo).Write([]byte(e.FormatMessage(log.SeverityInfo, &log.CallerInfo{}, "hello")))
+	if err := e.Flush(); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("err = %v", err)
+	}
+	if e.Dropped() != 1 {
+		t.Errorf("dropped %d records, want 1", e.Dropped())
+	}
+
+	if _, err := NewExporter(log.Config{Severity: "info", Network: "udp"}); err == nil {
+		t.Error("exporting over UDP")
+	}
+}
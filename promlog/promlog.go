@@ -0,0 +1,84 @@
+// Package promlog exports the volumes of the messages logged through the log package and the
+// failures to write them as Prometheus metrics:
+//
+//	log_messages_total{severity="error",logger="file"}
+//	log_write_errors_total{logger="syslog"}
+//	log_dropped_messages_total{logger="udplog"}
+//
+// the loggers being named after their configs, see log.InitWithConfig, for example:
+//
+//	prometheus.MustRegister(promlog.Collector())
+//
+// The messages are counted as they are written to the loggers, see log.AddWriteHook. The
+// package is kept apart from the log package so that programs not using it do not depend on
+// the Prometheus client.
+package promlog
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/mailgun/log"
+)
+
+var (
+	once      sync.Once
+	collector *metrics
+)
+
+// Collector returns the collector of the metrics, counting from its first call on. It can be
+// registered with a single registry.
+func Collector() prometheus.Collector {
+	once.Do(func() {
+		collector = newMetrics()
+		log.AddWriteHook(collector)
+	})
+	return collector
+}
+
+// metrics counts the messages written to the loggers.
+type metrics struct {
+	messages *prometheus.CounterVec
+	errors   *prometheus.CounterVec
+	dropped  *prometheus.Desc
+}
+
+func newMetrics() *metrics {
+	return &metrics{
+		messages: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "log_messages_total",
+			Help: "Number of messages written to the loggers, by severity and logger.",
+		}, []string{"severity", "logger"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "log_write_errors_total",
+			Help: "Number of messages the loggers failed to write, by logger.",
+		}, []string{"logger"}),
+		dropped: prometheus.NewDesc("log_dropped_messages_total",
+			"Number of messages the lossy loggers and the global rate limiter dropped, by logger.",
+			[]string{"logger"}, nil),
+	}
+}
+
+// Written counts the write of the message, and its failure if it failed.
+func (m *metrics) Written(logger string, sev log.Severity, err error) {
+	m.messages.WithLabelValues(strings.ToLower(sev.String()), logger).Inc()
+	if err != nil {
+		m.errors.WithLabelValues(logger).Inc()
+	}
+}
+
+func (m *metrics) Describe(ch chan<- *prometheus.Desc) {
+	m.messages.Describe(ch)
+	m.errors.Describe(ch)
+	ch <- m.dropped
+}
+
+func (m *metrics) Collect(ch chan<- prometheus.Metric) {
+	m.messages.Collect(ch)
+	m.errors.Collect(ch)
+	for logger, n := range log.DroppedCounts() {
+		ch <- prometheus.MustNewConstMetric(m.dropped, prometheus.CounterValue, float64(n), logger)
+	}
+}
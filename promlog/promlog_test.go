@@ -0,0 +1,83 @@
+package promlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/mailgun/log"
+)
+
+// brokenLogger is a logger failing every write.
+type brokenLogger struct{}
+
+func (brokenLogger) Writer(sev log.Severity) io.Writer {
+	if sev < log.SeverityWarning {
+		return nil
+	}
+	return brokenWriter{}
+}
+
+func (brokenLogger) FormatMessage(sev log.Severity, caller *log.CallerInfo, format string, args ...interface{}) string {
+	return fmt.Sprintf(format, args...)
+}
+
+type brokenWriter struct{}
+
+func (brokenWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("broken pipe")
+}
+
+func TestCollector(t *testing.T) {
+	memory, err := log.NewMemoryLogger(log.Config{Severity: "info"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer log.ReplaceLoggers(log.ReplaceLoggers(memory, brokenLogger{})...)
+
+	registry := prometheus.NewRegistry()
+	if err := registry.Register(Collector()); err != nil {
+		t.Fatal(err)
+	}
+
+	log.Debugf("filtered")
+	log.Infof("hello")
+	log.Infof("world")
+	log.Errorf("oops")
+
+	m := Collector().(*metrics)
+	for _, c := range []struct {
+		counter prometheus.Collector
+		want    float64
+	}{
+		{m.messages.WithLabelValues("info", "*log.MemoryLogger"), 2},
+		{m.messages.WithLabelValues("error", "*log.MemoryLogger"), 1},
+		{m.messages.WithLabelValues("debug", "*log.MemoryLogger"), 0},
+		{m.messages.WithLabelValues("error", "promlog.brokenLogger"), 1},
+		{m.errors.WithLabelValues("promlog.brokenLogger"), 1},
+		{m.errors.WithLabelValues("*log.MemoryLogger"), 0},
+	} {
+		if got := testutil.ToFloat64(c.counter); got != c.want {
+			t.Errorf("%v: got %v, want %v", c.counter.(prometheus.Metric).Desc(), got, c.want)
+		}
+	}
+
+	// the drops are collected along with the counters
+	names := map[string]bool{}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		names[f.GetName()] = true
+	}
+	for _, name := range []string{"log_messages_total", "log_write_errors_total", "log_dropped_messages_total"} {
+		if !names[name] {
+			t.Errorf("%s not gathered: %v", name, names)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// globalRateLimiter caps the aggregate rate of messages across all loggers.
+	globalRateLimiter = &tokenBucket{}
+
+	// rateLimitDropped counts messages dropped by the global rate limiter.
+	rateLimitDropped uint64
+)
+
+// SetGlobalRateLimit caps the total number of messages sent to the logger chain
+// at perSecond messages per second, no matter how many call sites are logging.
+// Messages above the rate are dropped; messages at ERROR are never dropped.
+//
+// A non-positive value removes the limit.
+func SetGlobalRateLimit(perSecond int) {
+	globalRateLimiter.setRate(perSecond)
+}
+
+// RateLimitDropped returns the number of messages dropped by the global rate limiter.
+func RateLimitDropped() uint64 {
+	return atomic.LoadUint64(&rateLimitDropped)
+}
+
+// rateLimited tells whether a message logged at the provided severity should be dropped
+// by the global rate limiter, and counts it if so.
+func rateLimited(sev Severity) bool {
+	if sev >= SeverityError || globalRateLimiter.allow() {
+		return false
+	}
+	atomic.AddUint64(&rateLimitDropped, 1)
+	return true
+}
+
+// tokenBucket is a token bucket rate limiter holding up to a second worth of tokens.
+type tokenBucket struct {
+	mu sync.Mutex
+
+	rate   float64 // tokens added per second, zero means unlimited
+	tokens float64
+	last   time.Time
+}
+
+func (b *tokenBucket) setRate(perSecond int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if perSecond < 0 {
+		perSecond = 0
+	}
+	b.rate = float64(perSecond)
+	b.tokens = b.rate
+	b.last = now()
+}
+
+// allow takes a token from the bucket if one is available.
+func (b *tokenBucket) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.rate == 0 {
+		return true
+	}
+
+	// refill the bucket for the time elapsed since the last call
+	t := now()
+	b.tokens += t.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.rate {
+		b.tokens = b.rate
+	}
+	b.last = t
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
@@ -0,0 +1,109 @@
+package log
+
+import (
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RateLimitSuite struct {
+	now time.Time
+}
+
+var _ = Suite(&RateLimitSuite{})
+
+func (s *RateLimitSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+
+	// freeze the clock so that the bucket is not refilled during a test
+	s.now = time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return s.now }))
+}
+
+func (s *RateLimitSuite) TearDownTest(c *C) {
+	SetGlobalRateLimit(0)
+	SetClock(nil)
+}
+
+func (s *RateLimitSuite) TestConcurrentCallers(c *C) {
+	logger := newCountingLogger()
+	Init(logger)
+	SetGlobalRateLimit(50)
+	dropped := RateLimitDropped()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				Infof("hello %s", "world")
+				Errorf("hello %s", "world")
+			}
+		}()
+	}
+	wg.Wait()
+
+	c.Assert(logger.count(SeverityInfo), Equals, 50)
+	c.Assert(logger.count(SeverityError), Equals, 200)
+	c.Assert(RateLimitDropped()-dropped, Equals, uint64(150))
+}
+
+func (s *RateLimitSuite) TestRefill(c *C) {
+	logger := newCountingLogger()
+	Init(logger)
+	SetGlobalRateLimit(10)
+
+	for i := 0; i < 20; i++ {
+		Warningf("hello %s", "world")
+	}
+	c.Assert(logger.count(SeverityWarning), Equals, 10)
+
+	// half a second later the bucket is half full
+	s.now = s.now.Add(500 * time.Millisecond)
+	for i := 0; i < 20; i++ {
+		Warningf("hello %s", "world")
+	}
+	c.Assert(logger.count(SeverityWarning), Equals, 15)
+}
+
+func (s *RateLimitSuite) TestNoLimit(c *C) {
+	logger := newCountingLogger()
+	Init(logger)
+	SetGlobalRateLimit(0)
+
+	for i := 0; i < 1000; i++ {
+		Debugf("hello %s", "world")
+	}
+	c.Assert(logger.count(SeverityDebug), Equals, 1000)
+}
+
+// countingLogger counts messages per severity and is safe for concurrent use.
+type countingLogger struct {
+	mu     sync.Mutex
+	counts map[Severity]int
+}
+
+func newCountingLogger() *countingLogger {
+	return &countingLogger{counts: make(map[Severity]int)}
+}
+
+func (l *countingLogger) count(sev Severity) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.counts[sev]
+}
+
+func (l *countingLogger) Writer(sev Severity) io.Writer {
+	return ioutil.Discard
+}
+
+func (l *countingLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.counts[sev]++
+	return ""
+}
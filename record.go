@@ -0,0 +1,96 @@
+package log
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Record holds the components of a message for loggers building their own wire format,
+// such as JSON, protobuf or GELF, see RecordFormatter.
+type Record struct {
+	Severity Severity
+
+	// Time is when the message was logged according to the clock in use, see SetClock.
+	Time time.Time
+
+	// File, Func and Line locate the caller, File being the base name of the source file.
+	File string
+	Func string
+	Line int
+
+	// Message is the formatted message.
+	Message string
+
+	// Fields are the fields attached to the message, they must not be modified.
+	Fields Fields
+}
+
+// RecordFormatter is an optional interface implemented by loggers that format messages out of
+// their components rather than out of the format string and arguments. It takes precedence
+// over Logger.FormatMessage and FieldFormatter.
+type RecordFormatter interface {
+	// FormatRecord constructs and returns a final message that will go to the logger's
+	// output channel.
+	FormatRecord(Record) string
+}
+
+// NewRecord returns the components of the message, which lets loggers implement FormatMessage
+// on top of FormatRecord. The time of the record is the time returned by Now.
+func NewRecord(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) Record {
+	return newRecord(sev, caller, now(), fields, fmt.Sprintf(format, args...))
+}
+
+// newRecord returns the components of the message logged at t.
+func newRecord(sev Severity, caller *CallerInfo, t time.Time, fields Fields, message string) Record {
+	return Record{
+		Severity: sev,
+		Time:     t,
+		File:     caller.FileName,
+		Func:     caller.FuncName,
+		Line:     caller.LineNo,
+		Message:  message,
+		Fields:   fields,
+	}
+}
+
+// lazyMessage is the format string and arguments of a message on its way to the loggers,
+// formatted on first use so that the message is formatted once however many loggers, hooks
+// and callbacks need its text. It is not safe for concurrent use.
+type lazyMessage struct {
+	format string
+	args   []interface{}
+
+	formatted bool
+	message   string
+}
+
+// formattedMessage returns the lazy message of an already formatted message.
+func formattedMessage(message string) *lazyMessage {
+	return &lazyMessage{format: "%s", args: []interface{}{message}, formatted: true, message: message}
+}
+
+// lazyMessages holds the lazy messages of the messages being logged, which are no longer
+// referenced once they have been sent, see writeMessage.
+var lazyMessages = sync.Pool{New: func() interface{} { return new(lazyMessage) }}
+
+// newLazyMessage returns a lazy message from the pool, to be released once it has been sent.
+func newLazyMessage(format string, args []interface{}) *lazyMessage {
+	m := lazyMessages.Get().(*lazyMessage)
+	m.format, m.args = format, args
+	return m
+}
+
+// release returns the message to the pool, dropping the arguments it holds.
+func (m *lazyMessage) release() {
+	*m = lazyMessage{}
+	lazyMessages.Put(m)
+}
+
+// text returns the formatted message.
+func (m *lazyMessage) text() string {
+	if !m.formatted {
+		m.message, m.formatted = fmt.Sprintf(m.format, m.args...), true
+	}
+	return m.message
+}
@@ -0,0 +1,73 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RecordSuite struct {
+}
+
+var _ = Suite(&RecordSuite{})
+
+func (s *RecordSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC) }))
+}
+
+func (s *RecordSuite) TearDownTest(c *C) {
+	SetClock(nil)
+}
+
+// recordLogger is a test logger keeping the records of the messages it logs.
+type recordLogger struct {
+	b       bytes.Buffer
+	records []Record
+}
+
+func (l *recordLogger) Writer(sev Severity) io.Writer {
+	return &l.b
+}
+
+func (l *recordLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.FormatRecord(NewRecord(sev, caller, nil, format, args...))
+}
+
+func (l *recordLogger) FormatRecord(r Record) string {
+	l.records = append(l.records, r)
+	return fmt.Sprintf("%s %s\n", r.Severity, r.Message)
+}
+
+func (s *RecordSuite) TestNewRecord(c *C) {
+	r := NewRecord(SeverityWarning, &CallerInfo{"filename", "filepath", "funcname", 42}, Fields{"user": "bob"}, "hello %s", "world")
+	c.Assert(r, DeepEquals, Record{
+		Severity: SeverityWarning,
+		Time:     time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC),
+		File:     "filename",
+		Func:     "funcname",
+		Line:     42,
+		Message:  "hello world",
+		Fields:   Fields{"user": "bob"},
+	})
+}
+
+func (s *RecordSuite) TestRecordFormatter(c *C) {
+	logger := &recordLogger{}
+	Init(logger)
+
+	WithFields(Fields{"user": "bob"}).Errorf("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "ERROR hello world\n")
+	c.Assert(logger.records, HasLen, 1)
+
+	r := logger.records[0]
+	c.Assert(r.Severity, Equals, SeverityError)
+	c.Assert(r.File, Equals, "record_test.go")
+	c.Assert(r.Func, Matches, ".*TestRecordFormatter")
+	c.Assert(r.Line > 0, Equals, true)
+	c.Assert(r.Message, Equals, "hello world")
+	c.Assert(r.Fields, DeepEquals, Fields{"user": "bob"})
+}
@@ -0,0 +1,41 @@
+package log
+
+import (
+	"runtime/debug"
+)
+
+// LogRecover recovers from a panic and logs the panic value along with the stack trace
+// at ERROR. It is supposed to be deferred at the top of a goroutine:
+//
+//	go func() {
+//		defer log.LogRecover()
+//		...
+//	}()
+func LogRecover() {
+	if r := recover(); r != nil {
+		logPanic(r, SeverityError)
+	}
+}
+
+// LogRecoverWith is like LogRecover but logs at the provided severity and, if repanic is set,
+// panics again with the recovered value once it has been logged.
+func LogRecoverWith(sev Severity, repanic bool) {
+	if r := recover(); r != nil {
+		logPanic(r, sev)
+		if repanic {
+			panic(r)
+		}
+	}
+}
+
+// Go runs the function in a new goroutine, logging a panic in it with LogRecover.
+func Go(fn func()) {
+	go func() {
+		defer LogRecover()
+		fn()
+	}()
+}
+
+func logPanic(r interface{}, sev Severity) {
+	writeMessage(2, sev, nil, "recovered from panic: %v\n%s", r, stackTrace(debug.Stack()))
+}
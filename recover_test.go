@@ -0,0 +1,87 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type RecoverSuite struct {
+}
+
+var _ = Suite(&RecoverSuite{})
+
+func (s *RecoverSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *RecoverSuite) TestLogRecover(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	func() {
+		defer LogRecover()
+		panicky()
+	}()
+
+	message := logger.b.String()
+	c.Assert(strings.HasPrefix(message, "ERROR recovered from panic: boom\n"), Equals, true)
+	c.Assert(strings.Contains(message, "panicky"), Equals, true)
+}
+
+func (s *RecoverSuite) TestLogRecoverNoPanic(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	func() {
+		defer LogRecover()
+	}()
+	c.Assert(logger.b.Len(), Equals, 0)
+}
+
+func (s *RecoverSuite) TestLogRecoverWith(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	var repanicked interface{}
+	func() {
+		defer func() { repanicked = recover() }()
+		defer LogRecoverWith(SeverityWarning, true)
+		panicky()
+	}()
+
+	c.Assert(repanicked, Equals, "boom")
+	c.Assert(strings.HasPrefix(logger.b.String(), "WARN recovered from panic: boom\n"), Equals, true)
+}
+
+func (s *RecoverSuite) TestGo(c *C) {
+	logger := &chanLogger{make(chan string, 1)}
+	Init(logger)
+
+	Go(panicky)
+
+	message := <-logger.messages
+	c.Assert(strings.HasPrefix(message, "ERROR recovered from panic: boom\n"), Equals, true)
+	c.Assert(strings.Contains(message, "panicky"), Equals, true)
+}
+
+func panicky() {
+	panic("boom")
+}
+
+// chanLogger sends formatted messages to a channel.
+type chanLogger struct {
+	messages chan string
+}
+
+func (l *chanLogger) Writer(sev Severity) io.Writer {
+	return ioutil.Discard
+}
+
+func (l *chanLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	l.messages <- fmt.Sprintf("%s %s", sev, fmt.Sprintf(format, args...))
+	return ""
+}
@@ -0,0 +1,188 @@
+package log
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+// RedactedMask replaces the values and the parts of messages masked by redaction.
+const RedactedMask = "[REDACTED]"
+
+// Patterns of common sensitive data for SetRedaction.
+const (
+	// CreditCardPattern matches 13 to 19 digit card numbers, optionally grouped by spaces or
+	// dashes.
+	CreditCardPattern = `\b(?:\d[ -]?){12,18}\d\b`
+
+	// EmailPattern matches email addresses.
+	EmailPattern = `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`
+)
+
+// redaction is what SetRedaction masks.
+type redaction struct {
+	fields   map[string]bool // lowercase names
+	patterns []*regexp.Regexp
+}
+
+// redactions holds the *redaction set with SetRedaction, nil when nothing is redacted.
+var redactions atomic.Value
+
+// SetRedaction masks sensitive data before any logger, hook or callback sees it: the values of
+// the fields of the provided names, in any case, such as "password", "token" or
+// "authorization", be they nested in maps or expanded from Loggable values, e.g.
+// "user.password", and the parts of messages and of the renderings of field values matching
+// any of the regular expression patterns, such as CreditCardPattern and EmailPattern, are
+// replaced with RedactedMask. For example:
+//
+//	err := log.SetRedaction([]string{"password", "token"}, log.CreditCardPattern)
+//
+// Messages in which a pattern matches reach the loggers already formatted, with a "%s" format
+// string. Calling SetRedaction with no names and no patterns turns redaction off.
+func SetRedaction(fields []string, patterns ...string) error {
+	r := &redaction{fields: make(map[string]bool, len(fields))}
+	for _, name := range fields {
+		r.fields[strings.ToLower(name)] = true
+	}
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid redaction pattern %q: %v", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+
+	if len(r.fields) == 0 && len(r.patterns) == 0 {
+		r = nil
+	}
+	redactions.Store(r)
+	return nil
+}
+
+// currentRedaction returns the redaction set with SetRedaction, or nil.
+func currentRedaction() *redaction {
+	r, _ := redactions.Load().(*redaction)
+	return r
+}
+
+// redact returns the fields and the message with the sensitive data masked.
+func redact(fields Fields, m *lazyMessage) (Fields, *lazyMessage) {
+	r := currentRedaction()
+	if r == nil {
+		return fields, m
+	}
+	if text, ok := r.mask(m.text()); ok {
+		m = formattedMessage(text)
+	}
+	fields, _ = r.redactFields(fields)
+	return fields, m
+}
+
+// mask replaces the parts of the string matching the patterns, and tells whether there were any.
+func (r *redaction) mask(s string) (string, bool) {
+	masked := false
+	for _, re := range r.patterns {
+		if re.MatchString(s) {
+			s, masked = re.ReplaceAllLiteralString(s, RedactedMask), true
+		}
+	}
+	return s, masked
+}
+
+// redactFields returns the fields with the values of the redacted fields and the values
+// rendered with sensitive data masked, be they nested in other fields, and whether anything
+// was masked. The fields are not modified, they are returned as is if nothing is masked.
+func (r *redaction) redactFields(fields Fields) (Fields, bool) {
+	var redacted Fields
+	for k, v := range fields {
+		masked, ok := r.redactValue(k, v)
+		if !ok {
+			continue
+		}
+		if redacted == nil {
+			redacted = make(Fields, len(fields))
+			for k, v := range fields {
+				redacted[k] = v
+			}
+		}
+		redacted[k] = masked
+	}
+	if redacted == nil {
+		return fields, false
+	}
+	return redacted, true
+}
+
+// redactValue returns the value of the field redacted, and whether it differs from the value.
+// Maps keyed by strings are redacted like fields.
+func (r *redaction) redactValue(name string, v interface{}) (interface{}, bool) {
+	if r.redactedName(name) {
+		return RedactedMask, true
+	}
+	switch v := v.(type) {
+	case Fields:
+		return r.redactFields(v)
+	case map[string]interface{}:
+		redacted, ok := r.redactFields(Fields(v))
+		return map[string]interface{}(redacted), ok
+	case map[string]string:
+		return r.redactStrings(v)
+	}
+	if len(r.patterns) == 0 {
+		return v, false
+	}
+
+	switch v := v.(type) {
+	case []string:
+		var redacted []string
+		for i, s := range v {
+			if masked, ok := r.mask(s); ok {
+				if redacted == nil {
+					redacted = append([]string(nil), v...)
+				}
+				redacted[i] = masked
+			}
+		}
+		return redacted, redacted != nil
+	}
+	return r.mask(fmt.Sprint(v))
+}
+
+// redactedName returns true if the values of fields of the name are redacted, the name
+// matching in full or by its last dotted segment, as the fields expanded from Loggable values
+// are named.
+func (r *redaction) redactedName(name string) bool {
+	name = strings.ToLower(name)
+	if r.fields[name] {
+		return true
+	}
+	i := strings.LastIndexByte(name, '.')
+	return i >= 0 && r.fields[name[i+1:]]
+}
+
+// redactStrings returns the map with its values redacted like those of fields, and whether
+// anything was masked. The map is not modified.
+func (r *redaction) redactStrings(m map[string]string) (map[string]string, bool) {
+	var redacted map[string]string
+	for k, v := range m {
+		masked := RedactedMask
+		if !r.redactedName(k) {
+			var ok bool
+			if masked, ok = r.mask(v); !ok {
+				continue
+			}
+		}
+		if redacted == nil {
+			redacted = make(map[string]string, len(m))
+			for k, v := range m {
+				redacted[k] = v
+			}
+		}
+		redacted[k] = masked
+	}
+	if redacted == nil {
+		return m, false
+	}
+	return redacted, true
+}
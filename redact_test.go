@@ -0,0 +1,103 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type RedactSuite struct {
+}
+
+var _ = Suite(&RedactSuite{})
+
+func (s *RedactSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *RedactSuite) TearDownTest(c *C) {
+	SetRedaction(nil)
+}
+
+func (s *RedactSuite) TestFields(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	c.Assert(SetRedaction([]string{"password", "Authorization"}), IsNil)
+
+	fields := Fields{"user": "bob", "PASSWORD": "hunter2", "request": Fields{"authorization": "Bearer abc"}}
+	WithFields(fields).Infof("logging in")
+	c.Assert(logger.b.String(), Equals, "INFO logging in PASSWORD=[REDACTED] request=map[authorization:[REDACTED]] user=bob\n")
+
+	// the fields of the entry are left alone
+	c.Assert(fields["PASSWORD"], Equals, "hunter2")
+}
+
+// account is a Loggable value with a sensitive field.
+type account struct {
+	name, password string
+}
+
+func (a account) LogFields() Fields {
+	return Fields{"name": a.name, "password": a.password}
+}
+
+func (s *RedactSuite) TestNestedFields(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	c.Assert(SetRedaction([]string{"password"}), IsNil)
+
+	// expanded fields are matched by their last segment, maps are redacted like fields
+	WithFields(Fields{
+		"user": account{"bob", "hunter2"},
+		"meta": map[string]interface{}{"password": "x", "nested": map[string]string{"password": "y", "host": "a"}},
+	}).Infof("logging in as %v", account{"alice", "s3cret"})
+	c.Assert(logger.b.String(), Equals, "INFO logging in as name=alice password=[REDACTED] meta=\"map[nested:map[host:a password:[REDACTED]] password:[REDACTED]]\" user.name=bob user.password=[REDACTED]\n")
+
+	// only whole segments match
+	logger.b.Reset()
+	WithFields(Fields{"password_hint": "pet", "userpassword": "hunter2"}).Infof("hello")
+	c.Assert(logger.b.String(), Equals, "INFO hello password_hint=pet userpassword=hunter2\n")
+}
+
+func (s *RedactSuite) TestPatterns(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+	var records []Record
+	AddHook(HookFunc(func(r Record) error {
+		records = append(records, r)
+		return nil
+	}))
+	defer func() { hooks = nil }()
+	c.Assert(SetRedaction(nil, CreditCardPattern, EmailPattern), IsNil)
+
+	WithFields(Fields{"card": 4111111111111111, "note": "call bob@example.com", "n": 42}).
+		WithError(fmt.Errorf("charge failed: %w", errors.New("card 4111-1111-1111-1111 declined"))).
+		Infof("charging %s for %d", "4111 1111 1111 1111", 42)
+
+	// hooks and loggers alike see the data masked
+	c.Assert(records, HasLen, 1)
+	c.Assert(records[0].Message, Equals, "charging [REDACTED] for 42")
+	c.Assert(records[0].Fields["card"], Equals, RedactedMask)
+	c.Assert(records[0].Fields["note"], Equals, "call [REDACTED]")
+	c.Assert(records[0].Fields["n"], Equals, 42)
+	c.Assert(records[0].Fields["error"], Equals, "charge failed: card [REDACTED] declined")
+	chain := records[0].Fields[ErrorChainField].([]string)
+	c.Assert(chain[len(chain)-1], Equals, "card [REDACTED] declined")
+	c.Assert(strings.Contains(logger.b.String(), "4111"), Equals, false)
+
+	c.Assert(SetRedaction(nil, "("), ErrorMatches, `invalid redaction pattern "\(": .*`)
+}
+
+func (s *RedactSuite) TestFallback(c *C) {
+	var fallback bytes.Buffer
+	saved := stderr
+	stderr = &fallback
+	defer func() { stderr = saved }()
+	c.Assert(SetRedaction([]string{"token"}, EmailPattern), IsNil)
+
+	writeFallback(SeverityInfo, WithFields(Fields{"token": "abc"}), "mail %s", "bob@example.com")
+	c.Assert(fallback.String(), Equals, "INFO mail [REDACTED] token=[REDACTED] (logged while logging)\n")
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+	"sync/atomic"
+)
+
+var (
+	// sends counts the messages being sent to the loggers, hooks and callbacks. While it is
+	// zero no goroutine can be logging from within a logger, which is checked at no other cost.
+	sends int32
+
+	// sendReturn is the return address of the call to the function sending a message in
+	// guardSending, which is on the stack of goroutines logging while they send a message.
+	sendReturn uintptr
+)
+
+func init() {
+	guardSending(func() {
+		var pcs [1]uintptr
+		runtime.Callers(2, pcs[:])
+		sendReturn = pcs[0]
+	})
+}
+
+// goroutineID returns the ID of the calling goroutine as found in its stack trace.
+func goroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+
+	var id uint64
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			break
+		}
+		id = id*10 + uint64(c-'0')
+	}
+	return id
+}
+
+// reentered returns true if the goroutine is logging from within a logger, e.g. from the error
+// handling of a writer. The stack is only looked at while messages are being sent.
+func reentered() bool {
+	if atomic.LoadInt32(&sends) == 0 {
+		return false
+	}
+	var pcs [32]uintptr
+	for skip := 2; ; skip += len(pcs) {
+		n := runtime.Callers(skip, pcs[:])
+		for _, pc := range pcs[:n] {
+			if pc == sendReturn {
+				return true
+			}
+		}
+		if n < len(pcs) {
+			return false
+		}
+	}
+}
+
+// guardSending runs send, the goroutine being found to be logging from within a logger if it
+// logs while send runs, see reentered.
+//
+//go:noinline
+func guardSending(send func()) {
+	atomic.AddInt32(&sends, 1)
+	defer atomic.AddInt32(&sends, -1)
+	send()
+}
+
+// writeFallback writes a message logged from within a logger to the standard error, bypassing
+// the loggers to avoid infinite recursion.
+func writeFallback(sev Severity, e *Entry, format string, args ...interface{}) {
+	fields, m := redact(e.getFields(), &lazyMessage{format: format, args: args})
+	message := m.text()
+	if len(fields) > 0 {
+		message += " " + formatFields(fields)
+	}
+	fmt.Fprintf(stderr, "%s %s (logged while logging)\n", sev, message)
+}
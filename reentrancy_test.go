@@ -0,0 +1,133 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync/atomic"
+
+	. "gopkg.in/check.v1"
+)
+
+type ReentrancySuite struct {
+	fallback *bytes.Buffer
+}
+
+var _ = Suite(&ReentrancySuite{})
+
+func (s *ReentrancySuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	s.fallback = &bytes.Buffer{}
+	stderr = s.fallback
+}
+
+func (s *ReentrancySuite) TearDownTest(c *C) {
+	stderr = os.Stderr
+}
+
+// failingLogger logs an error whenever a write fails, as a backend handling its errors by
+// logging them would.
+type failingLogger struct {
+	writes int
+}
+
+func (l *failingLogger) Writer(sev Severity) io.Writer {
+	return l
+}
+
+func (l *failingLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return fmt.Sprintf(format, args...)
+}
+
+func (l *failingLogger) Write(p []byte) (int, error) {
+	l.writes++
+	err := errors.New("connection refused")
+	WithError(err).Errorf("failed to write %d bytes", len(p))
+	return 0, err
+}
+
+func (s *ReentrancySuite) TestLoggingWhileLogging(c *C) {
+	failing := &failingLogger{}
+	sibling := newTestLogger("log")
+	Init(failing, sibling)
+
+	Infof("hello %s", "world")
+
+	// the inner message bypasses the loggers, including the working one
+	c.Assert(failing.writes, Equals, 1)
+	c.Assert(sibling.b.String(), Equals, "INFO hello world\n")
+	c.Assert(s.fallback.String(), Equals, "ERROR failed to write 11 bytes error=\"connection refused\" (logged while logging)\n")
+
+	// the guard is released once the message is sent
+	s.fallback.Reset()
+	loggers = []Logger{sibling}
+	Warningf("hello %s", "again")
+	c.Assert(sibling.b.String(), Equals, "INFO hello world\nWARN hello again\n")
+	c.Assert(s.fallback.Len(), Equals, 0)
+}
+
+// blockingLogger is a test logger whose first write blocks until it is released.
+type blockingLogger struct {
+	*testLogger
+
+	writes            int32
+	blocked, released chan struct{}
+}
+
+func (l *blockingLogger) Writer(sev Severity) io.Writer {
+	return l
+}
+
+func (l *blockingLogger) Write(p []byte) (int, error) {
+	if atomic.AddInt32(&l.writes, 1) == 1 {
+		close(l.blocked)
+		<-l.released
+	}
+	return len(p), nil
+}
+
+func (s *ReentrancySuite) TestConcurrentSending(c *C) {
+	blocking := &blockingLogger{testLogger: newTestLogger("blocking"), blocked: make(chan struct{}), released: make(chan struct{})}
+	sibling := newTestLogger("log")
+	Init(blocking, sibling)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		Infof("hello %s", "world")
+	}()
+	<-blocking.blocked
+
+	// another goroutine sending a message does not make this one log while logging
+	Warningf("hello %s", "again")
+	close(blocking.released)
+	<-done
+	c.Assert(s.fallback.Len(), Equals, 0)
+	c.Assert(sibling.b.String(), Equals, "WARN hello again\nINFO hello world\n")
+}
+
+func (s *ReentrancySuite) TestCallbackLogging(c *C) {
+	defer func() { callbacks = nil }()
+	logger := newTestLogger("log")
+	Init(logger)
+	OnSeverity(SeverityError, func(msg string) {
+		Errorf("callback saw %q", msg)
+	})
+
+	Errorf("hello %s", "world")
+
+	c.Assert(logger.b.String(), Equals, "ERROR hello world\n")
+	c.Assert(s.fallback.String(), Equals, "ERROR callback saw \"hello world\" (logged while logging)\n")
+}
+
+func (s *ReentrancySuite) TestGoroutineID(c *C) {
+	id := goroutineID()
+	c.Assert(id, Not(Equals), uint64(0))
+	c.Assert(goroutineID(), Equals, id)
+
+	other := make(chan uint64)
+	go func() { other <- goroutineID() }()
+	c.Assert(<-other, Not(Equals), id)
+}
@@ -0,0 +1,74 @@
+package log
+
+import (
+	"reflect"
+)
+
+// AddLogger adds the loggers to the chain while messages are being logged, like Init does.
+func AddLogger(l ...Logger) {
+	Init(l...)
+}
+
+// RemoveLogger takes the logger out of the chain and returns true if it was in it. Messages
+// being logged when it is called may still be sent to it. The logger is not closed, which is
+// up to the caller once it no longer needs it. Loggers of types that cannot be compared, such
+// as slices, cannot be removed, see ReplaceLoggers.
+func RemoveLogger(l Logger) bool {
+	loggersMu.Lock()
+	defer loggersMu.Unlock()
+
+	// messages being logged iterate over the current chain, build a new one
+	chain := make([]Logger, 0, len(loggers))
+	for _, logger := range loggers {
+		if !sameLogger(logger, l) {
+			chain = append(chain, logger)
+		}
+	}
+	if len(chain) == len(loggers) {
+		return false
+	}
+	loggers = chain
+	pruneLoggerNames()
+	return true
+}
+
+// ReplaceLoggers replaces the chain with the provided loggers at once, so that every message
+// goes either to the previous loggers or to the new ones, and returns the previous loggers,
+// which are not closed.
+func ReplaceLoggers(l ...Logger) []Logger {
+	loggersMu.Lock()
+	previous := loggers
+	loggers = append([]Logger(nil), l...)
+	pruneLoggerNames()
+	loggersMu.Unlock()
+
+	if len(l) > 0 {
+		bootstrap.flush(l)
+	}
+	return previous
+}
+
+// pruneLoggerNames forgets the names of the loggers no longer in the chain, it must be called
+// with loggersMu held.
+func pruneLoggerNames() {
+	var names []namedLogger
+	for _, named := range loggerNames {
+		for _, l := range loggers {
+			if sameLogger(named.logger, l) {
+				names = append(names, named)
+				break
+			}
+		}
+	}
+	loggerNames = names
+}
+
+// sameLogger returns true if the loggers are the same, without panicking on loggers of types
+// that cannot be compared.
+func sameLogger(a, b Logger) bool {
+	t := reflect.TypeOf(a)
+	if t == nil || t != reflect.TypeOf(b) || !t.Comparable() {
+		return false
+	}
+	return a == b
+}
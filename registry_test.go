@@ -0,0 +1,87 @@
+package log
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type RegistrySuite struct {
+}
+
+var _ = Suite(&RegistrySuite{})
+
+func (s *RegistrySuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	loggerNames = nil
+}
+
+// sliceLogger is a logger of a type that cannot be compared.
+type sliceLogger []string
+
+func (l sliceLogger) Writer(sev Severity) io.Writer {
+	return nil
+}
+
+func (l sliceLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return ""
+}
+
+func (s *RegistrySuite) TestAddAndRemove(c *C) {
+	logger1 := newTestLogger("log1")
+	logger2 := newTestLogger("log2")
+	AddLogger(logger1, sliceLogger{"a"})
+	AddLogger(logger2)
+
+	Infof("hello %s", "both")
+	c.Assert(RemoveLogger(logger1), Equals, true)
+	c.Assert(RemoveLogger(logger1), Equals, false)
+	c.Assert(RemoveLogger(sliceLogger{"a"}), Equals, false)
+	Infof("hello %s", "second")
+
+	c.Assert(logger1.b.String(), Equals, "INFO hello both\n")
+	c.Assert(logger2.b.String(), Equals, "INFO hello both\nINFO hello second\n")
+	c.Assert(loggers, HasLen, 2)
+}
+
+func (s *RegistrySuite) TestReplace(c *C) {
+	defer func() { stdout = os.Stdout }()
+	stdout = &bytes.Buffer{}
+	c.Assert(InitWithConfig(Config{Name: Console, Severity: "info"}), IsNil)
+	console := loggers[0]
+
+	logger := newTestLogger("log")
+	previous := ReplaceLoggers(logger)
+	c.Assert(previous, DeepEquals, []Logger{console})
+	c.Assert(loggerNames, HasLen, 0)
+
+	Infof("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "INFO hello world\n")
+	c.Assert(ReplaceLoggers(), DeepEquals, []Logger{logger})
+}
+
+func (s *RegistrySuite) TestConcurrent(c *C) {
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				l, _ := NewMemoryLogger(Config{Severity: "info"})
+				AddLogger(l)
+				RemoveLogger(l)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				Infof("hello %d", j)
+			}
+		}()
+	}
+	wg.Wait()
+	c.Assert(loggers, HasLen, 0)
+}
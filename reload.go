@@ -0,0 +1,198 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// fileLoggers are the loggers of the chain made from config files, by InitWithConfigFile and
+// ReloadConfigFile, which reloads replace. They are guarded by loggersMu.
+var fileLoggers []Logger
+
+var (
+	// sendEpoch is the epoch messages start being sent in, see beginSend and drainSends.
+	sendEpoch int32
+
+	// epochSends counts the messages being sent in each epoch.
+	epochSends [2]int32
+
+	// drainMu serializes drainSends.
+	drainMu sync.Mutex
+)
+
+// ReloadConfigFile rebuilds the loggers from the config file, see ConfigsFromFile, and
+// replaces those made from a config file before, by InitWithConfigFile or a previous reload,
+// with them at once, so that every message goes either to the previous loggers or to the new
+// ones, e.g. to change severities or add a file logger to a live process. Loggers added in
+// code, with Init or AddLogger, are kept. The previous loggers are flushed and closed once the
+// messages being sent to them are written. If the file cannot be read or one of its loggers
+// cannot be made, the chain is left as it is and the error is returned.
+func ReloadConfigFile(path string) error {
+	configs, err := ConfigsFromFile(path)
+	if err != nil {
+		return err
+	}
+
+	var l []Logger
+	var names []namedLogger
+	for i, config := range configs {
+		logger, err := NewLogger(config)
+		if err != nil {
+			closeLoggers(l)
+			return fmt.Errorf("%s: loggers[%d]: %v", path, i, err)
+		}
+		l = append(l, logger)
+		names = append(names, namedLogger{config.Name, logger})
+	}
+
+	loggersMu.Lock()
+	var previous []Logger
+	chain := make([]Logger, 0, len(loggers)+len(l))
+	for _, logger := range loggers {
+		if containsLogger(fileLoggers, logger) {
+			previous = append(previous, logger)
+		} else {
+			chain = append(chain, logger)
+		}
+	}
+	loggers, fileLoggers = append(chain, l...), l
+	loggerNames = append(loggerNames, names...)
+	pruneLoggerNames()
+	loggersMu.Unlock()
+	bootstrap.flush(l)
+
+	drainSends()
+	closeLoggers(previous)
+	return nil
+}
+
+// containsLogger returns true if the logger is one of the loggers.
+func containsLogger(l []Logger, logger Logger) bool {
+	for _, other := range l {
+		if sameLogger(other, logger) {
+			return true
+		}
+	}
+	return false
+}
+
+// beginSend counts a message about to be sent to the chain, which is to be read afterwards,
+// and returns the epoch to end the send in, see endSend.
+func beginSend() int32 {
+	for {
+		epoch := atomic.LoadInt32(&sendEpoch)
+		atomic.AddInt32(&epochSends[epoch], 1)
+		if atomic.LoadInt32(&sendEpoch) == epoch {
+			return epoch
+		}
+		// a drain started meanwhile, count the message in the new epoch
+		atomic.AddInt32(&epochSends[epoch], -1)
+	}
+}
+
+// endSend counts the message as sent.
+func endSend(epoch int32) {
+	atomic.AddInt32(&epochSends[epoch], -1)
+}
+
+// drainSends waits for the messages being sent to the chain read before it is called to be
+// sent, messages starting being sent afterwards reading the chain as it is.
+func drainSends() {
+	drainMu.Lock()
+	defer drainMu.Unlock()
+
+	epoch := atomic.LoadInt32(&sendEpoch)
+	atomic.StoreInt32(&sendEpoch, 1-epoch)
+	for atomic.LoadInt32(&epochSends[epoch]) > 0 {
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// WatchConfigFile reloads the config file, see ReloadConfigFile, whenever the process receives
+// SIGHUP, except in browsers, and if interval is positive, whenever the file changes, checking it every interval.
+// Reloads are logged at INFO and their errors at ERROR, the loggers being left as they are. It
+// returns a function to stop watching the file.
+func WatchConfigFile(path string, interval time.Duration) func() {
+	hup := make(chan os.Signal, 1)
+	if len(reloadSignals) > 0 {
+		signal.Notify(hup, reloadSignals...)
+	}
+	last := statConfigFile(path)
+
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		defer signal.Stop(hup)
+
+		var tick <-chan time.Time
+		if interval > 0 {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			tick = ticker.C
+		}
+
+		for {
+			select {
+			case <-hup:
+			case <-tick:
+				if statConfigFile(path).same(last) {
+					continue
+				}
+			case <-stop:
+				return
+			}
+			last = statConfigFile(path)
+			if err := ReloadConfigFile(path); err != nil {
+				Errorf("failed to reload the logging config: %v", err)
+				continue
+			}
+			Infof("reloaded the logging config from %s", path)
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-done
+		})
+	}
+}
+
+// configFileState is what tells that a config file changed.
+type configFileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// statConfigFile returns the state of the config file, the zero state if it cannot be read,
+// e.g. while it is being replaced.
+func statConfigFile(path string) configFileState {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return configFileState{}
+	}
+	return configFileState{fi.ModTime(), fi.Size()}
+}
+
+func (s configFileState) same(other configFileState) bool {
+	return s.modTime.Equal(other.modTime) && s.size == other.size
+}
+
+// closeLoggers flushes and closes the loggers.
+func closeLoggers(l []Logger) {
+	for _, logger := range l {
+		if f, ok := logger.(Flusher); ok {
+			f.Flush()
+		}
+		if c, ok := logger.(io.Closer); ok {
+			c.Close()
+		}
+		forgetComponentSeverities(logger)
+	}
+}
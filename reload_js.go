@@ -0,0 +1,8 @@
+//go:build js && wasm
+
+package log
+
+import "os"
+
+// reloadSignals are the signals WatchConfigFile reloads the config file on, browsers send none.
+var reloadSignals []os.Signal
@@ -0,0 +1,11 @@
+//go:build !js || !wasm
+
+package log
+
+import (
+	"os"
+	"syscall"
+)
+
+// reloadSignals are the signals WatchConfigFile reloads the config file on.
+var reloadSignals = []os.Signal{syscall.SIGHUP}
@@ -0,0 +1,29 @@
+//go:build !js || !wasm
+
+package log
+
+import (
+	"os"
+	"syscall"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *ReloadSuite) TestWatchConfigFileSignal(c *C) {
+	path := s.writeConfig(c, "a.log", "info")
+	c.Assert(InitWithConfigFile(path), IsNil)
+	stop := WatchConfigFile(path, 0)
+	defer stop()
+
+	s.writeConfig(c, "b.log", "info")
+	p, err := os.FindProcess(os.Getpid())
+	c.Assert(err, IsNil)
+	if err := p.Signal(syscall.SIGHUP); err != nil {
+		c.Skip(err.Error())
+	}
+	s.waitFor(c, "b.log")
+
+	// stopping is idempotent
+	stop()
+	stop()
+}
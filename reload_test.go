@@ -0,0 +1,143 @@
+package log
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type ReloadSuite struct {
+	dir string
+}
+
+var _ = Suite(&ReloadSuite{})
+
+func (s *ReloadSuite) SetUpTest(c *C) {
+	loggers, fileLoggers = []Logger{}, nil
+	s.dir = c.MkDir()
+}
+
+func (s *ReloadSuite) TearDownTest(c *C) {
+	closeLoggers(ReplaceLoggers())
+}
+
+// writeConfig writes a config file logging to the files at the severities.
+func (s *ReloadSuite) writeConfig(c *C, files ...string) string {
+	var configs []string
+	for i := 0; i < len(files); i += 2 {
+		configs = append(configs, fmt.Sprintf(`{"name": "file", "severity": %q, "path": %q}`, files[i+1], filepath.Join(s.dir, files[i])))
+	}
+	path := filepath.Join(s.dir, "logging.json")
+	c.Assert(ioutil.WriteFile(path, []byte(`{"loggers": [`+strings.Join(configs, ", ")+`]}`), 0644), IsNil)
+	return path
+}
+
+func (s *ReloadSuite) read(c *C, name string) string {
+	b, _ := ioutil.ReadFile(filepath.Join(s.dir, name))
+	return string(b)
+}
+
+func (s *ReloadSuite) TestReloadConfigFile(c *C) {
+	path := s.writeConfig(c, "a.log", "info")
+	c.Assert(InitWithConfigFile(path), IsNil)
+	previous := currentLoggers()
+	SetComponentSeverity(previous[0], "storage", SeverityDebug)
+	Infof("before")
+
+	s.writeConfig(c, "a.log", "warn", "b.log", "info")
+	c.Assert(ReloadConfigFile(path), IsNil)
+	Infof("after")
+	Warningf("warning")
+
+	c.Assert(strings.Count(s.read(c, "a.log"), "\n"), Equals, 2)
+	c.Assert(s.read(c, "a.log"), Matches, "(?s).*before.*warning.*")
+	c.Assert(s.read(c, "b.log"), Matches, "(?s).*after.*warning.*")
+	c.Assert(describe(loggers[1]).Destination, Equals, filepath.Join(s.dir, "b.log"))
+
+	// the component severities of the previous loggers are forgotten
+	_, ok := componentSeverity(previous[0], "storage")
+	c.Assert(ok, Equals, false)
+}
+
+func (s *ReloadSuite) TestReloadKeepsLoggersAddedInCode(c *C) {
+	added := newTestLogger("added")
+	Init(added)
+	path := s.writeConfig(c, "a.log", "info")
+	c.Assert(InitWithConfigFile(path), IsNil)
+
+	s.writeConfig(c, "b.log", "info")
+	c.Assert(ReloadConfigFile(path), IsNil)
+	Infof("after")
+	c.Assert(loggers, HasLen, 2)
+	c.Assert(loggers[0], Equals, added)
+	c.Assert(added.b.String(), Equals, "INFO after\n")
+	c.Assert(s.read(c, "a.log"), Not(Matches), "(?s).*after.*")
+	c.Assert(s.read(c, "b.log"), Matches, "(?s).*after.*")
+}
+
+func (s *ReloadSuite) TestReloadWaitsForMessagesBeingSent(c *C) {
+	blocking := &blockingLogger{testLogger: newTestLogger("blocking"), blocked: make(chan struct{}), released: make(chan struct{})}
+	Init(blocking)
+	path := s.writeConfig(c, "a.log", "info")
+	c.Assert(InitWithConfigFile(path), IsNil)
+
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		Infof("in flight")
+	}()
+	<-blocking.blocked
+
+	// the previous file logger is closed once the message has been written to it
+	reloaded := make(chan error)
+	go func() {
+		reloaded <- ReloadConfigFile(s.writeConfig(c, "b.log", "info"))
+	}()
+	select {
+	case <-reloaded:
+		c.Fatal("reloaded while a message is being sent")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(blocking.released)
+	<-sent
+	c.Assert(<-reloaded, IsNil)
+	c.Assert(s.read(c, "a.log"), Matches, "(?s).*in flight.*")
+}
+
+func (s *ReloadSuite) TestReloadConfigFileError(c *C) {
+	path := s.writeConfig(c, "a.log", "info")
+	c.Assert(InitWithConfigFile(path), IsNil)
+
+	s.writeConfig(c, "a.log", "info", "b.log", "loud")
+	c.Assert(ReloadConfigFile(path), ErrorMatches, `.*logging.json: loggers\[1\]\.severity: unsupported severity: LOUD`)
+	s.writeConfig(c, "a.log", "info", "missing/b.log", "info")
+	c.Assert(ReloadConfigFile(path), ErrorMatches, `.*logging.json: loggers\[1\]: .*`)
+
+	// the loggers are left as they are
+	Infof("still logging")
+	c.Assert(loggers, HasLen, 1)
+	c.Assert(s.read(c, "a.log"), Matches, "(?s).*still logging.*")
+}
+
+// waitFor logs messages until the file contains one.
+func (s *ReloadSuite) waitFor(c *C, name string) {
+	for deadline := time.Now().Add(5 * time.Second); !strings.Contains(s.read(c, name), "waiting"); time.Sleep(5 * time.Millisecond) {
+		c.Assert(time.Now().Before(deadline), Equals, true)
+		Infof("waiting")
+	}
+}
+
+func (s *ReloadSuite) TestWatchConfigFile(c *C) {
+	path := s.writeConfig(c, "a.log", "info")
+	c.Assert(InitWithConfigFile(path), IsNil)
+	stop := WatchConfigFile(path, 5*time.Millisecond)
+	defer stop()
+
+	s.writeConfig(c, "abc.log", "info")
+	s.waitFor(c, "abc.log")
+	c.Assert(s.read(c, "abc.log"), Matches, "(?s).*reloaded the logging config from .*logging.json.*")
+}
@@ -0,0 +1,307 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Defaults of RetryPolicy.
+const (
+	DefaultRetryBackoff    = time.Second
+	DefaultRetryMaxBackoff = time.Minute
+	DefaultSpoolSize       = 64 << 20
+)
+
+// RetryPolicy is what a RetryLogger does with the messages its logger fails to write.
+type RetryPolicy struct {
+	// Backoff is how long the messages that could not be written wait before being written
+	// again, doubling after every failed attempt up to MaxBackoff. They default to
+	// DefaultRetryBackoff and DefaultRetryMaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+
+	// SpoolSize is the number of bytes of messages kept on disk until the logger writes them,
+	// DefaultSpoolSize by default. Messages that do not fit are dropped, see Dropped.
+	SpoolSize int64
+
+	// Fallback, if set, is sent the messages the logger fails to write, and those logged while
+	// they are being retried, e.g. a console logger standing in for syslog. It formats them its
+	// own way.
+	Fallback Logger
+}
+
+// RetryLogger is a logger writing messages to the logger it wraps and, once writing one fails,
+// spooling it and the messages logged after it to a temporary file, from which they are
+// written again in order once the logger recovers, e.g. once the syslog daemon has restarted.
+// Writing them is attempted after the backoff of the policy, see RetryPolicy. Messages dropped
+// as the spool is full, or left in it when the logger is closed, are counted, see Dropped.
+//
+// Unlike a FailoverLogger, which leaves the messages logged while its primary logger fails to
+// the secondary one, a RetryLogger delivers every message to the logger it wraps, the fallback
+// only standing in while they are spooled.
+type RetryLogger struct {
+	logger Logger
+	policy RetryPolicy
+
+	mu      sync.Mutex
+	spool   spillFile
+	dropped uint64
+	closed  bool
+
+	spooled chan struct{} // signaled when messages are spooled
+	stop    chan struct{} // closed when the logger is closed
+	done    chan struct{}
+
+	closeOnce sync.Once
+}
+
+// NewRetryLogger makes a logger retrying the messages l fails to write with the policy.
+func NewRetryLogger(l Logger, policy RetryPolicy) *RetryLogger {
+	if policy.Backoff <= 0 {
+		policy.Backoff = DefaultRetryBackoff
+	}
+	if policy.MaxBackoff < policy.Backoff {
+		policy.MaxBackoff = DefaultRetryMaxBackoff
+		if policy.MaxBackoff < policy.Backoff {
+			policy.MaxBackoff = policy.Backoff
+		}
+	}
+	if policy.SpoolSize <= 0 {
+		policy.SpoolSize = DefaultSpoolSize
+	}
+
+	r := &RetryLogger{
+		logger:  l,
+		policy:  policy,
+		spool:   spillFile{limit: policy.SpoolSize},
+		spooled: make(chan struct{}, 1),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go r.run()
+	return r
+}
+
+// newRetryLogger wraps the logger made from the config in a RetryLogger with the policy of the
+// config, making its fallback loggers.
+func newRetryLogger(l Logger, config Config) (Logger, error) {
+	if config.SpoolSize < 0 || config.RetryBackoff < 0 || config.RetryMaxBackoff < 0 {
+		return nil, fmt.Errorf("spool size and retry backoffs must not be negative: %d, %v, %v", config.SpoolSize, config.RetryBackoff, config.RetryMaxBackoff)
+	}
+	policy := RetryPolicy{Backoff: config.RetryBackoff, MaxBackoff: config.RetryMaxBackoff, SpoolSize: config.SpoolSize}
+
+	fallbacks := &MultiLogger{}
+	for i, fallback := range config.Fallback {
+		if fallback.Severity == "" {
+			fallback.Severity = config.Severity
+		}
+		f, err := NewLogger(fallback)
+		if err != nil {
+			fallbacks.Close()
+			if c, ok := l.(io.Closer); ok {
+				c.Close()
+			}
+			return nil, fmt.Errorf("fallback %d (%s): %v", i, fallback.Name, err)
+		}
+		fallbacks.loggers = append(fallbacks.loggers, f)
+	}
+	switch len(fallbacks.loggers) {
+	case 0:
+	case 1:
+		policy.Fallback = fallbacks.loggers[0]
+	default:
+		policy.Fallback = fallbacks
+	}
+	return NewRetryLogger(l, policy), nil
+}
+
+func (l *RetryLogger) Writer(sev Severity) io.Writer {
+	w := l.logger.Writer(sev)
+	if w == nil {
+		return nil
+	}
+	return &retryWriter{l, w, sev}
+}
+
+func (l *RetryLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.logger.FormatMessage(sev, caller, format, args...)
+}
+
+func (l *RetryLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return formatMessage(l.logger, sev, caller, now(), fields, format, args...)
+}
+
+func (l *RetryLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	return formatLazyMessage(l.logger, sev, caller, t, fields, m)
+}
+
+// Spooled tells whether messages are waiting to be written again.
+func (l *RetryLogger) Spooled() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.spool.file != nil
+}
+
+// Dropped returns the number of messages that were not written and will not be.
+func (l *RetryLogger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Close makes a last attempt at writing the spooled messages, dropping those it fails to
+// write, then closes the logger and the fallback if they implement io.Closer and returns the
+// first error encountered. It is safe to call it more than once.
+func (l *RetryLogger) Close() error {
+	var first error
+	l.closeOnce.Do(func() {
+		close(l.stop)
+		<-l.done
+
+		l.replay()
+		l.mu.Lock()
+		l.closed = true
+		for l.spool.file != nil {
+			_, size, err := l.spool.peek()
+			l.dropped++
+			if err != nil {
+				l.spool.removeFile()
+				break
+			}
+			l.spool.advance(size)
+		}
+		l.mu.Unlock()
+
+		for _, logger := range []Logger{l.logger, l.policy.Fallback} {
+			if c, ok := logger.(io.Closer); ok {
+				if err := c.Close(); err != nil && first == nil {
+					first = err
+				}
+			}
+		}
+	})
+	return first
+}
+
+// send sends the message to the logger, and to the fallback if it is spooled. Write hooks are
+// told about the writes as writes to the top logger of the chain.
+func (l *RetryLogger) send(top Logger, e *Entry, sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) {
+	w := e.writer(l.logger, sev)
+	if w == nil {
+		return
+	}
+	spooled, err := l.write(w, sev, []byte(formatLazyMessage(l.logger, sev, caller, t, fields, m)))
+	runWriteHooks(top, sev, err)
+	if (spooled || err != nil) && l.policy.Fallback != nil {
+		sendMessageTo(top, l.policy.Fallback, sev, caller, t, e, fields, m)
+	}
+}
+
+// write writes the message to w, one of the logger's writers, unless messages are spooled, and
+// spools it if writing it fails. It tells whether the message was spooled, and returns an
+// error if the message was neither written nor spooled.
+func (l *RetryLogger) write(w io.Writer, sev Severity, p []byte) (bool, error) {
+	if !l.Spooled() {
+		if _, err := w.Write(p); err == nil {
+			return false, nil
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		l.dropped++
+		return false, fmt.Errorf("retry logger is closed")
+	}
+	if err := l.spool.spill(sev, p); err != nil {
+		l.dropped++
+		return false, err
+	}
+	select {
+	case l.spooled <- struct{}{}:
+	default:
+	}
+	return true, nil
+}
+
+// run writes the spooled messages again, backing off while the logger keeps failing, until
+// the logger is closed.
+func (l *RetryLogger) run() {
+	defer close(l.done)
+
+	for {
+		select {
+		case <-l.spooled:
+		case <-l.stop:
+			return
+		}
+		for backoff := l.policy.Backoff; ; backoff *= 2 {
+			if backoff > l.policy.MaxBackoff {
+				backoff = l.policy.MaxBackoff
+			}
+			timer := time.NewTimer(backoff)
+			select {
+			case <-timer.C:
+			case <-l.stop:
+				timer.Stop()
+				return
+			}
+			if l.replay() {
+				break
+			}
+		}
+	}
+}
+
+// replay writes the spooled messages to the logger, oldest first, and tells whether they have
+// all been written.
+func (l *RetryLogger) replay() bool {
+	for {
+		l.mu.Lock()
+		if l.spool.file == nil {
+			l.mu.Unlock()
+			return true
+		}
+		m, size, err := l.spool.peek()
+		if err != nil {
+			// the rest of the spool is lost
+			l.dropped++
+			l.spool.removeFile()
+			l.mu.Unlock()
+			return true
+		}
+		l.mu.Unlock()
+
+		if w := l.logger.Writer(m.sev); w != nil {
+			if _, err := w.Write(m.message); err != nil {
+				return false
+			}
+		}
+		l.mu.Lock()
+		l.spool.advance(size)
+		l.mu.Unlock()
+	}
+}
+
+// retryWriter writes messages formatted by the callers to a writer of a RetryLogger's logger,
+// and to the fallback's writer if they are spooled.
+type retryWriter struct {
+	l   *RetryLogger
+	w   io.Writer
+	sev Severity
+}
+
+func (w *retryWriter) Write(p []byte) (int, error) {
+	spooled, err := w.l.write(w.w, w.sev, p)
+	if (spooled || err != nil) && w.l.policy.Fallback != nil {
+		if fw := w.l.policy.Fallback.Writer(w.sev); fw != nil {
+			fw.Write(p)
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
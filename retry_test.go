@@ -0,0 +1,145 @@
+package log
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RetrySuite struct{}
+
+var _ = Suite(&RetrySuite{})
+
+func (s *RetrySuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+// flakyLogger is a test logger whose writes fail while it is broken, safe for concurrent use.
+type flakyLogger struct {
+	*testLogger
+
+	mu      sync.Mutex
+	broken  bool
+	written bytes.Buffer
+}
+
+func newFlakyLogger() *flakyLogger {
+	return &flakyLogger{testLogger: newTestLogger("flaky")}
+}
+
+func (l *flakyLogger) Writer(sev Severity) io.Writer {
+	return l
+}
+
+func (l *flakyLogger) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.broken {
+		return 0, errors.New("connection refused")
+	}
+	return l.written.Write(p)
+}
+
+func (l *flakyLogger) setBroken(broken bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.broken = broken
+}
+
+func (l *flakyLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.written.String()
+}
+
+// waitUnspooled waits for the spooled messages to be written.
+func waitUnspooled(c *C, l *RetryLogger) {
+	for deadline := time.Now().Add(5 * time.Second); l.Spooled(); time.Sleep(time.Millisecond) {
+		c.Assert(time.Now().Before(deadline), Equals, true)
+	}
+}
+
+func (s *RetrySuite) TestRetry(c *C) {
+	flaky, fallback := newFlakyLogger(), newTestLogger("fallback")
+	l := NewRetryLogger(flaky, RetryPolicy{Backoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond, Fallback: fallback})
+	defer l.Close()
+	Init(l)
+
+	Infof("hello %s", "one")
+	c.Assert(l.Spooled(), Equals, false)
+
+	// the failed message and those logged while it is spooled go to the fallback
+	flaky.setBroken(true)
+	Infof("hello %s", "two")
+	WithFields(Fields{"user": "bob"}).Warningf("hello %s", "three")
+	c.Assert(l.Spooled(), Equals, true)
+	c.Assert(fallback.b.String(), Equals, "INFO hello two\nWARN hello three user=bob\n")
+
+	// and are written in order once the logger recovers
+	time.Sleep(20 * time.Millisecond)
+	flaky.setBroken(false)
+	waitUnspooled(c, l)
+	Infof("hello %s", "four")
+	c.Assert(flaky.String(), Equals, "INFO hello one\nINFO hello two\nWARN hello three user=bob\nINFO hello four\n")
+	c.Assert(fallback.b.String(), Equals, "INFO hello two\nWARN hello three user=bob\n")
+	c.Assert(l.Dropped(), Equals, uint64(0))
+
+	// so are messages written directly
+	flaky.setBroken(true)
+	io.WriteString(l.Writer(SeverityError), "direct\n")
+	c.Assert(fallback.b.String(), Equals, "INFO hello two\nWARN hello three user=bob\ndirect\n")
+	flaky.setBroken(false)
+	waitUnspooled(c, l)
+	c.Assert(flaky.String(), Equals, "INFO hello one\nINFO hello two\nWARN hello three user=bob\nINFO hello four\ndirect\n")
+}
+
+func (s *RetrySuite) TestSpoolSize(c *C) {
+	flaky := newFlakyLogger()
+	flaky.setBroken(true)
+	l := NewRetryLogger(flaky, RetryPolicy{Backoff: time.Hour, SpoolSize: 2 * (spillHeaderSize + int64(len("INFO message\n")))})
+	Init(l)
+
+	for i := 0; i < 3; i++ {
+		Infof("message")
+	}
+	c.Assert(l.Dropped(), Equals, uint64(1))
+
+	// the messages left in the spool are dropped on close
+	c.Assert(l.Close(), IsNil)
+	c.Assert(l.Dropped(), Equals, uint64(3))
+	c.Assert(l.Spooled(), Equals, false)
+	c.Assert(flaky.String(), Equals, "")
+}
+
+func (s *RetrySuite) TestCloseReplays(c *C) {
+	flaky := newFlakyLogger()
+	l := NewRetryLogger(flaky, RetryPolicy{Backoff: time.Hour})
+	Init(l)
+
+	flaky.setBroken(true)
+	Infof("hello")
+	flaky.setBroken(false)
+	c.Assert(l.Close(), IsNil)
+	c.Assert(flaky.String(), Equals, "INFO hello\n")
+	c.Assert(l.Dropped(), Equals, uint64(0))
+}
+
+func (s *RetrySuite) TestConfig(c *C) {
+	l, err := NewLogger(Config{Name: Console, Severity: "warn", SpoolSize: 1 << 20, RetryBackoff: time.Second, Fallback: []Config{{Name: Console}}})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	c.Assert(typeOf(l), Equals, "*log.RetryLogger")
+	retry := l.(*RetryLogger)
+	c.Assert(retry.policy.MaxBackoff, Equals, DefaultRetryMaxBackoff)
+	c.Assert(retry.policy.Fallback.(LeveledLogger).Severity(), Equals, SeverityWarning)
+	c.Assert(describe(l).Destination, Equals, "spool of 1048576 bytes on disk, retried after 1s to 1m0s, falling back to the second logger")
+
+	_, err = NewLogger(Config{Name: Console, Severity: "info", Fallback: []Config{{Name: "nope"}}})
+	c.Assert(err, ErrorMatches, "fallback 0 \\(nope\\): unknown logger: .*")
+	_, err = NewLogger(Config{Name: Console, Severity: "info", SpoolSize: -1})
+	c.Assert(err, ErrorMatches, "spool size and retry backoffs must not be negative: .*")
+}
@@ -0,0 +1,221 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// maxTailers bounds the number of clients a RingLogger streams messages to at once.
+var maxTailers = 16
+
+// tailerBufferSize is the number of messages buffered for a tailing client; messages beyond
+// that are dropped for clients that cannot keep up.
+const tailerBufferSize = 256
+
+// RingLogger is a type of consoleLogger keeping the most recent messages in memory
+// instead of writing them out. It can stream them over HTTP, see TailHandler.
+type RingLogger struct {
+	*consoleLogger // provides FormatMessage() through embedding
+
+	mu      sync.Mutex
+	lines   []ringLine
+	next    int // index of the oldest message once the ring is full
+	tailers map[chan ringLine]struct{}
+	dropped uint64 // messages dropped for tailers not keeping up
+}
+
+// ringLine is a formatted message kept by a RingLogger.
+type ringLine struct {
+	sev  Severity
+	text string
+}
+
+// NewRingLogger makes a logger keeping the last size messages in memory.
+func NewRingLogger(conf Config, size int) (*RingLogger, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("ring size must be positive: %d", size)
+	}
+
+	sev, err := severityFromString(conf.Severity)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateLevelStyle(conf.LevelStyle); err != nil {
+		return nil, err
+	}
+
+	return &RingLogger{
+		consoleLogger: &consoleLogger{writerLogger: &writerLogger{sev: sev}, levelStyle: conf.LevelStyle},
+		lines:         make([]ringLine, 0, size),
+		tailers:       make(map[chan ringLine]struct{}),
+	}, nil
+}
+
+func (l *RingLogger) Writer(sev Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= l.Severity() {
+		return &ringWriter{l, sev}
+	}
+	return nil
+}
+
+// Lines returns the messages kept by the logger, oldest first.
+func (l *RingLogger) Lines() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	lines := make([]string, len(l.lines))
+	for i := range l.lines {
+		lines[i] = l.lines[(l.next+i)%len(l.lines)].text
+	}
+	return lines
+}
+
+// TailHandler returns a HTTP handler streaming the kept messages, followed by messages logged
+// while the client stays connected, as Server-Sent Events with one event per message.
+// The optional severity query parameter limits the stream to messages at or above the given
+// severity, e.g. /tail?severity=warn.
+//
+// At most 16 clients are served at once, others get 503 Service Unavailable.
+func (l *RingLogger) TailHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		minSev := SeverityTrace
+		if s := r.URL.Query().Get("severity"); s != "" {
+			var err error
+			if minSev, err = severityFromString(s); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		backlog, tailer, ok := l.subscribe()
+		if !ok {
+			http.Error(w, "too many clients", http.StatusServiceUnavailable)
+			return
+		}
+		defer l.unsubscribe(tailer)
+
+		// flushing sends the headers, which are still unsent if the writer cannot flush,
+		// reaching it through the writers of middlewares wrapping it
+		rc := http.NewResponseController(w)
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		if err := rc.Flush(); err != nil {
+			if errors.Is(err, http.ErrNotSupported) {
+				w.Header().Del("Cache-Control")
+				http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+			}
+			return
+		}
+
+		for _, line := range backlog {
+			if line.sev >= minSev {
+				writeEvent(w, line.text)
+			}
+		}
+		if rc.Flush() != nil {
+			return
+		}
+
+		for {
+			select {
+			case line := <-tailer:
+				if line.sev >= minSev {
+					writeEvent(w, line.text)
+					if rc.Flush() != nil {
+						return
+					}
+				}
+			case <-r.Context().Done():
+				return
+			}
+		}
+	})
+}
+
+// add keeps the message, dropping the oldest one if the ring is full, and sends it to the tailers.
+func (l *RingLogger) add(line ringLine) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.lines) < cap(l.lines) {
+		l.lines = append(l.lines, line)
+	} else {
+		l.lines[l.next] = line
+		l.next = (l.next + 1) % len(l.lines)
+	}
+
+	for tailer := range l.tailers {
+		select {
+		case tailer <- line:
+		default:
+			// the client is not keeping up, do not block logging
+			l.dropped++
+		}
+	}
+}
+
+// Dropped returns the number of messages that were not streamed to tailing clients because
+// they were not keeping up. Messages pushed out of the ring are not counted.
+func (l *RingLogger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.dropped
+}
+
+// subscribe registers a new tailer if there is room for it and returns it along with the kept messages.
+func (l *RingLogger) subscribe() ([]ringLine, chan ringLine, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if len(l.tailers) >= maxTailers {
+		return nil, nil, false
+	}
+	tailer := make(chan ringLine, tailerBufferSize)
+	l.tailers[tailer] = struct{}{}
+
+	backlog := make([]ringLine, len(l.lines))
+	for i := range l.lines {
+		backlog[i] = l.lines[(l.next+i)%len(l.lines)]
+	}
+	return backlog, tailer, true
+}
+
+func (l *RingLogger) unsubscribe(tailer chan ringLine) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.tailers, tailer)
+}
+
+// tailerCount returns the number of clients being streamed to.
+func (l *RingLogger) tailerCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.tailers)
+}
+
+// ringWriter adds messages written to it to a RingLogger.
+type ringWriter struct {
+	l   *RingLogger
+	sev Severity
+}
+
+func (w *ringWriter) Write(p []byte) (int, error) {
+	w.l.add(ringLine{w.sev, strings.TrimRight(string(p), "\r\n")})
+	return len(p), nil
+}
+
+// writeEvent writes the text as a Server-Sent Event, prefixing each of its lines with "data:".
+func writeEvent(w io.Writer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "data: %s\n", strings.TrimRight(line, "\r"))
+	}
+	io.WriteString(w, "\n")
+}
@@ -0,0 +1,155 @@
+package log
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RingLoggerSuite struct {
+}
+
+var _ = Suite(&RingLoggerSuite{})
+
+func (s *RingLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *RingLoggerSuite) TestNewRingLogger(c *C) {
+	l, err := NewRingLogger(Config{Severity: "info"}, 10)
+	c.Assert(err, IsNil)
+	c.Assert(l.sev, Equals, SeverityInfo)
+
+	_, err = NewRingLogger(Config{Severity: "info"}, 0)
+	c.Assert(err, NotNil)
+	_, err = NewRingLogger(Config{Severity: "loud"}, 10)
+	c.Assert(err, NotNil)
+}
+
+func (s *RingLoggerSuite) TestLines(c *C) {
+	l, _ := NewRingLogger(Config{Severity: "info"}, 3)
+	Init(l)
+
+	Debugf("message %d", 0)
+	for i := 1; i <= 4; i++ {
+		Infof("message %d", i)
+	}
+
+	lines := l.Lines()
+	c.Assert(lines, HasLen, 3)
+	for i, line := range lines {
+		c.Assert(strings.HasSuffix(line, fmt.Sprintf("message %d", i+2)), Equals, true, Commentf(line))
+	}
+}
+
+func (s *RingLoggerSuite) TestTailHandler(c *C) {
+	l, _ := NewRingLogger(Config{Severity: "debug"}, 10)
+	Init(l)
+	Warningf("hello %s", "backlog")
+	Infof("hello %s", "filtered backlog")
+
+	server := httptest.NewServer(l.TailHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "?severity=warn")
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), Equals, "text/event-stream")
+
+	s.waitForTailers(c, l, 1)
+	Infof("hello %s", "filtered")
+	Errorf("hello\n%s", "multiline")
+
+	r := bufio.NewReader(resp.Body)
+	c.Assert(readEvent(c, r), Matches, "data: .* WARN .* hello backlog\n")
+	c.Assert(readEvent(c, r), Matches, "data: .* ERROR .* hello\ndata: multiline\n")
+}
+
+func (s *RingLoggerSuite) TestTailHandlerDisconnect(c *C) {
+	l, _ := NewRingLogger(Config{Severity: "debug"}, 10)
+	server := httptest.NewServer(l.TailHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	c.Assert(err, IsNil)
+	s.waitForTailers(c, l, 1)
+
+	resp.Body.Close()
+	s.waitForTailers(c, l, 0)
+}
+
+func (s *RingLoggerSuite) TestTailHandlerLimits(c *C) {
+	defer func(max int) { maxTailers = max }(maxTailers)
+	maxTailers = 1
+
+	l, _ := NewRingLogger(Config{Severity: "debug"}, 10)
+	server := httptest.NewServer(l.TailHandler())
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	s.waitForTailers(c, l, 1)
+
+	resp2, err := http.Get(server.URL)
+	c.Assert(err, IsNil)
+	resp2.Body.Close()
+	c.Assert(resp2.StatusCode, Equals, http.StatusServiceUnavailable)
+
+	resp3, err := http.Get(server.URL + "?severity=loud")
+	c.Assert(err, IsNil)
+	resp3.Body.Close()
+	c.Assert(resp3.StatusCode, Equals, http.StatusBadRequest)
+}
+
+func (s *RingLoggerSuite) TestTailHandlerMiddleware(c *C) {
+	l, _ := NewRingLogger(Config{Severity: "debug"}, 10)
+	l.add(ringLine{SeverityInfo, "backlog"})
+	server := httptest.NewServer(HTTPMiddleware(l.TailHandler()))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	c.Assert(err, IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+	c.Assert(readEvent(c, bufio.NewReader(resp.Body)), Equals, "data: backlog\n")
+}
+
+func (s *RingLoggerSuite) TestTailHandlerNoStreaming(c *C) {
+	l, _ := NewRingLogger(Config{Severity: "debug"}, 10)
+
+	// a writer that cannot flush
+	w := struct{ http.ResponseWriter }{httptest.NewRecorder()}
+	l.TailHandler().ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	rec := w.ResponseWriter.(*httptest.ResponseRecorder)
+	c.Assert(rec.Code, Equals, http.StatusInternalServerError)
+	c.Assert(rec.Body.String(), Equals, "streaming is not supported\n")
+	c.Assert(l.tailerCount(), Equals, 0)
+}
+
+func (s *RingLoggerSuite) waitForTailers(c *C, l *RingLogger, n int) {
+	for deadline := time.Now().Add(5 * time.Second); l.tailerCount() != n; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			c.Fatalf("expected %d tailers, got %d", n, l.tailerCount())
+		}
+	}
+}
+
+// readEvent reads a Server-Sent Event without the blank line terminating it.
+func readEvent(c *C, r *bufio.Reader) string {
+	var event string
+	for {
+		line, err := r.ReadString('\n')
+		c.Assert(err, IsNil)
+		if line == "\n" {
+			return event
+		}
+		event += line
+	}
+}
@@ -0,0 +1,107 @@
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// rotation is the rotation policy of the file of a file logger, see Config.MaxSize.
+type rotation struct {
+	maxSize    int64
+	maxAge     time.Duration
+	maxBackups int
+	compress   bool
+}
+
+// newRotation returns the rotation policy configured in conf.
+func newRotation(conf Config) (rotation, error) {
+	if conf.MaxSize < 0 || conf.MaxAge < 0 || conf.MaxBackups < 0 {
+		return rotation{}, fmt.Errorf("rotation limits must not be negative: size %d, age %v, backups %d",
+			conf.MaxSize, conf.MaxAge, conf.MaxBackups)
+	}
+	return rotation{conf.MaxSize, conf.MaxAge, conf.MaxBackups, conf.Compress}, nil
+}
+
+// due tells whether the file should be rotated before n more bytes are written to it, given
+// its size and how long it has been written to. Empty files are never rotated.
+func (r rotation) due(size, n int64, age time.Duration) bool {
+	if size == 0 {
+		return false
+	}
+	return (r.maxSize > 0 && size+n > r.maxSize) || (r.maxAge > 0 && age >= r.maxAge)
+}
+
+// backupName returns the name of the i-th most recent backup of the file at path.
+func (r rotation) backupName(path string, i int) string {
+	name := fmt.Sprintf("%s.%d", path, i)
+	if r.compress {
+		name += ".gz"
+	}
+	return name
+}
+
+// rotate moves the file at path to the most recent backup, path.1, shifting the older backups
+// and removing those beyond maxBackups. The file must be closed. When backups are compressed
+// it returns the name of the new backup, which is left for compressBackup to gzip.
+func (r rotation) rotate(path string) (string, error) {
+	last := 0
+	for {
+		if _, err := os.Stat(r.backupName(path, last+1)); err != nil {
+			break
+		}
+		last++
+	}
+	for i := last; i >= 1; i-- {
+		var err error
+		if r.maxBackups > 0 && i >= r.maxBackups {
+			err = os.Remove(r.backupName(path, i))
+		} else {
+			err = os.Rename(r.backupName(path, i), r.backupName(path, i+1))
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+
+	backup := fmt.Sprintf("%s.1", path)
+	if err := os.Rename(path, backup); err != nil || !r.compress {
+		return "", err
+	}
+	return backup, nil
+}
+
+// compressBackup replaces the backup left by rotate with its gzipped copy, backup.gz.
+func compressBackup(backup string) error {
+	if err := compressFile(backup, backup+".gz"); err != nil {
+		return err
+	}
+	return os.Remove(backup)
+}
+
+// compressFile writes the gzipped content of the file at src to the file at dst.
+func compressFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err = io.Copy(gz, in); err == nil {
+		err = gz.Close()
+	}
+	if e := out.Close(); err == nil {
+		err = e
+	}
+	if err != nil {
+		os.Remove(dst)
+	}
+	return err
+}
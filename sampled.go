@@ -0,0 +1,83 @@
+package log
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// SampledLogger is a logger passing one in every n messages below ERROR to the logger it wraps
+// and dropping the others, which keeps chatty logging affordable. Messages at ERROR and above
+// always pass.
+//
+// Which messages pass is decided by a counter of the messages the wrapped logger would log:
+// those the counter is a multiple of n for pass, starting with the first one. Tests can make
+// the decision their own way, see SampleDecision.
+type SampledLogger struct {
+	logger Logger
+	n      uint64
+
+	counter uint64
+	decide  func(seq uint64) bool
+	dropped uint64
+}
+
+// SampleOption customizes a SampledLogger.
+type SampleOption func(*SampledLogger)
+
+// SampleCounterStart makes the counter of a SampledLogger start at start instead of 0.
+func SampleCounterStart(start uint64) SampleOption {
+	return func(l *SampledLogger) {
+		l.counter = start
+	}
+}
+
+// SampleDecision replaces the decision of a SampledLogger. Decide is called with the counter
+// value of every message below ERROR and returns whether it passes.
+func SampleDecision(decide func(seq uint64) bool) SampleOption {
+	return func(l *SampledLogger) {
+		l.decide = decide
+	}
+}
+
+// NewSampledLogger makes a logger passing one in every n messages to l, n below 2 passing all.
+func NewSampledLogger(l Logger, n int, options ...SampleOption) *SampledLogger {
+	if n < 1 {
+		n = 1
+	}
+	s := &SampledLogger{logger: l, n: uint64(n)}
+	s.decide = func(seq uint64) bool { return seq%s.n == 0 }
+	for _, option := range options {
+		option(s)
+	}
+	return s
+}
+
+func (l *SampledLogger) Writer(sev Severity) io.Writer {
+	w := l.logger.Writer(sev)
+	if w == nil || sev >= SeverityError {
+		return w
+	}
+	if l.decide(atomic.AddUint64(&l.counter, 1) - 1) {
+		return w
+	}
+	atomic.AddUint64(&l.dropped, 1)
+	return nil
+}
+
+func (l *SampledLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.logger.FormatMessage(sev, caller, format, args...)
+}
+
+func (l *SampledLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return formatMessage(l.logger, sev, caller, now(), fields, format, args...)
+}
+
+func (l *SampledLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	return formatLazyMessage(l.logger, sev, caller, t, fields, m)
+}
+
+// Dropped returns the number of messages that did not pass.
+func (l *SampledLogger) Dropped() uint64 {
+	return atomic.LoadUint64(&l.dropped)
+}
@@ -0,0 +1,62 @@
+package log
+
+import (
+	"fmt"
+
+	. "gopkg.in/check.v1"
+)
+
+type SampledLoggerSuite struct {
+}
+
+var _ = Suite(&SampledLoggerSuite{})
+
+func (s *SampledLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *SampledLoggerSuite) TestSampling(c *C) {
+	logger := newThresholdLogger("log", SeverityInfo)
+	l := NewSampledLogger(logger, 3)
+	Init(l)
+
+	for i := 0; i < 7; i++ {
+		Debugf("debug %d", i)
+		Infof("info %d", i)
+	}
+	Errorf("hello %s", "error")
+	c.Assert(logger.b.String(), Equals, "INFO info 0\nINFO info 3\nINFO info 6\nERROR hello error\n")
+	c.Assert(l.Dropped(), Equals, uint64(4))
+}
+
+func (s *SampledLoggerSuite) TestCounterStart(c *C) {
+	logger := newTestLogger("log")
+	Init(NewSampledLogger(logger, 2, SampleCounterStart(1)))
+
+	for i := 0; i < 4; i++ {
+		Infof("info %d", i)
+	}
+	c.Assert(logger.b.String(), Equals, "INFO info 1\nINFO info 3\n")
+}
+
+func (s *SampledLoggerSuite) TestDecision(c *C) {
+	logger := newTestLogger("log")
+	primes := map[uint64]bool{2: true, 3: true, 5: true, 7: true}
+	Init(NewSampledLogger(logger, 100, SampleDecision(func(seq uint64) bool { return primes[seq] })))
+
+	var expected string
+	for i := 0; i < 10; i++ {
+		Infof("info %d", i)
+		if primes[uint64(i)] {
+			expected += fmt.Sprintf("INFO info %d\n", i)
+		}
+	}
+	c.Assert(logger.b.String(), Equals, expected)
+}
+
+func (s *SampledLoggerSuite) TestFields(c *C) {
+	l, _ := NewConsoleLogger(Config{Name: Console, Severity: "info", Format: FormatJSON})
+	sampled := NewSampledLogger(l, 1)
+	message := sampled.FormatMessageWithFields(SeverityInfo, &CallerInfo{"filename", "filepath", "funcname", 42}, Fields{"user": "bob"}, "hello")
+	c.Assert(message, Matches, `\{.*"message":"hello".*"user":"bob"\}`+"\n")
+}
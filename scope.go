@@ -0,0 +1,60 @@
+package log
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// scopedSeverities holds the severities set by WithSeverity keyed by goroutine ID.
+	scopedSeverities sync.Map
+
+	// scopes counts the calls to WithSeverity in progress.
+	scopes int32
+)
+
+// WithSeverity runs fn logging messages at or above sev, and only those, no matter what
+// severities the loggers are configured with, e.g. to debug a suspect block of code. Loggers
+// configured with SeverityOff still do not log anything and the floors of entries, see
+// WithMinSeverity, still apply.
+//
+// The severity is scoped to the calling goroutine: messages logged by goroutines fn starts
+// are not affected. Calls can be nested, the previous severity is restored once fn returns
+// or panics.
+func WithSeverity(sev Severity, fn func()) {
+	atomic.AddInt32(&scopes, 1)
+	defer atomic.AddInt32(&scopes, -1)
+
+	gid := goroutineID()
+	if previous, ok := scopedSeverities.Load(gid); ok {
+		defer scopedSeverities.Store(gid, previous)
+	} else {
+		defer scopedSeverities.Delete(gid)
+	}
+	scopedSeverities.Store(gid, sev)
+	fn()
+}
+
+// scoped returns the entry the goroutine's messages are logged through, taking the severity
+// set by WithSeverity into account. The entry may be nil. The goroutine is only looked up while
+// WithSeverity is being called.
+func (e *Entry) scoped() *Entry {
+	if atomic.LoadInt32(&scopes) == 0 {
+		return e
+	}
+	v, ok := scopedSeverities.Load(goroutineID())
+	if !ok {
+		return e
+	}
+	sev := v.(Severity)
+
+	var child Entry
+	if e != nil {
+		child = *e
+	}
+	if sev > child.floor {
+		child.floor = sev
+	}
+	child.override, child.overridden = sev, true
+	return &child
+}
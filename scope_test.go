@@ -0,0 +1,72 @@
+package log
+
+import (
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type ScopeSuite struct {
+}
+
+var _ = Suite(&ScopeSuite{})
+
+func (s *ScopeSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *ScopeSuite) TestWithSeverity(c *C) {
+	logger := newThresholdLogger("log", SeverityInfo)
+	Init(logger)
+
+	WithSeverity(SeverityDebug, func() {
+		Debugf("hello %s", "debug")
+		WithFields(Fields{"user": "bob"}).Debugf("hello %s", "debug")
+
+		WithSeverity(SeverityError, func() {
+			Warningf("hello %s", "warning")
+			Errorf("hello %s", "error")
+		})
+		Debugf("hello %s", "again")
+	})
+	Debugf("hello %s", "after")
+	Infof("hello %s", "after")
+
+	c.Assert(logger.b.String(), Equals, "DEBUG hello debug\nDEBUG hello debug user=bob\nERROR hello error\nDEBUG hello again\nINFO hello after\n")
+}
+
+func (s *ScopeSuite) TestWithSeverityPanic(c *C) {
+	logger := newThresholdLogger("log", SeverityInfo)
+	Init(logger)
+
+	func() {
+		defer func() { recover() }()
+		WithSeverity(SeverityDebug, func() { panic("oops") })
+	}()
+	Debugf("hello %s", "debug")
+	c.Assert(logger.b.Len(), Equals, 0)
+}
+
+func (s *ScopeSuite) TestWithSeverityGoroutines(c *C) {
+	logger := newThresholdLogger("log", SeverityInfo)
+	Init(logger)
+
+	WithSeverity(SeverityDebug, func() {
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Debugf("hello %s", "goroutine")
+		}()
+		wg.Wait()
+	})
+	c.Assert(logger.b.Len(), Equals, 0)
+}
+
+func (s *ScopeSuite) TestWithSeverityOff(c *C) {
+	off := newThresholdLogger("off", SeverityOff)
+	Init(off)
+
+	WithSeverity(SeverityDebug, func() { Errorf("hello %s", "error") })
+	c.Assert(off.b.Len(), Equals, 0)
+}
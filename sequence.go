@@ -0,0 +1,32 @@
+package log
+
+import (
+	"sync/atomic"
+)
+
+// SequenceField is the name of the field carrying message sequence numbers.
+const SequenceField = "seq"
+
+var (
+	// includeSequence is non-zero when messages are numbered.
+	includeSequence int32
+
+	// sequence is the number of the last numbered message.
+	sequence uint64
+)
+
+// SetIncludeSequence turns on or off attaching a "seq" field with a process-wide,
+// monotonically increasing sequence number to every message, so that the true order
+// of messages can be restored after collectors reorder them. A message gets the same
+// number in all loggers it is sent to.
+func SetIncludeSequence(include bool) {
+	setFlag(&includeSequence, include)
+}
+
+// withSequence returns fields with the next sequence number attached if messages are numbered.
+func withSequence(fields Fields) Fields {
+	if !flagSet(&includeSequence) {
+		return fields
+	}
+	return mergeFields(fields, Fields{SequenceField: atomic.AddUint64(&sequence, 1)})
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	. "gopkg.in/check.v1"
+)
+
+type SequenceSuite struct {
+}
+
+var _ = Suite(&SequenceSuite{})
+
+func (s *SequenceSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	SetIncludeSequence(true)
+}
+
+func (s *SequenceSuite) TearDownTest(c *C) {
+	SetIncludeSequence(false)
+}
+
+func (s *SequenceSuite) TestSequence(c *C) {
+	logger1 := newTestLogger("log1")
+	logger2 := newTestLogger("log2")
+	Init(logger1, logger2)
+
+	Infof("hello %s", "world")
+	seq := atomic.LoadUint64(&sequence)
+	Infof("hello %s", "world")
+
+	// both loggers see the same number for a message
+	expected := fmt.Sprintf("INFO hello world seq=%d\nINFO hello world seq=%d\n", seq, seq+1)
+	c.Assert(logger1.b.String(), Equals, expected)
+	c.Assert(logger2.b.String(), Equals, expected)
+
+	SetIncludeSequence(false)
+	logger1.b.Reset()
+	Infof("hello %s", "world")
+	c.Assert(logger1.b.String(), Equals, "INFO hello world\n")
+}
+
+func (s *SequenceSuite) TestSequenceDoesNotModifyFields(c *C) {
+	Init(newTestLogger("log"))
+
+	fields := Fields{"user": "bob"}
+	WithFields(fields).Infof("hello %s", "world")
+	c.Assert(fields, DeepEquals, Fields{"user": "bob"})
+}
+
+func (s *SequenceSuite) TestConcurrentSequence(c *C) {
+	logger := &sequenceLogger{perCaller: make(map[string][]uint64)}
+	Init(logger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				WithFields(Fields{"goroutine": id}).Infof("hello %s", "world")
+			}
+		}(fmt.Sprint(i))
+	}
+	wg.Wait()
+
+	var all []uint64
+	for _, seqs := range logger.perCaller {
+		// numbers are strictly increasing for every goroutine
+		for i := 1; i < len(seqs); i++ {
+			c.Assert(seqs[i] > seqs[i-1], Equals, true)
+		}
+		all = append(all, seqs...)
+	}
+
+	// and there are no gaps or duplicates overall
+	c.Assert(all, HasLen, 1000)
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	for i := 1; i < len(all); i++ {
+		c.Assert(all[i], Equals, all[i-1]+1)
+	}
+}
+
+// sequenceLogger collects sequence numbers of messages per goroutine.
+type sequenceLogger struct {
+	mu        sync.Mutex
+	perCaller map[string][]uint64
+}
+
+func (l *sequenceLogger) Writer(sev Severity) io.Writer {
+	return ioutil.Discard
+}
+
+func (l *sequenceLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	var message, id string
+	var seq uint64
+	fmt.Sscanf(fmt.Sprintf(format, args...), "%s %s goroutine=%s seq=%d", &message, &message, &id, &seq)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.perCaller[id] = append(l.perCaller[id], seq)
+	return ""
+}
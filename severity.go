@@ -2,31 +2,195 @@ package log
 
 import (
 	"fmt"
+	"math"
 	"strings"
+	"sync/atomic"
 )
 
 type Severity int32
 
 // Supported severities.
+//
+// Their numeric values are part of the API, backends may store or transmit them, so they
+// never change. Gaps between them leave room for new severities.
 const (
-	SeverityDebug Severity = iota
-	SeverityInfo
-	SeverityWarning
-	SeverityError
+	SeverityTrace   Severity = 5
+	SeverityDebug   Severity = 10
+	SeverityInfo    Severity = 20
+	SeverityWarning Severity = 30
+	SeverityError   Severity = 40
+	SeverityFatal   Severity = 50
+	SeverityPanic   Severity = 60
 )
 
-var severityNames = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+// SeverityWarn is an alias of SeverityWarning.
+const SeverityWarn = SeverityWarning
+
+// SeverityOff is a sentinel above all severities. A logger configured with it
+// does not log anything.
+const SeverityOff Severity = math.MaxInt32
+
+// severities are all the severities messages can be logged at in ascending order, the
+// tables below hold their properties in the same order.
+var severities = []Severity{SeverityTrace, SeverityDebug, SeverityInfo, SeverityWarning, SeverityError, SeverityFatal, SeverityPanic}
+
+var severityNames = []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC"}
+
+// Supported styles of severity names in formatted messages.
+const (
+	LevelStyleFull   = "full"   // TRACE, DEBUG, INFO, WARN, ERROR, FATAL, PANIC
+	LevelStyleShort3 = "short3" // TRC, DBG, INF, WRN, ERR, FTL, PNC
+	LevelStyleChar   = "char"   // T, D, I, W, E, F, P
+)
+
+var severityShortNames = []string{"TRC", "DBG", "INF", "WRN", "ERR", "FTL", "PNC"}
+
+// index returns the position of the severity in severities, -1 if it is not one of them.
+func (s Severity) index() int {
+	for i, sev := range severities {
+		if sev == s {
+			return i
+		}
+	}
+	return -1
+}
 
 func (s Severity) String() string {
-	return severityNames[s]
+	if s == SeverityOff {
+		return "OFF"
+	}
+	if i := s.index(); i >= 0 {
+		return severityNames[i]
+	}
+	return fmt.Sprintf("Severity(%d)", int32(s))
+}
+
+// format returns the name of the severity in the provided style, see LevelStyleFull and
+// others. An empty style stands for LevelStyleFull.
+func (s Severity) format(style string) string {
+	i := s.index()
+	if i < 0 {
+		return s.String()
+	}
+	switch style {
+	case LevelStyleShort3:
+		return severityShortNames[i]
+	case LevelStyleChar:
+		return severityShortNames[i][:1]
+	}
+	return severityNames[i]
+}
+
+// validateLevelStyle returns an error if the style of severity names is not supported.
+func validateLevelStyle(style string) error {
+	switch style {
+	case "", LevelStyleFull, LevelStyleShort3, LevelStyleChar:
+		return nil
+	}
+	return fmt.Errorf("unsupported level style: %s", style)
+}
+
+// ParseSeverity returns the severity of the provided name as accepted in Config.Severity,
+// for loggers implemented outside of the package.
+func ParseSeverity(s string) (Severity, error) {
+	return severityFromString(s)
 }
 
 func severityFromString(s string) (Severity, error) {
 	s = strings.ToUpper(s)
-	for idx, name := range severityNames {
+	switch s {
+	case "OFF", "NONE":
+		return SeverityOff, nil
+	case "WARNING":
+		return SeverityWarning, nil
+	}
+	for i, name := range severityNames {
 		if name == s {
-			return Severity(idx), nil
+			return severities[i], nil
 		}
 	}
 	return -1, fmt.Errorf("unsupported severity: %s", s)
 }
+
+// SeverityMapper translates severities to numeric priorities used by a particular
+// logging backend, such as syslog levels.
+type SeverityMapper interface {
+	Priority(Severity) int
+}
+
+// SeverityMapperFunc is an adapter allowing the use of ordinary functions as severity mappers.
+type SeverityMapperFunc func(Severity) int
+
+func (f SeverityMapperFunc) Priority(sev Severity) int {
+	return f(sev)
+}
+
+// Default severity mappers of the backends with numeric priorities.
+var (
+	// SyslogSeverityMapper maps severities to RFC 5424 severity levels:
+	// TRACE=7 and DEBUG=7 (debug), INFO=6 (informational), WARN=4 (warning), ERROR=3 (error),
+	// FATAL=2 (critical), PANIC=1 (alert).
+	SyslogSeverityMapper SeverityMapper = SeverityMapperFunc(rfc5424Priority)
+
+	// GELFSeverityMapper maps severities to GELF levels, which use the syslog numbering.
+	GELFSeverityMapper SeverityMapper = SeverityMapperFunc(rfc5424Priority)
+
+	// JournaldSeverityMapper maps severities to journald PRIORITY values, which use the
+	// syslog numbering.
+	JournaldSeverityMapper SeverityMapper = SeverityMapperFunc(rfc5424Priority)
+)
+
+var rfc5424Priorities = []int{7, 7, 6, 4, 3, 2, 1}
+
+// Syslog returns the RFC 5424 numeric severity of the severity, the mapping being stable:
+//
+//	TRACE -> 7 (debug)
+//	DEBUG -> 7 (debug)
+//	INFO  -> 6 (informational)
+//	WARN  -> 4 (warning)
+//	ERROR -> 3 (error)
+//	FATAL -> 2 (critical)
+//	PANIC -> 1 (alert)
+//
+// Severities in between take the numeric severity of the closest severity below them, those
+// below TRACE take 7.
+func (s Severity) Syslog() int {
+	return rfc5424Priority(s)
+}
+
+// rfc5424Priority returns the priority of the highest severity not above the provided one.
+func rfc5424Priority(s Severity) int {
+	priority := rfc5424Priorities[0]
+	for i, sev := range severities {
+		if sev <= s {
+			priority = rfc5424Priorities[i]
+		}
+	}
+	return priority
+}
+
+// highestSeverity is the high-water mark of severities logged since the last reset.
+var highestSeverity = int32(SeverityTrace)
+
+// HighestSeverity returns the highest severity a message has been logged at since the
+// program start or the last call to ResetHighestSeverity, which is handy for health checks
+// reporting degraded state after errors. Returns SeverityTrace, the lowest severity, if
+// nothing above it has been logged.
+func HighestSeverity() Severity {
+	return Severity(atomic.LoadInt32(&highestSeverity))
+}
+
+// ResetHighestSeverity resets the high-water mark returned by HighestSeverity.
+func ResetHighestSeverity() {
+	atomic.StoreInt32(&highestSeverity, int32(SeverityTrace))
+}
+
+// raiseHighestSeverity bumps the high-water mark if the provided severity exceeds it.
+func raiseHighestSeverity(sev Severity) {
+	for {
+		current := atomic.LoadInt32(&highestSeverity)
+		if int32(sev) <= current || atomic.CompareAndSwapInt32(&highestSeverity, current, int32(sev)) {
+			return
+		}
+	}
+}
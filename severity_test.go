@@ -0,0 +1,122 @@
+package log
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type SeveritySuite struct {
+}
+
+var _ = Suite(&SeveritySuite{})
+
+func (s *SeveritySuite) TestSeverityFromString(c *C) {
+	for str, expected := range map[string]Severity{
+		"trace": SeverityTrace,
+		"debug": SeverityDebug,
+		"INFO":  SeverityInfo,
+		"Warn":  SeverityWarning,
+		"error": SeverityError,
+		"Fatal": SeverityFatal,
+		"panic": SeverityPanic,
+		"warning": SeverityWarning,
+		"off":   SeverityOff,
+		"NONE":  SeverityOff,
+	} {
+		sev, err := severityFromString(str)
+		c.Assert(err, IsNil)
+		c.Assert(sev, Equals, expected)
+	}
+
+	_, err := severityFromString("verbose")
+	c.Assert(err, NotNil)
+
+	c.Assert(SeverityOff.String(), Equals, "OFF")
+}
+
+func (s *SeveritySuite) TestSeverityValues(c *C) {
+	// the numeric values are part of the API, they must never change
+	c.Assert(int32(SeverityTrace), Equals, int32(5))
+	c.Assert(int32(SeverityDebug), Equals, int32(10))
+	c.Assert(int32(SeverityInfo), Equals, int32(20))
+	c.Assert(int32(SeverityWarning), Equals, int32(30))
+	c.Assert(int32(SeverityError), Equals, int32(40))
+	c.Assert(int32(SeverityFatal), Equals, int32(50))
+	c.Assert(int32(SeverityPanic), Equals, int32(60))
+	c.Assert(int32(SeverityOff), Equals, int32(2147483647))
+
+	for i := 1; i < len(severities); i++ {
+		c.Assert(severities[i] > severities[i-1], Equals, true)
+	}
+	c.Assert(severityNames, HasLen, len(severities))
+	c.Assert(severityShortNames, HasLen, len(severities))
+	c.Assert(rfc5424Priorities, HasLen, len(severities))
+	c.Assert(severityColors, HasLen, len(severities))
+}
+
+func (s *SeveritySuite) TestRoundTrip(c *C) {
+	for _, sev := range append(severities, SeverityOff) {
+		parsed, err := ParseSeverity(sev.String())
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, sev)
+	}
+	c.Assert(SeverityWarn, Equals, SeverityWarning)
+}
+
+func (s *SeveritySuite) TestUnknownSeverity(c *C) {
+	c.Assert(Severity(25).String(), Equals, "Severity(25)")
+	c.Assert(Severity(25).format(LevelStyleChar), Equals, "Severity(25)")
+	c.Assert(rfc5424Priority(Severity(25)), Equals, 6)
+	c.Assert(rfc5424Priority(Severity(0)), Equals, 7)
+}
+
+func (s *SeveritySuite) TestFormat(c *C) {
+	expected := map[string][]string{
+		"":               {"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC"},
+		LevelStyleFull:   {"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL", "PANIC"},
+		LevelStyleShort3: {"TRC", "DBG", "INF", "WRN", "ERR", "FTL", "PNC"},
+		LevelStyleChar:   {"T", "D", "I", "W", "E", "F", "P"},
+	}
+
+	for style, names := range expected {
+		c.Assert(validateLevelStyle(style), IsNil)
+		for i, sev := range []Severity{SeverityTrace, SeverityDebug, SeverityInfo, SeverityWarning, SeverityError, SeverityFatal, SeverityPanic} {
+			c.Assert(sev.format(style), Equals, names[i], Commentf("style %q", style))
+		}
+	}
+
+	c.Assert(validateLevelStyle("tiny"), NotNil)
+}
+
+func (s *SeveritySuite) TestDefaultSeverityMappers(c *C) {
+	expected := map[Severity]int{
+		SeverityTrace:   7,
+		SeverityDebug:   7,
+		SeverityInfo:    6,
+		SeverityWarning: 4,
+		SeverityError:   3,
+		SeverityFatal:   2,
+		SeverityPanic:   1,
+	}
+
+	for _, mapper := range []SeverityMapper{SyslogSeverityMapper, GELFSeverityMapper, JournaldSeverityMapper} {
+		for sev, priority := range expected {
+			c.Assert(mapper.Priority(sev), Equals, priority, Commentf("severity %s", sev))
+		}
+	}
+}
+
+func (s *SeveritySuite) TestSyslog(c *C) {
+	c.Assert(SeverityTrace.Syslog(), Equals, 7)
+	c.Assert(SeverityDebug.Syslog(), Equals, 7)
+	c.Assert(SeverityInfo.Syslog(), Equals, 6)
+	c.Assert(SeverityWarning.Syslog(), Equals, 4)
+	c.Assert(SeverityError.Syslog(), Equals, 3)
+	c.Assert(SeverityFatal.Syslog(), Equals, 2)
+	c.Assert(SeverityPanic.Syslog(), Equals, 1)
+	c.Assert(Severity(35).Syslog(), Equals, 4)
+}
+
+func (s *SeveritySuite) TestSeverityMapperFunc(c *C) {
+	mapper := SeverityMapperFunc(func(sev Severity) int { return int(sev) * 10 })
+	c.Assert(mapper.Priority(SeverityWarning), Equals, 300)
+}
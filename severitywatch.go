@@ -0,0 +1,65 @@
+package log
+
+import "sync"
+
+// severityChangesBuffer is the number of changes buffered for a subscriber, the oldest ones
+// being dropped for subscribers that do not keep up.
+const severityChangesBuffer = 16
+
+// SeverityChange is a change of the severity of a logger, see SubscribeSeverityChanges.
+type SeverityChange struct {
+	// Logger is the name of the logger, see InitWithConfig.
+	Logger string
+
+	Old Severity
+	New Severity
+}
+
+var (
+	subscribersMu sync.Mutex
+	subscribers   = make(map[chan SeverityChange]struct{})
+)
+
+// SubscribeSeverityChanges returns a channel receiving the changes made to the severities of
+// loggers through the package, e.g. with SetLoggerSeverity or the control server, along with
+// a function unsubscribing and closing the channel. Changing severities never blocks on
+// subscribers: the oldest changes are dropped for those that do not keep up.
+func SubscribeSeverityChanges() (<-chan SeverityChange, func()) {
+	ch := make(chan SeverityChange, severityChangesBuffer)
+
+	subscribersMu.Lock()
+	subscribers[ch] = struct{}{}
+	subscribersMu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			subscribersMu.Lock()
+			defer subscribersMu.Unlock()
+
+			delete(subscribers, ch)
+			close(ch)
+		})
+	}
+}
+
+// notifySeverityChange sends the change to the subscribers.
+func notifySeverityChange(change SeverityChange) {
+	subscribersMu.Lock()
+	defer subscribersMu.Unlock()
+
+	for ch := range subscribers {
+		for sent := false; !sent; {
+			select {
+			case ch <- change:
+				sent = true
+			default:
+				// make room by dropping the oldest change, unless the subscriber just did
+				select {
+				case <-ch:
+				default:
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package log
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type SeverityWatchSuite struct {
+}
+
+var _ = Suite(&SeverityWatchSuite{})
+
+func (s *SeverityWatchSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	loggerNames = nil
+}
+
+func (s *SeverityWatchSuite) TearDownTest(c *C) {
+	loggerNames = nil
+}
+
+func (s *SeverityWatchSuite) TestSubscribe(c *C) {
+	c.Assert(InitWithConfig(Config{Name: Console, Severity: "info"}), IsNil)
+	changes, unsubscribe := SubscribeSeverityChanges()
+	defer unsubscribe()
+
+	c.Assert(SetLoggerSeverity(Console, SeverityDebug), IsNil)
+	c.Assert(<-changes, Equals, SeverityChange{Console, SeverityInfo, SeverityDebug})
+
+	// setting the same severity is not a change
+	c.Assert(SetLoggerSeverity(Console, SeverityDebug), IsNil)
+	c.Assert(SetLoggerSeverity(Console, SeverityError), IsNil)
+	c.Assert(<-changes, Equals, SeverityChange{Console, SeverityDebug, SeverityError})
+
+	unsubscribe()
+	c.Assert(SetLoggerSeverity(Console, SeverityInfo), IsNil)
+	_, ok := <-changes
+	c.Assert(ok, Equals, false)
+	unsubscribe()
+}
+
+func (s *SeverityWatchSuite) TestSlowSubscriber(c *C) {
+	c.Assert(InitWithConfig(Config{Name: Console, Severity: "info"}), IsNil)
+	changes, unsubscribe := SubscribeSeverityChanges()
+	defer unsubscribe()
+
+	// the oldest changes are dropped rather than blocking the setter
+	for i := 0; i < severityChangesBuffer+2; i++ {
+		sev := SeverityDebug
+		if i%2 == 1 {
+			sev = SeverityInfo
+		}
+		c.Assert(SetLoggerSeverity(Console, sev), IsNil)
+	}
+	c.Assert(changes, HasLen, severityChangesBuffer)
+	c.Assert(<-changes, Equals, SeverityChange{Console, SeverityInfo, SeverityDebug})
+}
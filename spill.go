@@ -0,0 +1,305 @@
+package log
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+)
+
+// spillHeaderSize is the size of the header of messages spilled to disk: the severity and the
+// length of the message, both as big endian 32 bit integers.
+const spillHeaderSize = 8
+
+// SpillLogger is a logger queuing messages and writing them to the logger it wraps in the
+// background, so that logging does not wait for slow outputs. Up to memoryLimit bytes of
+// messages are queued in memory; beyond that messages spill over to a temporary file, which is
+// read back once the wrapped logger catches up and removed once it has been drained. Messages
+// keep their order throughout. Only when the messages yet to be read back would take more than
+// diskLimit bytes, or the file cannot be written, are messages dropped, see Dropped.
+//
+// Messages are formatted by the wrapped logger when they are logged. Flush waits for the
+// queued messages to be written, Close writes them and stops the logger.
+type SpillLogger struct {
+	logger      Logger
+	memoryLimit int
+
+	mu      sync.Mutex
+	changed *sync.Cond // signaled whenever the queue changes or the logger is closed
+
+	queue  []queuedMessage // messages queued in memory, oldest first
+	queued int             // bytes of the messages queued in memory
+
+	// messages spilled to disk, up to diskLimit bytes of them unread
+	spillFile
+
+	writing bool // set while a message is being written to the wrapped logger
+	closed  bool
+	done    chan struct{}
+
+	dropped uint64
+}
+
+// queuedMessage is a message queued by a SpillLogger or an AsyncLogger.
+type queuedMessage struct {
+	sev     Severity
+	message []byte
+}
+
+// NewSpillLogger makes a logger queuing messages for l in up to memoryLimit bytes of memory
+// and diskLimit bytes of messages on disk.
+func NewSpillLogger(l Logger, memoryLimit int, diskLimit int64) *SpillLogger {
+	s := &SpillLogger{logger: l, memoryLimit: memoryLimit, spillFile: spillFile{limit: diskLimit}, done: make(chan struct{})}
+	s.changed = sync.NewCond(&s.mu)
+	go s.run()
+	return s
+}
+
+func (l *SpillLogger) Writer(sev Severity) io.Writer {
+	if l.logger.Writer(sev) == nil {
+		return nil
+	}
+	return &spillWriter{l, sev}
+}
+
+func (l *SpillLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	return l.logger.FormatMessage(sev, caller, format, args...)
+}
+
+func (l *SpillLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return formatMessage(l.logger, sev, caller, now(), fields, format, args...)
+}
+
+func (l *SpillLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	return formatLazyMessage(l.logger, sev, caller, t, fields, m)
+}
+
+// Dropped returns the number of messages dropped as they did not fit on disk.
+func (l *SpillLogger) Dropped() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.dropped
+}
+
+// Flush waits for the queued messages to be written to the wrapped logger, then flushes it
+// if it implements Flusher.
+func (l *SpillLogger) Flush() error {
+	l.mu.Lock()
+	for l.pending() || l.writing {
+		l.changed.Wait()
+	}
+	l.mu.Unlock()
+
+	if f, ok := l.logger.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// Close writes the queued messages to the wrapped logger, then closes it if it implements
+// io.Closer. Messages logged afterwards are dropped.
+func (l *SpillLogger) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.changed.Broadcast()
+	l.mu.Unlock()
+
+	<-l.done
+	if c, ok := l.logger.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// pending returns true if messages are queued, in memory or on disk.
+func (l *SpillLogger) pending() bool {
+	return len(l.queue) > 0 || l.file != nil
+}
+
+// add queues the message, in memory unless doing so would exceed the memory limit or messages
+// spilled to disk are yet to be read back, which must be written first.
+func (l *SpillLogger) add(sev Severity, p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.closed {
+		l.dropped++
+		return
+	}
+	message := append([]byte(nil), p...)
+	if l.file == nil && l.queued+len(message) <= l.memoryLimit {
+		l.queue = append(l.queue, queuedMessage{sev, message})
+		l.queued += len(message)
+		l.changed.Broadcast()
+		return
+	}
+	if err := l.spill(sev, message); err != nil {
+		l.dropped++
+		return
+	}
+	l.changed.Broadcast()
+}
+
+// next takes the oldest queued message, the ones in memory being older than those on disk.
+func (l *SpillLogger) next() (queuedMessage, bool) {
+	if len(l.queue) > 0 {
+		m := l.queue[0]
+		l.queue[0] = queuedMessage{}
+		l.queue = l.queue[1:]
+		l.queued -= len(m.message)
+		return m, true
+	}
+	if l.file == nil {
+		return queuedMessage{}, false
+	}
+
+	m, size, err := l.peek()
+	if err != nil {
+		// the rest of the file is lost
+		l.dropped++
+		l.removeFile()
+		return queuedMessage{}, false
+	}
+	l.advance(size)
+	return m, true
+}
+
+// run writes the queued messages to the wrapped logger until the logger is closed and the
+// queue has been drained.
+func (l *SpillLogger) run() {
+	defer close(l.done)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for {
+		for !l.pending() && !l.closed {
+			l.changed.Wait()
+		}
+		m, ok := l.next()
+		if !ok {
+			l.changed.Broadcast()
+			if l.closed && !l.pending() {
+				return
+			}
+			continue
+		}
+
+		l.writing = true
+		l.mu.Unlock()
+		if w := l.logger.Writer(m.sev); w != nil {
+			w.Write(m.message)
+		}
+		l.mu.Lock()
+		l.writing = false
+		l.changed.Broadcast()
+	}
+}
+
+// spillWriter queues the messages written to it at the severity.
+type spillWriter struct {
+	l   *SpillLogger
+	sev Severity
+}
+
+func (w *spillWriter) Write(p []byte) (int, error) {
+	w.l.add(w.sev, p)
+	return len(p), nil
+}
+
+// spillFile is a temporary file messages are queued in, oldest first, in up to limit bytes of
+// messages yet to be taken back. It is created when the first message is spilled and removed
+// once every message has been taken back; the messages left are moved to a new file once they
+// take less room than the ones taken back, so the file stays within twice the limit.
+type spillFile struct {
+	limit int64
+
+	// file holds the messages between readAt and writeAt, it is nil unless messages have been
+	// spilled and not all of them have been taken back yet
+	file            *os.File
+	readAt, writeAt int64
+}
+
+// spill appends the message to the file, creating it if need be.
+func (f *spillFile) spill(sev Severity, message []byte) error {
+	size := spillHeaderSize + int64(len(message))
+	if f.writeAt-f.readAt+size > f.limit {
+		return fmt.Errorf("spilling the message would exceed %d bytes", f.limit)
+	}
+	if f.file != nil && f.writeAt+size > f.limit && f.writeAt-f.readAt <= f.readAt {
+		if err := f.moveUnread(); err != nil {
+			return err
+		}
+	}
+	if f.file == nil {
+		file, err := ioutil.TempFile("", "log-spill-")
+		if err != nil {
+			return err
+		}
+		f.file = file
+	}
+
+	record := make([]byte, size)
+	binary.BigEndian.PutUint32(record, uint32(sev))
+	binary.BigEndian.PutUint32(record[4:], uint32(len(message)))
+	copy(record[spillHeaderSize:], message)
+	if _, err := f.file.WriteAt(record, f.writeAt); err != nil {
+		return err
+	}
+	f.writeAt += int64(len(record))
+	return nil
+}
+
+// moveUnread moves the messages yet to be taken back to a new file, leaving behind the room
+// taken by the others.
+func (f *spillFile) moveUnread() error {
+	file, err := ioutil.TempFile("", "log-spill-")
+	if err != nil {
+		return err
+	}
+	unread := f.writeAt - f.readAt
+	if _, err := io.Copy(file, io.NewSectionReader(f.file, f.readAt, unread)); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	f.file.Close()
+	os.Remove(f.file.Name())
+	f.file, f.readAt, f.writeAt = file, 0, unread
+	return nil
+}
+
+// peek reads the oldest message of the file, which must exist, and returns the room it takes
+// for advance. Messages may be spilled in between, moving the file.
+func (f *spillFile) peek() (queuedMessage, int64, error) {
+	var m queuedMessage
+	header := make([]byte, spillHeaderSize)
+	_, err := f.file.ReadAt(header, f.readAt)
+	if err == nil {
+		m.sev = Severity(binary.BigEndian.Uint32(header))
+		m.message = make([]byte, binary.BigEndian.Uint32(header[4:]))
+		_, err = f.file.ReadAt(m.message, f.readAt+spillHeaderSize)
+	}
+	return m, spillHeaderSize + int64(len(m.message)), err
+}
+
+// advance takes back the oldest message, taking size bytes, removing the file once it has been
+// read back in full.
+func (f *spillFile) advance(size int64) {
+	if f.readAt += size; f.readAt == f.writeAt {
+		f.removeFile()
+	}
+}
+
+// removeFile removes the file, so that messages are queued in memory again.
+func (f *spillFile) removeFile() {
+	f.file.Close()
+	os.Remove(f.file.Name())
+	f.file, f.readAt, f.writeAt = nil, 0, 0
+}
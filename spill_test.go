@@ -0,0 +1,146 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type SpillLoggerSuite struct {
+}
+
+var _ = Suite(&SpillLoggerSuite{})
+
+func (s *SpillLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+// gatedLogger is a testLogger whose writes wait until it is opened.
+type gatedLogger struct {
+	*testLogger
+
+	mu   sync.Mutex
+	open chan struct{}
+}
+
+func newGatedLogger() *gatedLogger {
+	return &gatedLogger{testLogger: newTestLogger("gated"), open: make(chan struct{})}
+}
+
+func (l *gatedLogger) Writer(sev Severity) io.Writer {
+	return l
+}
+
+func (l *gatedLogger) Write(p []byte) (int, error) {
+	<-l.open
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.Write(p)
+}
+
+func (l *gatedLogger) String() string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.b.String()
+}
+
+func (s *SpillLoggerSuite) TestBurst(c *C) {
+	gated := newGatedLogger()
+	l := NewSpillLogger(gated, 64, 1<<20)
+	defer l.Close()
+	Init(l)
+
+	var expected bytes.Buffer
+	for i := 0; i < 100; i++ {
+		Infof("message %d", i)
+		fmt.Fprintf(&expected, "INFO message %d\n", i)
+	}
+
+	// the burst has overflowed the memory while the wrapped logger is stuck
+	l.mu.Lock()
+	c.Assert(l.file, NotNil)
+	spilled := l.file.Name()
+	l.mu.Unlock()
+	_, err := os.Stat(spilled)
+	c.Assert(err, IsNil)
+
+	close(gated.open)
+	c.Assert(l.Flush(), IsNil)
+	c.Assert(gated.String(), Equals, expected.String())
+	c.Assert(l.Dropped(), Equals, uint64(0))
+
+	// the file is gone once drained and messages are queued in memory again
+	_, err = os.Stat(spilled)
+	c.Assert(os.IsNotExist(err), Equals, true)
+	Warningf("after the burst")
+	c.Assert(l.Flush(), IsNil)
+	c.Assert(strings.HasSuffix(gated.String(), "WARN after the burst\n"), Equals, true)
+	l.mu.Lock()
+	c.Assert(l.file, IsNil)
+	l.mu.Unlock()
+}
+
+func (s *SpillLoggerSuite) TestDiskLimit(c *C) {
+	gated := newGatedLogger()
+	l := NewSpillLogger(gated, 0, 3*(spillHeaderSize+int64(len("INFO message 0\n"))))
+	Init(l)
+
+	for i := 0; i < 10; i++ {
+		Infof("message %d", i)
+	}
+	close(gated.open)
+	c.Assert(l.Close(), IsNil)
+
+	// the first message may be taken off the queue before the others are logged
+	c.Assert(gated.String(), Matches, "INFO message 0\nINFO message 1\nINFO message 2\n(INFO message 3\n)?")
+	c.Assert(l.Dropped() >= 6, Equals, true)
+
+	// closed loggers drop messages
+	dropped := l.Dropped()
+	Infof("too late")
+	c.Assert(l.Dropped(), Equals, dropped+1)
+	c.Assert(l.Close(), IsNil)
+}
+
+func (s *SpillLoggerSuite) TestDiskLimitCountsUnreadMessages(c *C) {
+	record := spillHeaderSize + int64(len("message 00"))
+	f := spillFile{limit: 4 * record}
+	defer func() {
+		if f.file != nil {
+			f.removeFile()
+		}
+	}()
+
+	// messages keep being spilled while the oldest are taken back, never more than three of
+	// them waiting, many times the limit over
+	next := 0
+	for i := 0; i < 40; i++ {
+		c.Assert(f.spill(SeverityInfo, []byte(fmt.Sprintf("message %02d", i))), IsNil)
+		if f.writeAt-f.readAt < 3*record {
+			continue
+		}
+		m, size, err := f.peek()
+		c.Assert(err, IsNil)
+		c.Assert(string(m.message), Equals, fmt.Sprintf("message %02d", next))
+		f.advance(size)
+		next++
+		c.Assert(f.writeAt <= 2*f.limit, Equals, true)
+	}
+
+	// the limit still holds for the messages waiting
+	c.Assert(f.spill(SeverityInfo, []byte("message 40")), IsNil)
+	c.Assert(f.spill(SeverityInfo, []byte("message 41")), IsNil)
+	c.Assert(f.spill(SeverityInfo, []byte("message 42")), ErrorMatches, "spilling the message would exceed .*")
+	for ; f.file != nil; next++ {
+		m, size, err := f.peek()
+		c.Assert(err, IsNil)
+		c.Assert(string(m.message), Equals, fmt.Sprintf("message %02d", next))
+		f.advance(size)
+	}
+	c.Assert(next, Equals, 42)
+}
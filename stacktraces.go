@@ -0,0 +1,181 @@
+package log
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync/atomic"
+)
+
+// Supported modes of dumping stack traces on fatal messages.
+const (
+	FatalStackAll     = "all"     // all goroutines, identical stacks are collapsed
+	FatalStackCurrent = "current" // the goroutine logging the message only
+	FatalStackNone    = "none"
+)
+
+// maxStackTracesSize bounds the size of stack traces dumped on fatal messages.
+const maxStackTracesSize = 64 << 20
+
+// maxErrorStackDepth bounds the number of frames of the stack traces of error messages.
+const maxErrorStackDepth = 32
+
+var fatalStackMode atomic.Value
+
+// errorStackSev is the severity from which messages logged with an error carry a stack trace.
+var errorStackSev = int32(SeverityError)
+
+func init() {
+	fatalStackMode.Store(FatalStackAll)
+}
+
+// SetFatalStackMode sets which stack traces Fatalf and Fatal append to the message:
+// of all goroutines ("all", default), of the goroutine logging the message ("current")
+// or none at all ("none"). In "all" mode goroutines with identical stacks are dumped once
+// with a count, e.g. "goroutine 7 [chan receive] (x 100):".
+func SetFatalStackMode(mode string) error {
+	switch mode {
+	case FatalStackAll, FatalStackCurrent, FatalStackNone:
+		fatalStackMode.Store(mode)
+		return nil
+	}
+	return fmt.Errorf("unsupported fatal stack mode: %s", mode)
+}
+
+// SetErrorStackSeverity sets the severity from which messages logged with an error, see
+// WithError and ErrorE, carry the stack trace of their call site as the "stack" field, ERROR
+// by default. SeverityOff leaves stack traces out.
+func SetErrorStackSeverity(sev Severity) {
+	atomic.StoreInt32(&errorStackSev, int32(sev))
+}
+
+func errorStackSeverity() Severity {
+	return Severity(atomic.LoadInt32(&errorStackSev))
+}
+
+// callerStack returns the stack trace of the caller at the depth, one function per line
+// followed by its location on the next, indented by a tab, like runtime.Stack.
+func callerStack(depth int) string {
+	pcs := make([]uintptr, maxErrorStackDepth)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(depth+2, pcs)])
+	var lines []string
+	for {
+		frame, more := frames.Next()
+		lines = append(lines, frame.Function, fmt.Sprintf("\t%s:%d", frame.File, frame.Line))
+		if !more {
+			break
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// stackTrace is a stack trace logged as a format argument, which is never truncated, see
+// SetMaxArgBytes.
+type stackTrace string
+
+// withStackTraces returns the format and arguments of a fatal message with the stack traces
+// appended to the formatted message according to the fatal stack mode. The stack of the
+// current goroutine starts at the caller at the depth, 1 being the caller of the function
+// calling withStackTraces, like messageCaller.
+func withStackTraces(depth int, format string, args []interface{}) (string, []interface{}) {
+	traces := stackTraces(fatalStackMode.Load().(string), depth+1)
+	if traces == "" {
+		return format, args
+	}
+	message := fmt.Sprintf(format, expandLoggableArgs(format, args)...)
+	return "%s\n%s", []interface{}{message, stackTrace(traces)}
+}
+
+// stackTraces returns the stack traces of goroutines in the provided mode, the skip innermost
+// frames of the current goroutine above the caller of stackTraces being left out.
+func stackTraces(mode string, skip int) string {
+	switch mode {
+	case FatalStackCurrent:
+		return string(skipFrames(stack(false), skip+2))
+	case FatalStackAll:
+		return collapseStacks(skipFrames(stack(true), skip+2))
+	}
+	return ""
+}
+
+// stack returns the output of runtime.Stack for the current or all goroutines.
+func stack(all bool) []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, all)
+		if n < len(buf) || len(buf) >= maxStackTracesSize {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// skipFrames removes the n innermost frames of the first goroutine in the output of
+// runtime.Stack, the current one, each of them a function line followed by its location.
+func skipFrames(traces []byte, n int) []byte {
+	header := bytes.IndexByte(traces, '\n') + 1
+	rest := traces[header:]
+	for i := 0; i < 2*n; i++ {
+		end := bytes.IndexByte(rest, '\n')
+		if end < 0 {
+			return traces
+		}
+		rest = rest[end+1:]
+	}
+	return append(traces[:header:header], rest...)
+}
+
+var (
+	// goroutineHeader matches the first line of a goroutine stack, e.g.
+	// "goroutine 7 [chan receive, 2 minutes]:".
+	goroutineHeader = regexp.MustCompile(`^goroutine (\d+) \[([^,\]]+)(?:, [^\]]+)?\]:`)
+
+	// volatile matches the parts of a stack that differ between goroutines running the same
+	// code: function arguments and IDs of goroutines that created them.
+	volatile = regexp.MustCompile(`\(0x[^)]*\)|\(\.\.\.\)| in goroutine \d+`)
+)
+
+// collapseStacks collapses goroutines with identical stacks in the output of runtime.Stack
+// into one entry with a count, keeping the order of the first occurrences.
+func collapseStacks(traces []byte) string {
+	type group struct {
+		header string
+		body   string
+		count  int
+	}
+
+	var groups []*group
+	byKey := make(map[string]*group)
+
+	for _, g := range bytes.Split(bytes.TrimSpace(traces), []byte("\n\n")) {
+		header, body := string(g), ""
+		if i := bytes.IndexByte(g, '\n'); i >= 0 {
+			header, body = string(g[:i]), string(g[i+1:])
+		}
+
+		key := header + "\n" + body
+		if m := goroutineHeader.FindStringSubmatch(header); m != nil {
+			key = m[2] + "\n" + volatile.ReplaceAllString(body, "")
+		}
+
+		if existing, ok := byKey[key]; ok {
+			existing.count++
+			continue
+		}
+		grp := &group{header, body, 1}
+		byKey[key] = grp
+		groups = append(groups, grp)
+	}
+
+	entries := make([]string, len(groups))
+	for i, grp := range groups {
+		header := grp.header
+		if grp.count > 1 {
+			header = fmt.Sprintf("%s (x %d):", strings.TrimSuffix(header, ":"), grp.count)
+		}
+		entries[i] = header + "\n" + grp.body
+	}
+	return strings.Join(entries, "\n\n")
+}
@@ -0,0 +1,148 @@
+package log
+
+import (
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type StackTracesSuite struct {
+}
+
+var _ = Suite(&StackTracesSuite{})
+
+func (s *StackTracesSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *StackTracesSuite) TearDownTest(c *C) {
+	SetFatalStackMode(FatalStackAll)
+	exit = os.Exit
+}
+
+func (s *StackTracesSuite) TestSetFatalStackMode(c *C) {
+	c.Assert(SetFatalStackMode(FatalStackNone), IsNil)
+	c.Assert(SetFatalStackMode("some"), NotNil)
+	c.Assert(fatalStackMode.Load(), Equals, FatalStackNone)
+}
+
+func (s *StackTracesSuite) TestFatalStackModes(c *C) {
+	exit = func(int) {}
+	logger := newTestLogger("log")
+	Init(logger)
+
+	c.Assert(SetFatalStackMode(FatalStackNone), IsNil)
+	Fatalf("hello %s", "world")
+	c.Assert(logger.b.String(), Equals, "FATAL hello world\n")
+
+	c.Assert(SetFatalStackMode(FatalStackCurrent), IsNil)
+	logger.b.Reset()
+	Fatal("hello world")
+	c.Assert(strings.HasPrefix(logger.b.String(), "FATAL hello world\ngoroutine "), Equals, true)
+	c.Assert(regexp.MustCompile(`(?m)^goroutine `).FindAllString(logger.b.String(), -1), HasLen, 1)
+	c.Assert(strings.Contains(logger.b.String(), "TestFatalStackModes"), Equals, true)
+}
+
+func (s *StackTracesSuite) TestFatalStackStartsAtCaller(c *C) {
+	exit = func(int) {}
+	logger := newTestLogger("log")
+	Init(logger)
+
+	// the frames of the package logging the message are left out of the stack
+	caller := "goroutine \\d+ \\[running\\]:\ngithub.com/mailgun/log.\\(\\*StackTracesSuite\\).TestFatalStackStartsAtCaller"
+	for _, mode := range []string{FatalStackCurrent, FatalStackAll} {
+		c.Assert(SetFatalStackMode(mode), IsNil)
+		for _, fatal := range []func(){
+			func() { Fatalf("hello %s", "world") },
+			func() { Fatal("hello world") },
+			func() { WithFields(Fields{}).Fatalf("hello %s", "world") },
+			func() { WithFields(Fields{}).Fatal("hello world") },
+			func() { Output(2, SeverityFatal, "hello %s", "world") },
+			func() { WithFields(Fields{}).Output(2, SeverityFatal, "hello %s", "world") },
+		} {
+			logger.b.Reset()
+			fatal()
+			c.Assert(logger.b.String(), Matches, "(?s)FATAL hello world\n"+caller+".*", Commentf(mode))
+		}
+	}
+
+	// the traces are appended to the formatted message, whatever its format
+	logger.b.Reset()
+	Fatalf("100%")
+	c.Assert(logger.b.String(), Matches, "(?s)FATAL 100%!\\(NOVERB\\)\n"+caller+".*")
+}
+
+func (s *StackTracesSuite) TestCollapseStacks(c *C) {
+	const n = 50
+
+	var started sync.WaitGroup
+	release := make(chan struct{})
+	defer close(release)
+	for i := 0; i < n; i++ {
+		started.Add(1)
+		go blockedGoroutine(i, &started, release)
+	}
+	started.Wait()
+
+	// wait until all the goroutines get blocked
+	var traces string
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if traces = stackTraces(FatalStackAll, 0); strings.Contains(traces, " (x 50):") {
+			break
+		}
+	}
+
+	c.Assert(strings.Count(traces, "blockedGoroutine"), Equals, 1, Commentf(traces))
+	c.Assert(traces, Matches, `(?s).*goroutine \d+ \[chan receive\] \(x 50\):\n[^\n]*blockedGoroutine.*`)
+	c.Assert(strings.Contains(traces, "TestCollapseStacks"), Equals, true)
+}
+
+func (s *StackTracesSuite) TestCollapseStacksOutput(c *C) {
+	traces := `goroutine 1 [running]:
+main.main()
+	/app/main.go:10 +0x1d
+
+goroutine 5 [chan receive, 2 minutes]:
+main.worker(0xc00001c0a0, 0x1)
+	/app/main.go:20 +0x25
+created by main.main in goroutine 1
+	/app/main.go:9 +0x2a
+
+goroutine 6 [chan receive]:
+main.worker(0xc00001c0b0, 0x2)
+	/app/main.go:20 +0x25
+created by main.main in goroutine 1
+	/app/main.go:9 +0x2a
+
+goroutine 7 [select]:
+main.worker(0xc00001c0c0, 0x3)
+	/app/main.go:22 +0x31
+created by main.main in goroutine 1
+	/app/main.go:9 +0x2a
+`
+
+	c.Assert(collapseStacks([]byte(traces)), Equals, `goroutine 1 [running]:
+main.main()
+	/app/main.go:10 +0x1d
+
+goroutine 5 [chan receive, 2 minutes] (x 2):
+main.worker(0xc00001c0a0, 0x1)
+	/app/main.go:20 +0x25
+created by main.main in goroutine 1
+	/app/main.go:9 +0x2a
+
+goroutine 7 [select]:
+main.worker(0xc00001c0c0, 0x3)
+	/app/main.go:22 +0x31
+created by main.main in goroutine 1
+	/app/main.go:9 +0x2a`)
+}
+
+func blockedGoroutine(i int, started *sync.WaitGroup, release chan struct{}) {
+	started.Done()
+	<-release
+}
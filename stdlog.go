@@ -0,0 +1,44 @@
+package log
+
+import (
+	stdlog "log"
+	"strings"
+)
+
+// stdlogCallDepth is the number of stack frames between stdlogWriter.Write and the caller of
+// the standard library logger writing to it.
+const stdlogCallDepth = 4
+
+// StdLogger returns a standard library logger logging its messages through the package at the
+// provided severity, e.g. to pass to http.Server as its ErrorLog. Messages are reported as
+// logged by the callers of the standard library logger's Print, Printf and Println methods.
+func StdLogger(sev Severity) *stdlog.Logger {
+	return stdlog.New(stdlogWriter{sev}, "", 0)
+}
+
+// RedirectStdLog makes the standard library's default logger, used by log.Printf and the like
+// in third-party packages, log its messages through the package at the provided severity. The
+// returned function restores the previous output, flags and prefix of the default logger.
+func RedirectStdLog(sev Severity) func() {
+	out, flags, prefix := stdlog.Writer(), stdlog.Flags(), stdlog.Prefix()
+	stdlog.SetOutput(stdlogWriter{sev})
+	stdlog.SetFlags(0)
+	stdlog.SetPrefix("")
+
+	return func() {
+		stdlog.SetOutput(out)
+		stdlog.SetFlags(flags)
+		stdlog.SetPrefix(prefix)
+	}
+}
+
+// stdlogWriter logs the messages written by a standard library logger at the severity.
+type stdlogWriter struct {
+	sev Severity
+}
+
+func (w stdlogWriter) Write(p []byte) (int, error) {
+	// the standard library logger terminates every message with a newline
+	Output(stdlogCallDepth, w.sev, "%s", strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
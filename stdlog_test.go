@@ -0,0 +1,50 @@
+package log
+
+import (
+	"bytes"
+	stdlog "log"
+
+	. "gopkg.in/check.v1"
+)
+
+type StdLogSuite struct {
+}
+
+var _ = Suite(&StdLogSuite{})
+
+func (s *StdLogSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *StdLogSuite) TestStdLogger(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	StdLogger(SeverityWarning).Printf("disk %d%% full", 90)
+	c.Assert(logger.b.String(), Equals, "WARN disk 90% full\n")
+	c.Assert(logger.caller.FuncName, Matches, ".*TestStdLogger")
+	c.Assert(logger.caller.FileName, Equals, "stdlog_test.go")
+}
+
+func (s *StdLogSuite) TestRedirectStdLog(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	out, flags := stdlog.Writer(), stdlog.Flags()
+	defer stdlog.SetOutput(out)
+	defer stdlog.SetFlags(flags)
+
+	var previous bytes.Buffer
+	stdlog.SetOutput(&previous)
+	stdlog.SetFlags(stdlog.Lshortfile)
+
+	restore := RedirectStdLog(SeverityInfo)
+	stdlog.Println("from", "stdlib")
+	c.Assert(logger.b.String(), Equals, "INFO from stdlib\n")
+	c.Assert(logger.caller.FuncName, Matches, ".*TestRedirectStdLog")
+
+	restore()
+	stdlog.Print("restored")
+	c.Assert(previous.String(), Matches, "stdlog_test.go:[0-9]+: restored\n")
+	c.Assert(logger.b.String(), Equals, "INFO from stdlib\n")
+}
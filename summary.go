@@ -0,0 +1,147 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// summaryFormat is the format of digests, which summarizers do not count.
+const summaryFormat = "in the last %v: %s"
+
+// Summarizer is a logger counting the messages it is sent and periodically logging a digest
+// of the counts at INFO, e.g. "in the last 1m0s: 1200 INFO, 30 WARN, 5 ERROR", optionally
+// followed by the most frequent format strings. Counts are reset after every digest and no
+// digest is logged when nothing has been logged.
+//
+// It is meant to be added to the logger chain next to loggers with high severities:
+//
+//	summarizer := log.NewSummarizer(time.Minute, 3)
+//	defer summarizer.Close()
+//	log.Init(console, summarizer)
+type Summarizer struct {
+	top int
+
+	mu      sync.Mutex
+	start   time.Time
+	counts  map[Severity]int
+	formats map[string]int
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// NewSummarizer makes a summarizer logging a digest every interval along with the top most
+// frequent format strings, none if top is not positive.
+func NewSummarizer(interval time.Duration, top int) *Summarizer {
+	s := &Summarizer{
+		top:     top,
+		start:   now(),
+		counts:  make(map[Severity]int),
+		formats: make(map[string]int),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run(interval)
+	return s
+}
+
+func (s *Summarizer) Writer(sev Severity) io.Writer {
+	return ioutil.Discard
+}
+
+// FormatMessage counts the message, the summarizer does not write anything out.
+func (s *Summarizer) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
+	if format == summaryFormat {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.counts[sev]++
+	if s.top > 0 {
+		s.formats[format]++
+	}
+	return ""
+}
+
+// Close stops logging digests. It is safe to call it more than once.
+func (s *Summarizer) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+	return nil
+}
+
+func (s *Summarizer) run(interval time.Duration) {
+	defer close(s.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.summarize()
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// summarize logs the digest of the messages counted since the last one and resets the counts.
+func (s *Summarizer) summarize() {
+	s.mu.Lock()
+	t := now()
+	elapsed := t.Sub(s.start).Round(time.Second)
+	counts, formats := s.counts, s.formats
+	s.start, s.counts, s.formats = t, make(map[Severity]int), make(map[string]int)
+	s.mu.Unlock()
+
+	if len(counts) == 0 {
+		return
+	}
+	writeMessage(1, SeverityInfo, nil, summaryFormat, elapsed, digest(counts, formats, s.top))
+}
+
+// digest renders the counts by ascending severity followed by the top most frequent formats.
+func digest(counts map[Severity]int, formats map[string]int, top int) string {
+	sevs := make([]Severity, 0, len(counts))
+	for sev := range counts {
+		sevs = append(sevs, sev)
+	}
+	sort.Slice(sevs, func(i, j int) bool { return sevs[i] < sevs[j] })
+
+	parts := make([]string, len(sevs))
+	for i, sev := range sevs {
+		parts[i] = fmt.Sprintf("%d %s", counts[sev], sev)
+	}
+	text := strings.Join(parts, ", ")
+
+	if top <= 0 || len(formats) == 0 {
+		return text
+	}
+	keys := make([]string, 0, len(formats))
+	for format := range formats {
+		keys = append(keys, format)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if formats[keys[i]] != formats[keys[j]] {
+			return formats[keys[i]] > formats[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+	if len(keys) > top {
+		keys = keys[:top]
+	}
+	for i, format := range keys {
+		keys[i] = fmt.Sprintf("%q %d", format, formats[format])
+	}
+	return text + "; top formats: " + strings.Join(keys, ", ")
+}
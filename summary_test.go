@@ -0,0 +1,76 @@
+package log
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SummarySuite struct {
+	now time.Time
+}
+
+var _ = Suite(&SummarySuite{})
+
+func (s *SummarySuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+
+	s.now = time.Date(2014, 1, 1, 0, 0, 0, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return s.now }))
+}
+
+func (s *SummarySuite) TearDownTest(c *C) {
+	SetClock(nil)
+}
+
+func (s *SummarySuite) TestDigest(c *C) {
+	logger := newThresholdLogger("log", SeverityInfo)
+	// the ticker never fires during the test, digests are triggered by hand
+	summarizer := NewSummarizer(time.Hour, 2)
+	defer summarizer.Close()
+	Init(logger, summarizer)
+
+	for i := 0; i < 3; i++ {
+		Debugf("hello %s", "debug")
+		Infof("hello %s", "world")
+		Infof("hello %s", "again")
+	}
+	Warningf("hello %s", "warning")
+	Error("hello error")
+	logger.b.Reset()
+
+	s.now = s.now.Add(time.Minute)
+	summarizer.summarize()
+	c.Assert(logger.b.String(), Equals, `INFO in the last 1m0s: 3 DEBUG, 6 INFO, 1 WARN, 1 ERROR; top formats: "hello %s" 10, "%s" 1`+"\n")
+
+	// the counts are reset and the digest itself is not counted
+	logger.b.Reset()
+	s.now = s.now.Add(time.Minute)
+	summarizer.summarize()
+	c.Assert(logger.b.Len(), Equals, 0)
+
+	Infof("hello %s", "world")
+	logger.b.Reset()
+	s.now = s.now.Add(30 * time.Second)
+	summarizer.summarize()
+	c.Assert(logger.b.String(), Equals, `INFO in the last 30s: 1 INFO; top formats: "hello %s" 1`+"\n")
+}
+
+func (s *SummarySuite) TestTicker(c *C) {
+	logger, err := NewMemoryLogger(Config{Severity: "info"})
+	c.Assert(err, IsNil)
+	summarizer := NewSummarizer(time.Millisecond, 0)
+	Init(logger, summarizer)
+
+	Warningf("hello %s", "warning")
+	deadline := time.Now().Add(5 * time.Second)
+	for len(logger.Messages()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	summarizer.Close()
+	summarizer.Close()
+	c.Assert(logger.Messages(), DeepEquals, []MemoryMessage{
+		{Severity: SeverityWarning, Message: "hello warning"},
+		{Severity: SeverityInfo, Message: "in the last 0s: 1 WARN"},
+	})
+}
@@ -0,0 +1,175 @@
+package log
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// suppressedFormat is the format of the summaries of suppressed messages, which are never
+// suppressed themselves.
+const suppressedFormat = "suppressed %d similar messages logged at %s:%d in the last %v: %q"
+
+var (
+	// repetitions samples repetitive messages, see SetSampling.
+	repetitions = &sampler{}
+
+	// samplingDropped counts the messages suppressed by sampling.
+	samplingDropped uint64
+)
+
+// SetSampling keeps tight loops from flooding the loggers with the same message: in every
+// interval, the first messages logged from a call site with a format string are sent to the
+// loggers, then only one in every thereafter of them, the others being suppressed; zero
+// thereafter suppresses them all. At the end of an interval in which messages were suppressed,
+// a summary such as
+//
+//	suppressed 9950 similar messages logged at /src/app/main.go:42 in the last 1s: "disk %s is full"
+//
+// is logged at the highest severity of the suppressed messages. FATAL and PANIC messages are
+// never suppressed. A non-positive interval or first turns sampling off, which logs the
+// pending summaries.
+//
+// Unlike a SampledLogger, which samples everything a logger is sent, sampling keeps track of
+// every call site so that a single repetitive one cannot hide the others.
+func SetSampling(interval time.Duration, first, thereafter int) {
+	repetitions.set(interval, first, thereafter)
+}
+
+// SamplingDropped returns the number of messages suppressed by sampling.
+func SamplingDropped() uint64 {
+	return atomic.LoadUint64(&samplingDropped)
+}
+
+// sampled tells whether the message logged from the caller should be suppressed by sampling,
+// and counts it if so.
+func sampled(sev Severity, caller *CallerInfo, format string) bool {
+	if sev >= SeverityFatal || format == suppressedFormat {
+		return false
+	}
+	if !repetitions.suppress(sev, sampleKey{caller.FilePath, caller.LineNo, format}) {
+		return false
+	}
+	atomic.AddUint64(&samplingDropped, 1)
+	return true
+}
+
+// sampleKey identifies similar messages: those logged from a call site with a format string.
+type sampleKey struct {
+	file   string
+	line   int
+	format string
+}
+
+// sampleCount counts the similar messages logged in the current interval.
+type sampleCount struct {
+	seen       uint64
+	suppressed uint64
+	sev        Severity // highest severity of the suppressed messages
+}
+
+// sampler samples similar messages in intervals.
+type sampler struct {
+	mu         sync.Mutex
+	interval   time.Duration // zero when sampling is off
+	first      uint64
+	thereafter uint64
+	counts     map[sampleKey]*sampleCount
+	stop       chan struct{}
+}
+
+func (s *sampler) set(interval time.Duration, first, thereafter int) {
+	s.mu.Lock()
+	if s.stop != nil {
+		close(s.stop)
+		s.stop = nil
+	}
+	counts, previous := s.counts, s.interval
+	s.interval, s.counts = 0, nil
+	if interval > 0 && first > 0 {
+		if thereafter < 0 {
+			thereafter = 0
+		}
+		s.interval, s.first, s.thereafter = interval, uint64(first), uint64(thereafter)
+		s.counts = make(map[sampleKey]*sampleCount)
+		s.stop = make(chan struct{})
+		go s.run(interval, s.stop)
+	}
+	s.mu.Unlock()
+
+	summarizeSuppressed(counts, previous)
+}
+
+// suppress counts the message and tells whether it should be suppressed.
+func (s *sampler) suppress(sev Severity, key sampleKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.interval == 0 {
+		return false
+	}
+	c := s.counts[key]
+	if c == nil {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.seen++
+	if c.seen <= s.first || (s.thereafter > 0 && (c.seen-s.first)%s.thereafter == 0) {
+		return false
+	}
+	c.suppressed++
+	if sev > c.sev {
+		c.sev = sev
+	}
+	return true
+}
+
+// rotate starts a new interval and logs the summaries of the messages suppressed in the last one.
+func (s *sampler) rotate() {
+	s.mu.Lock()
+	counts, interval := s.counts, s.interval
+	if interval > 0 {
+		s.counts = make(map[sampleKey]*sampleCount)
+	}
+	s.mu.Unlock()
+
+	summarizeSuppressed(counts, interval)
+}
+
+func (s *sampler) run(interval time.Duration, stop chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.rotate()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// summarizeSuppressed logs a summary for every call site messages were suppressed from, in the
+// order of the call sites.
+func summarizeSuppressed(counts map[sampleKey]*sampleCount, interval time.Duration) {
+	var keys []sampleKey
+	for key, c := range counts {
+		if c.suppressed > 0 {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].file != keys[j].file {
+			return keys[i].file < keys[j].file
+		}
+		if keys[i].line != keys[j].line {
+			return keys[i].line < keys[j].line
+		}
+		return keys[i].format < keys[j].format
+	})
+	for _, key := range keys {
+		c := counts[key]
+		writeMessage(1, c.sev, nil, suppressedFormat, c.suppressed, key.file, key.line, interval, key.format)
+	}
+}
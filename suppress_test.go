@@ -0,0 +1,84 @@
+package log
+
+import (
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type SuppressSuite struct {
+}
+
+var _ = Suite(&SuppressSuite{})
+
+func (s *SuppressSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *SuppressSuite) TearDownTest(c *C) {
+	SetSampling(0, 0, 0)
+}
+
+func (s *SuppressSuite) TestSampling(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	// a long interval so that the test rotates it
+	SetSampling(time.Hour, 2, 3)
+	dropped := SamplingDropped()
+	for i := 0; i < 10; i++ {
+		Errorf("disk %d is full", i)
+		Infof("other")
+	}
+	c.Assert(logger.b.String(), Equals, "ERROR disk 0 is full\nINFO other\nERROR disk 1 is full\nINFO other\n"+
+		"ERROR disk 4 is full\nINFO other\nERROR disk 7 is full\nINFO other\n")
+	c.Assert(SamplingDropped(), Equals, dropped+12)
+
+	logger.b.Reset()
+	repetitions.rotate()
+	c.Assert(logger.b.String(), Matches, `ERROR suppressed 6 similar messages logged at .*suppress_test.go:[0-9]+ in the last 1h0m0s: "disk %d is full"\n`+
+		`INFO suppressed 6 similar messages logged at .*suppress_test.go:[0-9]+ in the last 1h0m0s: "other"\n`)
+
+	// call sites start afresh in every interval
+	logger.b.Reset()
+	Errorf("disk %d is full", 10)
+	c.Assert(logger.b.String(), Equals, "ERROR disk 10 is full\n")
+}
+
+func (s *SuppressSuite) TestSamplingOff(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	SetSampling(time.Hour, 1, 0)
+	for i := 0; i < 3; i++ {
+		Warningf("retrying")
+	}
+	c.Assert(logger.b.String(), Equals, "WARN retrying\n")
+
+	// turning sampling off logs the pending summaries
+	SetSampling(0, 0, 0)
+	c.Assert(logger.b.String(), Matches, `WARN retrying\nWARN suppressed 2 similar messages .* in the last 1h0m0s: "retrying"\n`)
+	logger.b.Reset()
+	Warningf("retrying")
+	Warningf("retrying")
+	c.Assert(logger.b.String(), Equals, "WARN retrying\nWARN retrying\n")
+}
+
+func (s *SuppressSuite) TestSamplingTicker(c *C) {
+	memory, err := NewMemoryLogger(Config{Severity: "info"})
+	c.Assert(err, IsNil)
+	Init(memory)
+
+	// an interval may end between any two messages, keep logging until one ends with a summary
+	SetSampling(10*time.Millisecond, 1, 0)
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		Infof("tick")
+		for _, m := range memory.Messages() {
+			if m.Message != "tick" {
+				c.Assert(m.Message, Matches, `suppressed [0-9]+ similar messages .*: "tick"`)
+				return
+			}
+		}
+		c.Assert(time.Now().Before(deadline), Equals, true)
+	}
+}
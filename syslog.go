@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io"
 	"log/syslog"
+	"sync/atomic"
 )
 
-// sysLogger logs messages to rsyslog MAIL_LOG facility.
+// sysLogger logs messages to the local syslog server or, if configured with an address, sends
+// RFC 5424 messages to a remote one.
 type sysLogger struct {
 	sev Severity
 
@@ -14,55 +16,173 @@ type sysLogger struct {
 	infoW  io.Writer
 	warnW  io.Writer
 	errorW io.Writer
+	fatalW io.Writer
+	panicW io.Writer
+
+	levelStyle string
+
+	// remote is set when messages are sent to a remote server, in which case every writer is
+	// the same connection and messages carry their priority
+	remote   bool
+	mapper   SeverityMapper
+	facility syslog.Priority
+	tag      string
+	address  string
 }
 
 func NewSysLogger(conf Config) (Logger, error) {
-	debugW, err := syslog.New(syslog.LOG_MAIL|syslog.LOG_DEBUG, appname)
+	mapper := conf.SeverityMapper
+	if mapper == nil {
+		mapper = SyslogSeverityMapper
+	}
+
+	facility, err := syslogFacility(conf.Facility)
 	if err != nil {
 		return nil, err
 	}
+	tag := conf.Tag
+	if tag == "" {
+		tag = appname
+	}
 
-	infoW, err := syslog.New(syslog.LOG_MAIL|syslog.LOG_INFO, appname)
+	sev, err := severityFromString(conf.Severity)
 	if err != nil {
 		return nil, err
 	}
 
-	warnW, err := syslog.New(syslog.LOG_MAIL|syslog.LOG_WARNING, appname)
+	if err := validateLevelStyle(conf.LevelStyle); err != nil {
+		return nil, err
+	}
+
+	if conf.Address != "" || conf.Network != "" {
+		conn, err := dialSyslog(conf.Network, conf.Address, conf.TLSConfig)
+		if err != nil {
+			return nil, err
+		}
+		return &sysLogger{
+			sev:        sev,
+			debugW:     conn,
+			infoW:      conn,
+			warnW:      conn,
+			errorW:     conn,
+			fatalW:     conn,
+			panicW:     conn,
+			levelStyle: conf.LevelStyle,
+			remote:     true,
+			mapper:     mapper,
+			facility:   facility,
+			tag:        tag,
+			address:    conn.String(),
+		}, nil
+	}
+
+	debugW, err := newSyslogWriter(mapper, facility, tag, SeverityDebug)
 	if err != nil {
 		return nil, err
 	}
 
-	errorW, err := syslog.New(syslog.LOG_MAIL|syslog.LOG_ERR, appname)
+	infoW, err := newSyslogWriter(mapper, facility, tag, SeverityInfo)
 	if err != nil {
 		return nil, err
 	}
 
-	sev, err := severityFromString(conf.Severity)
+	warnW, err := newSyslogWriter(mapper, facility, tag, SeverityWarning)
+	if err != nil {
+		return nil, err
+	}
+
+	errorW, err := newSyslogWriter(mapper, facility, tag, SeverityError)
+	if err != nil {
+		return nil, err
+	}
+
+	fatalW, err := newSyslogWriter(mapper, facility, tag, SeverityFatal)
 	if err != nil {
 		return nil, err
 	}
 
-	return &sysLogger{sev, debugW, infoW, warnW, errorW}, nil
+	panicW, err := newSyslogWriter(mapper, facility, tag, SeverityPanic)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sysLogger{
+		sev:        sev,
+		debugW:     debugW,
+		infoW:      infoW,
+		warnW:      warnW,
+		errorW:     errorW,
+		fatalW:     fatalW,
+		panicW:     panicW,
+		levelStyle: conf.LevelStyle,
+		mapper:     mapper,
+		facility:   facility,
+		tag:        tag,
+	}, nil
+}
+
+// newSyslogWriter connects to the local syslog server with the priority the mapper assigns to
+// the severity.
+func newSyslogWriter(mapper SeverityMapper, facility syslog.Priority, tag string, sev Severity) (*syslog.Writer, error) {
+	return syslog.New(facility|syslog.Priority(mapper.Priority(sev)), tag)
 }
 
 func (l *sysLogger) Writer(sev Severity) io.Writer {
 	// is this logger configured to log at the provided severity?
-	if sev >= l.sev {
+	if sev >= l.Severity() {
 		// return an appropriate writer
 		switch sev {
-		case SeverityDebug:
+		case SeverityTrace, SeverityDebug:
 			return l.debugW
 		case SeverityInfo:
 			return l.infoW
 		case SeverityWarning:
 			return l.warnW
-		default:
+		case SeverityError:
 			return l.errorW
+		case SeverityPanic:
+			return l.panicW
+		default:
+			return l.fatalW
 		}
 	}
 	return nil
 }
 
+func (l *sysLogger) Severity() Severity {
+	return Severity(atomic.LoadInt32((*int32)(&l.sev)))
+}
+
+func (l *sysLogger) SetSeverity(sev Severity) {
+	atomic.StoreInt32((*int32)(&l.sev), int32(sev))
+}
+
+// Close closes the connections to syslog. It is safe to call it more than once.
+func (l *sysLogger) Close() error {
+	var err error
+	for _, w := range []io.Writer{l.debugW, l.infoW, l.warnW, l.errorW, l.fatalW, l.panicW} {
+		if c, ok := w.(io.Closer); ok {
+			if e := c.Close(); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return err
+}
+
 func (l *sysLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
-	return fmt.Sprintf("%s [%s:%d] %s", sev, caller.FileName, caller.LineNo, fmt.Sprintf(format, args...))
+	return l.FormatRecord(NewRecord(sev, caller, nil, format, args...))
+}
+
+// FormatRecord renders the fields after the message for the local syslog server, and as
+// structured data of RFC 5424 messages for remote ones.
+func (l *sysLogger) FormatRecord(r Record) string {
+	message := fmt.Sprintf("%s [%s:%d] %s", r.Severity.format(l.levelStyle), r.File, r.Line, r.Message)
+	if !l.remote {
+		if len(r.Fields) > 0 {
+			message += " " + formatFields(r.Fields)
+		}
+		return message
+	}
+	return formatRFC5424(int(l.facility)|l.mapper.Priority(r.Severity), r.Time, l.tag, r.Fields, message)
 }
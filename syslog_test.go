@@ -2,6 +2,14 @@ package log
 
 import (
 	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log/syslog"
+	"net"
+	"strconv"
+	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -11,40 +19,56 @@ type SysLoggerSuite struct {
 
 var _ = Suite(&SysLoggerSuite{})
 
+func (s *SysLoggerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
 func (s *SysLoggerSuite) TestWriter(c *C) {
 	debug, info, warning, error := &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}, &bytes.Buffer{}
 
 	// DEBUG logger should log DEBUG, INFO, WARN and ERROR
-	l := &sysLogger{SeverityDebug, debug, info, warning, error}
+	l := &sysLogger{sev: SeverityDebug, debugW: debug, infoW: info, warnW: warning, errorW: error}
 	c.Assert(l.Writer(SeverityDebug), Equals, debug)
 	c.Assert(l.Writer(SeverityInfo), Equals, info)
 	c.Assert(l.Writer(SeverityWarning), Equals, warning)
 	c.Assert(l.Writer(SeverityError), Equals, error)
+	c.Assert(l.Writer(SeverityTrace), IsNil)
+
+	// TRACE logger should log TRACE at the debug priority
+	l = &sysLogger{sev: SeverityTrace, debugW: debug, infoW: info, warnW: warning, errorW: error}
+	c.Assert(l.Writer(SeverityTrace), Equals, debug)
 
 	// INFO logger should log INFO, WARN and ERROR
-	l = &sysLogger{SeverityInfo, debug, info, warning, error}
+	l = &sysLogger{sev: SeverityInfo, debugW: debug, infoW: info, warnW: warning, errorW: error}
 	c.Assert(l.Writer(SeverityDebug), IsNil)
 	c.Assert(l.Writer(SeverityInfo), Equals, info)
 	c.Assert(l.Writer(SeverityWarning), Equals, warning)
 	c.Assert(l.Writer(SeverityError), Equals, error)
 
 	// WARN logger should log WARN and ERROR
-	l = &sysLogger{SeverityWarning, debug, info, warning, error}
+	l = &sysLogger{sev: SeverityWarning, debugW: debug, infoW: info, warnW: warning, errorW: error}
 	c.Assert(l.Writer(SeverityDebug), IsNil)
 	c.Assert(l.Writer(SeverityInfo), IsNil)
 	c.Assert(l.Writer(SeverityWarning), Equals, warning)
 	c.Assert(l.Writer(SeverityError), Equals, error)
 
 	// ERROR logger should log only ERROR
-	l = &sysLogger{SeverityError, debug, info, warning, error}
+	l = &sysLogger{sev: SeverityError, debugW: debug, infoW: info, warnW: warning, errorW: error}
 	c.Assert(l.Writer(SeverityDebug), IsNil)
 	c.Assert(l.Writer(SeverityInfo), IsNil)
 	c.Assert(l.Writer(SeverityWarning), IsNil)
 	c.Assert(l.Writer(SeverityError), Equals, error)
+
+	// OFF logger should not log at all
+	l = &sysLogger{sev: SeverityOff, debugW: debug, infoW: info, warnW: warning, errorW: error}
+	c.Assert(l.Writer(SeverityDebug), IsNil)
+	c.Assert(l.Writer(SeverityInfo), IsNil)
+	c.Assert(l.Writer(SeverityWarning), IsNil)
+	c.Assert(l.Writer(SeverityError), IsNil)
 }
 
 func (s *SysLoggerSuite) TestNewSysLogger(c *C) {
-	l, err := NewSysLogger(Config{Syslog, "debug"})
+	l, err := NewSysLogger(Config{Name: Syslog, Severity: "debug"})
 	c.Assert(err, IsNil)
 	c.Assert(l, NotNil)
 
@@ -54,4 +78,135 @@ func (s *SysLoggerSuite) TestNewSysLogger(c *C) {
 	c.Assert(syslog.infoW, NotNil)
 	c.Assert(syslog.warnW, NotNil)
 	c.Assert(syslog.errorW, NotNil)
+	c.Assert(syslog.fatalW, NotNil)
+	c.Assert(syslog.panicW, NotNil)
+}
+
+func (s *SysLoggerSuite) TestNewSysLoggerWithSeverityMapper(c *C) {
+	var mapped []Severity
+	mapper := SeverityMapperFunc(func(sev Severity) int {
+		mapped = append(mapped, sev)
+		return 5
+	})
+
+	l, err := NewSysLogger(Config{Name: Syslog, Severity: "debug", SeverityMapper: mapper})
+	c.Assert(err, IsNil)
+	c.Assert(l, NotNil)
+	c.Assert(mapped, DeepEquals, []Severity{SeverityDebug, SeverityInfo, SeverityWarning, SeverityError, SeverityFatal, SeverityPanic})
+}
+
+func (s *SysLoggerSuite) TestFormatMessageLevelStyle(c *C) {
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	l := &sysLogger{sev: SeverityInfo}
+	c.Assert(l.FormatMessage(SeverityError, caller, "hello %s", "world"), Equals, "ERROR [filename:42] hello world")
+
+	l = &sysLogger{sev: SeverityInfo, levelStyle: LevelStyleShort3}
+	c.Assert(l.FormatMessage(SeverityError, caller, "hello %s", "world"), Equals, "ERR [filename:42] hello world")
+
+	l = &sysLogger{sev: SeverityInfo, levelStyle: LevelStyleChar}
+	c.Assert(l.FormatMessage(SeverityError, caller, "hello %s", "world"), Equals, "E [filename:42] hello world")
+}
+
+func (s *SysLoggerSuite) TestFacility(c *C) {
+	facility, err := syslogFacility("")
+	c.Assert(err, IsNil)
+	c.Assert(facility, Equals, syslog.LOG_MAIL)
+
+	facility, err = syslogFacility("LOCAL3")
+	c.Assert(err, IsNil)
+	c.Assert(facility, Equals, syslog.LOG_LOCAL3)
+
+	_, err = syslogFacility("office")
+	c.Assert(err, ErrorMatches, "unsupported syslog facility: office")
+}
+
+func (s *SysLoggerSuite) TestStructuredData(c *C) {
+	c.Assert(structuredData(nil), Equals, "-")
+	c.Assert(structuredData(Fields{"user id": 7, "quote": `a "b" [c] \d`}), Equals,
+		`[fields@32473 quote="a \"b\" [c\] \\d" user_id="7"]`)
+	c.Assert(sdName(strings.Repeat("k", 40)), Equals, strings.Repeat("k", 32))
+	c.Assert(sdToken("", 48), Equals, "-")
+	c.Assert(sdToken("my app", 48), Equals, "my_app")
+}
+
+func (s *SysLoggerSuite) TestRemoteUDP(c *C) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer server.Close()
+
+	l, err := NewSysLogger(Config{Name: Syslog, Severity: "info", Facility: "local0", Tag: "app",
+		Network: "udp", Address: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+
+	caller := &CallerInfo{"main.go", "/src/main.go", "main.main", 42}
+	t := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	message := formatMessage(l, SeverityError, caller, t, Fields{"user": "bob"}, "hello %s", "world")
+	_, err = io.WriteString(l.Writer(SeverityError), message)
+	c.Assert(err, IsNil)
+
+	buf := make([]byte, 1024)
+	server.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := server.ReadFrom(buf)
+	c.Assert(err, IsNil)
+
+	// local0 is facility 16, ERROR is priority 3
+	expected := fmt.Sprintf(`<131>1 2024-05-06T07:08:09.123456Z %s app %d - [fields@32473 user="bob"] ERROR [main.go:42] hello world`,
+		sdToken(hostname, 255), pid)
+	c.Assert(string(buf[:n]), Equals, expected)
+	c.Assert(describe(l).Destination, Equals, "udp://"+server.LocalAddr().String())
+}
+
+func (s *SysLoggerSuite) TestRemoteTCP(c *C) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	defer server.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := server.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		b, _ := ioutil.ReadAll(conn)
+		received <- string(b)
+	}()
+
+	l, err := NewSysLogger(Config{Name: Syslog, Severity: "info", Network: "tcp", Address: server.Addr().String()})
+	c.Assert(err, IsNil)
+	Init(l)
+
+	Infof("first")
+	Warningf("second")
+	c.Assert(l.(io.Closer).Close(), IsNil)
+	c.Assert(l.(io.Closer).Close(), IsNil)
+
+	// messages are octet-counted, mail is facility 2
+	stream := <-received
+	for _, m := range []string{"<22>1 .* - INFO .*first", "<20>1 .* - WARN .*second"} {
+		c.Assert(stream, Matches, "[0-9]+ .*")
+		size, rest := readOctetCount(c, stream)
+		c.Assert(rest[:size], Matches, m)
+		stream = rest[size:]
+	}
+	c.Assert(stream, Equals, "")
+}
+
+// readOctetCount splits an octet-counted frame into the length of the message and the rest of
+// the stream.
+func readOctetCount(c *C, stream string) (int, string) {
+	i := strings.Index(stream, " ")
+	c.Assert(i > 0, Equals, true)
+	size, err := strconv.Atoi(stream[:i])
+	c.Assert(err, IsNil)
+	return size, stream[i+1:]
+}
+
+func (s *SysLoggerSuite) TestRemoteConfig(c *C) {
+	_, err := NewSysLogger(Config{Name: Syslog, Severity: "info", Network: "sctp", Address: "127.0.0.1:514"})
+	c.Assert(err, ErrorMatches, "unsupported syslog network: sctp")
+	_, err = NewSysLogger(Config{Name: Syslog, Severity: "info", Network: "tcp"})
+	c.Assert(err, ErrorMatches, "syslog network tcp needs an address")
 }
@@ -0,0 +1,138 @@
+package log
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log/syslog"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SyslogStructuredDataID is the ID of the RFC 5424 structured data element carrying the fields
+// of the messages sent to remote syslog servers.
+const SyslogStructuredDataID = "fields@32473"
+
+// syslogFacilities are the syslog facilities by name.
+var syslogFacilities = map[string]syslog.Priority{
+	"kern":     syslog.LOG_KERN,
+	"user":     syslog.LOG_USER,
+	"mail":     syslog.LOG_MAIL,
+	"daemon":   syslog.LOG_DAEMON,
+	"auth":     syslog.LOG_AUTH,
+	"syslog":   syslog.LOG_SYSLOG,
+	"lpr":      syslog.LOG_LPR,
+	"news":     syslog.LOG_NEWS,
+	"uucp":     syslog.LOG_UUCP,
+	"cron":     syslog.LOG_CRON,
+	"authpriv": syslog.LOG_AUTHPRIV,
+	"ftp":      syslog.LOG_FTP,
+	"local0":   syslog.LOG_LOCAL0,
+	"local1":   syslog.LOG_LOCAL1,
+	"local2":   syslog.LOG_LOCAL2,
+	"local3":   syslog.LOG_LOCAL3,
+	"local4":   syslog.LOG_LOCAL4,
+	"local5":   syslog.LOG_LOCAL5,
+	"local6":   syslog.LOG_LOCAL6,
+	"local7":   syslog.LOG_LOCAL7,
+}
+
+// syslogFacility returns the facility of the provided name, "mail" by default.
+func syslogFacility(name string) (syslog.Priority, error) {
+	if name == "" {
+		return syslog.LOG_MAIL, nil
+	}
+	if facility, ok := syslogFacilities[strings.ToLower(name)]; ok {
+		return facility, nil
+	}
+	return 0, fmt.Errorf("unsupported syslog facility: %s", name)
+}
+
+// formatRFC5424 formats an RFC 5424 message with the priority, logged at t, with the fields as
+// structured data.
+func formatRFC5424(priority int, t time.Time, tag string, fields Fields, message string) string {
+	host := hostname
+	if host == "" {
+		host = "-"
+	}
+	return fmt.Sprintf("<%d>1 %s %s %s %d - %s %s",
+		priority, t.Format("2006-01-02T15:04:05.000000Z07:00"), sdToken(host, 255), sdToken(tag, 48), pid,
+		structuredData(fields), message)
+}
+
+// structuredData renders the fields as a structured data element, the nil value "-" if there
+// are none.
+func structuredData(fields Fields) string {
+	if len(fields) == 0 {
+		return "-"
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("[" + SyslogStructuredDataID)
+	for _, k := range keys {
+		fmt.Fprintf(&b, " %s=\"%s\"", sdName(k), sdEscaper.Replace(fmt.Sprint(fields[k])))
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+// sdEscaper escapes the characters RFC 5424 parameter values cannot carry as they are.
+var sdEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+// sdName turns the field name into a valid parameter name: at most 32 printable characters,
+// none of which is a space, '=', ']' or '"'.
+func sdName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' || r == '=' || r == ']' || r == '"' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "_"
+	}
+	if len(name) > 32 {
+		name = name[:32]
+	}
+	return name
+}
+
+// sdToken turns the value into a valid header field of at most max printable characters, the
+// nil value "-" if it is empty.
+func sdToken(value string, max int) string {
+	value = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return '_'
+		}
+		return r
+	}, value)
+	if value == "" {
+		return "-"
+	}
+	if len(value) > max {
+		value = value[:max]
+	}
+	return value
+}
+
+// dialSyslog connects to the remote syslog server at address over the network, "udp" by
+// default.
+func dialSyslog(network, address string, tlsConfig *tls.Config) (*netConn, error) {
+	if network == "" {
+		network = "udp"
+	}
+	switch network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("unsupported syslog network: %s", network)
+	}
+	if address == "" {
+		return nil, fmt.Errorf("syslog network %s needs an address", network)
+	}
+	return dialNet(network, address, tlsConfig)
+}
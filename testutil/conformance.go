@@ -0,0 +1,82 @@
+// Package testutil provides helpers for testing code built on the log package.
+package testutil
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+// severities are all the severities a logger can be configured with and log at.
+var severities = []log.Severity{log.SeverityTrace, log.SeverityDebug, log.SeverityInfo, log.SeverityWarning, log.SeverityError, log.SeverityFatal, log.SeverityPanic}
+
+// TestLoggerConformance verifies that loggers made by the factory behave the way the log
+// package expects from a log.Logger implementation. The factory is called with the minimum
+// severity the returned logger should be logging messages at.
+//
+// It checks that:
+//   - Writer returns nil below the logger's severity and a writer at and above it;
+//   - FormatMessage (and FormatMessageWithFields for log.FieldFormatter implementations)
+//     includes the formatted message;
+//   - Close, for loggers implementing io.Closer, can be called more than once.
+func TestLoggerConformance(t *testing.T, factory func(log.Severity) log.Logger) {
+	t.Run("Writer", func(t *testing.T) {
+		for _, threshold := range severities {
+			l := factory(threshold)
+			for _, sev := range severities {
+				w := l.Writer(sev)
+				if sev < threshold && w != nil {
+					t.Errorf("%s logger: Writer(%s) = %v, want nil", threshold, sev, w)
+				}
+				if sev >= threshold && w == nil {
+					t.Errorf("%s logger: Writer(%s) = nil, want a writer", threshold, sev)
+				}
+			}
+			closeLogger(t, l)
+		}
+	})
+
+	t.Run("FormatMessage", func(t *testing.T) {
+		l := factory(log.SeverityTrace)
+		defer closeLogger(t, l)
+
+		caller := &log.CallerInfo{FileName: "file.go", FilePath: "/path/to/file.go", FuncName: "pkg.Func", LineNo: 42}
+		for _, sev := range severities {
+			if message := l.FormatMessage(sev, caller, "hello %s %d", "conformance", 42); !strings.Contains(message, "hello conformance 42") {
+				t.Errorf("FormatMessage(%s) = %q, want it to contain the message", sev, message)
+			}
+
+			f, ok := l.(log.FieldFormatter)
+			if !ok {
+				continue
+			}
+			fields := log.Fields{"conformance": "yes"}
+			if message := f.FormatMessageWithFields(sev, caller, fields, "hello %s %d", "conformance", 42); !strings.Contains(message, "hello conformance 42") {
+				t.Errorf("FormatMessageWithFields(%s) = %q, want it to contain the message", sev, message)
+			}
+		}
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		l := factory(log.SeverityDebug)
+		c, ok := l.(io.Closer)
+		if !ok {
+			t.Skip("logger does not implement io.Closer")
+		}
+		c.Close()
+		if err := c.Close(); err != nil {
+			t.Errorf("second Close() = %v, want nil", err)
+		}
+	})
+}
+
+// closeLogger closes the logger if it implements io.Closer.
+func closeLogger(t *testing.T, l log.Logger) {
+	if c, ok := l.(io.Closer); ok {
+		if err := c.Close(); err != nil {
+			t.Errorf("Close() = %v", err)
+		}
+	}
+}
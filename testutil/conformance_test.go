@@ -0,0 +1,49 @@
+package testutil
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+func TestConsoleLoggerConformance(t *testing.T) {
+	TestLoggerConformance(t, func(sev log.Severity) log.Logger {
+		l, err := log.NewLogger(log.Config{Name: log.Console, Severity: sev.String()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	})
+}
+
+func TestUDPLoggerConformance(t *testing.T) {
+	TestLoggerConformance(t, func(sev log.Severity) log.Logger {
+		l, err := log.NewLogger(log.Config{Name: log.UDPLog, Severity: sev.String()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	})
+}
+
+func TestSysLoggerConformance(t *testing.T) {
+	TestLoggerConformance(t, func(sev log.Severity) log.Logger {
+		l, err := log.NewLogger(log.Config{Name: log.Syslog, Severity: sev.String()})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	})
+}
+
+func TestFileLoggerConformance(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "conformance.log")
+	TestLoggerConformance(t, func(sev log.Severity) log.Logger {
+		l, err := log.NewLogger(log.Config{Name: log.File, Severity: sev.String(), Path: path})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return l
+	})
+}
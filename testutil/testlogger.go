@@ -0,0 +1,78 @@
+package testutil
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+// TestLogger is a logger sending messages to a test through t.Log, so that they are attributed
+// to the test and only shown if it fails or runs verbosely. Messages at ERROR and above are
+// sent through t.Error and fail the test.
+//
+// The logger lets go of the test once it completes, messages logged afterwards are dropped.
+type TestLogger struct {
+	sev int32
+
+	mu sync.Mutex
+	t  testing.TB
+}
+
+// NewTestLogger makes a logger sending messages of any severity to the test, for example:
+//
+//	log.Init(testutil.NewTestLogger(t))
+func NewTestLogger(t testing.TB) *TestLogger {
+	l := &TestLogger{sev: int32(log.SeverityTrace), t: t}
+	t.Cleanup(func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		l.t = nil
+	})
+	return l
+}
+
+func (l *TestLogger) Writer(sev log.Severity) io.Writer {
+	// is this logger configured to log at the provided severity?
+	if sev >= l.Severity() {
+		return &testWriter{l, sev}
+	}
+	return nil
+}
+
+func (l *TestLogger) FormatMessage(sev log.Severity, caller *log.CallerInfo, format string, args ...interface{}) string {
+	return fmt.Sprintf("%s [%s:%d] %s", sev, caller.FileName, caller.LineNo, fmt.Sprintf(format, args...))
+}
+
+func (l *TestLogger) Severity() log.Severity {
+	return log.Severity(atomic.LoadInt32(&l.sev))
+}
+
+func (l *TestLogger) SetSeverity(sev log.Severity) {
+	atomic.StoreInt32(&l.sev, int32(sev))
+}
+
+// testWriter sends messages written to it to the test of a TestLogger.
+type testWriter struct {
+	l   *TestLogger
+	sev log.Severity
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.l.mu.Lock()
+	defer w.l.mu.Unlock()
+
+	if t := w.l.t; t != nil {
+		message := strings.TrimRight(string(p), "\r\n")
+		if w.sev >= log.SeverityError {
+			t.Error(message)
+		} else {
+			t.Log(message)
+		}
+	}
+	return len(p), nil
+}
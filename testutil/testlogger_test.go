@@ -0,0 +1,71 @@
+package testutil
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/mailgun/log"
+)
+
+// fakeTB records what a TestLogger sends to a test.
+type fakeTB struct {
+	testing.TB
+
+	logs, errors []string
+	cleanups     []func()
+}
+
+func (t *fakeTB) Log(args ...interface{}) {
+	t.logs = append(t.logs, fmt.Sprint(args...))
+}
+
+func (t *fakeTB) Error(args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprint(args...))
+}
+
+func (t *fakeTB) Cleanup(f func()) {
+	t.cleanups = append(t.cleanups, f)
+}
+
+// finish runs the cleanups the way the testing package does when a test completes.
+func (t *fakeTB) finish() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+func TestTestLogger(t *testing.T) {
+	defer log.CaptureOutput(ioutil.Discard)()
+	fake := &fakeTB{}
+	l := NewTestLogger(fake)
+	l.SetSeverity(log.SeverityInfo)
+	log.Init(l)
+
+	log.Debugf("hello %s", "debug")
+	log.Infof("hello %s", "info")
+	log.WithFields(log.Fields{"user": "bob"}).Errorf("hello %s", "error")
+
+	if len(fake.logs) != 1 || !strings.HasPrefix(fake.logs[0], "INFO [testlogger_test.go:") || !strings.HasSuffix(fake.logs[0], "] hello info") {
+		t.Errorf("logs = %q, want the INFO message", fake.logs)
+	}
+	if len(fake.errors) != 1 || !strings.HasSuffix(fake.errors[0], "] hello error user=bob") {
+		t.Errorf("errors = %q, want the ERROR message", fake.errors)
+	}
+
+	// the test is let go of once it completes
+	fake.finish()
+	log.Errorf("hello %s", "again")
+	if len(fake.logs) != 1 || len(fake.errors) != 1 {
+		t.Errorf("messages logged after the test completed reached it: %q %q", fake.logs, fake.errors)
+	}
+}
+
+func TestTestLoggerConformance(t *testing.T) {
+	TestLoggerConformance(t, func(sev log.Severity) log.Logger {
+		l := NewTestLogger(&fakeTB{})
+		l.SetSeverity(sev)
+		return l
+	})
+}
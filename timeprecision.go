@@ -0,0 +1,47 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// defaultTimePrecision is the precision of console timestamps unless set otherwise.
+const defaultTimePrecision = time.Millisecond
+
+// timePrecisions holds the timestamp precision of every severity, zero meaning the default.
+var timePrecisions = make([]int64, len(severities))
+
+// SetTimePrecision sets the precision of the timestamps of console messages at the provided
+// severity, for example nanoseconds for DEBUG messages used for timing while the others are
+// rendered to the second:
+//
+//	log.SetTimePrecision(log.SeverityDebug, time.Nanosecond)
+//	log.SetTimePrecision(log.SeverityInfo, time.Second)
+//
+// Precisions between powers of ten, such as 10ms, render as many fractional digits as needed.
+// Zero restores the default of milliseconds.
+func SetTimePrecision(sev Severity, precision time.Duration) {
+	if i := sev.index(); i >= 0 {
+		atomic.StoreInt64(&timePrecisions[i], int64(precision))
+	}
+}
+
+// timestampLayout returns the layout of console timestamps of messages at the provided severity.
+func timestampLayout(sev Severity) string {
+	precision := defaultTimePrecision
+	if i := sev.index(); i >= 0 {
+		if p := time.Duration(atomic.LoadInt64(&timePrecisions[i])); p > 0 {
+			precision = p
+		}
+	}
+
+	digits := 0
+	for p := time.Second; p > precision && digits < 9; p /= 10 {
+		digits++
+	}
+	if digits == 0 {
+		return time.Stamp
+	}
+	// "Jan _2 15:04:05.000000000" cut short after the digits
+	return time.StampNano[:len(time.Stamp)+1+digits]
+}
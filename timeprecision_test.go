@@ -0,0 +1,53 @@
+package log
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TimePrecisionSuite struct {
+}
+
+var _ = Suite(&TimePrecisionSuite{})
+
+func (s *TimePrecisionSuite) SetUpTest(c *C) {
+	SetClock(ClockFunc(func() time.Time { return time.Date(2014, 3, 5, 7, 9, 11, 123456789, time.UTC) }))
+}
+
+func (s *TimePrecisionSuite) TearDownTest(c *C) {
+	SetClock(nil)
+	for _, sev := range severities {
+		SetTimePrecision(sev, 0)
+	}
+}
+
+func (s *TimePrecisionSuite) TestDefault(c *C) {
+	l, _ := NewConsoleLogger(Config{Name: Console, Severity: "debug"})
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	for _, sev := range []Severity{SeverityDebug, SeverityInfo, SeverityError} {
+		message := l.FormatMessage(sev, caller, "hello")
+		c.Assert(strings.HasPrefix(message, "Mar  5 07:09:11.123 "), Equals, true, Commentf(message))
+	}
+}
+
+func (s *TimePrecisionSuite) TestPerSeverity(c *C) {
+	SetTimePrecision(SeverityDebug, time.Nanosecond)
+	SetTimePrecision(SeverityInfo, time.Second)
+	SetTimePrecision(SeverityWarning, 10*time.Millisecond)
+
+	l, _ := NewConsoleLogger(Config{Name: Console, Severity: "debug"})
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	for sev, prefix := range map[Severity]string{
+		SeverityDebug:   "Mar  5 07:09:11.123456789 ",
+		SeverityInfo:    "Mar  5 07:09:11 ",
+		SeverityWarning: "Mar  5 07:09:11.12 ",
+		SeverityError:   "Mar  5 07:09:11.123 ",
+	} {
+		message := l.FormatMessage(sev, caller, "hello")
+		c.Assert(strings.HasPrefix(message, prefix), Equals, true, Commentf(message))
+	}
+}
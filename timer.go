@@ -0,0 +1,39 @@
+package log
+
+import (
+	"sync/atomic"
+)
+
+// DurationField is the name of the field carrying the elapsed time of timed operations.
+const DurationField = "duration"
+
+// timerSeverity is the severity timed operations are logged at.
+var timerSeverity = int32(SeverityInfo)
+
+// SetTimerSeverity sets the severity StartTimer logs operations at, INFO by default.
+func SetTimerSeverity(sev Severity) {
+	atomic.StoreInt32(&timerSeverity, int32(sev))
+}
+
+// StartTimer starts timing the named operation, see Entry.StartTimer.
+func StartTimer(name string) func(keyvals ...interface{}) {
+	return (&Entry{}).StartTimer(name)
+}
+
+// StartTimer starts timing the named operation and returns a function logging its completion
+// along with the time elapsed since the start as the "duration" field and the provided key/value
+// pairs as additional fields, typically deferred:
+//
+//	defer log.StartTimer("sync")("items", len(items))
+//
+// The time is measured with the clock set with SetClock and the message is logged at the
+// severity set with SetTimerSeverity.
+func (e *Entry) StartTimer(name string) func(keyvals ...interface{}) {
+	start := now()
+	return func(keyvals ...interface{}) {
+		fields := keyvalFields(keyvals)
+		fields[DurationField] = now().Sub(start)
+		writeMessage(1, Severity(atomic.LoadInt32(&timerSeverity)), e.WithFields(fields), "%s completed", name)
+		warnOddKeyvals(1, keyvals)
+	}
+}
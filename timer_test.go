@@ -0,0 +1,52 @@
+package log
+
+import (
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TimerSuite struct {
+	now time.Time
+}
+
+var _ = Suite(&TimerSuite{})
+
+func (s *TimerSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+	resetKeyvalWarnings()
+	s.now = time.Date(2014, 3, 5, 7, 9, 11, 0, time.UTC)
+	SetClock(ClockFunc(func() time.Time { return s.now }))
+}
+
+func (s *TimerSuite) TearDownTest(c *C) {
+	SetClock(nil)
+	SetTimerSeverity(SeverityInfo)
+}
+
+func (s *TimerSuite) TestStartTimer(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	func() {
+		defer StartTimer("sync")("items", 3, "source", "db")
+		s.now = s.now.Add(1500 * time.Millisecond)
+	}()
+
+	c.Assert(logger.b.String(), Equals, "INFO sync completed duration=1.5s items=3 source=db\n")
+	c.Assert(strings.HasSuffix(logger.caller.FuncName, "TestStartTimer.func1"), Equals, true, Commentf(logger.caller.FuncName))
+}
+
+func (s *TimerSuite) TestEntryStartTimer(c *C) {
+	SetTimerSeverity(SeverityDebug)
+	logger := newTestLogger("log")
+	Init(logger)
+
+	done := WithFields(Fields{"user": "bob"}).StartTimer("login")
+	s.now = s.now.Add(42 * time.Millisecond)
+	done("attempt")
+
+	c.Assert(logger.b.String(), Matches, "DEBUG login completed attempt=\\(MISSING\\) duration=42ms user=bob\n"+
+		"WARN malformed log call at .*timer_test.go:[0-9]+: key attempt is missing its value\n")
+}
@@ -0,0 +1,259 @@
+package log
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// TruncatedMarker is appended to arguments and field values cut short to MaxArgBytes.
+const TruncatedMarker = "…(truncated)"
+
+// maxArgBytes is the limit set with SetMaxArgBytes, 0 when arguments are not limited.
+var maxArgBytes int64
+
+// SetMaxArgBytes limits the length of the rendering of every format argument and field value
+// to n bytes, cutting longer ones short and marking them with TruncatedMarker. It guards the
+// loggers against accidentally logging huge slices, maps or strings, which are rendered no
+// further than the limit; values rendering themselves, with String, Error or Format methods,
+// are still rendered in full before being cut short. Stack traces, such as those of fatal
+// messages and the "stack" field, are never cut. Zero or a negative n, the default, removes
+// the limit.
+func SetMaxArgBytes(n int) {
+	if n < 0 {
+		n = 0
+	}
+	atomic.StoreInt64(&maxArgBytes, int64(n))
+}
+
+// truncateArgs returns the arguments wrapped to be rendered within the limit, if there is one.
+// Numbers, booleans and stack traces, as well as arguments of %T and %p, which would see the
+// wrapper instead of the argument, are left untouched.
+func truncateArgs(format string, args []interface{}) []interface{} {
+	max := int(atomic.LoadInt64(&maxArgBytes))
+	if max == 0 || len(args) == 0 {
+		return args
+	}
+	verbs, _ := argVerbs(nil, format)
+	truncated := make([]interface{}, len(args))
+	for i, arg := range args {
+		truncated[i] = arg
+		if _, stack := arg.(stackTrace); stack || isScalar(arg) || (i < len(verbs) && (verbs[i] == 'T' || verbs[i] == 'p')) {
+			continue
+		}
+		truncated[i] = truncatedArg{arg, max}
+	}
+	return truncated
+}
+
+// truncateFields returns the fields with the values rendered longer than the limit, if there
+// is one, replaced with their truncated renderings. Numbers, booleans and the stack trace of
+// the "stack" field are left untouched.
+func truncateFields(fields Fields) Fields {
+	max := int(atomic.LoadInt64(&maxArgBytes))
+	if max == 0 || len(fields) == 0 {
+		return fields
+	}
+
+	var truncated Fields
+	for k, v := range fields {
+		if isScalar(v) || k == StackField {
+			continue
+		}
+		s := renderCapped("%v", v, max)
+		if len(s) <= max {
+			continue
+		}
+		if truncated == nil {
+			truncated = make(Fields, len(fields))
+			for k, v := range fields {
+				truncated[k] = v
+			}
+		}
+		truncated[k] = truncate(s, max)
+	}
+	if truncated == nil {
+		return fields
+	}
+	return truncated
+}
+
+// isScalar returns true for values rendered within a few bytes.
+func isScalar(v interface{}) bool {
+	switch v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64, complex64, complex128:
+		return true
+	}
+	return false
+}
+
+// truncatedArg renders the argument it wraps with the verb it is formatted with, cutting
+// the rendering short at max bytes.
+type truncatedArg struct {
+	arg interface{}
+	max int
+}
+
+func (a truncatedArg) Format(f fmt.State, verb rune) {
+	s := renderCapped(directive(f, verb), a.arg, a.max)
+	f.Write([]byte(truncate(s, a.max)))
+}
+
+// renderCapped renders the value with the formatting directive like fmt does, giving up once
+// the rendering is longer than max bytes: strings, byte slices, slices, arrays and maps are
+// rendered piece by piece, other values by fmt. The rendering is cut short a few bytes past
+// max, leaving it to truncate to cut it between runes.
+func renderCapped(directive string, v interface{}, max int) string {
+	w := &cappedWriter{max: max + utf8.UTFMax}
+	w.render(directive, v)
+	return string(w.b)
+}
+
+// cappedWriter collects what is written to it up to max bytes, dropping the rest.
+type cappedWriter struct {
+	b   []byte
+	max int
+}
+
+func (w *cappedWriter) Write(p []byte) (int, error) {
+	if room := w.max - len(w.b); len(p) > room {
+		w.b = append(w.b, p[:room]...)
+	} else {
+		w.b = append(w.b, p...)
+	}
+	return len(p), nil
+}
+
+func (w *cappedWriter) WriteString(s string) {
+	if room := w.max - len(w.b); len(s) > room {
+		s = s[:room]
+	}
+	w.b = append(w.b, s...)
+}
+
+func (w *cappedWriter) full() bool {
+	return len(w.b) >= w.max
+}
+
+// render writes the rendering of the value, rendering the elements of slices, arrays and maps
+// with the same directive as fmt does, until the writer is full.
+func (w *cappedWriter) render(directive string, v interface{}) {
+	switch v.(type) {
+	case fmt.Formatter, fmt.Stringer, error:
+		fmt.Fprintf(w, directive, v)
+		return
+	}
+	rv := reflect.ValueOf(v)
+	plain := directive == "%v" || directive == "%s"
+	switch {
+	case strings.ContainsRune(directive, '#'):
+		// Go syntax, which fmt renders its own way
+		fmt.Fprintf(w, directive, v)
+	case rv.Kind() == reflect.String:
+		// no verb renders a string shorter than its prefix
+		s := rv.String()
+		if len(s) > w.max {
+			s = s[:w.max]
+		}
+		if plain {
+			w.WriteString(s)
+		} else {
+			fmt.Fprintf(w, directive, s)
+		}
+	case rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 && directive != "%v" && directive != "%d":
+		// rendered as a string of bytes
+		n := rv.Len()
+		if n > w.max {
+			n = w.max
+		}
+		fmt.Fprintf(w, directive, rv.Slice(0, n).Bytes())
+	case rv.Kind() == reflect.Array && rv.Type().Elem().Kind() == reflect.Uint8 && directive != "%v" && directive != "%d":
+		fmt.Fprintf(w, directive, v)
+	case rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array:
+		w.WriteString("[")
+		for i := 0; i < rv.Len() && !w.full(); i++ {
+			if i > 0 {
+				w.WriteString(" ")
+			}
+			w.render(directive, rv.Index(i).Interface())
+		}
+		w.WriteString("]")
+	case rv.Kind() == reflect.Map:
+		w.WriteString("map[")
+		keys := rv.MapKeys()
+		sortMapKeys(keys)
+		for i, k := range keys {
+			if w.full() {
+				break
+			}
+			if i > 0 {
+				w.WriteString(" ")
+			}
+			w.render(directive, k.Interface())
+			w.WriteString(":")
+			w.render(directive, rv.MapIndex(k).Interface())
+		}
+		w.WriteString("]")
+	default:
+		fmt.Fprintf(w, directive, v)
+	}
+}
+
+// sortMapKeys sorts the keys of a map of strings, numbers or booleans like fmt does, leaving
+// keys of other types as they are.
+func sortMapKeys(keys []reflect.Value) {
+	if len(keys) == 0 {
+		return
+	}
+	var less func(a, b reflect.Value) bool
+	switch keys[0].Kind() {
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	case reflect.Bool:
+		less = func(a, b reflect.Value) bool { return !a.Bool() && b.Bool() }
+	default:
+		return
+	}
+	sort.Slice(keys, func(i, j int) bool { return less(keys[i], keys[j]) })
+}
+
+// directive rebuilds the formatting directive, such as %-8.3f, the verb was given with.
+func directive(f fmt.State, verb rune) string {
+	d := []byte{'%'}
+	for _, flag := range "+-# 0" {
+		if f.Flag(int(flag)) {
+			d = append(d, byte(flag))
+		}
+	}
+	if width, ok := f.Width(); ok {
+		d = strconv.AppendInt(d, int64(width), 10)
+	}
+	if precision, ok := f.Precision(); ok {
+		d = append(d, '.')
+		d = strconv.AppendInt(d, int64(precision), 10)
+	}
+	return string(utf8.AppendRune(d, verb))
+}
+
+// truncate cuts the string short at max bytes, without splitting a UTF-8 sequence, and
+// marks it as truncated.
+func truncate(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	cut := max
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + TruncatedMarker
+}
@@ -0,0 +1,144 @@
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type TruncateSuite struct {
+}
+
+var _ = Suite(&TruncateSuite{})
+
+func (s *TruncateSuite) SetUpTest(c *C) {
+	loggers = []Logger{}
+}
+
+func (s *TruncateSuite) TearDownTest(c *C) {
+	SetMaxArgBytes(0)
+}
+
+func (s *TruncateSuite) TestLargeSliceTruncated(c *C) {
+	SetMaxArgBytes(16)
+	logger := newTestLogger("log")
+	Init(logger)
+
+	huge := make([]int, 1000000)
+	Infof("ids %v, count %d", huge, len(huge))
+
+	c.Assert(logger.b.String(), Equals, "INFO ids [0 0 0 0 0 0 0 0…(truncated), count 1000000\n")
+}
+
+func (s *TruncateSuite) TestVerbsPreserved(c *C) {
+	SetMaxArgBytes(8)
+	logger := newTestLogger("log")
+	Init(logger)
+
+	Infof("%5d|%*s|%-4s|%.2f|%T|%q|%x", 42, 3, "a", "ab", 3.14159, []int{}, "quoted string", "abcdefgh")
+
+	c.Assert(logger.b.String(), Equals, "INFO    42|  a|ab  |3.14|[]int|\"quoted …(truncated)|61626364…(truncated)\n")
+}
+
+func (s *TruncateSuite) TestFieldsTruncated(c *C) {
+	SetMaxArgBytes(8)
+	logger := newTestLogger("log")
+	Init(logger)
+
+	fields := Fields{"big": strings.Repeat("x", 100), "small": "abc", "n": 1234567890123}
+	WithFields(fields).Infof("hello")
+
+	c.Assert(logger.b.String(), Equals, "INFO hello big=xxxxxxxx…(truncated) n=1234567890123 small=abc\n")
+	c.Assert(fields["big"], HasLen, 100)
+}
+
+func (s *TruncateSuite) TestUnlimited(c *C) {
+	logger := newTestLogger("log")
+	Init(logger)
+
+	long := strings.Repeat("x", 10000)
+	Infof("%s", long)
+
+	c.Assert(logger.b.String(), Equals, fmt.Sprintf("INFO %s\n", long))
+}
+
+func (s *TruncateSuite) TestTruncateUTF8(c *C) {
+	c.Assert(truncate("héllo", 2), Equals, "h"+TruncatedMarker)
+	c.Assert(truncate("héllo", 3), Equals, "hé"+TruncatedMarker)
+	c.Assert(truncate("hello", 5), Equals, "hello")
+}
+
+func (s *TruncateSuite) TestRenderCapped(c *C) {
+	type point struct{ X, Y int }
+	x := 1
+	for _, t := range []struct {
+		directive string
+		v         interface{}
+	}{
+		{"%v", []interface{}{nil, 1, "a", errors.New("e"), point{1, 2}}},
+		{"%+v", []point{{1, 2}}},
+		{"%v", map[string][]int{"b": {2}, "a": {1, 1}}},
+		{"%v", map[int]bool{3: true, -1: false}},
+		{"%v", []int(nil)},
+		{"%v", map[string]int(nil)},
+		{"%5v", [2]int{1, 2}},
+		{"%q", []string{"a b", "c"}},
+		{"%x", [4]byte{0xde, 0xad, 0xbe, 0xef}},
+		{"%x", []byte("hello")},
+		{"%s", []byte("hello")},
+		{"%v", []byte("hi")},
+		{"%q", "hello"},
+		{"%-8s", "hi"},
+		{"%v", &x},
+		{"%#v", []int{1}},
+	} {
+		c.Assert(renderCapped(t.directive, t.v, 1000), Equals, fmt.Sprintf(t.directive, t.v), Commentf("%s %#v", t.directive, t.v))
+	}
+}
+
+// countedStringer counts how many times values are rendered.
+type countedStringer struct {
+	calls *int
+}
+
+func (s countedStringer) String() string {
+	*s.calls++
+	return "item"
+}
+
+func (s *TruncateSuite) TestRenderingStops(c *C) {
+	SetMaxArgBytes(16)
+	logger := newTestLogger("log")
+	Init(logger)
+
+	// elements past the limit are not rendered at all
+	calls := 0
+	items := make([]countedStringer, 100000)
+	for i := range items {
+		items[i] = countedStringer{&calls}
+	}
+	Infof("%v", items)
+	WithFields(Fields{"items": map[int]countedStringer{1: items[0], 2: items[1], 3: items[2], 4: items[3], 5: items[4]}}).Infof("hello")
+	c.Assert(logger.b.String(), Equals, "INFO [item item item …(truncated)\n"+"INFO hello items=\"map[1:item 2:ite…(truncated)\"\n")
+	c.Assert(calls < 20, Equals, true, Commentf("%d calls", calls))
+}
+
+func (s *TruncateSuite) TestStacksNotTruncated(c *C) {
+	SetMaxArgBytes(16)
+	logger := newTestLogger("log")
+	Init(logger)
+
+	func() {
+		defer LogRecover()
+		panic("oops")
+	}()
+	WithError(errors.New("failed")).Errorf("hello")
+	c.Assert(strings.Contains(logger.b.String(), TruncatedMarker), Equals, false)
+	c.Assert(logger.b.String(), Matches, "(?s)ERROR recovered from panic: oops\ngoroutine .*TestStacksNotTruncated.*ERROR hello error=failed .*stack=.*TestStacksNotTruncated.*")
+
+	// nor are the stack traces of fatal messages
+	format, args := withStackTraces(0, "%s", []interface{}{"dying"})
+	c.Assert(truncateArgs(format, args)[1], Equals, args[1])
+}
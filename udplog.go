@@ -3,7 +3,8 @@ package log
 import (
 	"encoding/json"
 	"fmt"
-	"net"
+	"io"
+	"sync"
 	"time"
 )
 
@@ -14,6 +15,16 @@ const (
 	DefaultCategory = "go_logging"
 )
 
+// Encodings of the udplog logger's messages, see Config.Encoding.
+const (
+	EncodingUDPLog = "udplog"
+	EncodingJSON   = "json"
+	EncodingGELF   = "gelf"
+)
+
+// maxDatagramSize is the largest UDP payload over IPv4.
+const maxDatagramSize = 65507
+
 type udpLogRecord struct {
 	AppName   string  `json:"appname"`
 	HostName  string  `json:"hostname"`
@@ -23,48 +34,253 @@ type udpLogRecord struct {
 	LineNo    int     `json:"lineno"`
 	Message   string  `json:"message"`
 	Timestamp float64 `json:"timestamp"`
+
+	Fields map[string]interface{} `json:"fields,omitempty"`
 }
 
 // udpLogger is a type of writerLogger that sends messages in a special format to a udplog server.
 type udpLogger struct {
 	*writerLogger // provides Writer() through embedding
+
+	conn *netConn
+
+	// batch packs messages in datagrams, it is nil unless messages are batched
+	batch *datagramBatcher
+
+	// drops counts the messages that could not be sent as dropped
+	drops Droppable
+
+	encoding string
+	mapper   SeverityMapper
+	maxSize  int
+
+	closeOnce sync.Once
 }
 
 func NewUDPLogger(conf Config) (Logger, error) {
-	addr, err := net.ResolveUDPAddr("udp", fmt.Sprintf("%s:%v", DefaultHost, DefaultPort))
+	sev, err := severityFromString(conf.Severity)
 	if err != nil {
 		return nil, err
 	}
 
-	conn, err := net.DialUDP("udp", nil, addr)
-	if err != nil {
-		return nil, err
+	encoding := conf.Encoding
+	switch encoding {
+	case "":
+		encoding = EncodingUDPLog
+	case EncodingUDPLog, EncodingJSON, EncodingGELF:
+	default:
+		return nil, fmt.Errorf("unsupported udplog encoding: %s", encoding)
+	}
+	if conf.FlushInterval < 0 || conf.MaxDatagramSize < 0 {
+		return nil, fmt.Errorf("flush interval and datagram size must not be negative: %v, %d", conf.FlushInterval, conf.MaxDatagramSize)
+	}
+	if encoding == EncodingGELF && conf.FlushInterval > 0 {
+		return nil, fmt.Errorf("gelf messages cannot be batched")
+	}
+	maxSize := conf.MaxDatagramSize
+	if maxSize == 0 {
+		maxSize = maxDatagramSize
+	}
+	mapper := conf.SeverityMapper
+	if mapper == nil {
+		mapper = GELFSeverityMapper
 	}
 
-	sev, err := severityFromString(conf.Severity)
+	address := conf.Address
+	if address == "" {
+		address = fmt.Sprintf("%s:%v", DefaultHost, DefaultPort)
+	}
+	conn, err := dialNet("udp", address, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	return &udpLogger{&writerLogger{sev, conn}}, nil
+	l := &udpLogger{conn: conn, encoding: encoding, mapper: mapper, maxSize: maxSize}
+	if conf.FlushInterval > 0 {
+		l.batch = newDatagramBatcher(conn, maxSize, conf.FlushInterval)
+		l.writerLogger, l.drops = &writerLogger{sev, l.batch}, l.batch
+	} else {
+		counted := &dropCountingWriter{w: conn}
+		l.writerLogger, l.drops = &writerLogger{sev, counted}, counted
+	}
+	return l, nil
+}
+
+// Flush sends the batched messages, if any.
+func (l *udpLogger) Flush() error {
+	if l.batch == nil {
+		return nil
+	}
+	return l.batch.Flush()
+}
+
+// Close sends the batched messages, if any, and closes the connection to the udplog server.
+// It is safe to call it more than once.
+func (l *udpLogger) Close() error {
+	var err error
+	l.closeOnce.Do(func() {
+		if l.batch != nil {
+			l.batch.Close()
+		}
+		err = l.conn.Close()
+	})
+	return err
+}
+
+// Dropped returns the number of messages that could not be sent to the udplog server.
+func (l *udpLogger) Dropped() uint64 {
+	return l.drops.Dropped()
 }
 
 func (l *udpLogger) FormatMessage(sev Severity, caller *CallerInfo, format string, args ...interface{}) string {
-	rec := &udpLogRecord{
-		AppName:   appname,
-		HostName:  hostname,
-		LogLevel:  sev.String(),
-		FileName:  caller.FilePath,
-		FuncName:  caller.FuncName,
-		LineNo:    caller.LineNo,
-		Message:   fmt.Sprintf(format, args...),
-		Timestamp: float64(time.Now().UnixNano()) / 1000000000,
-	}
-
-	dump, err := json.Marshal(rec)
+	return l.FormatMessageWithFields(sev, caller, nil, format, args...)
+}
+
+// FormatMessageWithFields sends the fields of the message as the "fields" object of the record,
+// or as additional fields of GELF messages, so that the server gets them as typed values rather
+// than as part of the message. Messages are cut short to fit in a datagram.
+func (l *udpLogger) FormatMessageWithFields(sev Severity, caller *CallerInfo, fields Fields, format string, args ...interface{}) string {
+	return l.formatTimed(sev, caller, now(), fields, &lazyMessage{format: format, args: args})
+}
+
+func (l *udpLogger) formatTimed(sev Severity, caller *CallerInfo, t time.Time, fields Fields, m *lazyMessage) string {
+	r := newRecord(sev, caller, t, fields, m.text())
+	if l.encoding == EncodingGELF {
+		return string(fitDatagram(r, l.maxSize, func(r Record) []byte {
+			return formatGELF(r, l.mapper.Priority(r.Severity))
+		}))
+	}
+
+	return string(fitDatagram(r, l.maxSize, func(r Record) []byte {
+		rec := &udpLogRecord{
+			AppName:   appname,
+			HostName:  hostname,
+			LogLevel:  r.Severity.String(),
+			FileName:  caller.FilePath,
+			FuncName:  r.Func,
+			LineNo:    r.Line,
+			Message:   r.Message,
+			Timestamp: float64(r.Time.UnixNano()) / 1000000000,
+		}
+		if len(r.Fields) > 0 {
+			rec.Fields = make(map[string]interface{}, len(r.Fields))
+			for k, v := range r.Fields {
+				rec.Fields[k] = jsonValue(v)
+			}
+		}
+
+		dump, err := json.Marshal(rec)
+		if err != nil {
+			return nil
+		}
+		if l.encoding == EncodingJSON {
+			return dump
+		}
+		return []byte(fmt.Sprintf("%s:%s", DefaultCategory, dump))
+	}))
+}
+
+// fitDatagram encodes the record, cutting its message short if need be so that it fits in max
+// bytes. Records whose fields alone do not fit are left to be dropped when sent.
+func fitDatagram(r Record, max int, encode func(Record) []byte) []byte {
+	b := encode(r)
+	message := r.Message
+	for keep := len(message); len(b) > max && keep > 0; {
+		if keep -= len(b) - max + len(TruncatedMarker); keep < 0 {
+			keep = 0
+		}
+		r.Message = truncate(message, keep)
+		b = encode(r)
+	}
+	return b
+}
+
+// datagramBatcher packs the messages written to it in datagrams of up to maxSize bytes,
+// separating them with newlines, and sends them when they are full and at least every
+// interval.
+type datagramBatcher struct {
+	w       io.Writer
+	maxSize int
+
+	mu       sync.Mutex
+	buf      []byte
+	messages uint64 // number of messages in buf
+	dropped  uint64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newDatagramBatcher(w io.Writer, maxSize int, interval time.Duration) *datagramBatcher {
+	b := &datagramBatcher{w: w, maxSize: maxSize, stop: make(chan struct{}), done: make(chan struct{})}
+	go b.run(interval)
+	return b
+}
+
+func (b *datagramBatcher) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if len(p) > b.maxSize {
+		b.dropped++
+		return 0, fmt.Errorf("message of %d bytes does not fit in a datagram of %d", len(p), b.maxSize)
+	}
+	if len(b.buf) > 0 && len(b.buf)+1+len(p) > b.maxSize {
+		b.send()
+	}
+	if len(b.buf) > 0 {
+		b.buf = append(b.buf, '\n')
+	}
+	b.buf = append(b.buf, p...)
+	b.messages++
+	return len(p), nil
+}
+
+// Flush sends the batched messages.
+func (b *datagramBatcher) Flush() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.send()
+}
+
+// Close stops sending batches periodically and sends the last one.
+func (b *datagramBatcher) Close() error {
+	close(b.stop)
+	<-b.done
+	return b.Flush()
+}
+
+// Dropped returns the number of messages that could not be sent.
+func (b *datagramBatcher) Dropped() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.dropped
+}
+
+// send sends the batched messages in a datagram, it must be called with mu held.
+func (b *datagramBatcher) send() error {
+	if len(b.buf) == 0 {
+		return nil
+	}
+	_, err := b.w.Write(b.buf)
 	if err != nil {
-		return ""
+		b.dropped += b.messages
 	}
+	b.buf, b.messages = b.buf[:0], 0
+	return err
+}
+
+func (b *datagramBatcher) run(interval time.Duration) {
+	defer close(b.done)
 
-	return fmt.Sprintf("%s:%s", DefaultCategory, dump)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			b.Flush()
+		case <-b.stop:
+			return
+		}
+	}
 }
@@ -1,7 +1,12 @@
 package log
 
 import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
 	"strings"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -12,7 +17,7 @@ type UDPLoggerSuite struct {
 var _ = Suite(&UDPLoggerSuite{})
 
 func (s *UDPLoggerSuite) TestNewUDPLogger(c *C) {
-	l, err := NewUDPLogger(Config{UDPLog, "info"})
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info"})
 	c.Assert(err, IsNil)
 	c.Assert(l, NotNil)
 
@@ -22,7 +27,7 @@ func (s *UDPLoggerSuite) TestNewUDPLogger(c *C) {
 }
 
 func (s *UDPLoggerSuite) TestFormatMessage(c *C) {
-	l, _ := NewUDPLogger(Config{UDPLog, "info"})
+	l, _ := NewUDPLogger(Config{Name: UDPLog, Severity: "info"})
 
 	udplog := l.(*udpLogger)
 
@@ -33,3 +38,150 @@ func (s *UDPLoggerSuite) TestFormatMessage(c *C) {
 	c.Assert(strings.Contains(message, "42"), Equals, true)
 	c.Assert(strings.Contains(message, "hello world"), Equals, true)
 }
+
+func (s *UDPLoggerSuite) TestFormatMessageCallerFields(c *C) {
+	l, _ := NewUDPLogger(Config{Name: UDPLog, Severity: "info"})
+
+	message := l.FormatMessage(SeverityInfo, &CallerInfo{"filename", "filepath", "funcname", 42}, "hello %s", "world")
+
+	var rec map[string]interface{}
+	err := json.Unmarshal([]byte(strings.TrimPrefix(message, DefaultCategory+":")), &rec)
+	c.Assert(err, IsNil)
+
+	// caller components are emitted as distinct, typed fields
+	c.Assert(rec["filename"], Equals, "filepath")
+	c.Assert(rec["funcName"], Equals, "funcname")
+	c.Assert(rec["lineno"], Equals, float64(42))
+}
+
+func (s *UDPLoggerSuite) TestFormatMessageWithFields(c *C) {
+	l, _ := NewUDPLogger(Config{Name: UDPLog, Severity: "info"})
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+
+	message := l.(FieldFormatter).FormatMessageWithFields(SeverityInfo, caller, Fields{"user": "bob", "attempt": 2, "err": fmt.Errorf("oops")}, "hello %s", "world")
+
+	var rec map[string]interface{}
+	err := json.Unmarshal([]byte(strings.TrimPrefix(message, DefaultCategory+":")), &rec)
+	c.Assert(err, IsNil)
+
+	// the fields keep their types and stay out of the message
+	c.Assert(rec["message"], Equals, "hello world")
+	c.Assert(rec["fields"], DeepEquals, map[string]interface{}{"user": "bob", "attempt": float64(2), "err": "oops"})
+
+	message = l.FormatMessage(SeverityInfo, caller, "hello %s", "world")
+	c.Assert(strings.Contains(message, `"fields"`), Equals, false)
+}
+
+// listenUDP returns a server udplog loggers can be pointed at and a function reading the next
+// datagram it receives.
+func listenUDP(c *C) (net.PacketConn, func() string) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	return server, func() string {
+		buf := make([]byte, maxDatagramSize)
+		server.SetReadDeadline(time.Now().Add(5 * time.Second))
+		n, _, err := server.ReadFrom(buf)
+		c.Assert(err, IsNil)
+		return string(buf[:n])
+	}
+}
+
+func (s *UDPLoggerSuite) TestEncodings(c *C) {
+	server, read := listenUDP(c)
+	defer server.Close()
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+	fields := Fields{"user": "bob", "id": 7, "bad key": true}
+
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info", Encoding: EncodingJSON, Address: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	io.WriteString(l.Writer(SeverityError), formatMessage(l, SeverityError, caller, now(), fields, "hello %s", "world"))
+
+	var rec map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(read()), &rec), IsNil)
+	c.Assert(rec["message"], Equals, "hello world")
+	c.Assert(rec["logLevel"], Equals, "ERROR")
+	c.Assert(describe(l).Format, Equals, EncodingJSON)
+
+	l, err = NewUDPLogger(Config{Name: UDPLog, Severity: "info", Encoding: EncodingGELF, Address: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	io.WriteString(l.Writer(SeverityError), formatMessage(l, SeverityError, caller, now(), fields, "hello %s", "world"))
+
+	var gelf map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(read()), &gelf), IsNil)
+	c.Assert(gelf["version"], Equals, "1.1")
+	c.Assert(gelf["short_message"], Equals, "hello world")
+	c.Assert(gelf["level"], Equals, float64(3))
+	c.Assert(gelf["_line"], Equals, float64(42))
+	c.Assert(gelf["_user"], Equals, "bob")
+	c.Assert(gelf["__id"], Equals, float64(7))
+	c.Assert(gelf["_bad_key"], Equals, true)
+}
+
+func (s *UDPLoggerSuite) TestMaxDatagramSize(c *C) {
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info", MaxDatagramSize: 300})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+
+	caller := &CallerInfo{"filename", "filepath", "funcname", 42}
+	message := l.FormatMessage(SeverityInfo, caller, "%s", strings.Repeat("é", 500))
+	c.Assert(len(message) <= 300, Equals, true)
+
+	// the message is cut short, the record is left intact
+	var rec map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(strings.TrimPrefix(message, DefaultCategory+":")), &rec), IsNil)
+	c.Assert(strings.HasSuffix(rec["message"].(string), TruncatedMarker), Equals, true)
+	c.Assert(rec["lineno"], Equals, float64(42))
+}
+
+func (s *UDPLoggerSuite) TestBatching(c *C) {
+	server, read := listenUDP(c)
+	defer server.Close()
+
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info", FlushInterval: time.Hour, MaxDatagramSize: 400, Address: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+	loggers = []Logger{}
+	Init(l)
+
+	for i := 0; i < 5; i++ {
+		Infof("message %d", i)
+	}
+	c.Assert(l.(Flusher).Flush(), IsNil)
+
+	// full datagrams are sent as messages are logged, the last one on Flush
+	var lines []string
+	for len(lines) < 5 {
+		datagram := read()
+		c.Assert(len(datagram) <= 400, Equals, true)
+		lines = append(lines, strings.Split(datagram, "\n")...)
+	}
+	c.Assert(len(lines), Equals, 5)
+	for i, line := range lines {
+		c.Assert(strings.HasPrefix(line, DefaultCategory+":"), Equals, true)
+		c.Assert(strings.Contains(line, fmt.Sprintf(`"message":"message %d"`, i)), Equals, true)
+	}
+	c.Assert(l.(Droppable).Dropped(), Equals, uint64(0))
+}
+
+func (s *UDPLoggerSuite) TestFlushInterval(c *C) {
+	server, read := listenUDP(c)
+	defer server.Close()
+
+	l, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info", FlushInterval: 10 * time.Millisecond, Address: server.LocalAddr().String()})
+	c.Assert(err, IsNil)
+	defer l.(io.Closer).Close()
+
+	io.WriteString(l.Writer(SeverityInfo), "hello")
+	c.Assert(read(), Equals, "hello")
+}
+
+func (s *UDPLoggerSuite) TestConfig(c *C) {
+	_, err := NewUDPLogger(Config{Name: UDPLog, Severity: "info", Encoding: "xml"})
+	c.Assert(err, ErrorMatches, "unsupported udplog encoding: xml")
+	_, err = NewUDPLogger(Config{Name: UDPLog, Severity: "info", Encoding: EncodingGELF, FlushInterval: time.Second})
+	c.Assert(err, ErrorMatches, "gelf messages cannot be batched")
+	_, err = NewUDPLogger(Config{Name: UDPLog, Severity: "info", MaxDatagramSize: -1})
+	c.Assert(err, NotNil)
+}